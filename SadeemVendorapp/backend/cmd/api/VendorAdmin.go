@@ -2,41 +2,36 @@ package main
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 
 	"project/internal/data"
 	"project/utils"
+	"project/utils/i18n"
 	"project/utils/validator"
 
 	"github.com/google/uuid"
 )
 
 func (app *application) GetVendorAdminHandler(w http.ResponseWriter, r *http.Request) {
-	UserID := r.FormValue("user_id")
-	if UserID == "" {
-		app.errorResponse(w, r, http.StatusBadRequest, "invalid UserID")
-		return
-	}
 	vendorIDUUID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "invalid vendor_id")
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
+	UserID := r.URL.Query().Get("user_id")
 	userIDUUID, err := uuid.Parse(UserID)
-	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "invalid user_id")
+	v := validator.New()
+	v.Check(UserID != "", "user_id", i18n.UserIDRequired)
+	v.Check(err == nil, "user_id", i18n.IDInvalid)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
 	vendorAdmin, err := app.Model.VendorAdminDB.GetVendorAdmin(r.Context(), userIDUUID, vendorIDUUID)
 	if err != nil {
-		if err.Error() == "vendor admin not found" {
-			app.errorResponse(w, r, http.StatusNotFound, err.Error())
-			return
-		}
-		app.serverErrorResponse(w, r, err)
+		app.handleRetrievalError(w, r, err)
 		return
 	}
 
@@ -46,32 +41,35 @@ func (app *application) GetVendorAdminsHandler(w http.ResponseWriter, r *http.Re
 
 	vendorIDUUID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "invalid vendor_id")
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
-	vendorAdmin, err := app.Model.VendorAdminDB.GetVendorAdmins(r.Context(), vendorIDUUID)
+	v := validator.New()
+	page := app.readPage(v, r, data.VendorAdminSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	vendorAdmin, meta, err := app.Model.VendorAdminDB.GetVendorAdminsPage(r.Context(), vendorIDUUID, page)
 	if err != nil {
-		if err.Error() == "vendor admin not found" {
-			app.errorResponse(w, r, http.StatusNotFound, err.Error())
-			return
-		}
-		app.serverErrorResponse(w, r, err)
+		app.handleRetrievalError(w, r, err)
 		return
 	}
 
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"vendor_admin": vendorAdmin})
+	app.sendPage(w, r, "vendor_admin", vendorAdmin, meta, nil)
 }
 
 func (app *application) DeleteVendorAdminHandler(w http.ResponseWriter, r *http.Request) {
 
 	vendorIDUUID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "invalid vendor_id")
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
-	UserID := r.PathValue("adminId")
+	UserID := r.PathValue("admin_id")
 
 	UserIDUUID, err := uuid.Parse(UserID)
 	if err != nil {
@@ -83,7 +81,7 @@ func (app *application) DeleteVendorAdminHandler(w http.ResponseWriter, r *http.
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
-			app.errorResponse(w, r, http.StatusNotFound, fmt.Sprintf("vendor admin with User ID %s and Vendor ID %s was not found", UserIDUUID, vendorIDUUID))
+			app.notFoundResponse(w, r)
 			return
 		default:
 			app.serverErrorResponse(w, r, err)
@@ -105,24 +103,46 @@ func (app *application) DeleteVendorAdminHandler(w http.ResponseWriter, r *http.
 	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "vendor admin deleted successfully"})
 }
 
+type vendorAdminRequest struct {
+	Email string `json:"Email"`
+}
+
+func (input vendorAdminRequest) rules() []validator.Rule {
+	return []validator.Rule{validator.String("email", input.Email).Required(i18n.EmailRequired)}
+}
+
+type updateVendorAdminRequest struct {
+	UserID uuid.UUID `json:"User_ID"`
+}
+
+func (input updateVendorAdminRequest) rules() []validator.Rule {
+	return []validator.Rule{validator.UUID("user_id", input.UserID).Required(i18n.UserIDRequired)}
+}
+
 // CreateVendorAdminHandler handles the creation of a new vendor admin.
 func (app *application) CreateVendorAdminHandler(w http.ResponseWriter, r *http.Request) {
 	vendorIDUUID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "invalid vendor_id")
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
-	UserEmail := r.FormValue("Email")
+	var input vendorAdminRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
 
 	user := &data.User{}
-	user.Email = string(UserEmail)
+	user.Email = input.Email
 
 	v := validator.New()
 
-	data.ValidatingUser(v, user, "email")
+	v.Validate(input.rules()...)
+	data.ValidatingUser(v, user)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
+		return
 	}
 
 	getuser, err := app.Model.UserDB.GetUserByEmail(user.Email)
@@ -172,18 +192,24 @@ func (app *application) CreateVendorAdminHandler(w http.ResponseWriter, r *http.
 func (app *application) UpdateVendorAdminHandler(w http.ResponseWriter, r *http.Request) {
 	vendorIDUUID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "invalid vendor_id")
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
-	UserID, err := uuid.Parse(r.FormValue("User_ID"))
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid user_id"))
+	var input updateVendorAdminRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	v := validator.New()
+	v.Validate(input.rules()...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
 	vendorAdmin := data.VendorAdmin{
-		UserID:   UserID,
+		UserID:   input.UserID,
 		VendorID: vendorIDUUID,
 	}
 
@@ -198,13 +224,40 @@ func (app *application) UpdateVendorAdminHandler(w http.ResponseWriter, r *http.
 func (app *application) GetUserVendor(w http.ResponseWriter, r *http.Request) {
 	userUUID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "invalid vendor_id")
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+	v := validator.New()
+	page := app.readPage(v, r, data.VendorSorts(nil))
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
-	vendor, err := app.Model.VendorDB.GetUserVendors(r.Context(), userUUID)
+	vendor, meta, err := app.Model.VendorDB.GetUserVendorsPage(r.Context(), userUUID, utils.Filters{}, page)
 	if err != nil {
 		app.handleRetrievalError(w, r, err)
 		return
 	}
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"vendor": vendor})
+	if err = app.localizeVendors(r, vendor); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	app.sendPage(w, r, "vendor", vendor, meta, nil)
+}
+
+// setVendorAdmins embeds the admins of each vendor, loaded in one query.
+func (app *application) setVendorAdmins(r *http.Request, vendors []data.Vendor) error {
+	ids := make([]uuid.UUID, len(vendors))
+	for i := range vendors {
+		ids[i] = vendors[i].ID
+	}
+
+	admins, err := app.Model.VendorAdminDB.GetVendorsAdmins(r.Context(), ids)
+	if err != nil {
+		return err
+	}
+	for i := range vendors {
+		vendors[i].Admins = admins[vendors[i].ID]
+	}
+	return nil
 }
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const adminUsage = `usage: api admin <command> [-json] [flags]
+
+commands:
+  create-user -name n -email e -phone p [-password pw] [-role admin|vendor|customer]
+  grant-role -user id|email -role admin|vendor|customer
+  revoke-role -user id|email -role admin|vendor|customer
+  create-vendor -name n -description d [-days 30] [-admin id|email]
+  add-vendor-admin -vendor id -user id|email
+  reset-password -user id|email [-password pw]
+  extend-subscription -vendor id [-days n] [-plan id]
+  expired-vendors [-lapsed]
+
+-json prints the result as JSON. Without -password a random one is generated and printed.`
+
+// roles are the rows seeded by migration 000002.
+var roles = map[string]int{"admin": 1, "vendor": 2, "customer": 3}
+
+// adminResult is what a command prints: value with -json, text otherwise.
+type adminResult struct {
+	value interface{}
+	text  string
+}
+
+// adminCommand declares its flags on fs and returns what runs once they are parsed.
+type adminCommand func(ctx context.Context, m data.Model, fs *flag.FlagSet) func() (adminResult, error)
+
+var adminCommands = map[string]adminCommand{
+	"create-user":         adminCreateUser,
+	"grant-role":          adminGrantRole,
+	"revoke-role":         adminRevokeRole,
+	"create-vendor":       adminCreateVendor,
+	"add-vendor-admin":    adminAddVendorAdmin,
+	"reset-password":      adminResetPassword,
+	"extend-subscription": adminExtendSubscription,
+	"expired-vendors":     adminExpiredVendors,
+}
+
+// runAdmin runs the admin subcommand, the way to bootstrap and repair accounts without the
+// API: the first admin especially, since only an admin can grant roles over HTTP.
+func runAdmin(ctx context.Context, m data.Model, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(adminUsage)
+	}
+	command, ok := adminCommands[args[0]]
+	if !ok {
+		return errors.New(adminUsage)
+	}
+	fs := flag.NewFlagSet("admin "+args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	run := command(ctx, m, fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%v\n%s", err, adminUsage)
+	}
+
+	result, err := run()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "\t")
+		return enc.Encode(result.value)
+	}
+	_, err = fmt.Fprintln(out, result.text)
+	return err
+}
+
+func adminCreateUser(ctx context.Context, m data.Model, fs *flag.FlagSet) func() (adminResult, error) {
+	name := fs.String("name", "", "Name of the user")
+	email := fs.String("email", "", "Email the user signs in with")
+	phone := fs.String("phone", "", "Phone number, as +2189XXXXXXXX")
+	password := fs.String("password", "", "Password; generated when empty")
+	role := fs.String("role", "customer", "Role to grant")
+
+	return func() (adminResult, error) {
+		roleID, err := parseRole(*role)
+		if err != nil {
+			return adminResult{}, err
+		}
+		generated, err := passwordOrRandom(password)
+		if err != nil {
+			return adminResult{}, err
+		}
+		user := &data.User{Name: *name, Email: *email, Phone: *phone, Password: *password}
+		v := validator.New()
+		data.ValidatingUser(v, user)
+		if !v.Valid() {
+			return adminResult{}, validationFailed(v)
+		}
+		if user.Password, err = utils.HashPassword(user.Password); err != nil {
+			return adminResult{}, err
+		}
+		if err = m.UserDB.Insert(user); err != nil {
+			return adminResult{}, err
+		}
+		if _, err = m.UserRoleDB.GrantRole(user.ID, roleID); err != nil {
+			return adminResult{}, err
+		}
+
+		text := fmt.Sprintf("created %s %s (%s)", *role, user.ID, user.Email)
+		value := utils.Envelope{"user": user, "role": *role}
+		if generated {
+			text += "\npassword: " + *password
+			value["password"] = *password
+		}
+		return adminResult{value, text}, nil
+	}
+}
+
+// adminGrantRole gives a user role, replacing the one it has: users hold a single role.
+func adminGrantRole(ctx context.Context, m data.Model, fs *flag.FlagSet) func() (adminResult, error) {
+	ref := fs.String("user", "", "ID or email of the user")
+	role := fs.String("role", "", "Role to grant")
+
+	return func() (adminResult, error) {
+		roleID, err := parseRole(*role)
+		if err != nil {
+			return adminResult{}, err
+		}
+		user, err := findUser(m, *ref)
+		if err != nil {
+			return adminResult{}, err
+		}
+		granted, err := m.UserRoleDB.GrantRole(user.ID, roleID)
+		if errors.Is(err, data.ErrHasRole) {
+			granted, err = m.UserRoleDB.UpdateRole(user.ID, roleID)
+		}
+		if err != nil {
+			return adminResult{}, err
+		}
+		return adminResult{
+			utils.Envelope{"user_role": granted},
+			fmt.Sprintf("%s (%s) is now %s", user.ID, user.Email, *role),
+		}, nil
+	}
+}
+
+func adminRevokeRole(ctx context.Context, m data.Model, fs *flag.FlagSet) func() (adminResult, error) {
+	ref := fs.String("user", "", "ID or email of the user")
+	role := fs.String("role", "", "Role to revoke")
+
+	return func() (adminResult, error) {
+		roleID, err := parseRole(*role)
+		if err != nil {
+			return adminResult{}, err
+		}
+		user, err := findUser(m, *ref)
+		if err != nil {
+			return adminResult{}, err
+		}
+		if err = m.UserRoleDB.RevokeRole(user.ID, roleID); err != nil {
+			return adminResult{}, err
+		}
+		return adminResult{
+			utils.Envelope{"user_id": user.ID, "revoked": *role},
+			fmt.Sprintf("revoked %s from %s (%s)", *role, user.ID, user.Email),
+		}, nil
+	}
+}
+
+func adminCreateVendor(ctx context.Context, m data.Model, fs *flag.FlagSet) func() (adminResult, error) {
+	name := fs.String("name", "", "Name of the vendor")
+	description := fs.String("description", "", "Description of the vendor")
+	days := fs.Int("days", 30, "Days of subscription to start with")
+	adminRef := fs.String("admin", "", "ID or email of a user to make its admin")
+
+	return func() (adminResult, error) {
+		vendor := &data.Vendor{Name: *name, Description: *description, SubscriptionDays: *days}
+		v := validator.New()
+		data.ValidatingVendor(v, vendor)
+		if !v.Valid() {
+			return adminResult{}, validationFailed(v)
+		}
+		// Find the admin first so a typo doesn't leave a vendor without one
+		var admin *data.User
+		if *adminRef != "" {
+			var err error
+			if admin, err = findUser(m, *adminRef); err != nil {
+				return adminResult{}, err
+			}
+		}
+		if err := m.VendorDB.InsertVendor(vendor); err != nil {
+			return adminResult{}, err
+		}
+
+		text := fmt.Sprintf("created vendor %s (%s), subscribed until %s", vendor.ID, vendor.Name, vendor.SubscriptionEnd.Format(time.DateOnly))
+		value := utils.Envelope{"vendor": vendor}
+		if admin != nil {
+			if err := attachVendorAdmin(ctx, m, vendor.ID, admin); err != nil {
+				return adminResult{}, fmt.Errorf("vendor %s created but its admin was not attached: %w", vendor.ID, err)
+			}
+			text += fmt.Sprintf("\nadmin: %s (%s)", admin.ID, admin.Email)
+			value["admin"] = data.VendorAdmin{UserID: admin.ID, VendorID: vendor.ID}
+		}
+		return adminResult{value, text}, nil
+	}
+}
+
+func adminAddVendorAdmin(ctx context.Context, m data.Model, fs *flag.FlagSet) func() (adminResult, error) {
+	vendorID := fs.String("vendor", "", "ID of the vendor")
+	ref := fs.String("user", "", "ID or email of the user")
+
+	return func() (adminResult, error) {
+		id, err := uuid.Parse(*vendorID)
+		if err != nil {
+			return adminResult{}, fmt.Errorf("-vendor: %w", err)
+		}
+		if _, err = m.VendorDB.GetVendor(id, true); err != nil {
+			return adminResult{}, err
+		}
+		user, err := findUser(m, *ref)
+		if err != nil {
+			return adminResult{}, err
+		}
+		if err = attachVendorAdmin(ctx, m, id, user); err != nil {
+			return adminResult{}, err
+		}
+		return adminResult{
+			utils.Envelope{"vendor_admin": data.VendorAdmin{UserID: user.ID, VendorID: id}},
+			fmt.Sprintf("%s (%s) now administers vendor %s", user.ID, user.Email, id),
+		}, nil
+	}
+}
+
+// attachVendorAdmin makes user an admin of the vendor and, like the API, promotes a customer
+// to the vendor role.
+func attachVendorAdmin(ctx context.Context, m data.Model, vendorID uuid.UUID, user *data.User) error {
+	if _, err := m.VendorAdminDB.InsertVendorAdmin(ctx, data.VendorAdmin{UserID: user.ID, VendorID: vendorID}); err != nil {
+		return err
+	}
+	role, err := m.UserRoleDB.GetUserRole(user.ID)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		_, err = m.UserRoleDB.GrantRole(user.ID, roles["vendor"])
+	case err == nil && role.RoleID == roles["customer"]:
+		_, err = m.UserRoleDB.UpdateRole(user.ID, roles["vendor"])
+	}
+	return err
+}
+
+func adminResetPassword(ctx context.Context, m data.Model, fs *flag.FlagSet) func() (adminResult, error) {
+	ref := fs.String("user", "", "ID or email of the user")
+	password := fs.String("password", "", "New password; generated when empty")
+
+	return func() (adminResult, error) {
+		generated, err := passwordOrRandom(password)
+		if err != nil {
+			return adminResult{}, err
+		}
+		v := validator.New()
+		v.Validate(validator.String("password", *password).MinLength(8, i18n.PasswordTooShort))
+		if !v.Valid() {
+			return adminResult{}, validationFailed(v)
+		}
+		user, err := findUser(m, *ref)
+		if err != nil {
+			return adminResult{}, err
+		}
+		// Reads return the image as a URL; the row stores its path
+		if user.Img != nil {
+			*user.Img = strings.TrimPrefix(*user.Img, data.Domain+"/")
+		}
+		if user.Password, err = utils.HashPassword(*password); err != nil {
+			return adminResult{}, err
+		}
+		if err = m.UserDB.Update(user); err != nil {
+			return adminResult{}, err
+		}
+
+		text := fmt.Sprintf("reset the password of %s (%s)", user.ID, user.Email)
+		value := utils.Envelope{"user_id": user.ID}
+		if generated {
+			text += "\npassword: " + *password
+			value["password"] = *password
+		}
+		return adminResult{value, text}, nil
+	}
+}
+
+// adminExtendSubscription renews a vendor the way a paid invoice does, so the extension shows
+// in its invoices and subscription history.
+func adminExtendSubscription(ctx context.Context, m data.Model, fs *flag.FlagSet) func() (adminResult, error) {
+	vendorID := fs.String("vendor", "", "ID of the vendor")
+	days := fs.Int("days", 0, "Days to extend by; one billing period of the plan when 0")
+	planID := fs.String("plan", "", "ID of the plan to switch to; the vendor's plan when empty")
+
+	return func() (adminResult, error) {
+		id, err := uuid.Parse(*vendorID)
+		if err != nil {
+			return adminResult{}, fmt.Errorf("-vendor: %w", err)
+		}
+		var plan *uuid.UUID
+		if *planID != "" {
+			parsed, err := uuid.Parse(*planID)
+			if err != nil {
+				return adminResult{}, fmt.Errorf("-plan: %w", err)
+			}
+			plan = &parsed
+		}
+		if *days < 0 {
+			return adminResult{}, errors.New("-days must not be negative")
+		}
+
+		invoice, err := m.SubscriptionDB.CreateRenewalInvoice(ctx, id, plan, *days)
+		if err != nil {
+			return adminResult{}, err
+		}
+		invoice, vendor, err := m.SubscriptionDB.PayInvoice(ctx, invoice.ID)
+		if err != nil {
+			return adminResult{}, err
+		}
+		return adminResult{
+			utils.Envelope{"invoice": invoice, "vendor": vendor},
+			fmt.Sprintf("extended vendor %s (%s) by %d days to %s", vendor.ID, vendor.Name, invoice.Days, vendor.SubscriptionEnd.Format(time.DateOnly)),
+		}, nil
+	}
+}
+
+func adminExpiredVendors(ctx context.Context, m data.Model, fs *flag.FlagSet) func() (adminResult, error) {
+	lapsed := fs.Bool("lapsed", false, "Leave out vendors still in their grace period")
+
+	return func() (adminResult, error) {
+		vendors, err := m.SubscriptionDB.GetExpiredVendors(ctx, *lapsed)
+		if err != nil {
+			return adminResult{}, err
+		}
+		var text strings.Builder
+		for _, vendor := range vendors {
+			fmt.Fprintf(&text, "%s\t%s\texpired %s\tvisible=%t\n",
+				vendor.ID, vendor.Name, vendor.SubscriptionEnd.Format(time.DateOnly), vendor.IsVisible)
+		}
+		fmt.Fprintf(&text, "%d expired vendors", len(vendors))
+		return adminResult{utils.Envelope{"vendors": vendors}, text.String()}, nil
+	}
+}
+
+// parseRole reads a role by name or by its ID.
+func parseRole(role string) (int, error) {
+	if id, ok := roles[role]; ok {
+		return id, nil
+	}
+	if id, err := strconv.Atoi(role); err == nil {
+		for _, known := range roles {
+			if id == known {
+				return id, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("-role %q: want admin, vendor or customer", role)
+}
+
+// findUser looks a user up by ID, or by email when ref isn't one.
+func findUser(m data.Model, ref string) (*data.User, error) {
+	if ref == "" {
+		return nil, errors.New("-user is required")
+	}
+	if id, err := uuid.Parse(ref); err == nil {
+		return m.UserDB.GetUser(id)
+	}
+	return m.UserDB.GetUserByEmail(ref)
+}
+
+// passwordOrRandom fills an empty password with a random one and reports whether it did.
+func passwordOrRandom(password *string) (bool, error) {
+	if *password != "" {
+		return false, nil
+	}
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return false, err
+	}
+	*password = base64.RawURLEncoding.EncodeToString(b)
+	return true, nil
+}
+
+// validationFailed reports every rejected field, in the order they failed.
+func validationFailed(v *validator.Validator) error {
+	problems := make([]string, len(v.ErrorOrder))
+	for i, field := range v.ErrorOrder {
+		problems[i] = field + ": " + v.Errors[field].Text(i18n.DefaultLanguage)
+	}
+	return errors.New("invalid input: " + strings.Join(problems, "; "))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"project/internal/data"
+	"strings"
+	"testing"
+)
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		role string
+		want int
+		ok   bool
+	}{
+		{"admin", 1, true},
+		{"vendor", 2, true},
+		{"customer", 3, true},
+		{"1", 1, true},
+		{"4", 0, false},
+		{"Admin", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseRole(tt.role)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("parseRole(%q) = %d, %v", tt.role, got, err)
+		}
+	}
+}
+
+// TestRunAdminRejects covers the failures caught before the database is touched.
+func TestRunAdminRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no command", nil, "usage: api admin"},
+		{"unknown command", []string{"drop-everything"}, "usage: api admin"},
+		{"unknown flag", []string{"grant-role", "-force"}, "flag provided but not defined: -force"},
+		{"bad role", []string{"grant-role", "-user", "a@b.co", "-role", "owner"}, `-role "owner"`},
+		{"no user", []string{"revoke-role", "-role", "admin"}, "-user is required"},
+		{
+			"invalid user",
+			[]string{"create-user", "-name", "Al", "-email", "nope", "-phone", "+218911234567", "-password", "short", "-role", "admin"},
+			"invalid input: name: ",
+		},
+		{"bad vendor", []string{"extend-subscription", "-vendor", "42"}, "-vendor: "},
+		{"short password", []string{"reset-password", "-user", "a@b.co", "-password", "short"}, "password: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runAdmin(context.Background(), data.Model{}, tt.args, &out)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+			if out.Len() > 0 {
+				t.Errorf("printed %q on failure", out.String())
+			}
+		})
+	}
+}
+
+func TestPasswordOrRandom(t *testing.T) {
+	password := "kept-as-is"
+	if generated, err := passwordOrRandom(&password); err != nil || generated || password != "kept-as-is" {
+		t.Errorf("given password: generated %v, %q, %v", generated, password, err)
+	}
+	var first, second string
+	passwordOrRandom(&first)
+	passwordOrRandom(&second)
+	if len(first) < 16 || first == second {
+		t.Errorf("random passwords %q and %q", first, second)
+	}
+}
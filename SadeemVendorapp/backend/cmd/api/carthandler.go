@@ -5,7 +5,7 @@ import (
 	"net/http"
 	"project/internal/data"
 	"project/utils"
-	"strings"
+	"project/utils/i18n"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,7 +27,7 @@ func (app *application) CreateCartHandler(w http.ResponseWriter, r *http.Request
 func (app *application) DeleteCartHandler(w http.ResponseWriter, r *http.Request) {
 	cartID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid cart ID"))
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
@@ -43,15 +43,29 @@ func (app *application) DeleteCartHandler(w http.ResponseWriter, r *http.Request
 
 	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "cart deleted successfully"})
 }
+
+type cartRequest struct {
+	VendorID uuid.UUID `json:"vendor_id"`
+}
+
 func (app *application) UpdateCartHandler(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
 	userID := uuid.MustParse(r.Context().Value(UserIDKey).(string))
 
-	// Get cart ID from request
-	cartIDStr := r.PathValue("id")
-	cartID, err := uuid.Parse(cartIDStr)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid cart ID"))
+	// A user's cart shares their ID; only the legacy route names it in the path
+	cartID := userID
+	if cartIDStr := r.PathValue("id"); cartIDStr != "" {
+		parsed, err := uuid.Parse(cartIDStr)
+		if err != nil {
+			app.invalidIDResponse(w, r, "id")
+			return
+		}
+		cartID = parsed
+	}
+
+	var input cartRequest
+	if err := app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
 		return
 	}
 
@@ -87,7 +101,9 @@ func (app *application) UpdateCartHandler(w http.ResponseWriter, r *http.Request
 	// Update the cart's total price and quantity
 	cart.TotalPrice = totalPrice
 	cart.Quantity = len(cartItems) // Update quantity based on the number of items
-	cart.VendorID = uuid.MustParse(r.FormValue("vendor_id"))
+	if input.VendorID != uuid.Nil {
+		cart.VendorID = input.VendorID
+	}
 	// Update the cart in the database
 	err = app.Model.CartDB.UpdateCart(cart)
 	if err != nil {
@@ -135,7 +151,7 @@ func (app *application) CheckoutHandler(w http.ResponseWriter, r *http.Request)
 
 	// Check vendor consistency
 	if len(cartItems) == 0 {
-		app.errorResponse(w, r, http.StatusBadRequest, "Cart is empty.")
+		app.codedErrorResponse(w, r, http.StatusBadRequest, i18n.Message{Code: i18n.CartEmpty})
 		return
 	}
 
@@ -148,10 +164,13 @@ func (app *application) CheckoutHandler(w http.ResponseWriter, r *http.Request)
 			return
 		}
 		if itemData.VendorID != vendorID {
-			app.errorResponse(w, r, http.StatusBadRequest, "All items in the cart must be from the same vendor.")
+			app.codedErrorResponse(w, r, http.StatusBadRequest, i18n.Message{Code: i18n.CartMixedVendors})
 			return
 		}
 	}
+	if !app.requireVendorOpen(w, r, vendorID) {
+		return
+	}
 
 	// Begin a transaction
 	tx, err := app.Model.BeginTransaction()
@@ -159,13 +178,8 @@ func (app *application) CheckoutHandler(w http.ResponseWriter, r *http.Request)
 		app.serverErrorResponse(w, r, err)
 		return
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback() // Rollback on error
-		} else {
-			err = tx.Commit() // Commit if no errors
-		}
-	}()
+	// Every early return rolls the whole checkout back; after Commit this does nothing
+	defer tx.Rollback()
 
 	// Create a new order
 	order := &data.Order{
@@ -187,7 +201,8 @@ func (app *application) CheckoutHandler(w http.ResponseWriter, r *http.Request)
 	// Insert order items
 	for _, item := range cartItems {
 		// Fetch the item to get its price
-		itemData, err := app.Model.ItemDB.GetItem(item.ItemID)
+		var itemData *data.Item
+		itemData, err = app.Model.ItemDB.GetItem(item.ItemID)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
 			return
@@ -203,17 +218,9 @@ func (app *application) CheckoutHandler(w http.ResponseWriter, r *http.Request)
 			app.serverErrorResponse(w, r, err)
 			return
 		}
-		newQuantity := itemData.Quantity - item.Quantity
-		if newQuantity < 0 {
-			app.serverErrorResponse(w, r, errors.New("not enough quantity"))
-			return
-		}
-		itemData.Quantity = newQuantity
-		if itemData.Img != nil {
-			*itemData.Img = strings.TrimPrefix(*itemData.Img, data.Domain+"/")
-		}
-		if err = app.Model.ItemDB.UpdateItem(itemData); err != nil {
-			app.serverErrorResponse(w, r, err)
+		// Stock is taken in the transaction, so a concurrent checkout can't oversell it
+		if err = tx.DecrementStock(item.ItemID, item.Quantity); err != nil {
+			app.handleRetrievalError(w, r, err)
 			return
 		}
 	}
@@ -228,11 +235,9 @@ func (app *application) CheckoutHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// If we reach here, commit the transaction
-	if err != nil {
-		tx.Rollback() // Rollback on error
-	} else {
-		err = tx.Commit() // Commit if no errors
+	if err = tx.Commit(); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
 	}
 
 	// Respond with a success message
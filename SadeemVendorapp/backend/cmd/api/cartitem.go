@@ -5,34 +5,65 @@ import (
 	"net/http"
 	"project/internal/data"
 	"project/utils"
-	"strconv"
+	"project/utils/i18n"
+	"project/utils/validator"
 
 	"github.com/google/uuid"
 )
 
+// cartItemRequest is the body of adding an item to the cart and of changing its quantity.
+type cartItemRequest struct {
+	ItemID   uuid.UUID `json:"item_id"`
+	Quantity int       `json:"quantity"`
+}
+
+// rules need a positive quantity to add an item; changing it to zero empties the line.
+func (input cartItemRequest) rules(adding bool) []validator.Rule {
+	quantity := validator.Number("quantity", input.Quantity)
+	if adding {
+		quantity.Required(i18n.QuantityRequired).Above(0, i18n.QuantityNotPositive)
+	} else {
+		quantity.Min(0, i18n.QuantityNegative)
+	}
+	return []validator.Rule{
+		validator.UUID("item_id", input.ItemID).Required(i18n.ItemIDRequired),
+		quantity,
+	}
+}
+
+// removeCartItemRequest is the optional body of removing a cart item; a zero quantity removes it all.
+type removeCartItemRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+func (input removeCartItemRequest) rules() []validator.Rule {
+	return []validator.Rule{validator.Number("quantity", input.Quantity).Min(0, i18n.QuantityNegative)}
+}
+
 func (app *application) CreateCartItemHandler(w http.ResponseWriter, r *http.Request) {
 	cartIDStr := r.Context().Value(UserIDKey).(string)
-	itemIDStr := r.FormValue("item_id")
-	quantityStr := r.FormValue("quantity")
-
-	quantity, err := strconv.Atoi(quantityStr)
-	if err != nil || quantity <= 0 {
-		app.badRequestResponse(w, r, errors.New("invalid quantity"))
+	var input cartItemRequest
+	if err := app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
 		return
 	}
 
-	cartID, err := uuid.Parse(cartIDStr)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid cart ID"))
+	v := validator.New()
+	v.Validate(input.rules(true)...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
+	quantity := input.Quantity
 
-	itemID, err := uuid.Parse(itemIDStr)
+	cartID, err := uuid.Parse(cartIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid item ID"))
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
 		return
 	}
 
+	itemID := input.ItemID
+
 	// Check if item is available in the required quantity
 	isAvailable, err := app.Model.ItemDB.IsStockAvailable(itemID, quantity)
 	if err != nil {
@@ -79,12 +110,15 @@ func (app *application) CreateCartItemHandler(w http.ResponseWriter, r *http.Req
 	// Check if the user has a table
 	_, err = app.Model.TableDB.GetCustomertable(r.Context(), cartID)
 	if err != nil {
-		app.errorResponse(w, r, http.StatusForbidden, "You must have a table to add items.")
+		app.codedErrorResponse(w, r, http.StatusForbidden, i18n.Message{Code: i18n.UserHasNoTable})
 		return
 	}
 	// Check vendor consistency
 	if cart.VendorID != uuid.Nil && cart.VendorID != itemVendorID {
-		app.errorResponse(w, r, http.StatusBadRequest, "You can only add items from the same vendor to this cart.")
+		app.codedErrorResponse(w, r, http.StatusBadRequest, i18n.Message{Code: i18n.CartOtherVendor})
+		return
+	}
+	if !app.requireVendorOpen(w, r, itemVendorID) {
 		return
 	}
 
@@ -135,25 +169,31 @@ func (app *application) CreateCartItemHandler(w http.ResponseWriter, r *http.Req
 func (app *application) DeleteCartItemHandler(w http.ResponseWriter, r *http.Request) {
 	cartIDStr := r.Context().Value(UserIDKey).(string)
 	itemIDStr := r.PathValue("id")
-	quantityStr := r.FormValue("quantity")
+	var input removeCartItemRequest
+	if err := app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	v := validator.New()
+	v.Validate(input.rules()...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
 
 	cartID, err := uuid.Parse(cartIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid cart ID"))
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
 		return
 	}
 
 	itemID, err := uuid.Parse(itemIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid item ID"))
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
-	// Parse the quantity string to an integer
-	quantity, err := strconv.Atoi(quantityStr)
-	if err != nil {
-		quantity = 0
-	}
+	quantity := input.Quantity
 
 	// Fetch the current item to get its price and quantity
 	cartItems, err := app.Model.CartItemDB.GetCartItems(cartID)
@@ -205,13 +245,9 @@ func (app *application) DeleteCartItemHandler(w http.ResponseWriter, r *http.Req
 			cart.TotalPrice -= itemPrice.Discount * float64(currentItem.Quantity)
 			cart.Quantity -= currentItem.Quantity
 		}
-	} else if quantity < 0 {
-		// Return an error message if quantity is less than 0
-		app.badRequestResponse(w, r, errors.New("quantity must be greater than 0"))
-		return
 	} else {
 		if quantity > currentItem.Quantity {
-			app.errorResponse(w, r, http.StatusBadRequest, "cannot remove more items than exist in the cart")
+			app.codedErrorResponse(w, r, http.StatusBadRequest, i18n.Message{Code: i18n.CartRemoveTooMany})
 			return
 		}
 
@@ -250,27 +286,28 @@ func (app *application) DeleteCartItemHandler(w http.ResponseWriter, r *http.Req
 
 func (app *application) UpdateCartItemHandler(w http.ResponseWriter, r *http.Request) {
 	cartIDStr := r.Context().Value(UserIDKey).(string)
-	itemIDStr := r.FormValue("item_id")
-	quantityStr := r.FormValue("quantity")
-
-	quantity, err := strconv.Atoi(quantityStr)
-	if err != nil || quantity < 0 {
-		app.badRequestResponse(w, r, errors.New("invalid quantity"))
+	var input cartItemRequest
+	if err := app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
 		return
 	}
 
-	cartID, err := uuid.Parse(cartIDStr)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid cart ID"))
+	v := validator.New()
+	v.Validate(input.rules(false)...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
+	quantity := input.Quantity
 
-	itemID, err := uuid.Parse(itemIDStr)
+	cartID, err := uuid.Parse(cartIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid item ID"))
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
 		return
 	}
 
+	itemID := input.ItemID
+
 	// Fetch current items in the cart
 	cartItems, err := app.Model.CartItemDB.GetCartItems(cartID)
 	if err != nil {
@@ -298,7 +335,7 @@ func (app *application) UpdateCartItemHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 	if !isAvailable {
-		app.errorResponse(w, r, http.StatusConflict, "requested quantity is not available")
+		app.codedErrorResponse(w, r, http.StatusConflict, i18n.Message{Code: i18n.QuantityUnavailable})
 		return
 	}
 
@@ -324,7 +361,11 @@ func (app *application) UpdateCartItemHandler(w http.ResponseWriter, r *http.Req
 
 	// Check vendor consistency
 	if cart.VendorID != uuid.Nil && cart.VendorID != itemVendorID {
-		app.errorResponse(w, r, http.StatusBadRequest, "You can only update items from the same vendor in this cart.")
+		app.codedErrorResponse(w, r, http.StatusBadRequest, i18n.Message{Code: i18n.CartOtherVendor})
+		return
+	}
+	// Removing items is fine while the vendor is closed; adding more is not
+	if quantity > currentItem.Quantity && !app.requireVendorOpen(w, r, itemVendorID) {
 		return
 	}
 
@@ -373,12 +414,19 @@ func (app *application) GetCartItemswithimage(w http.ResponseWriter, r *http.Req
 	// Get user ID from the context (assuming you have middleware that sets this)
 	cartID := uuid.MustParse(r.Context().Value(UserIDKey).(string))
 
-	// Retrieve all items in the cart
-	cartItems, err := app.Model.CartItemDB.GetCartItemswithimage(cartID)
+	v := validator.New()
+	page := app.readPage(v, r, data.CartItemSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Retrieve one page of the items in the cart
+	cartItems, meta, err := app.Model.CartItemDB.GetCartItemswithimage(r.Context(), cartID, app.contentLang(r), page)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"cart": cartItems})
+	app.sendPage(w, r, "cart", cartItems, meta, nil)
 }
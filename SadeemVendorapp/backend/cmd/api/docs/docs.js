@@ -0,0 +1,110 @@
+// Renders /openapi.json as a browsable reference. Schemas are shown as example-like trees
+// with component names in place of nested references.
+"use strict";
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [key, value] of Object.entries(attrs || {})) node.setAttribute(key, value);
+  for (const child of children) node.append(child);
+  return node;
+}
+
+function refName(ref) {
+  return ref.split("/").pop();
+}
+
+// describe renders a schema one level deep; referenced components are linked by name.
+function describe(schema, depth) {
+  if (!schema || Object.keys(schema).length === 0) return "any";
+  if (schema.$ref) return refName(schema.$ref);
+  if (schema.anyOf) return schema.anyOf.map((s) => describe(s, depth)).join(" | ");
+  if (schema.allOf) return schema.allOf.map((s) => describe(s, depth)).join(" & ");
+  const type = Array.isArray(schema.type) ? schema.type.join(" | ") : schema.type;
+  if (type && type.startsWith("array")) return "[" + describe(schema.items, depth) + "]";
+  if (schema.properties && depth < 3) {
+    const pad = "  ".repeat(depth + 1);
+    const fields = Object.keys(schema.properties).sort()
+      .map((name) => pad + name + ": " + describe(schema.properties[name], depth + 1));
+    return "{\n" + fields.join("\n") + "\n" + "  ".repeat(depth) + "}";
+  }
+  if (schema.additionalProperties && typeof schema.additionalProperties === "object") {
+    return "{ [key]: " + describe(schema.additionalProperties, depth) + " }";
+  }
+  let text = type || "any";
+  if (schema.format) text += " (" + schema.format + ")";
+  if (schema.enum) text += " " + schema.enum.join("|");
+  return text;
+}
+
+function operationView(path, method, operation) {
+  const body = el("div");
+  if (operation.description) body.append(el("p", {}, operation.description));
+
+  if (operation.parameters && operation.parameters.length) {
+    const rows = operation.parameters.map((p) =>
+      el("tr", {}, el("td", {}, p.name), el("td", {}, p.in), el("td", {}, describe(p.schema, 0)), el("td", {}, p.description || "")));
+    body.append(el("h4", {}, "Parameters"), el("table", {}, ...rows));
+  }
+  if (operation.requestBody) {
+    body.append(el("h4", {}, "Body (" + Object.keys(operation.requestBody.content).join(", ") + ")"));
+    body.append(el("pre", {}, describe(operation.requestBody.content["application/json"].schema, 0)));
+  }
+  for (const [status, response] of Object.entries(operation.responses)) {
+    if (status === "default") continue;
+    body.append(el("h4", {}, "Response " + status));
+    for (const [mediaType, content] of Object.entries(response.content || {})) {
+      body.append(el("pre", {}, content.schema ? describe(content.schema, 0) : mediaType));
+    }
+  }
+  body.append(el("p", {}, "Errors: application/problem+json with a stable code."));
+
+  const line = el("summary", {},
+    el("span", { class: "method " + method }, method),
+    el("span", { class: "path" }, path), " ", operation.summary);
+  if (operation.security) line.append(el("span", { class: "lock", title: "Bearer token" }, "🔒"));
+  if (operation.deprecated) line.append(el("span", { class: "deprecated" }, "deprecated"));
+  const details = el("details", { "data-search": (path + " " + operation.summary).toLowerCase() }, line, body);
+  return details;
+}
+
+async function render() {
+  const spec = await (await fetch("/openapi.json")).json();
+  document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+  document.getElementById("description").textContent = spec.info.description;
+
+  const byTag = new Map(spec.tags.map((tag) => [tag.name, []]));
+  for (const [path, methods] of Object.entries(spec.paths)) {
+    for (const [method, operation] of Object.entries(methods)) {
+      const tag = (operation.tags || ["Other"])[0];
+      if (!byTag.has(tag)) byTag.set(tag, []);
+      byTag.get(tag).push(operationView(path, method, operation));
+    }
+  }
+
+  const nav = document.getElementById("tags");
+  const operations = document.getElementById("operations");
+  for (const [tag, views] of byTag) {
+    const id = "tag-" + tag.replace(/\W+/g, "-");
+    nav.append(el("a", { href: "#" + id }, tag));
+    operations.append(el("h2", { id: id }, tag), ...views);
+  }
+
+  const schemas = document.getElementById("schemas");
+  schemas.append(el("h2", { id: "schemas-title" }, "Schemas"));
+  nav.append(el("a", { href: "#schemas-title" }, "Schemas"));
+  for (const name of Object.keys(spec.components.schemas).sort()) {
+    schemas.append(el("details", { id: "schema-" + name },
+      el("summary", {}, name), el("div", {}, el("pre", {}, describe(spec.components.schemas[name], 0)))));
+  }
+
+  document.getElementById("filter").addEventListener("input", (event) => {
+    const query = event.target.value.toLowerCase();
+    for (const details of operations.querySelectorAll("details")) {
+      details.classList.toggle("hidden", !details.dataset.search.includes(query));
+    }
+  });
+}
+
+render().catch((err) => {
+  document.getElementById("operations").textContent = "Could not load the API description: " + err;
+});
@@ -3,48 +3,97 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"project/internal/data"
 	"project/utils"
+	"project/utils/i18n"
 )
 
+// problem is an RFC 7807 problem document. Detail is in the request's language; code, errors
+// and the extensions are extension members clients can rely on instead of the text.
+type problem struct {
+	Type       string                  `json:"type"`
+	Title      string                  `json:"title"`
+	Status     int                     `json:"status"`
+	Detail     string                  `json:"detail,omitempty"`
+	Instance   string                  `json:"instance,omitempty"`
+	Code       i18n.Code               `json:"code"`
+	Errors     map[string]fieldProblem `json:"errors,omitempty"`
+	Extensions map[string]interface{}  `json:"-"`
+}
+
+type fieldProblem struct {
+	Code   i18n.Code `json:"code"`
+	Detail string    `json:"detail"`
+}
+
+func (p problem) MarshalJSON() ([]byte, error) {
+	type document problem
+	body, err := json.Marshal(document(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return body, err
+	}
+
+	members := map[string]interface{}{}
+	if err = json.Unmarshal(body, &members); err != nil {
+		return nil, err
+	}
+	for key, value := range p.Extensions {
+		if _, taken := members[key]; !taken {
+			members[key] = value
+		}
+	}
+	return json.Marshal(members)
+}
+
+func newProblem(r *http.Request, status int, code i18n.Code, detail string) problem {
+	return problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	}
+}
+
+func (app *application) problemResponse(w http.ResponseWriter, r *http.Request, p problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		app.logError(r, err)
+	}
+}
+
+// handleRetrievalError answers with the status, code and message of a data layer error.
+// Anything else is logged and hidden behind a server error, so SQL never reaches clients.
 func (app *application) handleRetrievalError(w http.ResponseWriter, r *http.Request, err error) {
-	switch {
-	case errors.Is(err, data.ErrRecordNotFound):
-		app.errorResponse(w, r, http.StatusNotFound, "Resources could not be found")
-	case errors.Is(err, data.ErrDuplicatedKey):
-		app.errorResponse(w, r, http.StatusConflict, "Email already exists, try something else")
-	case errors.Is(err, data.ErrUserNotFound):
-		app.errorResponse(w, r, http.StatusConflict, "Email not found, try again")
-	case errors.Is(err, data.ErrDuplicatedRole):
-		app.errorResponse(w, r, http.StatusConflict, "User already have the role")
-	case errors.Is(err, data.ErrHasRole):
-		app.errorResponse(w, r, http.StatusConflict, "User already has a role")
-	case errors.Is(err, data.ErrHasNoRoles):
-		app.errorResponse(w, r, http.StatusConflict, data.ErrHasNoRoles.Error())
-	case errors.Is(err, data.ErrForeignKeyViolation):
-		app.errorResponse(w, r, http.StatusNotFound, "Vendor ID is incorrect")
-	case errors.Is(err, data.ErrUserAlreadyhaveatable):
-		app.errorResponse(w, r, http.StatusConflict, data.ErrUserAlreadyhaveatable.Error())
-	case errors.Is(err, data.ErrUserHasNoTable):
-		app.errorResponse(w, r, http.StatusNotFound, data.ErrUserHasNoTable.Error())
-	case errors.Is(err, data.ErrItemAlreadyInserted):
-		app.errorResponse(w, r, http.StatusConflict, data.ErrItemAlreadyInserted.Error())
-	case errors.Is(err, data.ErrInvalidQuantity):
-		app.errorResponse(w, r, http.StatusConflict, data.ErrInvalidQuantity.Error())
-	default:
+	var dataErr *data.Error
+	if !errors.As(err, &dataErr) {
 		app.serverErrorResponse(w, r, err)
+		return
 	}
+	app.dataErrorResponse(w, r, dataErr)
 }
-func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, status int, message interface{}) {
-	env := utils.Envelope{"error": message}
-	err := utils.SendJSONResponse(w, status, env)
-	if err != nil {
-		app.logError(r, err)
-		w.WriteHeader(500)
 
+func (app *application) dataErrorResponse(w http.ResponseWriter, r *http.Request, dataErr *data.Error) {
+	lang := requestLang(r)
+	p := newProblem(r, dataErr.Status, dataErr.Code, dataErr.Text(lang))
+	if len(dataErr.Fields) > 0 {
+		p.Errors = make(map[string]fieldProblem, len(dataErr.Fields))
+		for field, message := range dataErr.Fields {
+			p.Errors[field] = fieldProblem{Code: message.Code, Detail: message.Text(lang)}
+		}
 	}
+	app.problemResponse(w, r, p)
+}
+
+// codedErrorResponse sends a catalog message in the request's language together with its code.
+// Every error a client sees goes through it, so none is sent without a code and a translation.
+func (app *application) codedErrorResponse(w http.ResponseWriter, r *http.Request, status int, message i18n.Message) {
+	app.problemResponse(w, r, newProblem(r, status, message.Code, message.Text(requestLang(r))))
 }
 
 func (app *application) logError(r *http.Request, err error) {
@@ -52,56 +101,63 @@ func (app *application) logError(r *http.Request, err error) {
 }
 func (app *application) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
 	app.logError(r, err)
-	message := "the server encountered a problem and could not process your request"
-	app.errorResponse(w, r, http.StatusInternalServerError, message)
+	app.codedErrorResponse(w, r, http.StatusInternalServerError, i18n.Message{Code: i18n.ServerError})
 }
 func (app *application) notFoundResponse(w http.ResponseWriter, r *http.Request) {
-	message := "resources not found"
-	app.errorResponse(w, r, http.StatusNotFound, message)
+	app.codedErrorResponse(w, r, http.StatusNotFound, i18n.Message{Code: i18n.NotFound})
 }
+
+// badRequestResponse reports input the handler rejected. A data layer error keeps its own status;
+// an error with no catalog message is a bug, logged and sent as a plain bad request.
 func (app *application) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
-	app.errorResponse(w, r, http.StatusBadRequest, err.Error())
+	var dataErr *data.Error
+	if errors.As(err, &dataErr) {
+		app.dataErrorResponse(w, r, dataErr)
+		return
+	}
+	var message i18n.Message
+	if errors.As(err, &message) {
+		app.codedErrorResponse(w, r, http.StatusBadRequest, message)
+		return
+	}
+	app.logError(r, fmt.Errorf("uncoded bad request: %w", err))
+	app.codedErrorResponse(w, r, http.StatusBadRequest, i18n.Message{Code: i18n.BadRequest})
 }
-func (app *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
-	app.errorResponse(w, r, http.StatusUnprocessableEntity, errors)
+
+// invalidIDResponse reports a path parameter that is not a valid ID.
+func (app *application) invalidIDResponse(w http.ResponseWriter, r *http.Request, param string) {
+	app.codedErrorResponse(w, r, http.StatusBadRequest, i18n.Message{Code: i18n.InvalidPathID, Args: []interface{}{param}})
+}
+
+// noPermissionResponse refuses a signed-in user access to a resource.
+func (app *application) noPermissionResponse(w http.ResponseWriter, r *http.Request) {
+	app.codedErrorResponse(w, r, http.StatusForbidden, i18n.Message{Code: i18n.NoPermission})
+}
+func (app *application) failedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]i18n.Message) {
+	app.dataErrorResponse(w, r, data.ValidationError(errors))
 }
 func (app *application) jwtErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
-	var message string
+	var code i18n.Code
 	switch {
 	case errors.Is(err, utils.ErrInvalidToken):
-		message = "invalid token"
+		code = i18n.InvalidToken
 	case errors.Is(err, utils.ErrExpiredToken):
-		message = "token has expired"
+		code = i18n.ExpiredToken
 	case errors.Is(err, utils.ErrMissingToken):
-		message = "missing authorization token"
+		code = i18n.MissingToken
 	case errors.Is(err, utils.ErrInvalidClaims):
-		message = "invalid token claims"
+		code = i18n.InvalidClaims
 	default:
-		app.errorResponse(w, r, http.StatusUnauthorized, "You don't have a premission")
-		return
+		code = i18n.NoPermission
 	}
-	app.errorResponse(w, r, http.StatusUnauthorized, message)
-}
-
-type ErrorResponse struct {
-	Error string `json:"error"`
+	app.codedErrorResponse(w, r, http.StatusUnauthorized, i18n.Message{Code: code})
 }
 
 func (app *application) ErrorHandlerMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				// Log the error
-				app.log.Println("Recovered from panic:", err)
-
-				// Send the error response
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusInternalServerError)
-
-				response := ErrorResponse{
-					Error: "Internal Server Error",
-				}
-				json.NewEncoder(w).Encode(response)
+				app.serverErrorResponse(w, r, fmt.Errorf("recovered from panic: %v", err))
 			}
 		}()
 
@@ -109,9 +165,6 @@ func (app *application) ErrorHandlerMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-/*
 func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
-	message := "rate limit exceeded"
-	app.errorResponse(w, r, http.StatusTooManyRequests, message)
+	app.codedErrorResponse(w, r, http.StatusTooManyRequests, i18n.Message{Code: i18n.RateLimited})
 }
-*/
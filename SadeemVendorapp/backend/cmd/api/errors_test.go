@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/i18n"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// routes lists the routes router serves, read from the OpenAPI document every route and
+// alias is registered in, so a new route is covered without touching this test.
+func routes(t *testing.T, router http.Handler) [][2]string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("reading /openapi.json: %v", err)
+	}
+	var found [][2]string
+	for path, methods := range spec.Paths {
+		path = pathParamRX.ReplaceAllString(path, "00000000-0000-0000-0000-000000000001")
+		for method := range methods {
+			found = append(found, [2]string{strings.ToUpper(method), path})
+		}
+	}
+	if len(found) == 0 {
+		t.Fatal("no routes in /openapi.json")
+	}
+	return found
+}
+
+// testApplication has a database that refuses connections, so every query fails.
+func testApplication(t *testing.T) *application {
+	t.Helper()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	db, err := sqlx.Open("postgres", "host=127.0.0.1 port=1 user=test dbname=test sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	logger := log.New(io.Discard, "", 0)
+	app := &application{log: logger, infoLog: logger, Model: data.NewModels(db)}
+	app.scheduler = newScheduler(realClock{}, &app.Model.JobDB, logger, "test")
+	return app
+}
+
+func TestRoutesRespondWithProblems(t *testing.T) {
+	app := testApplication(t)
+	router := app.Router()
+
+	identities := map[string]string{"anonymous": ""}
+	for name, role := range map[string]string{"customer": "3", "vendor": "2", "admin": "1"} {
+		token, err := utils.GenerateToken("00000000-0000-0000-0000-000000000002", role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		identities[name] = token
+	}
+
+	for _, route := range routes(t, router) {
+		for identity, token := range identities {
+			req := httptest.NewRequest(route[0], route[1], strings.NewReader(""))
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if identity == "anonymous" && rec.Code < 400 {
+				t.Errorf("%s %s as anonymous: got %d, want a failure", route[0], route[1], rec.Code)
+				continue
+			}
+			if rec.Code < 400 {
+				continue
+			}
+			checkProblem(t, route[0]+" "+route[1]+" as "+identity, rec)
+			if strings.Contains(rec.Body.String(), `"code":"`+string(i18n.BadRequest)+`"`) {
+				t.Errorf("%s %s as %s: error without a catalog message: %s", route[0], route[1], identity, rec.Body.String())
+			}
+		}
+	}
+}
+
+func checkProblem(t *testing.T, name string, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("%s: Content-Type %q, want application/problem+json", name, contentType)
+		return
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Errorf("%s: body is not JSON: %v", name, err)
+		return
+	}
+	for _, member := range []string{"type", "title", "detail", "code"} {
+		if value, _ := doc[member].(string); value == "" {
+			t.Errorf("%s: %q member missing in %s", name, member, rec.Body.String())
+		}
+	}
+	if status, _ := doc["status"].(float64); int(status) != rec.Code {
+		t.Errorf("%s: status member %v, response status %d", name, doc["status"], rec.Code)
+	}
+	if title, _ := doc["title"].(string); title != http.StatusText(rec.Code) {
+		t.Errorf("%s: title %q, want %q", name, title, http.StatusText(rec.Code))
+	}
+}
+
+func TestProblemHidesServerErrors(t *testing.T) {
+	app := testApplication(t)
+	req := httptest.NewRequest(http.MethodGet, "/vendors", nil)
+	rec := httptest.NewRecorder()
+	app.handleRetrievalError(rec, req, io.ErrUnexpectedEOF)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("got %d, want 500", rec.Code)
+	}
+	checkProblem(t, "unknown error", rec)
+	if strings.Contains(rec.Body.String(), io.ErrUnexpectedEOF.Error()) {
+		t.Errorf("internal error leaked: %s", rec.Body.String())
+	}
+}
+
+func TestProblemKeepsSpecificCode(t *testing.T) {
+	app := testApplication(t)
+	req := httptest.NewRequest(http.MethodPost, "/vendors", nil)
+	rec := httptest.NewRecorder()
+	app.handleRetrievalError(rec, req, data.ErrPlanLimitReached.With("plan_limit_items", 5))
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("got %d, want 403", rec.Code)
+	}
+	checkProblem(t, "plan limit", rec)
+	if !strings.Contains(rec.Body.String(), `"code":"plan_limit_items"`) {
+		t.Errorf("specific code missing: %s", rec.Body.String())
+	}
+}
+
+func TestProblemFieldErrors(t *testing.T) {
+	app := testApplication(t)
+	req := httptest.NewRequest(http.MethodPost, "/tags?lang=ar", nil)
+	req = req.WithContext(context.WithValue(req.Context(), LangKey, "ar"))
+	rec := httptest.NewRecorder()
+	app.failedValidationResponse(rec, req, map[string]i18n.Message{"name": {Code: i18n.NameTooLong, Args: []interface{}{50}}})
+
+	checkProblem(t, "validation", rec)
+	var doc struct {
+		Errors map[string]fieldProblem `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	field := doc.Errors["name"]
+	if field.Code != i18n.NameTooLong || field.Detail != i18n.T("ar", i18n.NameTooLong, 50) {
+		t.Errorf("got field %+v", field)
+	}
+}
+
+// TestHandlersSendCodedErrors fails when a handler builds the message of an error response
+// itself instead of sending a catalog code. Only server errors may wrap text, as it's logged
+// and never shown.
+func TestHandlersSendCodedErrors(t *testing.T) {
+	fset := token.NewFileSet()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			method := calledName(call)
+			if method == "http.Error" {
+				t.Errorf("%s: http.Error sends plain text", fset.Position(call.Pos()))
+				return true
+			}
+			if !strings.HasPrefix(method, "app.") || !strings.HasSuffix(method, "Response") || method == "app.serverErrorResponse" {
+				return true
+			}
+			for i, arg := range call.Args {
+				if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING && !(method == "app.invalidIDResponse" && i == 2) {
+					t.Errorf("%s: %s sends the text %s", fset.Position(arg.Pos()), method, lit.Value)
+				}
+				ast.Inspect(arg, func(n ast.Node) bool {
+					if inner, ok := n.(*ast.CallExpr); ok {
+						switch calledName(inner) {
+						case "errors.New", "fmt.Errorf", "fmt.Sprintf", "fmt.Sprint":
+							t.Errorf("%s: %s sends text built with %s", fset.Position(inner.Pos()), method, calledName(inner))
+						}
+					}
+					return true
+				})
+			}
+			return true
+		})
+	}
+}
+
+// calledName is the package.Func or receiver.Method a call names, or "" for other calls.
+func calledName(call *ast.CallExpr) string {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	return ident.Name + "." + selector.Sel.Name
+}
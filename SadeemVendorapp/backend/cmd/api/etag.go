@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"project/utils"
+	"project/utils/i18n"
+	"strconv"
+	"strings"
+)
+
+// etag is the entity tag of a resource at versions: its row's version, then those of any
+// resources embedded in it.
+func etag(versions ...int) string {
+	parts := make([]string, len(versions))
+	for i, version := range versions {
+		parts[i] = strconv.Itoa(version)
+	}
+	return fmt.Sprintf(`"%s"`, strings.Join(parts, "."))
+}
+
+// sendTagged sends env with an ETag of the versions of what it shows and a hash of the body.
+// The hash covers what the versions can't: the language, fields=, the opening status and
+// anything else the body is built from. Reads and updates both answer with it, so any ETag a
+// client holds can be sent back in If-Match.
+func sendTagged(w http.ResponseWriter, r *http.Request, env utils.Envelope, versions ...int) {
+	body, err := json.Marshal(env)
+	if err != nil {
+		utils.SendJSONResponse(w, http.StatusOK, env)
+		return
+	}
+	sum := sha256.Sum256(body)
+	tag := strings.TrimSuffix(etag(versions...), `"`) + "-" + hex.EncodeToString(sum[:8]) + `"`
+	if notModified(w, r, tag) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(body, '\n'))
+}
+
+// notModified sets the ETag of a response and reports whether the client's copy of a read,
+// named in If-None-Match, is still current; if so it has answered 304 and the handler is done.
+func notModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	for _, match := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		// If-None-Match compares weakly
+		match = strings.TrimPrefix(strings.TrimSpace(match), "W/")
+		if match == tag || match == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// readIfMatch reads the version an update was made against from its If-Match header: the
+// leading version of the ETag, the row's own. The versions of embedded resources and the body
+// hash don't bear on the update and are ignored. Updates must send one: without it the client
+// gets 428, and with a tag that doesn't start with a version, 412.
+func (app *application) readIfMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		app.codedErrorResponse(w, r, http.StatusPreconditionRequired, i18n.Message{Code: i18n.PreconditionRequired})
+		return 0, false
+	}
+	versions, _, _ := strings.Cut(strings.Trim(header, `"`), "-")
+	own, _, _ := strings.Cut(versions, ".")
+	version, err := strconv.Atoi(own)
+	if err != nil || len(header) < 2 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		app.preconditionFailedResponse(w, r)
+		return 0, false
+	}
+	return version, true
+}
+
+// checkVersion answers 412 and reports false when the row the client is updating is no longer
+// at the version it read.
+func (app *application) checkVersion(w http.ResponseWriter, r *http.Request, expected, current int) bool {
+	if expected != current {
+		app.preconditionFailedResponse(w, r)
+		return false
+	}
+	return true
+}
+
+func (app *application) preconditionFailedResponse(w http.ResponseWriter, r *http.Request) {
+	app.codedErrorResponse(w, r, http.StatusPreconditionFailed, i18n.Message{Code: i18n.PreconditionFailed})
+}
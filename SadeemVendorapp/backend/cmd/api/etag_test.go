@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"project/utils"
+	"strings"
+	"testing"
+)
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{`"3"`, true},
+		{`W/"3"`, true},
+		{`"2", "3"`, true},
+		{"*", true},
+		{`"2"`, false},
+		{`"3.1"`, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		if got := notModified(w, r, etag(3)); got != tt.want {
+			t.Errorf("If-None-Match %q: notModified = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+		if w.Header().Get("ETag") != `"3"` {
+			t.Errorf("ETag = %q", w.Header().Get("ETag"))
+		}
+		if tt.want && w.Code != http.StatusNotModified {
+			t.Errorf("status = %d, want 304", w.Code)
+		}
+	}
+}
+
+func TestSendTagged(t *testing.T) {
+	send := func(env utils.Envelope, ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		sendTagged(w, r, env, 3)
+		return w
+	}
+
+	english := send(utils.Envelope{"vendor": map[string]string{"name": "Grill", "opening_status": "open"}}, "")
+	tag := english.Header().Get("ETag")
+	if english.Code != http.StatusOK || !strings.HasPrefix(tag, `"3-`) {
+		t.Fatalf("status %d, ETag %q", english.Code, tag)
+	}
+	if again := send(utils.Envelope{"vendor": map[string]string{"name": "Grill", "opening_status": "open"}}, tag); again.Code != http.StatusNotModified {
+		t.Errorf("same body: status %d, want 304", again.Code)
+	}
+	// Same version, but translated or closed since: the client's copy is stale
+	for _, changed := range []utils.Envelope{
+		{"vendor": map[string]string{"name": "مشاوي", "opening_status": "open"}},
+		{"vendor": map[string]string{"name": "Grill", "opening_status": "closed"}},
+	} {
+		w := send(changed, tag)
+		if w.Code != http.StatusOK || w.Header().Get("ETag") == tag {
+			t.Errorf("%v: status %d, ETag %q", changed, w.Code, w.Header().Get("ETag"))
+		}
+	}
+}
+
+func TestReadIfMatch(t *testing.T) {
+	app := testApplication(t)
+	tests := []struct {
+		ifMatch     string
+		wantVersion int
+		wantStatus  int
+	}{
+		{`"7"`, 7, 0},
+		{"", 0, http.StatusPreconditionRequired},
+		{"7", 0, http.StatusPreconditionFailed},
+		{"*", 0, http.StatusPreconditionFailed},
+		{`"7.2"`, 7, 0},
+		{`"7-0a1b2c3d4e5f6071"`, 7, 0},
+		{`"7.2-0a1b2c3d4e5f6071"`, 7, 0},
+		{`".2-0a1b2c3d4e5f6071"`, 0, http.StatusPreconditionFailed},
+		{`"7`, 0, http.StatusPreconditionFailed},
+		{`"`, 0, http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("PATCH", "/", nil)
+		if tt.ifMatch != "" {
+			r.Header.Set("If-Match", tt.ifMatch)
+		}
+		w := httptest.NewRecorder()
+		version, ok := app.readIfMatch(w, r)
+		if ok != (tt.wantStatus == 0) || version != tt.wantVersion {
+			t.Errorf("If-Match %q: readIfMatch = %d, %v", tt.ifMatch, version, ok)
+		}
+		if tt.wantStatus != 0 && w.Code != tt.wantStatus {
+			t.Errorf("If-Match %q: status = %d, want %d", tt.ifMatch, w.Code, tt.wantStatus)
+		}
+	}
+}
+
+// TestETagRoundTrip sends back in If-Match the ETags reads and updates answer with, including
+// one of a read that embeds another resource.
+func TestETagRoundTrip(t *testing.T) {
+	app := testApplication(t)
+	for _, method := range []string{http.MethodGet, http.MethodPatch} {
+		for _, versions := range [][]int{{3}, {3, 5}} {
+			r := httptest.NewRequest(method, "/", nil)
+			r.Header.Set("If-None-Match", "*")
+			w := httptest.NewRecorder()
+			sendTagged(w, r, utils.Envelope{"item": map[string]int{"version": 3}}, versions...)
+			if method == http.MethodPatch && w.Code != http.StatusOK {
+				t.Errorf("PATCH answered %d to If-None-Match", w.Code)
+			}
+
+			update := httptest.NewRequest(http.MethodPatch, "/", nil)
+			update.Header.Set("If-Match", w.Header().Get("ETag"))
+			if version, ok := app.readIfMatch(httptest.NewRecorder(), update); !ok || version != 3 {
+				t.Errorf("%s %v: If-Match %q read as %d, %v", method, versions, w.Header().Get("ETag"), version, ok)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/validator"
+
+	"github.com/google/uuid"
+)
+
+// GetFloorPlanHandler returns the vendor's areas and the layout of its tables.
+func (app *application) GetFloorPlanHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	plan, err := app.Model.FloorPlanDB.GetFloorPlan(r.Context(), vendorID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"floor_plan": plan})
+}
+
+// SaveFloorPlanHandler replaces the vendor's areas and table layout in one go.
+// The editor sends the whole room as JSON, so this handler doesn't read form values.
+func (app *application) SaveFloorPlanHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	var input data.FloorPlanInput
+	if err = readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidatingFloorPlan(v, &input)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	plan, err := app.Model.FloorPlanDB.SaveFloorPlan(r.Context(), vendorID, &input)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"floor_plan": plan})
+}
+
+// LiveFloorHandler returns the floor plan with each table's session, open orders and pending requests.
+func (app *application) LiveFloorHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	floor, err := app.Model.FloorPlanDB.GetLiveFloor(r.Context(), vendorID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"floor": floor})
+}
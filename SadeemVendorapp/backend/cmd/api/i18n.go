@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"project/internal/data"
+	"project/utils/validator"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const LangKey contextKey = "lang"
+
+// resolveLanguage picks the response language from ?lang, then Accept-Language, then the
+// configured default, and stores it in the request context.
+func (app *application) resolveLanguage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := r.URL.Query().Get("lang")
+		if !validator.In(lang, data.Languages...) {
+			lang = negotiateLanguage(r.Header.Get("Accept-Language"))
+		}
+		if lang == "" {
+			lang = app.defaultLang()
+		}
+
+		w.Header().Set("Content-Language", lang)
+		w.Header().Add("Vary", "Accept-Language")
+
+		ctx := context.WithValue(r.Context(), LangKey, lang)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// negotiateLanguage returns the supported language the client prefers most, e.g. "ar" for
+// "ar-SA,ar;q=0.9,en;q=0.8", or "" when none of them is acceptable.
+func negotiateLanguage(header string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if q > 0 && validator.In(primary, data.Languages...) {
+			candidates = append(candidates, candidate{primary, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+func (app *application) defaultLang() string {
+	if app.cfg.i18n.defaultLang == "" {
+		return "en"
+	}
+	return app.cfg.i18n.defaultLang
+}
+
+// requestLang is the language resolved for the request.
+func requestLang(r *http.Request) string {
+	lang, _ := r.Context().Value(LangKey).(string)
+	return lang
+}
+
+// translating reports the language to translate into, or false when the stored content is
+// already in the requested language.
+func (app *application) translating(r *http.Request) (string, bool) {
+	lang := requestLang(r)
+	return lang, lang != "" && lang != app.defaultLang()
+}
+
+// contentLang is the language for queries that join translations themselves, empty when the
+// stored content is already in the requested language.
+func (app *application) contentLang(r *http.Request) string {
+	if lang, ok := app.translating(r); ok {
+		return lang
+	}
+	return ""
+}
+
+func (app *application) localizeVendors(r *http.Request, vendors []data.Vendor) error {
+	if lang, ok := app.translating(r); ok {
+		return app.Model.TranslationDB.LocalizeVendors(r.Context(), lang, vendors)
+	}
+	return nil
+}
+
+func (app *application) localizeItems(r *http.Request, items []data.Item) error {
+	if lang, ok := app.translating(r); ok {
+		return app.Model.TranslationDB.LocalizeItems(r.Context(), lang, items)
+	}
+	return nil
+}
+
+func (app *application) localizeReviews(r *http.Request, reviews []data.Review) error {
+	if lang, ok := app.translating(r); ok {
+		return app.Model.TranslationDB.LocalizeReviews(r.Context(), lang, reviews)
+	}
+	return nil
+}
+
+func (app *application) localizeTags(r *http.Request, tags []data.Tag) error {
+	if lang, ok := app.translating(r); ok {
+		return app.Model.TranslationDB.LocalizeTags(r.Context(), lang, tags)
+	}
+	return nil
+}
+
+func (app *application) localizeTagFacets(r *http.Request, facets []data.TagFacet) error {
+	if lang, ok := app.translating(r); ok {
+		return app.Model.TranslationDB.LocalizeTagFacets(r.Context(), lang, facets)
+	}
+	return nil
+}
+
+func (app *application) localizeSearch(r *http.Request, vendors []data.VendorSearchResult, items []data.ItemSearchResult) error {
+	if lang, ok := app.translating(r); ok {
+		return app.Model.TranslationDB.LocalizeSearch(r.Context(), lang, vendors, items)
+	}
+	return nil
+}
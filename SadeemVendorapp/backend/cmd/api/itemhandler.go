@@ -5,76 +5,97 @@ import (
 	"net/http"
 	"project/internal/data"
 	"project/utils"
+	"project/utils/filter"
+	"project/utils/i18n"
 	"project/utils/validator"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-func (app *application) CreateItemHandler(w http.ResponseWriter, r *http.Request) {
-	vendorID := r.PathValue("id")
-	name := r.FormValue("name")
-	priceStr := utils.NormalizeFloatInput(r.FormValue("price"))
-	discountStr := utils.NormalizeFloatInput(r.FormValue("discount"))
-	discountDaysStr := r.FormValue("discount_days")
-	quantityStr := r.FormValue("quantity")
+// itemRequest is the body of item creation and updates; the image comes as the "img" multipart
+// file. On update, fields left out keep their value.
+type itemRequest struct {
+	Name         string   `json:"name"`
+	Price        *float64 `json:"price"`
+	Discount     *float64 `json:"discount"`
+	DiscountDays *int     `json:"discount_days"`
+	Quantity     *int     `json:"quantity"`
+}
 
-	price, err := strconv.ParseFloat(priceStr, 64)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid price"))
-		return
+// rules are the checks on the body itself; the item it makes is checked by data.ValidatingItem.
+// Creating an item requires its price and quantity.
+func (input itemRequest) rules(creating bool) []validator.Rule {
+	price := validator.OptionalNumber("price", input.Price)
+	quantity := validator.OptionalNumber("quantity", input.Quantity)
+	if creating {
+		price.Required(i18n.PriceRequired)
+		quantity.Required(i18n.QuantityRequired)
 	}
-
-	discount, err := strconv.ParseFloat(discountStr, 64)
-	if err != nil {
-		discount = 0
+	return []validator.Rule{
+		price,
+		quantity,
+		validator.OptionalNumber("discount_days", input.DiscountDays).Above(0, i18n.DaysNotPositive),
 	}
+}
 
-	quantity, err := strconv.Atoi(quantityStr)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid quantity"))
-		return
+// apply copies the fields given in the body onto item. A new discount replaces the old one's
+// expiry; without discount_days it has none, which data.ValidatingItem rejects.
+func (input itemRequest) apply(item *data.Item) {
+	if input.Name != "" {
+		item.Name = input.Name
 	}
-
-	var discountExpiresAt *time.Time
-	if discountStr != "" && discount > 0 {
-		if discountDaysStr == "" {
-			app.badRequestResponse(w, r, errors.New("discount expiration date is required when a discount is provided"))
-			return
+	if input.Price != nil {
+		item.Price = *input.Price
+	}
+	if input.Discount != nil {
+		item.Discount = *input.Discount
+		item.DiscountExpiry = nil
+		if item.Discount > 0 {
+			item.DiscountExpiry = discountExpiry(input.DiscountDays)
 		}
+	}
+	if input.Quantity != nil {
+		item.Quantity = *input.Quantity
+	}
+}
 
-		discountDays, err := strconv.Atoi(discountDaysStr)
-		if err != nil {
-			app.badRequestResponse(w, r, errors.New("invalid discount days"))
-			return
-		}
-		expiration := time.Now().Add(time.Duration(discountDays) * 24 * time.Hour)
-		discountExpiresAt = &expiration
+// discountExpiry is when a discount given for days days ends, or nil without days.
+func discountExpiry(days *int) *time.Time {
+	if days == nil {
+		return nil
+	}
+	expiration := time.Now().Add(time.Duration(*days) * 24 * time.Hour)
+	return &expiration
+}
+
+func (app *application) CreateItemHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID := r.PathValue("id")
+	var input itemRequest
+	err := app.readRequest(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
 	}
 
 	vendorsID, err := uuid.Parse(vendorID)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid vendor ID"))
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
 	item := &data.Item{
-		ID:             uuid.New(),
-		VendorID:       vendorsID,
-		Name:           name,
-		Price:          price,
-		Discount:       discount,
-		DiscountExpiry: discountExpiresAt,
-		Quantity:       quantity,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
-	}
-
-	// Validate item
+		ID:        uuid.New(),
+		VendorID:  vendorsID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	input.apply(item)
+
 	v := validator.New()
-	data.ValidatingItem(v, item, "name", "price", "discount", "discount_expiry", "quantity")
+	v.Validate(input.rules(true)...)
+	data.ValidatingItem(v, item)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
@@ -84,7 +105,7 @@ func (app *application) CreateItemHandler(w http.ResponseWriter, r *http.Request
 		defer file.Close()
 		imageName, err := utils.SaveImageFile(file, "items", fileHeader.Filename)
 		if err != nil {
-			app.errorResponse(w, r, http.StatusBadRequest, "invalid image")
+			app.codedErrorResponse(w, r, http.StatusBadRequest, i18n.Message{Code: i18n.ImageInvalid})
 			return
 		}
 		item.Img = &imageName
@@ -95,7 +116,7 @@ func (app *application) CreateItemHandler(w http.ResponseWriter, r *http.Request
 		if item.Img != nil {
 			utils.DeleteImageFile(*item.Img)
 		}
-		app.serverErrorResponse(w, r, err)
+		app.handleRetrievalError(w, r, err)
 		return
 	}
 
@@ -104,16 +125,16 @@ func (app *application) CreateItemHandler(w http.ResponseWriter, r *http.Request
 
 // DeleteItemHandler handles the deletion of an item by its ID.
 func (app *application) DeleteItemHandler(w http.ResponseWriter, r *http.Request) {
-	itemID, err := uuid.Parse(r.PathValue("itemid"))
+	itemID, err := uuid.Parse(r.PathValue("item_id"))
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid item ID"))
+		app.invalidIDResponse(w, r, "item_id")
 		return
 	}
 
 	err = app.Model.ItemDB.DeleteItem(itemID)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
-			app.errorResponse(w, r, http.StatusNotFound, "item already deleted")
+			app.notFoundResponse(w, r)
 		} else {
 			app.serverErrorResponse(w, r, err)
 		}
@@ -125,43 +146,64 @@ func (app *application) DeleteItemHandler(w http.ResponseWriter, r *http.Request
 func (app *application) GetAllItemsHandler(w http.ResponseWriter, r *http.Request) {
 	vendorID := uuid.MustParse(r.PathValue("id"))
 
-	// Create a Filters instance from request parameters
-	var itemsSortSafelist = []string{"created_at", "name", "price"}
+	v := validator.New()
 	filters := utils.Filters{
-		Page:         1,                 // Default page
-		PageSize:     10,                // Default page size
-		Sort:         "created_at",      // Default sort
-		SortSafelist: itemsSortSafelist, // Set the safelist
+		Search:     r.URL.Query().Get("search"),
+		Conditions: filter.Parse(v, r.URL.Query(), data.ItemFilters),
 	}
-
-	// Parse query parameters for filters
-	if page := r.URL.Query().Get("page"); page != "" {
-		filters.Page, _ = strconv.Atoi(page)
+	page := app.readPage(v, r, data.ItemSorts)
+	shape := readShape(v, r, data.Item{}, "vendor")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
 	}
-	if pageSize := r.URL.Query().Get("page_size"); pageSize != "" {
-		filters.PageSize, _ = strconv.Atoi(pageSize)
+	items, meta, err := app.Model.ItemDB.GetAllItems(r.Context(), vendorID, filters, page)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
 	}
-	if sort := r.URL.Query().Get("sort"); sort != "" {
-		filters.Sort = sort
+	if err = app.localizeItems(r, items); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
 	}
-	if search := r.URL.Query().Get("search"); search != "" {
-		filters.Search = search
+	if shape.includes("vendor") {
+		if err = app.setItemVendors(r, items); err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
 	}
 
-	v := validator.New()
+	app.sendPage(w, r, "items", shape.trim(items), meta, nil)
+}
 
-	utils.ValidateFilters(v, filters)
-	if !v.Valid() {
-		app.failedValidationResponse(w, r, v.Errors)
-		return
+// setItemVendors embeds the vendor of each item, loaded in one query.
+func (app *application) setItemVendors(r *http.Request, items []data.Item) error {
+	ids := make([]uuid.UUID, len(items))
+	for i := range items {
+		ids[i] = items[i].VendorID
 	}
-	items, err := app.Model.ItemDB.GetAllItems(vendorID, filters)
+
+	loaded, err := app.Model.VendorDB.GetVendorsByID(r.Context(), ids)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		return
+		return err
+	}
+	vendors := make([]data.Vendor, 0, len(loaded))
+	for _, vendor := range loaded {
+		vendors = append(vendors, vendor)
+	}
+	if err = app.localizeVendors(r, vendors); err != nil {
+		return err
+	}
+	for i := range vendors {
+		loaded[vendors[i].ID] = vendors[i]
 	}
 
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"items": items})
+	for i := range items {
+		if vendor, ok := loaded[items[i].VendorID]; ok {
+			items[i].Vendor = &vendor
+		}
+	}
+	return nil
 }
 func (app *application) GetAllItemsCountHandler(w http.ResponseWriter, r *http.Request) {
 	vendorID := uuid.MustParse(r.PathValue("id"))
@@ -179,9 +221,15 @@ func (app *application) GetAllItemsCountHandler(w http.ResponseWriter, r *http.R
 
 // GetItemHandler handles fetching a single item by its ID.
 func (app *application) GetItemHandler(w http.ResponseWriter, r *http.Request) {
-	itemID, err := uuid.Parse(r.PathValue("itemid"))
+	itemID, err := uuid.Parse(r.PathValue("item_id"))
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid item ID"))
+		app.invalidIDResponse(w, r, "item_id")
+		return
+	}
+	v := validator.New()
+	shape := readShape(v, r, data.Item{}, "vendor")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
@@ -194,27 +242,38 @@ func (app *application) GetItemHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"item": item})
+	shown := []data.Item{*item}
+	if err = app.localizeItems(r, shown); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	versions := []int{item.Version}
+	if shape.includes("vendor") {
+		if err = app.setItemVendors(r, shown); err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if shown[0].Vendor != nil {
+			versions = append(versions, shown[0].Vendor.Version)
+		}
+	}
+	sendTagged(w, r, utils.Envelope{"item": shape.trim(shown[0])}, versions...)
 }
 func (app *application) UpdateItemHandler(w http.ResponseWriter, r *http.Request) {
 
-	itemID, err := uuid.Parse(r.PathValue("itemid"))
+	itemID, err := uuid.Parse(r.PathValue("item_id"))
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid item ID"))
+		app.invalidIDResponse(w, r, "item_id")
+		return
+	}
+	version, ok := app.readIfMatch(w, r)
+	if !ok {
 		return
 	}
 
-	name := r.FormValue("name")
-	priceStr := r.FormValue("price")
-	discountStr := r.FormValue("discount")
-	discountDaysStr := r.FormValue("discount_days")
-	quantityStr := r.FormValue("quantity")
-
-	price := utils.NormalizeFloatInput(priceStr)
-	pricee, err := strconv.ParseFloat(price, 64)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid price"))
+	var input itemRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
 		return
 	}
 	// Get the existing item
@@ -227,48 +286,15 @@ func (app *application) UpdateItemHandler(w http.ResponseWriter, r *http.Request
 		}
 		return
 	}
-
-	discount, err := strconv.ParseFloat(discountStr, 64)
-	if err != nil && discountStr != "" {
-		discount = 0 // Default to 0 if invalid
+	if !app.checkVersion(w, r, version, item.Version) {
+		return
 	}
 
-	if name != "" {
-		item.Name = name
-	}
-	if priceStr != "" {
-		item.Price = pricee
-	}
-	if discountStr != "" {
-		item.Discount = discount
-		if discount > 0 {
-			if discountDaysStr == "" {
-				app.badRequestResponse(w, r, errors.New("discount expiration date is required when a discount is provided"))
-				return
-			}
-			discountDays, err := strconv.Atoi(discountDaysStr)
-			if err != nil {
-				app.badRequestResponse(w, r, errors.New("invalid discount days"))
-				return
-			}
-			expiration := time.Now().Add(time.Duration(discountDays) * 24 * time.Hour)
-			item.DiscountExpiry = &expiration
-		} else {
-			item.DiscountExpiry = nil
-		}
-	}
-
-	if quantityStr != "" {
-		quantity, err := strconv.Atoi(quantityStr)
-		if err != nil {
-			app.badRequestResponse(w, r, errors.New("invalid quantity"))
-			return
-		}
-		item.Quantity = quantity
-	}
+	input.apply(item)
 
 	v := validator.New()
-	data.ValidatingItem(v, item, "name", "price", "discount", "discount_expiry", "quantity")
+	v.Validate(input.rules(false)...)
+	data.ValidatingItem(v, item)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
@@ -283,20 +309,22 @@ func (app *application) UpdateItemHandler(w http.ResponseWriter, r *http.Request
 		defer file.Close()
 		imageName, err := utils.SaveImageFile(file, "items", fileHeader.Filename)
 		if err != nil {
-			app.errorResponse(w, r, http.StatusBadRequest, "invalid image")
+			app.codedErrorResponse(w, r, http.StatusBadRequest, i18n.Message{Code: i18n.ImageInvalid})
 			return
 		}
-		if item.Img != nil {
-			utils.DeleteImageFile(*item.Img)
-		}
+		// The old image goes once the update is saved, which may fail on a conflict
 		item.Img = &imageName
 	}
 
 	err = app.Model.ItemDB.UpdateItem(item)
 	if err != nil {
-		if item.Img != nil {
+		if item.Img != nil && (oldImg == nil || *item.Img != *oldImg) {
 			utils.DeleteImageFile(*item.Img)
 		}
+		if errors.Is(err, data.ErrEditConflict) {
+			app.preconditionFailedResponse(w, r)
+			return
+		}
 		app.serverErrorResponse(w, r, err)
 		return
 	}
@@ -304,6 +332,5 @@ func (app *application) UpdateItemHandler(w http.ResponseWriter, r *http.Request
 	if oldImg != nil && item.Img != nil && *oldImg != *item.Img {
 		utils.DeleteImageFile(*oldImg)
 	}
-
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"item": item})
+	sendTagged(w, r, utils.Envelope{"item": item}, item.Version)
 }
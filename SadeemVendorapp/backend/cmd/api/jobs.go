@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/validator"
+	"time"
+)
+
+// completedOrderRetention is how long a completed order is kept before the cleanup job removes it.
+const completedOrderRetention = 30 * time.Minute
+
+// registerJobs adds the time-based business rules to the scheduler. They used to be DB
+// triggers that only fired when a row happened to be updated.
+func (app *application) registerJobs() error {
+	jobs := []struct {
+		name string
+		spec string
+		run  jobFunc
+	}{
+		{"discount-expiry", "* * * * *", app.expireDiscountsJob},
+		{"subscription-visibility", "* * * * *", app.syncSubscriptions},
+		{"completed-order-cleanup", "*/5 * * * *", app.completedOrderCleanupJob},
+	}
+	for _, j := range jobs {
+		if err := app.scheduler.Register(j.name, j.spec, j.run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (app *application) expireDiscountsJob(ctx context.Context, now time.Time) (string, error) {
+	expired, err := app.Model.ItemDB.ExpireDiscounts(ctx, now)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d discounts expired", expired), nil
+}
+
+func (app *application) completedOrderCleanupJob(ctx context.Context, now time.Time) (string, error) {
+	deleted, err := app.Model.OrderDB.DeleteCompletedOrdersBefore(ctx, now.Add(-completedOrderRetention))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d completed orders deleted", deleted), nil
+}
+
+// instanceName identifies this replica in the job run history.
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// GetJobsHandler lists the scheduled jobs with their next and last runs.
+func (app *application) GetJobsHandler(w http.ResponseWriter, r *http.Request) {
+	lastRuns, err := app.Model.JobDB.GetLastRuns(r.Context())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	jobs := app.scheduler.Jobs()
+	for i := range jobs {
+		if run, ok := lastRuns[jobs[i].Name]; ok {
+			jobs[i].LastRun = &run
+		}
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"jobs": jobs, "scheduler_enabled": app.cfg.jobs.enabled})
+}
+
+// GetJobRunsHandler returns a page of a job's run history, newest first by default.
+func (app *application) GetJobRunsHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !app.scheduler.Has(name) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	page := app.readPage(v, r, data.JobRunSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	runs, meta, err := app.Model.JobDB.GetRuns(r.Context(), name, page)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.sendPage(w, r, "runs", runs, meta, nil)
+}
+
+// TriggerJobHandler runs a job now. The run happens in the background; its outcome shows up in the run history.
+func (app *application) TriggerJobHandler(w http.ResponseWriter, r *http.Request) {
+	err := app.scheduler.Trigger(r.PathValue("name"))
+	if err != nil {
+		if errors.Is(err, errUnknownJob) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusAccepted, utils.Envelope{"message": "job started"})
+}
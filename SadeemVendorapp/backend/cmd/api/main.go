@@ -8,8 +8,11 @@ import (
 	"net/http"
 	"os"
 	"time"
+	// Vendor time zones must load even on hosts without a zoneinfo database
+	_ "time/tzdata"
 
 	"project/internal/data"
+	"project/utils/pagination"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
@@ -21,6 +24,7 @@ type config struct {
 	env  string
 	db   struct {
 		dsn          string
+		checkSchema  bool
 		maxOpenConns int
 		maxIdleConns int
 		maxIdleTime  string
@@ -30,13 +34,33 @@ type config struct {
 		burst   int
 		enabled bool
 	}
+	qr struct {
+		baseURL string
+	}
+	subscription struct {
+		reminderDays string
+	}
+	jobs struct {
+		enabled bool
+	}
+	i18n struct {
+		defaultLang string
+	}
+	legacy struct {
+		sunset time.Time
+	}
+	pagination struct {
+		secret string
+		limits pagination.Limits
+	}
 }
 
 type application struct {
-	cfg     config
-	log     *log.Logger
-	Model   data.Model
-	infoLog *log.Logger
+	cfg       config
+	log       *log.Logger
+	Model     data.Model
+	infoLog   *log.Logger
+	scheduler *scheduler
 }
 
 func main() {
@@ -54,14 +78,43 @@ func main() {
 	flag.IntVar(&cfg.db.maxOpenConns, "db-max-open-conns", 25, "PostgreSQL max open connections")
 	flag.IntVar(&cfg.db.maxIdleConns, "db-max-idle-conns", 25, "PostgreSQL max idle connections")
 	flag.StringVar(&cfg.db.maxIdleTime, "db-max-idle-time", "15m", "PostgreSQL max connection idle time")
+	flag.BoolVar(&cfg.db.checkSchema, "db-check-schema", true, "Refuse to serve when the database lacks migrations of this build")
 
 	// Add rate limiter flags
 	flag.Float64Var(&cfg.limiter.rps, "limiter-rps", 2, "Rate limiter maximum requests per second")
 	flag.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum burst")
 	flag.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable rate limiter")
 
+	// Table QR codes deep-link into the customer app
+	qrBaseURL := os.Getenv("QR_BASE_URL")
+	if qrBaseURL == "" {
+		qrBaseURL = "http://localhost:3000/scan"
+	}
+	flag.StringVar(&cfg.qr.baseURL, "qr-base-url", qrBaseURL, "Base URL that table QR codes link to")
+
+	flag.StringVar(&cfg.subscription.reminderDays, "subscription-reminder-days", "7,1", "Comma separated days before expiry to remind vendors")
+
+	flag.BoolVar(&cfg.jobs.enabled, "jobs-enabled", true, "Run scheduled background jobs on this instance")
+
+	flag.StringVar(&cfg.i18n.defaultLang, "default-lang", "en", "Language of the stored vendor, item and tag content; served when no translation exists (en or ar)")
+
+	// The unversioned routes answer as deprecated aliases of /v1 until this date
+	var legacySunset string
+	flag.StringVar(&legacySunset, "legacy-sunset", "2027-04-30", "Date the unversioned routes are removed, as YYYY-MM-DD")
+
+	// List cursors are signed so clients can't forge them; without a secret they only last
+	// until the next restart
+	flag.StringVar(&cfg.pagination.secret, "cursor-secret", os.Getenv("CURSOR_SECRET"), "Secret that signs list cursors")
+	flag.IntVar(&cfg.pagination.limits.Default, "page-limit", pagination.DefaultLimits.Default, "Rows on a list page when the request sets no limit")
+	flag.IntVar(&cfg.pagination.limits.Max, "page-limit-max", pagination.DefaultLimits.Max, "Largest limit a list request may ask for")
+
 	flag.Parse()
 
+	cfg.legacy.sunset, err = time.Parse("2006-01-02", legacySunset)
+	if err != nil {
+		log.Fatalf("invalid -legacy-sunset: %v", err)
+	}
+
 	infoLog := log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime)
 	logger := log.New(os.Stdout, "", log.Ldate|log.Ltime)
 
@@ -70,8 +123,29 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// api migrate ... manages the schema instead of serving
+	if flag.Arg(0) == "migrate" {
+		if err = runMigrate(context.Background(), db, flag.Args()[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if cfg.db.checkSchema {
+		if err = checkSchema(context.Background(), db); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	model := data.NewModels(db)
-	app := application{
+
+	// api admin ... manages accounts and vendors from the shell
+	if flag.Arg(0) == "admin" {
+		if err = runAdmin(context.Background(), model, flag.Args()[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	app := &application{
 		cfg:     cfg,
 		log:     logger,
 		Model:   model,
@@ -86,6 +160,14 @@ func main() {
 		WriteTimeout: 30 * time.Second,
 	}
 
+	app.scheduler = newScheduler(realClock{}, &app.Model.JobDB, logger, instanceName())
+	if err := app.registerJobs(); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.jobs.enabled {
+		go app.scheduler.Start(context.Background())
+	}
+
 	log.Printf("starting %s server on %s", cfg.env, srv.Addr)
 	err = srv.ListenAndServe()
 	logger.Fatal(err)
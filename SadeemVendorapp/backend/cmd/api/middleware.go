@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"project/internal/data"
 	"project/utils"
+	"project/utils/i18n"
 	"project/utils/validator"
 	"strconv"
 	"strings"
@@ -38,12 +39,7 @@ func (app *application) AuthMiddleware(next http.Handler) http.HandlerFunc {
 		tokenString := parts[1]
 		token, err := utils.ValidateToken(tokenString)
 		if err != nil {
-			switch err.Error() {
-			case "token contains an invalid number of segments":
-				app.jwtErrorResponse(w, r, utils.ErrInvalidToken)
-			default:
-				app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
-			}
+			app.jwtErrorResponse(w, r, err)
 			return
 		}
 
@@ -87,7 +83,7 @@ func (app *application) requireAdmin(next http.Handler) http.HandlerFunc {
 			if len(v.Errors) > 0 {
 				app.failedValidationResponse(w, r, v.Errors)
 			} else {
-				app.jwtErrorResponse(w, r, errors.New("you do not have permission to access this resource"))
+				app.noPermissionResponse(w, r)
 			}
 			return
 		}
@@ -99,7 +95,7 @@ func (app *application) requireAdmin(next http.Handler) http.HandlerFunc {
 func (app *application) isAdmin(v *validator.Validator, r *http.Request) bool {
 	userIDStr, ok := r.Context().Value(UserRoleKey).(string)
 	if !ok {
-		v.AddError("Token", "User ID is missing from context")
+		v.AddError("Token", i18n.UserIDMissing)
 		return false
 	}
 	userIDStrs, err := strconv.Atoi(userIDStr)
@@ -114,13 +110,13 @@ func (app *application) requireVendorPermission(next http.Handler) http.HandlerF
 		vendorIDStr := r.PathValue("id")
 
 		if vendorIDStr == "" {
-			app.badRequestResponse(w, r, errors.New("vendor ID is required"))
+			app.invalidIDResponse(w, r, "id")
 			return
 		}
 
 		vendorID, err := uuid.Parse(vendorIDStr)
 		if err != nil {
-			app.badRequestResponse(w, r, errors.New("invalid vendor ID format"))
+			app.invalidIDResponse(w, r, "id")
 			return
 		}
 
@@ -132,10 +128,10 @@ func (app *application) requireVendorPermission(next http.Handler) http.HandlerF
 
 		err = app.isVendorOwner(r, vendorID)
 		if err != nil {
-			if errors.Is(err, data.ErrRecordNotFound) {
-				app.jwtErrorResponse(w, r, errors.New("you do not have permission to access this resource"))
+			if errors.Is(err, errNotVendorOwner) {
+				app.noPermissionResponse(w, r)
 			} else {
-				app.jwtErrorResponse(w, r, errors.New("internal server error while checking vendor permissions"))
+				app.serverErrorResponse(w, r, err)
 			}
 			return
 		}
@@ -144,51 +140,54 @@ func (app *application) requireVendorPermission(next http.Handler) http.HandlerF
 	})
 }
 
-// Check if the user is the owner of the vendor
+var errNotVendorOwner = errors.New("user does not administer the vendor")
+
+// Check if the user is the owner of the vendor; any other error is the lookup failing
 func (app *application) isVendorOwner(r *http.Request, vendorID uuid.UUID) error {
 	userIDStr, ok := r.Context().Value(UserIDKey).(string)
 	if !ok {
-		return errors.New("user ID is missing from context")
+		return errNotVendorOwner
 	}
 
 	userid, err := uuid.Parse(userIDStr)
 	if err != nil {
-		return errors.New("invalid user ID format")
+		return errNotVendorOwner
 	}
 
 	ownerns, err := app.Model.VendorAdminDB.GetVendorAdmins(r.Context(), vendorID)
 	if err != nil {
 		if errors.Is(err, data.ErrRecordNotFound) {
-			return errors.New("you do not have permission to access this resource")
+			return errNotVendorOwner
 		}
-		return errors.New("error checking vendor ownership")
+		return err
 	}
 	for _, value := range ownerns {
 		if value.UserID == userid {
 			return nil // user is a vendor admin, so return no error
 		}
 	}
-	return errors.New("you do not have permission to access this resource")
+	return errNotVendorOwner
 }
 func (app *application) AuthorizeUserUpdate(next http.Handler) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userIDFromURL := r.PathValue("id")
 		userIDFromContext, ok := r.Context().Value(UserIDKey).(string)
 		if !ok {
-			app.errorResponse(w, r, http.StatusUnauthorized, "user ID is missing from context")
+			app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
 			return
 		}
 
 		userRole, ok := r.Context().Value(UserRoleKey).(string)
 		if !ok {
-			app.errorResponse(w, r, http.StatusUnauthorized, "user role is missing from context")
+			app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
 			return
 		}
 
-		if r.Method == http.MethodPut {
+		// Routes without an {id} act on the caller's own resources
+		if (r.Method == http.MethodPut || r.Method == http.MethodPatch) && userIDFromURL != "" {
 			// Check if the user is updating their own account or is an admin
 			if userIDFromContext != userIDFromURL && userRole != "1" {
-				app.errorResponse(w, r, http.StatusForbidden, "you do not have permission to update this user")
+				app.codedErrorResponse(w, r, http.StatusForbidden, i18n.Message{Code: i18n.UserUpdateForbidden})
 				return
 			}
 		}
@@ -206,8 +205,9 @@ func secureHeaders(next http.Handler) http.Handler {
 
 		// CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000") // Allow only your frontend's origin
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Accept-Language, If-Match, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "Deprecation, Sunset, Link, ETag")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		// Handle preflight request
@@ -227,6 +227,40 @@ func (app *application) logRequest(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// deprecatedRoute returns the wrapper of legacy route aliases. Their responses carry the
+// Deprecation and Sunset headers and a successor-version link to the /v1 route, and each use
+// is logged so the aliases can be removed once clients have moved.
+func (app *application) deprecatedRoute(deprecated, sunset time.Time) func(alias, successor string, next http.Handler) http.HandlerFunc {
+	return func(alias, successor string, next http.Handler) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", deprecated.Unix()))
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			_, path, _ := strings.Cut(successor, " ")
+			if link, ok := fillPathValues(r, path); ok {
+				w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", link))
+			}
+			app.infoLog.Printf("deprecated route %q called by %s (%s), successor is %s", alias, r.RemoteAddr, r.UserAgent(), successor)
+			next.ServeHTTP(w, r)
+		}
+	}
+}
+
+// fillPathValues puts the request's path values into the wildcards of path. It is false when
+// the request lacks one of them.
+func fillPathValues(r *http.Request, path string) (string, bool) {
+	ok := true
+	filled := pathParamRX.ReplaceAllStringFunc(path, func(wildcard string) string {
+		value := r.PathValue(strings.Trim(wildcard, "{}"))
+		if value == "" {
+			ok = false
+		}
+		return value
+	})
+	return filled, ok
+}
 func (app *application) recoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
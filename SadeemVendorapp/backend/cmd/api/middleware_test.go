@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLegacyRoutesAreDeprecated(t *testing.T) {
+	app := testApplication(t)
+	app.cfg.legacy.sunset = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+	var logged bytes.Buffer
+	app.infoLog = log.New(&logged, "", 0)
+	router := app.Router()
+
+	const userID = "00000000-0000-0000-0000-000000000001"
+	req := httptest.NewRequest(http.MethodPut, "/users/"+userID, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	for header, want := range map[string]string{
+		"Deprecation": "@1792368000",
+		"Sunset":      "Fri, 30 Apr 2027 00:00:00 GMT",
+		"Link":        `</v1/users/` + userID + `>; rel="successor-version"`,
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if !strings.Contains(logged.String(), `deprecated route "PUT users/{id}"`) {
+		t.Errorf("alias use was not logged: %q", logged.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/v1/users/"+userID, nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("Deprecation") != "" {
+		t.Errorf("PATCH /v1/users/{id}: status %d, Deprecation %q", rec.Code, rec.Header().Get("Deprecation"))
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"project/internal/data"
+	"project/internal/migrations"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const migrateUsage = "usage: api migrate up [n] | down [n|-all] | status | force <version> | verify"
+
+// runMigrate runs the migrate subcommand: up and down apply n migrations, all pending ones for
+// up; down needs -all to revert everything. status prints the version and what is pending,
+// force records a version after a failed migration was fixed by hand, and verify applies
+// every migration to a scratch schema and reports where it disagrees with internal/data.
+func runMigrate(ctx context.Context, db *sqlx.DB, args []string, out io.Writer) error {
+	all, err := migrations.All()
+	if err != nil {
+		return err
+	}
+	m := migrations.New(db.DB, all)
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	// steps reads the count after up or down, 0 meaning all; down only reverts all with -all
+	steps := func(down bool) (int, error) {
+		switch {
+		case len(args) < 2 && !down:
+			return 0, nil
+		case len(args) < 2:
+			return 0, errors.New(migrateUsage)
+		case args[1] == "-all" && down:
+			return 0, nil
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return 0, errors.New(migrateUsage)
+		}
+		return n, nil
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := steps(false)
+		if err != nil {
+			return err
+		}
+		applied, err := m.Up(ctx, n)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %06d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no change")
+		}
+		return err
+	case "down":
+		n, err := steps(true)
+		if err != nil {
+			return err
+		}
+		reverted, err := m.Down(ctx, n)
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %06d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "version %d of %d", status.Version, status.Latest)
+		if status.Dirty {
+			fmt.Fprint(out, " (dirty)")
+		}
+		fmt.Fprintln(out)
+		for _, migration := range status.Pending {
+			fmt.Fprintf(out, "pending %06d_%s\n", migration.Version, migration.Name)
+		}
+		return nil
+	case "force":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return errors.New(migrateUsage)
+		}
+		if err = m.Force(ctx, version); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "forced version %d\n", version)
+		return nil
+	case "verify":
+		var drift []string
+		err := m.Scratch(ctx, func(tx *sql.Tx) error {
+			var err error
+			drift, err = data.VerifySchema(ctx, tx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for _, mismatch := range drift {
+			fmt.Fprintln(out, mismatch)
+		}
+		if len(drift) > 0 {
+			return fmt.Errorf("%d mismatches between the migrations and internal/data", len(drift))
+		}
+		fmt.Fprintln(out, "schema matches internal/data")
+		return nil
+	}
+	return errors.New(migrateUsage)
+}
+
+// checkSchema refuses to serve on a database that lacks migrations this binary relies on.
+func checkSchema(ctx context.Context, db *sqlx.DB) error {
+	all, err := migrations.All()
+	if err != nil {
+		return err
+	}
+	status, err := migrations.New(db.DB, all).Status(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case status.Dirty:
+		return fmt.Errorf("schema is dirty at version %d; fix it and run migrate force", status.Version)
+	case status.Behind():
+		return fmt.Errorf("schema is at version %d but this build needs %d; run migrate up", status.Version, status.Latest)
+	}
+	return nil
+}
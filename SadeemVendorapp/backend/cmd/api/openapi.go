@@ -0,0 +1,577 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/filter"
+	"project/utils/i18n"
+	"project/utils/pagination"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-michi/michi"
+	"github.com/google/uuid"
+)
+
+// access is who may call a route, as enforced by its middleware chain.
+type access int
+
+const (
+	public access = iota
+	signedIn
+	vendorStaff
+	adminOnly
+)
+
+// param is a query parameter. Type is a JSON schema type and defaults to string.
+type param struct {
+	Name        string
+	Type        string
+	Description string
+	Enum        []string
+}
+
+var (
+	limitParam  = param{Name: "limit", Type: "integer", Description: "Results per page."}
+	cursorParam = param{Name: "cursor", Description: "The next_cursor or prev_cursor of another page."}
+	searchParam = param{Name: "search", Description: "Free text search."}
+	fieldsParam = param{Name: "fields", Description: "Comma separated fields to send, all of them by default."}
+)
+
+// includeParam is the include parameter of a read that can embed includes.
+func includeParam(includes ...string) param {
+	return param{Name: "include", Description: fmt.Sprintf("Comma separated related resources to embed: %s.", strings.Join(includes, ", "))}
+}
+
+// paged adds the limit, cursor and sort parameters of a list read in sorts to params.
+func paged(sorts pagination.Sorts, params ...param) []param {
+	names := make([]string, 0, len(sorts.Orders))
+	for name := range sorts.Orders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sortParam := param{Name: "sort", Enum: names, Description: fmt.Sprintf("Order of the list, %s by default.", sorts.Default)}
+	if len(sorts.Fields) > 0 {
+		// Fields combine freely, so they can't be listed as an enum
+		fields := make([]string, 0, len(sorts.Fields))
+		for name := range sorts.Fields {
+			fields = append(fields, name)
+		}
+		sort.Strings(fields)
+		sortParam.Enum = nil
+		sortParam.Description += fmt.Sprintf(" Either one of %s, or a comma separated list of %s, each descending with a leading -.",
+			strings.Join(names, ", "), strings.Join(fields, ", "))
+	}
+	return append(params, limitParam, cursorParam, sortParam)
+}
+
+// filtered adds a parameter for each field of a list filtered on fields to params. The
+// operators other than eq go in brackets after the name, as in price[gte]=5.
+func filtered(fields filter.Fields, params ...param) []param {
+	for _, name := range fields.Names() {
+		operators := make([]string, len(fields[name].Operators))
+		for i, operator := range fields[name].Operators {
+			operators[i] = string(operator)
+		}
+		params = append(params, param{Name: name, Description: fmt.Sprintf("Filter on %s with %s[op]; op is one of %s.", name, name, strings.Join(operators, ", "))})
+	}
+	return params
+}
+
+// pageOf is the envelope of a page of rows sent as name.
+func pageOf(name string, rows interface{}) utils.Envelope {
+	return utils.Envelope{name: rows, "metadata": pagination.Metadata{}}
+}
+
+// op documents a route for the OpenAPI document.
+type op struct {
+	Summary     string
+	Description string
+	Access      access
+	Query       []param
+	// Body is the request struct the handler decodes; it is accepted as JSON, url-encoded or
+	// multipart form unless JSON is set.
+	Body interface{}
+	JSON bool
+	// Upload names the multipart file field the handler reads besides Body.
+	Upload string
+	// Status is the success status, 200 unless set.
+	Status int
+	// Response is the success body: a utils.Envelope of zero values, one per key. Nil means an
+	// object whose keys aren't fixed.
+	Response utils.Envelope
+	// Produces replaces the JSON success body with these media types.
+	Produces []string
+	// Legacy lists the unversioned patterns that still serve this route as deprecated aliases.
+	Legacy []string
+	// ETag marks reads that answer with an ETag and honour If-None-Match, and IfMatch updates
+	// that require the ETag they were made against.
+	ETag    bool
+	IfMatch bool
+}
+
+// documentedRouter registers routes on a michi router and describes them in an OpenAPI document.
+type documentedRouter struct {
+	mux  *michi.Router
+	spec *openAPI
+	tag  string
+	// deprecate wraps the handler of a legacy alias of successor.
+	deprecate func(alias, successor string, next http.Handler) http.HandlerFunc
+}
+
+func newDocumentedRouter(mux *michi.Router, spec *openAPI, deprecate func(alias, successor string, next http.Handler) http.HandlerFunc) *documentedRouter {
+	return &documentedRouter{mux: mux, spec: spec, deprecate: deprecate}
+}
+
+// group tags the routes registered after it.
+func (d *documentedRouter) group(tag string) {
+	d.tag = tag
+	for _, known := range d.spec.Tags {
+		if known.Name == tag {
+			return
+		}
+	}
+	d.spec.Tags = append(d.spec.Tags, openAPITag{Name: tag})
+}
+
+// handle registers handler for pattern, a "METHOD path" relative to the router, and documents it.
+// Its legacy aliases are registered and documented as deprecated.
+func (d *documentedRouter) handle(pattern string, handler http.HandlerFunc, o op) {
+	d.mux.HandleFunc(pattern, handler)
+	method, path := splitPattern(pattern)
+	d.spec.add(method, path, d.tag, o)
+
+	successor := strings.ToUpper(method) + " " + path
+	for i, alias := range o.Legacy {
+		d.mux.HandleFunc(alias, d.deprecate(alias, successor, handler))
+		legacy := o
+		legacy.Description = joinSentences("Deprecated alias of "+successor+".", o.Description)
+		aliasMethod, aliasPath := splitPattern(alias)
+		oper := d.spec.add(aliasMethod, aliasPath, d.tag, legacy)
+		oper.Deprecated = true
+		oper.OperationID += "Legacy"
+		if i > 0 {
+			oper.OperationID += fmt.Sprint(i + 1)
+		}
+	}
+}
+
+// splitPattern splits "GET users/{id}" into "get" and "/users/{id}".
+func splitPattern(pattern string) (method, path string) {
+	method, path, _ = strings.Cut(pattern, " ")
+	return strings.ToLower(method), "/" + strings.TrimSpace(path)
+}
+
+type openAPI struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       openAPIInfo                      `json:"info"`
+	Tags       []openAPITag                     `json:"tags"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components struct {
+		Schemas         map[string]interface{} `json:"schemas"`
+		Responses       map[string]interface{} `json:"responses"`
+		SecuritySchemes map[string]interface{} `json:"securitySchemes"`
+	} `json:"components"`
+
+	schemas *schemaGenerator
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+type openAPITag struct {
+	Name string `json:"name"`
+}
+
+type operation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary"`
+	Description string                 `json:"description,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  []parameter            `json:"parameters,omitempty"`
+	RequestBody *requestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]interface{} `json:"responses"`
+	Security    []map[string][]string  `json:"security,omitempty"`
+	Deprecated  bool                   `json:"deprecated,omitempty"`
+}
+
+type parameter struct {
+	Name        string                 `json:"name"`
+	In          string                 `json:"in"`
+	Required    bool                   `json:"required,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                              `json:"required"`
+	Content  map[string]map[string]interface{} `json:"content"`
+}
+
+func newOpenAPI() *openAPI {
+	spec := &openAPI{
+		OpenAPI: "3.1.0",
+		Info: openAPIInfo{
+			Title:   "Sadeem Vendor API",
+			Version: "1.0.0",
+			Description: "Errors are RFC 7807 problem documents with a stable code. Send Accept-Language " +
+				"or ?lang= for Arabic messages and content. Routes live under /v1; the unversioned paths " +
+				"are deprecated aliases that answer with Deprecation and Sunset headers.",
+		},
+		Paths:   map[string]map[string]*operation{},
+		schemas: newSchemaGenerator(),
+	}
+	spec.Components.Schemas = spec.schemas.components
+	spec.Components.SecuritySchemes = map[string]interface{}{
+		"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+	}
+
+	problemSchema := spec.schemas.objectSchema(reflect.TypeOf(problem{}))
+	problemSchema["additionalProperties"] = true
+	spec.schemas.components["Problem"] = problemSchema
+	spec.Components.Responses = map[string]interface{}{
+		"Problem": map[string]interface{}{
+			"description": "An RFC 7807 problem document.",
+			"content": map[string]interface{}{
+				"application/problem+json": map[string]interface{}{"schema": ref("Problem")},
+			},
+		},
+	}
+	return spec
+}
+
+var pathParamRX = regexp.MustCompile(`\{([^}]+)\}`)
+
+func (spec *openAPI) add(method, path, tag string, o op) *operation {
+	oper := &operation{
+		OperationID: operationID(o.Summary),
+		Summary:     o.Summary,
+		Description: o.Description,
+		Responses:   map[string]interface{}{},
+	}
+	if tag != "" {
+		oper.Tags = []string{tag}
+	}
+
+	for _, match := range pathParamRX.FindAllStringSubmatch(path, -1) {
+		oper.Parameters = append(oper.Parameters, parameter{
+			Name: match[1], In: "path", Required: true, Schema: pathParamSchema(match[1]),
+		})
+	}
+	for _, q := range o.Query {
+		schema := map[string]interface{}{"type": "string"}
+		if q.Type != "" {
+			schema["type"] = q.Type
+		}
+		if len(q.Enum) > 0 {
+			schema["enum"] = q.Enum
+		}
+		oper.Parameters = append(oper.Parameters, parameter{
+			Name: q.Name, In: "query", Description: q.Description, Schema: schema,
+		})
+	}
+
+	if o.Body != nil {
+		schema := spec.schemas.schemaOf(reflect.TypeOf(o.Body))
+		oper.RequestBody = &requestBody{Required: true, Content: map[string]map[string]interface{}{
+			"application/json": {"schema": schema},
+		}}
+		if !o.JSON {
+			multipart := schema
+			if o.Upload != "" {
+				multipart = map[string]interface{}{"allOf": []interface{}{schema, map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						o.Upload: map[string]interface{}{"type": "string", "contentMediaType": "application/octet-stream"},
+					},
+				}}}
+			}
+			oper.RequestBody.Content["application/x-www-form-urlencoded"] = map[string]interface{}{"schema": schema}
+			oper.RequestBody.Content["multipart/form-data"] = map[string]interface{}{"schema": multipart}
+		}
+	}
+
+	status := o.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	if len(o.Produces) > 0 {
+		content := map[string]interface{}{}
+		for _, mediaType := range o.Produces {
+			content[mediaType] = map[string]interface{}{}
+		}
+		success["content"] = content
+	} else {
+		success["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": spec.schemas.envelopeSchema(o.Response)},
+		}
+	}
+	oper.Responses[fmt.Sprint(status)] = success
+	oper.Responses["default"] = ref("Problem", "responses")
+	if o.ETag {
+		success["headers"] = map[string]interface{}{"ETag": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}}
+		oper.Parameters = append(oper.Parameters, parameter{
+			Name: "If-None-Match", In: "header", Description: "An ETag of this resource; answers 304 while it is current.",
+			Schema: map[string]interface{}{"type": "string"},
+		})
+		oper.Responses["304"] = map[string]interface{}{"description": http.StatusText(http.StatusNotModified)}
+	}
+	if o.IfMatch {
+		success["headers"] = map[string]interface{}{"ETag": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}}
+		oper.Parameters = append(oper.Parameters, parameter{
+			Name: "If-Match", In: "header", Required: true, Description: "The ETag the resource was read with.",
+			Schema: map[string]interface{}{"type": "string"},
+		})
+		oper.Responses["412"] = ref("Problem", "responses")
+		oper.Responses["428"] = ref("Problem", "responses")
+	}
+
+	switch o.Access {
+	case signedIn:
+		oper.Description = joinSentences(oper.Description, "Requires a signed in user.")
+	case vendorStaff:
+		oper.Description = joinSentences(oper.Description, "Requires a staff member of the vendor {id} or an admin.")
+	case adminOnly:
+		oper.Description = joinSentences(oper.Description, "Requires an admin.")
+	}
+	if o.Access != public {
+		oper.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+
+	if spec.Paths[path] == nil {
+		spec.Paths[path] = map[string]*operation{}
+	}
+	spec.Paths[path][method] = oper
+	return oper
+}
+
+func joinSentences(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + " " + b
+}
+
+// operationID turns a summary such as "List vendor items" into "listVendorItems".
+func operationID(summary string) string {
+	var b strings.Builder
+	for i, word := range strings.FieldsFunc(summary, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if i == 0 {
+			b.WriteString(strings.ToLower(word))
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// pathParamSchema describes a path parameter by its name: ids are UUIDs.
+func pathParamSchema(name string) map[string]interface{} {
+	switch {
+	case name == "lang":
+		return map[string]interface{}{"type": "string", "enum": i18n.Languages}
+	case name == "action":
+		actions := make([]string, 0, len(data.ReviewModerationStatus))
+		for action := range data.ReviewModerationStatus {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+		return map[string]interface{}{"type": "string", "enum": actions}
+	case strings.HasSuffix(strings.ToLower(name), "id"):
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+func ref(name string, kind ...string) map[string]interface{} {
+	section := "schemas"
+	if len(kind) > 0 {
+		section = kind[0]
+	}
+	return map[string]interface{}{"$ref": "#/components/" + section + "/" + name}
+}
+
+// schemaGenerator derives JSON schemas from Go types the way encoding/json marshals them.
+// Named structs become components and are referenced.
+type schemaGenerator struct {
+	components map[string]interface{}
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{components: map[string]interface{}{}, names: map[reflect.Type]string{}}
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	uuidType      = reflect.TypeOf(uuid.UUID{})
+	envelopeType  = reflect.TypeOf(utils.Envelope{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// envelopeSchema describes a response envelope from the zero values it holds.
+func (g *schemaGenerator) envelopeSchema(envelope utils.Envelope) map[string]interface{} {
+	if envelope == nil {
+		return map[string]interface{}{"type": "object"}
+	}
+	properties := map[string]interface{}{}
+	for key, value := range envelope {
+		switch value := value.(type) {
+		case nil:
+			properties[key] = map[string]interface{}{}
+		case utils.Envelope:
+			properties[key] = g.envelopeSchema(value)
+		default:
+			properties[key] = g.schemaOf(reflect.TypeOf(value))
+		}
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case envelopeType:
+		return map[string]interface{}{"type": "object"}
+	}
+	if t.Implements(marshalerType) {
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.schemaOf(t.Elem()))
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.objectSchema(t)
+		}
+		return ref(g.component(t))
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// component registers the named struct t and returns its component name.
+func (g *schemaGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := componentName(t.Name())
+	if _, taken := g.components[name]; taken {
+		name = componentName(t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]) + name
+	}
+	g.names[t] = name
+	g.components[name] = map[string]interface{}{}
+	g.components[name] = g.objectSchema(t)
+	return name
+}
+
+func componentName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func (g *schemaGenerator) objectSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	g.addFields(t, properties)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// addFields adds the JSON fields of struct t, promoting those of untagged embedded structs.
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(embedded, properties)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schemaOf(field.Type)
+	}
+}
+
+// nullable lets schema also be null, as pointers marshal to null.
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if kind, ok := schema["type"].(string); ok {
+		copied := map[string]interface{}{}
+		for key, value := range schema {
+			copied[key] = value
+		}
+		copied["type"] = []string{kind, "null"}
+		return copied
+	}
+	return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+}
+
+//go:embed docs
+var docsFiles embed.FS
+
+// openAPIHandler serves the document describing every documented route.
+func (app *application) openAPIHandler(spec *openAPI) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := json.Marshal(spec)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// docsHandler serves the API reference page, which renders /openapi.json.
+func docsHandler() http.Handler {
+	files, err := fs.Sub(docsFiles, "docs")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix("/docs/", http.FileServer(http.FS(files)))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// registeredRoutes reads every route registration and legacy alias from router.go, documented
+// or not.
+func registeredRoutes(t *testing.T) map[string]string {
+	t.Helper()
+	source, err := os.ReadFile("router.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registration := regexp.MustCompile(`(\w+)\.(?:handle|HandleFunc|Handle)\("([A-Z]+)\s+([^"]+)"`)
+	found := map[string]string{}
+	for _, match := range registration.FindAllStringSubmatch(string(source), -1) {
+		found[match[2]+" /"+strings.TrimPrefix(match[3], "/")] = match[1]
+	}
+	aliases := regexp.MustCompile(`Legacy:\s*\[\]string\{(.*)\},\n`)
+	for _, list := range aliases.FindAllStringSubmatch(string(source), -1) {
+		for _, alias := range regexp.MustCompile(`"([A-Z]+) ([^"]+)"`).FindAllStringSubmatch(list[1], -1) {
+			found[alias[1]+" /"+alias[2]] = "api"
+		}
+	}
+	return found
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	app := testApplication(t)
+	router := app.Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d", rec.Code)
+	}
+	var spec struct {
+		OpenAPI string                                       `json:"openapi"`
+		Paths   map[string]map[string]map[string]interface{} `json:"paths"`
+	}
+	body := rec.Body.String()
+	if err := json.Unmarshal([]byte(body), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q, want 3.1.0", spec.OpenAPI)
+	}
+
+	documented := 0
+	for route, router := range registeredRoutes(t) {
+		if route == "GET /openapi.json" || route == "GET /docs/" {
+			continue
+		}
+		if router != "api" {
+			t.Errorf("%s is registered on %s instead of api, so it is missing from the spec", route, router)
+			continue
+		}
+		documented++
+		method, path, _ := strings.Cut(route, " ")
+		if _, ok := spec.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("%s is missing from /openapi.json", route)
+		}
+	}
+	if documented == 0 {
+		t.Fatal("no routes found in router.go")
+	}
+
+	operationIDs := map[string]string{}
+	for path, operations := range spec.Paths {
+		for method, operation := range operations {
+			route := strings.ToUpper(method) + " " + path
+			id, _ := operation["operationId"].(string)
+			if id == "" || operation["summary"] == "" {
+				t.Errorf("%s has no summary", route)
+			}
+			if other, ok := operationIDs[id]; ok {
+				t.Errorf("%s and %s share the operationId %q", other, route, id)
+			}
+			operationIDs[id] = route
+		}
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &document); err != nil {
+		t.Fatal(err)
+	}
+	components := document["components"].(map[string]interface{})
+	for _, ref := range regexp.MustCompile(`"\$ref":"#/components/(\w+)/([^"]+)"`).FindAllStringSubmatch(body, -1) {
+		section, _ := components[ref[1]].(map[string]interface{})
+		if _, ok := section[ref[2]]; !ok {
+			t.Errorf("$ref %s/%s does not resolve", ref[1], ref[2])
+		}
+	}
+}
+
+func TestDocsPage(t *testing.T) {
+	router := testApplication(t).Router()
+
+	for path, contentType := range map[string]string{
+		"/docs/":        "text/html",
+		"/docs/docs.js": "javascript",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Content-Type"), contentType) {
+			t.Errorf("GET %s: status %d, Content-Type %q", path, rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"net/http"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxPauseMinutes caps a temporary pause; longer closures belong in the hour exceptions.
+const maxPauseMinutes = 24 * 60
+
+// requireVendorOpen writes a 409 and returns false when the vendor isn't taking orders right now.
+func (app *application) requireVendorOpen(w http.ResponseWriter, r *http.Request, vendorID uuid.UUID) bool {
+	hours, err := app.Model.OpeningHoursDB.GetVendorOpeningHours(r.Context(), vendorID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return false
+	}
+
+	status := hours.Status(time.Now())
+	if status.IsOpen {
+		return true
+	}
+
+	code := i18n.VendorClosed
+	if status.Reason == "paused" {
+		code = i18n.VendorPaused
+	}
+	p := newProblem(r, http.StatusConflict, code, i18n.T(requestLang(r), code))
+	p.Extensions = map[string]interface{}{
+		"reason":       status.Reason,
+		"next_open":    status.NextOpen,
+		"pause_reason": status.PauseReason,
+	}
+	app.problemResponse(w, r, p)
+	return false
+}
+
+// setOpeningStatus fills in the opening status of the given vendors with one batch of queries.
+func (app *application) setOpeningStatus(r *http.Request, vendors []data.Vendor) error {
+	ids := make([]uuid.UUID, len(vendors))
+	for i := range vendors {
+		ids[i] = vendors[i].ID
+	}
+
+	hours, err := app.Model.OpeningHoursDB.GetOpeningHours(r.Context(), ids)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range vendors {
+		if h, ok := hours[vendors[i].ID]; ok {
+			status := h.Status(now)
+			vendors[i].OpeningStatus = &status
+		}
+	}
+	return nil
+}
+
+// GetOpeningHoursHandler returns the vendor's weekly hours, upcoming exceptions and current status.
+func (app *application) GetOpeningHoursHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	hours, err := app.Model.OpeningHoursDB.GetVendorOpeningHours(r.Context(), vendorID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"hours": hours, "status": hours.Status(time.Now())})
+}
+
+type openingHoursRequest struct {
+	Timezone string                 `json:"timezone"`
+	Weekly   []data.OpeningInterval `json:"weekly"`
+}
+
+// SetOpeningHoursHandler replaces the vendor's time zone and weekly hours. The body is JSON:
+// {"timezone": "Asia/Riyadh", "weekly": [{"weekday": 5, "opens_at": "18:00", "closes_at": "02:00"}]}.
+// An empty weekly list means the vendor is always open.
+func (app *application) SetOpeningHoursHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	var input openingHoursRequest
+	if err = readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidatingTimezone(v, input.Timezone)
+	for i := range input.Weekly {
+		data.ValidatingOpeningInterval(v, &input.Weekly[i])
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.Model.OpeningHoursDB.SetWeeklyHours(r.Context(), vendorID, input.Timezone, input.Weekly)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	hours, err := app.Model.OpeningHoursDB.GetVendorOpeningHours(r.Context(), vendorID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"hours": hours, "status": hours.Status(time.Now())})
+}
+
+// hourExceptionRequest is one date's exception; opens_at and closes_at are ignored when closed.
+type hourExceptionRequest struct {
+	Day      string `json:"day"`
+	Closed   bool   `json:"closed"`
+	OpensAt  string `json:"opens_at"`
+	ClosesAt string `json:"closes_at"`
+	Note     string `json:"note"`
+}
+
+// CreateHourExceptionHandler adds a holiday or special hours for one date.
+func (app *application) CreateHourExceptionHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	var input hourExceptionRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	exception := &data.HourException{
+		VendorID: vendorID,
+		Day:      input.Day,
+		IsClosed: input.Closed,
+	}
+	if !exception.IsClosed {
+		if input.OpensAt != "" {
+			exception.OpensAt = &input.OpensAt
+		}
+		if input.ClosesAt != "" {
+			exception.ClosesAt = &input.ClosesAt
+		}
+	}
+	if note := strings.TrimSpace(input.Note); note != "" {
+		exception.Note = &note
+	}
+
+	v := validator.New()
+	data.ValidatingHourException(v, exception)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.Model.OpeningHoursDB.InsertException(r.Context(), exception)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusCreated, utils.Envelope{"exception": exception})
+}
+
+func (app *application) DeleteHourExceptionHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+	exceptionID, err := uuid.Parse(r.PathValue("exception_id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "exception_id")
+		return
+	}
+
+	err = app.Model.OpeningHoursDB.DeleteException(r.Context(), vendorID, exceptionID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "exception deleted"})
+}
+
+type pauseRequest struct {
+	Minutes int    `json:"minutes"`
+	Reason  string `json:"reason"`
+}
+
+func (input pauseRequest) rules() []validator.Rule {
+	return []validator.Rule{
+		validator.Number("minutes", input.Minutes).Required(i18n.MinutesOutOfRange).Min(1, i18n.MinutesOutOfRange).Max(maxPauseMinutes, i18n.MinutesOutOfRange),
+		validator.String("reason", strings.TrimSpace(input.Reason)).MaxLength(200, i18n.ReasonTooLong),
+	}
+}
+
+// PauseOrderingHandler stops the vendor from taking orders for the given number of minutes.
+func (app *application) PauseOrderingHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	var input pauseRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Validate(input.rules()...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	minutes := input.Minutes
+	var reason *string
+	if reasonStr := strings.TrimSpace(input.Reason); reasonStr != "" {
+		reason = &reasonStr
+	}
+
+	until := time.Now().Add(time.Duration(minutes) * time.Minute)
+	err = app.Model.OpeningHoursDB.PauseOrdering(r.Context(), vendorID, &until, reason)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"paused_until": until, "pause_reason": reason})
+}
+
+// ResumeOrderingHandler ends a pause early.
+func (app *application) ResumeOrderingHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	err = app.Model.OpeningHoursDB.PauseOrdering(r.Context(), vendorID, nil, nil)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "ordering resumed"})
+}
@@ -5,56 +5,51 @@ import (
 	"net/http"
 	"project/internal/data"
 	"project/utils"
-	"strconv"
+	"project/utils/i18n"
+	"project/utils/validator"
 
 	"github.com/google/uuid"
 )
 
-// CreateOrderItemHandler handles the creation of a new order item.
-func (app *application) CreateOrderItemHandler(w http.ResponseWriter, r *http.Request) {
-	err := r.ParseForm()
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("failed to parse form"))
-		return
-	}
-
-	orderIDStr := r.FormValue("order_id")
-	itemIDStr := r.FormValue("item_id")
-	quantityStr := r.FormValue("quantity")
-	priceStr := r.FormValue("price")
-
-	// Parse quantity and price to appropriate types
-	quantity, err := strconv.Atoi(quantityStr)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid quantity"))
-		return
-	}
+type orderItemRequest struct {
+	OrderID  uuid.UUID `json:"order_id"`
+	ItemID   uuid.UUID `json:"item_id"`
+	Quantity *int      `json:"quantity"`
+	Price    *float64  `json:"price"`
+}
 
-	price, err := strconv.ParseFloat(priceStr, 64)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid price"))
-		return
+func (input orderItemRequest) rules() []validator.Rule {
+	return []validator.Rule{
+		validator.UUID("order_id", input.OrderID).Required(i18n.OrderIDRequired),
+		validator.UUID("item_id", input.ItemID).Required(i18n.ItemIDRequired),
+		validator.OptionalNumber("quantity", input.Quantity).Required(i18n.QuantityRequired).Min(0, i18n.QuantityNegative),
+		validator.OptionalNumber("price", input.Price).Required(i18n.PriceRequired).Min(0, i18n.PriceNegative),
 	}
+}
 
-	orderID, err := uuid.Parse(orderIDStr)
+// CreateOrderItemHandler handles the creation of a new order item.
+func (app *application) CreateOrderItemHandler(w http.ResponseWriter, r *http.Request) {
+	var input orderItemRequest
+	err := app.readRequest(w, r, &input)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid order ID"))
+		app.badRequestResponse(w, r, err)
 		return
 	}
 
-	itemID, err := uuid.Parse(itemIDStr)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid item ID"))
+	v := validator.New()
+	v.Validate(input.rules()...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
 	// Create a new order item
 	orderItem := &data.OrderItem{
 		ID:       uuid.New(),
-		OrderID:  orderID,
-		ItemID:   itemID,
-		Quantity: quantity,
-		Price:    price,
+		OrderID:  input.OrderID,
+		ItemID:   input.ItemID,
+		Quantity: *input.Quantity,
+		Price:    *input.Price,
 	}
 
 	// Insert the order item into the database
@@ -69,11 +64,11 @@ func (app *application) CreateOrderItemHandler(w http.ResponseWriter, r *http.Re
 
 // DeleteOrderItemHandler handles the deletion of an order item by its ID.
 func (app *application) DeleteOrderItemHandler(w http.ResponseWriter, r *http.Request) {
-	orderItemIDStr := r.FormValue("id")
+	orderItemIDStr := r.PathValue("id")
 
 	orderItemID, err := uuid.Parse(orderItemIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid order item ID"))
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
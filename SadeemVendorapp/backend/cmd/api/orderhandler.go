@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"project/internal/data"
 	"project/utils"
-	"strconv"
+	"project/utils/filter"
+	"project/utils/i18n"
+	"project/utils/validator"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,7 +16,13 @@ import (
 func (app *application) GetOrdersHandler(w http.ResponseWriter, r *http.Request) {
 	customerID, err := uuid.Parse(r.Context().Value(UserIDKey).(string))
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid customer ID"))
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
+		return
+	}
+	v := validator.New()
+	page := app.readPage(v, r, data.CustomerOrderSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 	// Retrieve the customer's table
@@ -23,71 +31,126 @@ func (app *application) GetOrdersHandler(w http.ResponseWriter, r *http.Request)
 		app.handleRetrievalError(w, r, err)
 		return
 	}
-	orders, err := app.Model.OrderDB.GetOrders(customerID, table.ID)
+	orders, meta, err := app.Model.OrderDB.GetOrders(r.Context(), customerID, table.ID, nil, app.contentLang(r), page)
 	if err != nil {
 		app.handleRetrievalError(w, r, err)
 		return
 	}
 
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"orders": orders})
+	app.sendPage(w, r, "orders", orders, meta, nil)
 }
 func (app *application) GetVendorOrdersHandler(w http.ResponseWriter, r *http.Request) {
 	vendorID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid vendor ID"))
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
-	orders, err := app.Model.OrderDB.GetVendorOrders(vendorID)
+	v := validator.New()
+	filters := utils.Filters{Conditions: filter.Parse(v, r.URL.Query(), data.VendorOrderFilters)}
+	page := app.readPage(v, r, data.VendorOrderSorts)
+	shape := readShape(v, r, data.Order{}, "items", "customer")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	orders, meta, err := app.Model.OrderDB.GetVendorOrders(r.Context(), vendorID, filters, page)
 	if err != nil {
 		app.handleRetrievalError(w, r, err)
 		return
 	}
+	if shape.includes("items") {
+		if err = app.setOrderItems(r, orders); err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+	if shape.includes("customer") {
+		if err = app.setOrderCustomers(r, orders); err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
 
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"orders": orders})
+	app.sendPage(w, r, "orders", shape.trim(orders), meta, nil)
 }
 
-// CreateOrderHandler handles the creation of a new order.
-func (app *application) CreateOrderHandler(w http.ResponseWriter, r *http.Request) {
-	err := r.ParseForm()
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("failed to parse form"))
-		return
+// setOrderItems embeds the items of each order, loaded in one query.
+func (app *application) setOrderItems(r *http.Request, orders []data.Order) error {
+	ids := make([]uuid.UUID, len(orders))
+	for i := range orders {
+		ids[i] = orders[i].ID
 	}
 
-	totalOrderCostStr := r.FormValue("total_order_cost")
-	customerIDStr := r.FormValue("customer_id")
-	vendorIDStr := r.FormValue("vendor_id")
-	status := r.FormValue("status")
-
-	// Parse total_order_cost to a float64
-	totalOrderCost, err := strconv.ParseFloat(totalOrderCostStr, 64)
+	items, err := app.Model.OrderItemDB.GetOrdersItems(r.Context(), ids)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid total order cost"))
-		return
+		return err
+	}
+	for i := range orders {
+		orders[i].Items = items[orders[i].ID]
+	}
+	return nil
+}
+
+// setOrderCustomers embeds the id and name of each order's customer, loaded in one query.
+func (app *application) setOrderCustomers(r *http.Request, orders []data.Order) error {
+	ids := make([]uuid.UUID, len(orders))
+	for i := range orders {
+		ids[i] = orders[i].CustomerID
 	}
 
-	customerID, err := uuid.Parse(customerIDStr)
+	customers, err := app.Model.UserDB.GetUserSummaries(r.Context(), ids)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid customer ID"))
-		return
+		return err
+	}
+	for i := range orders {
+		if customer, ok := customers[orders[i].CustomerID]; ok {
+			orders[i].Customer = &customer
+		}
 	}
+	return nil
+}
 
-	vendorID, err := uuid.Parse(vendorIDStr)
+type orderRequest struct {
+	TotalOrderCost *float64  `json:"total_order_cost"`
+	CustomerID     uuid.UUID `json:"customer_id"`
+	VendorID       uuid.UUID `json:"vendor_id"`
+	Status         string    `json:"status"`
+}
+
+func (input orderRequest) rules() []validator.Rule {
+	return []validator.Rule{validator.OptionalNumber("total_order_cost", input.TotalOrderCost).Required(i18n.TotalCostRequired)}
+}
+
+// CreateOrderHandler handles the creation of a new order.
+func (app *application) CreateOrderHandler(w http.ResponseWriter, r *http.Request) {
+	var input orderRequest
+	err := app.readRequest(w, r, &input)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid vendor ID"))
+		app.badRequestResponse(w, r, err)
 		return
 	}
 
 	// Create a new order
 	order := &data.Order{
-		ID:             uuid.New(),
-		TotalOrderCost: totalOrderCost,
-		CustomerID:     customerID,
-		VendorID:       vendorID,
-		Status:         status,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+		ID:         uuid.New(),
+		CustomerID: input.CustomerID,
+		VendorID:   input.VendorID,
+		Status:     input.Status,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if input.TotalOrderCost != nil {
+		order.TotalOrderCost = *input.TotalOrderCost
+	}
+
+	v := validator.New()
+	v.Validate(input.rules()...)
+	data.ValidatingOrder(v, order)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
 	}
 
 	// Insert the order into the database
@@ -102,9 +165,9 @@ func (app *application) CreateOrderHandler(w http.ResponseWriter, r *http.Reques
 
 // DeleteOrderHandler handles the deletion of an order by its ID.
 func (app *application) DeleteOrderHandler(w http.ResponseWriter, r *http.Request) {
-	orderID, err := uuid.Parse(r.FormValue("id"))
+	orderID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid order ID"))
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
@@ -120,29 +183,45 @@ func (app *application) DeleteOrderHandler(w http.ResponseWriter, r *http.Reques
 
 	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "order deleted successfully"})
 }
+
+type orderStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// rules allow only completing an order; it is created preparing.
+func (input orderStatusRequest) rules() []validator.Rule {
+	return []validator.Rule{validator.String("status", input.Status).Required(i18n.OrderStatusRequired).OneOf(i18n.OrderStatusInvalid, "completed")}
+}
+
 func (app *application) UpdateOrderStatusHandler(w http.ResponseWriter, r *http.Request) {
 	orderIDStr := r.PathValue("id")
-	status := r.FormValue("status")
 
 	// Validate the order ID
 	orderID, err := uuid.Parse(orderIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid order ID"))
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
-	// Update the order status
+	var input orderStatusRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	v := validator.New()
+	v.Validate(input.rules()...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	status := input.Status
+
+	// Completed orders are kept for completedOrderRetention, so they can still be reviewed;
+	// the retention job purges them
 	err = app.Model.OrderDB.UpdateOrder(orderID, status)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		if status == "completed" {
-			// If status is completed, delete the order after updating
-			err = app.Model.OrderDB.DeleteCompletedOrder(orderID)
-			if err != nil {
-				app.serverErrorResponse(w, r, err)
-				return
-			}
-		}
+		app.handleRetrievalError(w, r, err)
+		return
 	}
 
 	// Respond with a success message
@@ -154,13 +233,20 @@ func (app *application) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 
 	customerID, err := uuid.Parse(userID)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid user ID"))
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
 		return
 	}
 
 	vendorUUID, err := uuid.Parse(vendorID)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid vendor ID"))
+		app.invalidIDResponse(w, r, "vendor_id")
+		return
+	}
+
+	v := validator.New()
+	page := app.readPage(v, r, data.CustomerOrderSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
@@ -171,20 +257,12 @@ func (app *application) GetUserOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Retrieve the orders for the customer on the specific table
-	orders, err := app.Model.OrderDB.GetOrders(customerID, table.ID)
+	// Retrieve the customer's orders from the vendor on the specific table
+	orders, meta, err := app.Model.OrderDB.GetOrders(r.Context(), customerID, table.ID, &vendorUUID, app.contentLang(r), page)
 	if err != nil {
 		app.handleRetrievalError(w, r, err)
 		return
 	}
 
-	// Filter orders by vendor
-	var vendorOrders []data.OrderDetails
-	for _, order := range orders {
-		if order.VendorID == vendorUUID {
-			vendorOrders = append(vendorOrders, order)
-		}
-	}
-
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"orders": vendorOrders})
+	app.sendPage(w, r, "orders", orders, meta, nil)
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"project/utils"
+	"project/utils/pagination"
+	"project/utils/validator"
+)
+
+// readPage reads the limit, sort and cursor of a list request, adding any problem to v.
+func (app *application) readPage(v *validator.Validator, r *http.Request, sorts pagination.Sorts) pagination.Params {
+	return pagination.Parse(v, r.URL.Query(), sorts, app.cfg.pagination.limits, pagination.NewSigner(app.cfg.pagination.secret))
+}
+
+// sendPage writes one page of a list as name, with its metadata and Link headers. envelope
+// holds anything else the response carries and may be nil.
+func (app *application) sendPage(w http.ResponseWriter, r *http.Request, name string, rows interface{}, meta pagination.Metadata, envelope utils.Envelope) {
+	meta = app.signPage(meta)
+	app.addPageLinks(w, r, meta)
+
+	if envelope == nil {
+		envelope = utils.Envelope{}
+	}
+	envelope[name] = rows
+	envelope["metadata"] = meta
+	utils.SendJSONResponse(w, http.StatusOK, envelope)
+}
+
+// signPage turns the cursors of the pages around meta's page into tokens.
+func (app *application) signPage(meta pagination.Metadata) pagination.Metadata {
+	signer := pagination.NewSigner(app.cfg.pagination.secret)
+	if meta.Next != nil {
+		meta.NextCursor = signer.Encode(meta.Next)
+	}
+	if meta.Prev != nil {
+		meta.PrevCursor = signer.Encode(meta.Prev)
+	}
+	return meta
+}
+
+// addPageLinks points the Link header at the first, previous and next pages of a signed page.
+func (app *application) addPageLinks(w http.ResponseWriter, r *http.Request, meta pagination.Metadata) {
+	for _, link := range pagination.Links(r.URL, meta) {
+		w.Header().Add("Link", link)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"net/http"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/i18n"
+	"project/utils/validator"
+
+	"github.com/google/uuid"
+)
+
+// planRequest is the body of plan creation and updates. Limits left out mean unlimited.
+type planRequest struct {
+	Name        string   `json:"name"`
+	MaxTables   *int     `json:"max_tables"`
+	MaxItems    *int     `json:"max_items"`
+	MaxStaff    *int     `json:"max_staff"`
+	MaxAPIKeys  *int     `json:"max_api_keys"`
+	Price       *float64 `json:"price"`
+	BillingDays *int     `json:"billing_days"`
+	IsDefault   bool     `json:"is_default"`
+}
+
+func (input planRequest) rules() []validator.Rule {
+	return []validator.Rule{
+		validator.OptionalNumber("price", input.Price).Required(i18n.PriceRequired),
+		validator.OptionalNumber("billing_days", input.BillingDays).Required(i18n.BillingDaysRequired),
+	}
+}
+
+// readPlanRequest fills plan from the request and checks it into v.
+func (app *application) readPlanRequest(w http.ResponseWriter, r *http.Request, plan *data.Plan, v *validator.Validator) error {
+	var input planRequest
+	if err := app.readRequest(w, r, &input); err != nil {
+		return err
+	}
+
+	plan.Name = input.Name
+	plan.MaxTables, plan.MaxItems = input.MaxTables, input.MaxItems
+	plan.MaxStaff, plan.MaxAPIKeys = input.MaxStaff, input.MaxAPIKeys
+	if input.Price != nil {
+		plan.Price = *input.Price
+	}
+	if input.BillingDays != nil {
+		plan.BillingDays = *input.BillingDays
+	}
+	plan.IsDefault = input.IsDefault
+
+	v.Validate(input.rules()...)
+	data.ValidatingPlan(v, plan)
+	return nil
+}
+
+func (app *application) GetPlansHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	page := app.readPage(v, r, data.PlanSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	plans, meta, err := app.Model.PlanDB.GetPlans(r.Context(), page)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.sendPage(w, r, "plans", plans, meta, nil)
+}
+
+func (app *application) GetPlanHandler(w http.ResponseWriter, r *http.Request) {
+	planID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	plan, err := app.Model.PlanDB.GetPlan(r.Context(), planID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"plan": plan})
+}
+
+func (app *application) CreatePlanHandler(w http.ResponseWriter, r *http.Request) {
+	plan := &data.Plan{}
+	v := validator.New()
+	if err := app.readPlanRequest(w, r, plan, v); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if err := app.Model.PlanDB.InsertPlan(r.Context(), plan); err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusCreated, utils.Envelope{"plan": plan})
+}
+
+// UpdatePlanHandler replaces a plan's definition. Lowered limits only stop vendors from adding more.
+func (app *application) UpdatePlanHandler(w http.ResponseWriter, r *http.Request) {
+	planID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	plan := &data.Plan{ID: planID}
+	v := validator.New()
+	if err := app.readPlanRequest(w, r, plan, v); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if err := app.Model.PlanDB.UpdatePlan(r.Context(), plan); err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"plan": plan})
+}
+
+func (app *application) DeletePlanHandler(w http.ResponseWriter, r *http.Request) {
+	planID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	if err := app.Model.PlanDB.DeletePlan(r.Context(), planID); err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "plan deleted successfully"})
+}
+
+// planChangeRequest is the body of assigning a plan and of renewing a subscription.
+type planChangeRequest struct {
+	PlanID *uuid.UUID `json:"plan_id"`
+	Days   *int       `json:"days"`
+}
+
+// rules check days when given; assigning also needs the plan, renewing defaults to the current one.
+func (input planChangeRequest) rules(assigning bool) []validator.Rule {
+	plan := validator.OptionalUUID("plan_id", input.PlanID)
+	if assigning {
+		plan.Required(i18n.PlanIDRequired)
+	}
+	return []validator.Rule{
+		plan,
+		validator.OptionalNumber("days", input.Days).Above(0, i18n.DaysNotPositive).Max(1000, i18n.DaysTooMany),
+	}
+}
+
+// AssignVendorPlanHandler moves a vendor to a plan and extends its subscription.
+// days defaults to the plan's billing period.
+func (app *application) AssignVendorPlanHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	var input planChangeRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	v := validator.New()
+	v.Validate(input.rules(true)...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	plan, err := app.Model.PlanDB.GetPlan(r.Context(), *input.PlanID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	days := plan.BillingDays
+	if input.Days != nil {
+		days = *input.Days
+	}
+
+	data.ValidatingVendor(v, &data.Vendor{SubscriptionDays: days})
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	vendor, err := app.Model.PlanDB.AssignPlan(r.Context(), vendorID, plan.ID, days)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"vendor": vendor, "plan": plan})
+}
+
+// GetVendorPlanHandler shows the vendor's effective plan and its current usage.
+func (app *application) GetVendorPlanHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	usage, err := app.Model.PlanDB.GetVendorUsage(r.Context(), vendorID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"usage": usage})
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"project/utils/i18n"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxJSONBytes caps JSON bodies; uploads go through multipart forms instead.
+	maxJSONBytes = 1 << 20
+	// maxMultipartMemory is how much of a multipart body is kept in memory, the rest is spooled to disk.
+	maxMultipartMemory = 32 << 20
+)
+
+// readRequest decodes the request body into dst, a pointer to a request struct, picking the
+// format from Content-Type. JSON bodies are decoded strictly. Url-encoded and multipart forms,
+// and requests without a body, are matched to dst's fields by their json tag; like r.FormValue,
+// query values are read too, and empty values leave the field unset. Errors are i18n messages.
+func (app *application) readRequest(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		return readJSON(w, r, dst)
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+			return i18n.Message{Code: i18n.FormInvalid}
+		}
+	case "", "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return i18n.Message{Code: i18n.FormInvalid}
+		}
+	default:
+		return i18n.Message{Code: i18n.ContentTypeUnsupported, Args: []interface{}{mediaType}}
+	}
+	return decodeForm(r.Form, dst)
+}
+
+// readJSON decodes a single JSON object into dst, rejecting unknown fields.
+func readJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBytes))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(dst)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		var typeError *json.UnmarshalTypeError
+		var maxBytesError *http.MaxBytesError
+		switch {
+		case errors.As(err, &syntaxError), errors.Is(err, io.ErrUnexpectedEOF):
+			return i18n.Message{Code: i18n.BodyMalformed}
+		case errors.As(err, &typeError):
+			if typeError.Field != "" {
+				return i18n.Message{Code: i18n.BodyFieldType, Args: []interface{}{typeError.Field}}
+			}
+			return i18n.Message{Code: i18n.BodyMalformed}
+		case errors.Is(err, io.EOF):
+			return i18n.Message{Code: i18n.BodyEmpty}
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return i18n.Message{Code: i18n.BodyUnknownField, Args: []interface{}{strings.TrimPrefix(err.Error(), "json: unknown field ")}}
+		case errors.As(err, &maxBytesError):
+			return i18n.Message{Code: i18n.BodyTooLarge, Args: []interface{}{maxBytesError.Limit}}
+		default:
+			return i18n.Message{Code: i18n.BodyInvalid}
+		}
+	}
+
+	if err = decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return i18n.Message{Code: i18n.BodyMultipleValues}
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// decodeForm copies form values into the fields of the struct dst points to.
+func decodeForm(values url.Values, dst interface{}) error {
+	target := reflect.ValueOf(dst).Elem()
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "" || key == "-" || !field.IsExported() {
+			continue
+		}
+		value, ok := formValue(values, key, field.Type)
+		if !ok {
+			continue
+		}
+		if err := setFormField(target.Field(i), value); err != nil {
+			return i18n.Message{Code: i18n.FormValueInvalid, Args: []interface{}{key}}
+		}
+	}
+	return nil
+}
+
+// formValue returns the value of key. A slice field takes repeated keys or a comma separated list.
+func formValue(values url.Values, key string, fieldType reflect.Type) ([]string, bool) {
+	raw := values[key]
+	if len(raw) == 0 || (len(raw) == 1 && raw[0] == "") {
+		return nil, false
+	}
+	if fieldType.Kind() != reflect.Slice {
+		return raw[:1], true
+	}
+	var items []string
+	for _, value := range raw {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items, true
+}
+
+func setFormField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setFormField(elem.Elem(), values); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	if field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values[0]))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(values[0])
+	case reflect.Bool:
+		b, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(values[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setFormField(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"project/utils/i18n"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type testRequest struct {
+	Name     string    `json:"name"`
+	Price    *float64  `json:"price"`
+	Quantity int       `json:"quantity"`
+	Open     bool      `json:"open"`
+	Tags     []string  `json:"tags"`
+	VendorID uuid.UUID `json:"vendor_id"`
+	Secret   string    `json:"-"`
+}
+
+func TestReadRequest(t *testing.T) {
+	price := 2.5
+	vendorID := uuid.MustParse("8f4e2a1c-1d3b-4c5e-9f7a-0b1c2d3e4f5a")
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		want        testRequest
+		wantErr     i18n.Code
+	}{
+		{"json", "/", "application/json; charset=utf-8", `{"name":"Tea","price":2.5,"tags":["hot"]}`, testRequest{Name: "Tea", Price: &price, Tags: []string{"hot"}}, ""},
+		{"unknown field", "/", "application/json", `{"name":"Tea","colour":"green"}`, testRequest{}, i18n.BodyUnknownField},
+		{"second json value", "/", "application/json", `{"name":"Tea"}{"name":"Cake"}`, testRequest{}, i18n.BodyMultipleValues},
+		{"trailing garbage", "/", "application/json", `{"name":"Tea"} x`, testRequest{}, i18n.BodyMultipleValues},
+		{"empty json body", "/", "application/json", ``, testRequest{}, i18n.BodyEmpty},
+		{"badly-formed json", "/", "application/json", `{"name":`, testRequest{}, i18n.BodyMalformed},
+		{"wrong json type", "/", "application/json", `{"quantity":"two"}`, testRequest{}, i18n.BodyFieldType},
+		{"too large", "/", "application/json", `{"name":"` + strings.Repeat("a", maxJSONBytes) + `"}`, testRequest{}, i18n.BodyTooLarge},
+		{"form", "/", "application/x-www-form-urlencoded", "name=Tea&price=2.5&quantity=3&open=true&tags=hot,iced&tags=sweet&vendor_id=" + vendorID.String(),
+			testRequest{Name: "Tea", Price: &price, Quantity: 3, Open: true, Tags: []string{"hot", "iced", "sweet"}, VendorID: vendorID}, ""},
+		{"form skips empty values and untagged fields", "/", "application/x-www-form-urlencoded", "name=&price=&Secret=x&-=x", testRequest{}, ""},
+		{"form reads the query too", "/?name=Tea", "application/x-www-form-urlencoded", "quantity=1", testRequest{Name: "Tea", Quantity: 1}, ""},
+		{"no body falls back to the query", "/?quantity=4", "", "", testRequest{Quantity: 4}, ""},
+		{"bad form value", "/", "application/x-www-form-urlencoded", "quantity=many", testRequest{}, i18n.FormValueInvalid},
+		{"bad form uuid", "/", "application/x-www-form-urlencoded", "vendor_id=cafe", testRequest{}, i18n.FormValueInvalid},
+		{"unsupported type", "/", "text/plain", "name=Tea", testRequest{}, i18n.ContentTypeUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			var got testRequest
+			err := (&application{}).readRequest(httptest.NewRecorder(), r, &got)
+			if tt.wantErr != "" {
+				var message i18n.Message
+				if !errors.As(err, &message) || message.Code != tt.wantErr {
+					t.Fatalf("error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRequestMultipart(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("name", "Tea")
+	form.WriteField("tags", "hot")
+	form.WriteField("tags", "iced")
+	file, _ := form.CreateFormFile("image", "tea.png")
+	file.Write([]byte("png"))
+	form.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	var got testRequest
+	if err := (&application{}).readRequest(httptest.NewRecorder(), r, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (testRequest{Name: "Tea", Tags: []string{"hot", "iced"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not a form"))
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	var message i18n.Message
+	if err := (&application{}).readRequest(httptest.NewRecorder(), r, &got); !errors.As(err, &message) || message.Code != i18n.FormInvalid {
+		t.Errorf("error = %v, want %s", err, i18n.FormInvalid)
+	}
+}
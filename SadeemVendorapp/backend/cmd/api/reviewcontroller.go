@@ -0,0 +1,222 @@
+package main
+
+import (
+	"net/http"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// CreateReviewHandler lets the customer who placed a completed order rate the vendor and
+// the items they ordered. The body is JSON:
+// {"rating": 5, "comment": "great", "items": [{"item_id": "...", "rating": 4}]}.
+func (app *application) CreateReviewHandler(w http.ResponseWriter, r *http.Request) {
+	orderID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+	customerID := uuid.MustParse(r.Context().Value(UserIDKey).(string))
+
+	var input data.ReviewInput
+	if err = readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if input.Comment != nil {
+		comment := strings.TrimSpace(*input.Comment)
+		input.Comment = &comment
+		if comment == "" {
+			input.Comment = nil
+		}
+	}
+
+	v := validator.New()
+	data.ValidatingReview(v, &input)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	review, err := app.Model.ReviewDB.InsertReview(r.Context(), customerID, orderID, &input)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	shown := []data.Review{*review}
+	if err = app.localizeReviews(r, shown); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	utils.SendJSONResponse(w, http.StatusCreated, utils.Envelope{"review": shown[0]})
+}
+
+// GetVendorReviewsHandler lists a page of a vendor's reviews, newest first by default. Hidden reviews are left out.
+func (app *application) GetVendorReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+	v := validator.New()
+	page := app.readPage(v, r, data.ReviewSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	reviews, meta, err := app.Model.ReviewDB.GetReviews(r.Context(), &vendorID, []string{"visible", "flagged"}, page)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if err = app.localizeReviews(r, reviews); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	app.sendPage(w, r, "reviews", reviews, meta, nil)
+}
+
+type reviewReplyRequest struct {
+	Reply string `json:"reply"`
+}
+
+func (input reviewReplyRequest) rules() []validator.Rule {
+	return []validator.Rule{
+		validator.String("reply", input.Reply).Required(i18n.ReplyRequired).MaxLength(1000, i18n.ReplyTooLong),
+	}
+}
+
+// ReplyReviewHandler stores the vendor's one reply to a review.
+func (app *application) ReplyReviewHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+	reviewID, err := uuid.Parse(r.PathValue("review_id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "review_id")
+		return
+	}
+	userID := uuid.MustParse(r.Context().Value(UserIDKey).(string))
+
+	var input reviewReplyRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	input.Reply = strings.TrimSpace(input.Reply)
+	v := validator.New()
+	v.Validate(input.rules()...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	review, err := app.Model.ReviewDB.Reply(r.Context(), vendorID, reviewID, userID, input.Reply)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	shown := []data.Review{*review}
+	if err = app.localizeReviews(r, shown); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"review": shown[0]})
+}
+
+// GetReviewsHandler is the admin moderation queue. ?status=flagged|hidden|visible narrows it down.
+func (app *application) GetReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	var statuses []string
+	status := r.URL.Query().Get("status")
+	if status != "" {
+		statuses = []string{status}
+	}
+	v := validator.New()
+	v.Validate(validator.String("status", status).OneOf(i18n.ReviewStatusInvalid, "visible", "flagged", "hidden"))
+	page := app.readPage(v, r, data.ReviewSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	reviews, meta, err := app.Model.ReviewDB.GetReviews(r.Context(), nil, statuses, page)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if err = app.localizeReviews(r, reviews); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	app.sendPage(w, r, "reviews", reviews, meta, nil)
+}
+
+type moderationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// rules need a reason for every action but restore.
+func (input moderationRequest) rules(action string) []validator.Rule {
+	return []validator.Rule{
+		validator.Expect("reason", action == "restore" || input.Reason != "", i18n.ReasonRequired),
+		validator.String("reason", input.Reason).MaxLength(200, i18n.ReasonTooLong),
+	}
+}
+
+// ModerateReviewHandler hides, flags or restores a review. Hiding and flagging need a reason.
+func (app *application) ModerateReviewHandler(w http.ResponseWriter, r *http.Request) {
+	reviewID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+	action := r.PathValue("action")
+	if _, ok := data.ReviewModerationStatus[action]; !ok {
+		app.notFoundResponse(w, r)
+		return
+	}
+	adminID := uuid.MustParse(r.Context().Value(UserIDKey).(string))
+
+	var input moderationRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	input.Reason = strings.TrimSpace(input.Reason)
+	v := validator.New()
+	v.Validate(input.rules(action)...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	var reason *string
+	if input.Reason != "" {
+		reason = &input.Reason
+	}
+	review, err := app.Model.ReviewDB.Moderate(r.Context(), reviewID, adminID, action, reason)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	shown := []data.Review{*review}
+	if err = app.localizeReviews(r, shown); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"review": shown[0]})
+}
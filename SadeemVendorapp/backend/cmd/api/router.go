@@ -2,100 +2,675 @@ package main
 
 import (
 	"net/http"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/pagination"
+	"time"
 
 	"github.com/go-michi/michi"
+	"github.com/google/uuid"
 )
 
+// v1Released is when the /v1 routes replaced the unversioned ones.
+var v1Released = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
 func (app *application) Router() *michi.Router {
 	r := michi.NewRouter()
 	// Apply global middleware
 	r.Use(app.logRequest)
 	r.Use(app.recoverPanic)
 	r.Use(secureHeaders)
+	r.Use(app.resolveLanguage)
 	r.Use(app.ErrorHandlerMiddleware)
 	/* 	r.Use(app.rateLimit)
 	 */
 	r.Handle("/uploads/", http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads"))))
 
+	// Every route below is registered through api so it is described in /openapi.json. The
+	// unversioned paths of the original API stay as deprecated aliases of their /v1 route.
+	spec := newOpenAPI()
+	r.HandleFunc("GET /openapi.json", app.openAPIHandler(spec))
+	r.Handle("GET /docs/", docsHandler())
+
+	message := utils.Envelope{"message": ""}
+
 	r.Route("/", func(sub *michi.Router) {
+		api := newDocumentedRouter(sub, spec, app.deprecatedRoute(v1Released, app.cfg.legacy.sunset))
 		// User routes
-		sub.HandleFunc("GET users", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.IndexUserHandler)))))
-		sub.HandleFunc("GET users/{id}", app.AuthMiddleware(http.HandlerFunc(app.ShowUserHandler)))
-		sub.HandleFunc("PUT users/{id}", app.AuthMiddleware(http.HandlerFunc(app.AuthorizeUserUpdate(http.HandlerFunc(app.UpdateUserHandler)))))
-		sub.HandleFunc("DELETE users/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.DeleteUserHandler)))))
+		api.group("Users")
+		api.handle("GET v1/users", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.IndexUserHandler)))), op{
+			Summary: "List users", Access: adminOnly,
+			Query:    paged(data.UserSorts, filtered(data.UserFilters, searchParam)...),
+			Response: pageOf("users", []data.User{}),
+			Legacy:   []string{"GET users"},
+		})
+		api.handle("GET v1/users/{id}", app.AuthMiddleware(http.HandlerFunc(app.ShowUserHandler)), op{
+			Summary: "Get user", Access: signedIn,
+			ETag:     true,
+			Response: utils.Envelope{"user": data.User{}},
+			Legacy:   []string{"GET users/{id}"},
+		})
+		api.handle("PATCH v1/users/{id}", app.AuthMiddleware(http.HandlerFunc(app.AuthorizeUserUpdate(http.HandlerFunc(app.UpdateUserHandler)))), op{
+			Summary: "Update user", Description: "Users update themselves; admins update anyone.", Access: signedIn,
+			Body: userRequest{}, Upload: "img", IfMatch: true,
+			Legacy: []string{"PUT users/{id}"},
+		})
+		api.handle("DELETE v1/users/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.DeleteUserHandler)))), op{
+			Summary: "Delete user", Access: adminOnly,
+			Response: utils.Envelope{"deleted user": data.User{}},
+			Legacy:   []string{"DELETE users/{id}"},
+		})
 		// Auth routes (public)
-		sub.HandleFunc("POST signin", http.HandlerFunc(app.LoginHandler))
-		sub.HandleFunc("POST signup", http.HandlerFunc(app.SignupHandler))
+		api.group("Auth")
+		api.handle("POST v1/auth/signin", http.HandlerFunc(app.LoginHandler), op{
+			Summary: "Sign in", Body: loginRequest{},
+			Response: utils.Envelope{"token": "", "expires": ""},
+			Legacy:   []string{"POST signin"},
+		})
+		api.handle("POST v1/auth/signup", http.HandlerFunc(app.SignupHandler), op{
+			Summary: "Sign up", Body: userRequest{}, Upload: "img", Status: http.StatusCreated,
+			Response: utils.Envelope{"user": data.User{}},
+			Legacy:   []string{"POST signup"},
+		})
 		// Table routes
+		api.group("Tables")
 		//to get the table details of assigned customer's table
-		sub.HandleFunc("GET usertable", app.AuthMiddleware(http.HandlerFunc(app.GetCustomertable)))
+		api.handle("GET v1/me/table", app.AuthMiddleware(http.HandlerFunc(app.GetCustomertable)), op{
+			Summary: "Get my table", Access: signedIn,
+			Response: utils.Envelope{"tables": data.Table{}},
+			Legacy:   []string{"GET usertable"},
+		})
 		//to get the table details of vendor's tables
-		sub.HandleFunc("GET  vendor/{id}/tables", app.AuthMiddleware(http.HandlerFunc(app.GetTablesHandler)))
+		api.handle("GET v1/vendors/{id}/tables", app.AuthMiddleware(http.HandlerFunc(app.GetTablesHandler)), op{
+			Summary: "List vendor tables", Access: signedIn,
+			Query:    paged(data.TableSorts),
+			Response: pageOf("tables", []data.Table{}),
+			Legacy:   []string{"GET vendor/{id}/tables", "GET vendortables/{id}"},
+		})
 		//to get the table details of vendor's table
-		sub.HandleFunc("GET vendor/{id}/tables/{table_id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetTableHandler)))))
+		api.handle("GET v1/vendors/{id}/tables/{table_id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetTableHandler)))), op{
+			Summary: "Get table", Access: vendorStaff,
+			ETag:     true,
+			Response: utils.Envelope{"table": data.Table{}},
+			Legacy:   []string{"GET vendor/{id}/tables/{table_id}"},
+		})
 		//to add the table of a vendor
-		sub.HandleFunc("POST vendor/{id}/tables", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.CreateTableHandler)))))
+		api.handle("POST v1/vendors/{id}/tables", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.CreateTableHandler)))), op{
+			Summary: "Create table", Access: vendorStaff, Body: tableRequest{}, Status: http.StatusCreated,
+			Response: utils.Envelope{"table": data.Table{}},
+			Legacy:   []string{"POST vendor/{id}/tables"},
+		})
 		//to update a  table of a vendor
-		sub.HandleFunc("PUT vendor/{id}/table/{table_id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.UpdateTableHandler)))))
+		api.handle("PATCH v1/vendors/{id}/tables/{table_id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.UpdateTableHandler)))), op{
+			Summary: "Update table", Access: vendorStaff, Body: tableRequest{},
+			IfMatch:  true,
+			Response: utils.Envelope{"table": data.Table{}},
+			Legacy:   []string{"PUT vendor/{id}/table/{table_id}"},
+		})
 		//to update a free a table of vendors
-		sub.HandleFunc("PUT vendor/{id}/freetable/{table_id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.FreeCustomerTableHandler)))))
+		api.handle("POST v1/vendors/{id}/tables/{table_id}/free", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.FreeCustomerTableHandler)))), op{
+			Summary: "Free customer table", Access: vendorStaff, Response: message,
+			Legacy: []string{"PUT vendor/{id}/freetable/{table_id}"},
+		})
 		//to delte a  table of a vendor
-		sub.HandleFunc("DELETE vendor/{id}/tables/{table_id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.DeleteTableHandler)))))
+		api.handle("DELETE v1/vendors/{id}/tables/{table_id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.DeleteTableHandler)))), op{
+			Summary: "Delete table", Access: vendorStaff,
+			Response: utils.Envelope{"message": "", "table": data.Table{}},
+			Legacy:   []string{"DELETE vendor/{id}/tables/{table_id}"},
+		})
 		//to assign a table to a user by the users only
-		sub.HandleFunc("PUT vendor/{id}/tables/{table_id}/needs-service", app.AuthMiddleware(http.HandlerFunc(app.UpdateTableNeedsServiceHandler)))
-		//to free a table by the user who assigned it
-		sub.HandleFunc("PUT vendor/{id}/tables/{table_id}/needs-serviceDone", app.AuthMiddleware(http.HandlerFunc(app.UpdateTableNeedsServiceHandler)))
+		api.handle("POST v1/vendors/{id}/tables/{table_id}/occupy", app.AuthMiddleware(http.HandlerFunc(app.UpdateTableNeedsServiceHandler)), op{
+			Summary: "Take table", Description: "Assigns the table to the caller; is_needs_service also calls a waiter.", Access: signedIn,
+			Body: tableRequest{}, Response: utils.Envelope{"table": data.Table{}},
+			Legacy: []string{"PUT vendor/{id}/tables/{table_id}/needs-service"},
+		})
+		//to withdraw the open service requests of the user's table
+		api.handle("DELETE v1/vendors/{id}/tables/{table_id}/service-requests", app.AuthMiddleware(http.HandlerFunc(app.TableServiceDoneHandler)), op{
+			Summary: "Withdraw service requests", Access: signedIn, Response: message,
+			Legacy: []string{"PUT vendor/{id}/tables/{table_id}/needs-serviceDone"},
+		})
+		//to call a waiter, the bill, water or cleaning to the user's table
+		api.handle("POST v1/vendors/{id}/tables/{table_id}/service-requests", app.AuthMiddleware(http.HandlerFunc(app.CreateServiceRequestHandler)), op{
+			Summary: "Call for service", Access: signedIn, Body: serviceRequestRequest{}, Status: http.StatusCreated,
+			Response: utils.Envelope{"service_request": data.ServiceRequest{}},
+			Legacy:   []string{"POST vendor/{id}/tables/{table_id}/service-requests"},
+		})
+		//to list, acknowledge and resolve a vendor's service requests
+		api.handle("GET v1/vendors/{id}/service-requests", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetServiceRequestsHandler)))), op{
+			Summary: "List service requests", Access: vendorStaff,
+			Query:    paged(data.ServiceRequestSorts, param{Name: "status", Enum: []string{"pending", "acknowledged", "resolved", "cancelled"}}),
+			Response: pageOf("service_requests", []data.ServiceRequest{}),
+			Legacy:   []string{"GET vendor/{id}/service-requests"},
+		})
+		api.handle("POST v1/vendors/{id}/service-requests/{request_id}/acknowledge", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.AcknowledgeServiceRequestHandler)))), op{
+			Summary: "Acknowledge service request", Access: vendorStaff,
+			Response: utils.Envelope{"service_request": data.ServiceRequest{}},
+			Legacy:   []string{"PUT vendor/{id}/service-requests/{request_id}/acknowledge"},
+		})
+		api.handle("POST v1/vendors/{id}/service-requests/{request_id}/resolve", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.ResolveServiceRequestHandler)))), op{
+			Summary: "Resolve service request", Access: vendorStaff,
+			Response: utils.Envelope{"service_request": data.ServiceRequest{}},
+			Legacy:   []string{"PUT vendor/{id}/service-requests/{request_id}/resolve"},
+		})
+		//to report average service response times
+		reportRange := []param{
+			{Name: "from", Description: "Start of the report, RFC 3339."},
+			{Name: "to", Description: "End of the report, RFC 3339."},
+		}
+		api.handle("GET v1/vendors/{id}/service-requests/report", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.ServiceResponseTimesHandler)))), op{
+			Summary: "Report vendor service response times", Access: vendorStaff, Query: reportRange,
+			Response: utils.Envelope{"vendors": []data.ResponseTimeReport{}, "staff": []data.ResponseTimeReport{}},
+			Legacy:   []string{"GET vendor/{id}/service-requests/report"},
+		})
+		api.handle("GET v1/service-requests/report", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.AdminServiceResponseTimesHandler)))), op{
+			Summary: "Report service response times", Access: adminOnly, Query: reportRange,
+			Response: utils.Envelope{"vendors": []data.ResponseTimeReport{}, "staff": []data.ResponseTimeReport{}},
+			Legacy:   []string{"GET service-requests/report"},
+		})
 		//to free a table by the user who assigned it
-		sub.HandleFunc("PUT vendor/{id}/tables/{table_id}/freetable", app.AuthMiddleware(http.HandlerFunc(app.FreeTableHandler)))
+		api.handle("POST v1/vendors/{id}/tables/{table_id}/leave", app.AuthMiddleware(http.HandlerFunc(app.FreeTableHandler)), op{
+			Summary: "Leave table", Access: signedIn, Response: message,
+			Legacy: []string{"PUT vendor/{id}/tables/{table_id}/freetable"},
+		})
+		//to render a table's QR code as png or svg
+		api.handle("GET v1/vendors/{id}/tables/{table_id}/qr", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetTableQRHandler)))), op{
+			Summary: "Get table QR code", Access: vendorStaff,
+			Query: []param{{Name: "format", Enum: []string{"png", "svg"}},
+				{Name: "scale", Type: "integer", Description: "Pixels per module, 1 to 40."}},
+			Produces: []string{"image/png", "image/svg+xml"},
+			Legacy:   []string{"GET vendor/{id}/tables/{table_id}/qr"},
+		})
+		//to invalidate a table's printed QR codes
+		api.handle("POST v1/vendors/{id}/tables/{table_id}/qr/rotate", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.RotateTableQRHandler)))), op{
+			Summary: "Rotate table QR code", Access: vendorStaff,
+			Response: utils.Envelope{"table": data.Table{}, "link": ""},
+			Legacy:   []string{"POST vendor/{id}/tables/{table_id}/qr/rotate"},
+		})
+		//to print the QR codes of all of a vendor's tables
+		api.handle("GET v1/vendors/{id}/tables/qr-sheet", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.TableQRSheetHandler)))), op{
+			Summary: "Print table QR codes", Access: vendorStaff, Produces: []string{"text/html"},
+			Legacy: []string{"GET vendor/{id}/tables/qr-sheet"},
+		})
+		//to open or join a table session from a scanned QR code
+		api.handle("POST v1/tables/scan", app.AuthMiddleware(http.HandlerFunc(app.ScanTableHandler)), op{
+			Summary: "Scan table QR code", Access: signedIn, Body: scanRequest{},
+			Response: utils.Envelope{"table": data.Table{}, "session": data.TableSession{}},
+			Legacy:   []string{"POST tables/scan"},
+		})
+		//to get and save a vendor's floor plan
+		api.handle("GET v1/vendors/{id}/floor-plan", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetFloorPlanHandler)))), op{
+			Summary: "Get floor plan", Access: vendorStaff,
+			Response: utils.Envelope{"floor_plan": data.FloorPlan{}},
+			Legacy:   []string{"GET vendor/{id}/floor-plan"},
+		})
+		api.handle("PUT v1/vendors/{id}/floor-plan", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.SaveFloorPlanHandler)))), op{
+			Summary: "Save floor plan", Access: vendorStaff, Body: data.FloorPlanInput{}, JSON: true,
+			Response: utils.Envelope{"floor_plan": data.FloorPlan{}},
+			Legacy:   []string{"PUT vendor/{id}/floor-plan"},
+		})
+		//to see who is seated where, what is being prepared and who needs service
+		api.handle("GET v1/vendors/{id}/floor/live", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.LiveFloorHandler)))), op{
+			Summary: "Get live floor", Access: vendorStaff,
+			Response: utils.Envelope{"floor": data.LiveFloor{}},
+			Legacy:   []string{"GET vendor/{id}/floor/live"},
+		})
+		//to set opening hours, holidays and temporary pauses
+		api.group("Opening hours")
+		hours := utils.Envelope{"hours": data.OpeningHours{}, "status": data.OpenStatus{}}
+		api.handle("GET v1/vendors/{id}/hours", app.AuthMiddleware(http.HandlerFunc(app.GetOpeningHoursHandler)), op{
+			Summary: "Get opening hours", Access: signedIn, Response: hours,
+			Legacy: []string{"GET vendor/{id}/hours"},
+		})
+		api.handle("PUT v1/vendors/{id}/hours", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.SetOpeningHoursHandler)))), op{
+			Summary: "Set opening hours", Access: vendorStaff, Body: openingHoursRequest{}, JSON: true, Response: hours,
+			Legacy: []string{"PUT vendor/{id}/hours"},
+		})
+		api.handle("POST v1/vendors/{id}/hours/exceptions", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.CreateHourExceptionHandler)))), op{
+			Summary: "Add hours exception", Access: vendorStaff, Body: hourExceptionRequest{}, Status: http.StatusCreated,
+			Response: utils.Envelope{"exception": data.HourException{}},
+			Legacy:   []string{"POST vendor/{id}/hours/exceptions"},
+		})
+		api.handle("DELETE v1/vendors/{id}/hours/exceptions/{exception_id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.DeleteHourExceptionHandler)))), op{
+			Summary: "Delete hours exception", Access: vendorStaff, Response: message,
+			Legacy: []string{"DELETE vendor/{id}/hours/exceptions/{exception_id}"},
+		})
+		api.handle("PUT v1/vendors/{id}/pause", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.PauseOrderingHandler)))), op{
+			Summary: "Pause ordering", Access: vendorStaff, Body: pauseRequest{},
+			Response: utils.Envelope{"paused_until": time.Time{}, "pause_reason": new(string)},
+			Legacy:   []string{"PUT vendor/{id}/pause"},
+		})
+		api.handle("DELETE v1/vendors/{id}/pause", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.ResumeOrderingHandler)))), op{
+			Summary: "Resume ordering", Access: vendorStaff, Response: message,
+			Legacy: []string{"DELETE vendor/{id}/pause"},
+		})
+		//to search vendors and menu items together
+		api.group("Search")
+		api.handle("GET v1/search", app.AuthMiddleware(http.HandlerFunc(app.SearchHandler)), op{
+			Summary: "Search vendors and items", Access: signedIn,
+			Description: "Each group is paged on its own; a cursor pages the group it came from and needs type set to it.",
+			Query: paged(data.SearchSorts("v", data.SearchQuery{}),
+				param{Name: "q", Description: "Words to search for, prefixes and typos included."},
+				param{Name: "type", Enum: []string{"all", "vendors", "items"}}),
+			Response: utils.Envelope{"query": "",
+				"vendors": pageOf("results", []data.VendorSearchResult{}), "items": pageOf("results", []data.ItemSearchResult{})},
+			Legacy: []string{"GET search"},
+		})
 		// Vendor routes
-		sub.HandleFunc("GET vendors", app.AuthMiddleware(http.HandlerFunc(app.IndexVendorHandler)))
-		sub.HandleFunc("GET vendors/{id}", app.AuthMiddleware(http.HandlerFunc(app.ShowVendorHandler)))
-		sub.HandleFunc("POST vendors", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.CreateVendor)))))
-		sub.HandleFunc("PUT vendors/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.UpdateVendorHandler)))))
-		sub.HandleFunc("DELETE vendors/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.DeleteVendorHandler)))))
-		sub.HandleFunc("GET vendortables/{id}", app.AuthMiddleware(http.HandlerFunc(app.GetVendorTablesHandler)))
+		api.group("Vendors")
+		api.handle("GET v1/vendors", app.AuthMiddleware(http.HandlerFunc(app.IndexVendorHandler)), op{
+			Summary: "List vendors", Access: signedIn,
+			Description: "Sorting by distance needs lat and lng, and is the default with them. Only admins, and owners listing their vendors, can include admins.",
+			Query: paged(data.VendorSorts(&utils.Near{}), filtered(data.VendorFilters, searchParam,
+				param{Name: "tags", Description: "Comma separated tag slugs, all of which must match."},
+				param{Name: "lat", Type: "number"}, param{Name: "lng", Type: "number"},
+				param{Name: "radius", Type: "number", Description: "Kilometres around lat and lng, 5 by default."},
+				fieldsParam, includeParam("admins"))...),
+			Response: utils.Envelope{"Vendors": []data.Vendor{}, "metadata": pagination.Metadata{}, "Facets": []data.TagFacet{}},
+			Legacy:   []string{"GET vendors"},
+		})
+		api.handle("GET v1/vendors/{id}", app.AuthMiddleware(http.HandlerFunc(app.ShowVendorHandler)), op{
+			Summary: "Get vendor", Access: signedIn,
+			ETag:        true,
+			Description: "Only admins can include admins.",
+			Query:       []param{fieldsParam, includeParam("admins")},
+			Response:    utils.Envelope{"vendor": data.Vendor{}},
+			Legacy:      []string{"GET vendors/{id}"},
+		})
+		api.handle("POST v1/vendors", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.CreateVendor)))), op{
+			Summary: "Create vendor", Access: adminOnly, Body: vendorRequest{}, Upload: "img", Status: http.StatusCreated,
+			Response: utils.Envelope{"vendor created successfully ": uuid.UUID{}},
+			Legacy:   []string{"POST vendors"},
+		})
+		api.handle("PATCH v1/vendors/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.UpdateVendorHandler)))), op{
+			Summary: "Update vendor", Access: vendorStaff, Body: vendorRequest{}, Upload: "img",
+			IfMatch:  true,
+			Response: utils.Envelope{"vendor": data.Vendor{}},
+			Legacy:   []string{"PUT vendors/{id}"},
+		})
+		api.handle("DELETE v1/vendors/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.DeleteVendorHandler)))), op{
+			Summary: "Delete vendor", Access: adminOnly,
+			Response: utils.Envelope{"deleted vendor": data.Vendor{}},
+			Legacy:   []string{"DELETE vendors/{id}"},
+		})
 		// Vendor Admin routes
-		sub.HandleFunc("GET vendors/{id}/admins", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetVendorAdminsHandler)))))
-		sub.HandleFunc("POST vendors/{id}/admins", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.CreateVendorAdminHandler)))))
-		sub.HandleFunc("GET vendors/{id}/admins/{adminId}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetVendorAdminHandler)))))
-		sub.HandleFunc("PUT vendors/{id}/admins/{adminId}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.UpdateVendorAdminHandler)))))
-		sub.HandleFunc("DELETE vendors/{id}/admins/{adminId}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.DeleteVendorAdminHandler)))))
-		sub.HandleFunc("GET uservendors/{id}", app.AuthMiddleware(http.HandlerFunc(app.AuthorizeUserUpdate(http.HandlerFunc(app.GetUserVendor)))))
+		api.group("Vendor staff")
+		api.handle("GET v1/vendors/{id}/admins", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetVendorAdminsHandler)))), op{
+			Summary: "List vendor staff", Access: vendorStaff,
+			Query:    paged(data.VendorAdminSorts),
+			Response: pageOf("vendor_admin", []data.VendorAdminUser{}),
+			Legacy:   []string{"GET vendors/{id}/admins"},
+		})
+		api.handle("POST v1/vendors/{id}/admins", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.CreateVendorAdminHandler)))), op{
+			Summary: "Add vendor staff", Access: vendorStaff, Body: vendorAdminRequest{}, Status: http.StatusCreated,
+			Response: utils.Envelope{"vendor_admin": data.VendorAdmin{}},
+			Legacy:   []string{"POST vendors/{id}/admins"},
+		})
+		api.handle("GET v1/vendors/{id}/admins/{admin_id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetVendorAdminHandler)))), op{
+			Summary: "Get vendor staff member", Access: vendorStaff,
+			Query:    []param{{Name: "user_id", Description: "The staff member's user ID."}},
+			Response: utils.Envelope{"vendor_admin": data.VendorAdmin{}},
+			Legacy:   []string{"GET vendors/{id}/admins/{admin_id}"},
+		})
+		api.handle("PUT v1/vendors/{id}/admins/{admin_id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.UpdateVendorAdminHandler)))), op{
+			Summary: "Update vendor staff member", Access: vendorStaff, Body: updateVendorAdminRequest{},
+			Response: utils.Envelope{"vendor_admin": data.VendorAdmin{}},
+			Legacy:   []string{"PUT vendors/{id}/admins/{admin_id}"},
+		})
+		api.handle("DELETE v1/vendors/{id}/admins/{admin_id}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.DeleteVendorAdminHandler)))), op{
+			Summary: "Remove vendor staff member", Access: vendorStaff, Response: message,
+			Legacy: []string{"DELETE vendors/{id}/admins/{admin_id}"},
+		})
+		//to manage discovery tags and assign them to vendors
+		api.group("Tags")
+		api.handle("GET v1/tags", app.AuthMiddleware(http.HandlerFunc(app.GetTagsHandler)), op{
+			Summary: "List tags", Access: signedIn,
+			Query:    paged(data.TagSorts, param{Name: "kind", Enum: data.TagKinds}),
+			Response: pageOf("tags", []data.Tag{}),
+			Legacy:   []string{"GET tags"},
+		})
+		api.handle("POST v1/tags", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.CreateTagHandler)))), op{
+			Summary: "Create tag", Access: adminOnly, Body: tagRequest{}, Status: http.StatusCreated,
+			Response: utils.Envelope{"tag": data.Tag{}},
+			Legacy:   []string{"POST tags"},
+		})
+		api.handle("PATCH v1/tags/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.UpdateTagHandler)))), op{
+			Summary: "Update tag", Access: adminOnly, Body: tagRequest{},
+			Response: utils.Envelope{"tag": data.Tag{}},
+			Legacy:   []string{"PUT tags/{id}"},
+		})
+		api.handle("DELETE v1/tags/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.DeleteTagHandler)))), op{
+			Summary: "Delete tag", Access: adminOnly, Response: message,
+			Legacy: []string{"DELETE tags/{id}"},
+		})
+		api.handle("PUT v1/vendors/{id}/tags", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.SetVendorTagsHandler)))), op{
+			Summary: "Set vendor tags", Access: vendorStaff, Body: vendorTagsRequest{},
+			Response: utils.Envelope{"tags": []data.Tag{}},
+			Legacy:   []string{"PUT vendors/{id}/tags"},
+		})
+		//to translate vendors, items and tags into English or Arabic
+		api.group("Translations")
+		missing := pageOf("missing", []data.MissingTranslation{})
+		missing["lang"] = ""
+		translationsMissingQuery := paged(data.MissingTranslationSorts, param{Name: "lang", Description: "Language to look for gaps in; the request's language by default."})
+		api.handle("GET v1/vendors/{id}/translations", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetVendorTranslationsHandler)))), op{
+			Summary: "List vendor translations", Access: vendorStaff, Description: "The item translations are paged.",
+			Query:    paged(data.ItemTranslationSorts),
+			Response: utils.Envelope{"vendor": []data.VendorTranslation{}, "items": []data.ItemTranslation{}, "metadata": pagination.Metadata{}},
+			Legacy:   []string{"GET vendors/{id}/translations"},
+		})
+		api.handle("GET v1/vendors/{id}/translations/missing", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.MissingTranslationsHandler)))), op{
+			Summary: "List vendor missing translations", Access: vendorStaff, Query: translationsMissingQuery, Response: missing,
+			Legacy: []string{"GET vendors/{id}/translations/missing"},
+		})
+		api.handle("PUT v1/vendors/{id}/translations/{lang}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.UpsertVendorTranslationHandler)))), op{
+			Summary: "Translate vendor", Access: vendorStaff, Body: translationRequest{},
+			Response: utils.Envelope{"translation": data.VendorTranslation{}},
+			Legacy:   []string{"PUT vendors/{id}/translations/{lang}"},
+		})
+		api.handle("DELETE v1/vendors/{id}/translations/{lang}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.DeleteVendorTranslationHandler)))), op{
+			Summary: "Delete vendor translation", Access: vendorStaff, Response: message,
+			Legacy: []string{"DELETE vendors/{id}/translations/{lang}"},
+		})
+		api.handle("PUT v1/vendors/{id}/items/{item_id}/translations/{lang}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.UpsertItemTranslationHandler)))), op{
+			Summary: "Translate item", Access: vendorStaff, Body: nameTranslationRequest{},
+			Response: utils.Envelope{"translation": data.ItemTranslation{}},
+			Legacy:   []string{"PUT vendors/{id}/items/{item_id}/translations/{lang}"},
+		})
+		api.handle("DELETE v1/vendors/{id}/items/{item_id}/translations/{lang}", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.DeleteItemTranslationHandler)))), op{
+			Summary: "Delete item translation", Access: vendorStaff, Response: message,
+			Legacy: []string{"DELETE vendors/{id}/items/{item_id}/translations/{lang}"},
+		})
+		api.handle("PUT v1/tags/{id}/translations/{lang}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.UpsertTagTranslationHandler)))), op{
+			Summary: "Translate tag", Access: adminOnly, Body: nameTranslationRequest{},
+			Response: utils.Envelope{"translation": data.TagTranslation{}},
+			Legacy:   []string{"PUT tags/{id}/translations/{lang}"},
+		})
+		api.handle("DELETE v1/tags/{id}/translations/{lang}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.DeleteTagTranslationHandler)))), op{
+			Summary: "Delete tag translation", Access: adminOnly, Response: message,
+			Legacy: []string{"DELETE tags/{id}/translations/{lang}"},
+		})
+		api.handle("GET v1/translations/missing", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.MissingTranslationsHandler)))), op{
+			Summary: "List missing translations", Access: adminOnly,
+			Query:    append([]param{{Name: "vendor_id", Description: "Only this vendor's gaps."}}, translationsMissingQuery...),
+			Response: missing,
+			Legacy:   []string{"GET translations/missing"},
+		})
+		// Subscription plan routes
+		api.group("Plans")
+		api.handle("GET v1/plans", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.GetPlansHandler)))), op{
+			Summary: "List plans", Access: adminOnly,
+			Query:    paged(data.PlanSorts),
+			Response: pageOf("plans", []data.Plan{}),
+			Legacy:   []string{"GET plans"},
+		})
+		api.handle("POST v1/plans", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.CreatePlanHandler)))), op{
+			Summary: "Create plan", Access: adminOnly, Body: planRequest{}, Status: http.StatusCreated,
+			Response: utils.Envelope{"plan": data.Plan{}},
+			Legacy:   []string{"POST plans"},
+		})
+		api.handle("GET v1/plans/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.GetPlanHandler)))), op{
+			Summary: "Get plan", Access: adminOnly,
+			Response: utils.Envelope{"plan": data.Plan{}},
+			Legacy:   []string{"GET plans/{id}"},
+		})
+		api.handle("PUT v1/plans/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.UpdatePlanHandler)))), op{
+			Summary: "Update plan", Access: adminOnly, Body: planRequest{},
+			Response: utils.Envelope{"plan": data.Plan{}},
+			Legacy:   []string{"PUT plans/{id}"},
+		})
+		api.handle("DELETE v1/plans/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.DeletePlanHandler)))), op{
+			Summary: "Delete plan", Access: adminOnly, Response: message,
+			Legacy: []string{"DELETE plans/{id}"},
+		})
+		//to move a vendor to a plan and extend its subscription
+		api.handle("PUT v1/vendors/{id}/plan", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.AssignVendorPlanHandler)))), op{
+			Summary: "Assign vendor plan", Access: adminOnly, Body: planChangeRequest{},
+			Response: utils.Envelope{"vendor": data.Vendor{}, "plan": data.Plan{}},
+			Legacy:   []string{"PUT vendors/{id}/plan"},
+		})
+		//to see the vendor's plan limits and usage
+		api.handle("GET v1/vendors/{id}/plan", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetVendorPlanHandler)))), op{
+			Summary: "Get vendor plan usage", Access: vendorStaff,
+			Response: utils.Envelope{"usage": data.PlanUsage{}},
+			Legacy:   []string{"GET vendors/{id}/plan"},
+		})
+		// Subscription billing routes
+		api.group("Subscriptions")
+		api.handle("GET v1/vendors/{id}/subscription", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetSubscriptionHandler)))), op{
+			Summary: "Get subscription", Access: vendorStaff,
+			Response: utils.Envelope{"subscription": data.SubscriptionStatus{}},
+			Legacy:   []string{"GET vendors/{id}/subscription"},
+		})
+		api.handle("POST v1/vendors/{id}/subscription/renew", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.RenewSubscriptionHandler)))), op{
+			Summary: "Renew subscription", Access: vendorStaff, Body: planChangeRequest{}, Status: http.StatusCreated,
+			Response: utils.Envelope{"invoice": data.Invoice{}},
+			Legacy:   []string{"POST vendors/{id}/subscription/renew"},
+		})
+		api.handle("GET v1/vendors/{id}/subscription/history", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetSubscriptionHistoryHandler)))), op{
+			Summary: "Get subscription history", Access: vendorStaff,
+			Query:    paged(data.SubscriptionSorts),
+			Response: pageOf("history", []data.SubscriptionEvent{}),
+			Legacy:   []string{"GET vendors/{id}/subscription/history"},
+		})
+		api.handle("POST v1/vendors/{id}/subscription/reminders/read", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.MarkRemindersReadHandler)))), op{
+			Summary: "Mark subscription reminders read", Access: vendorStaff, Response: message,
+		})
+		api.handle("GET v1/vendors/{id}/invoices", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetVendorInvoicesHandler)))), op{
+			Summary: "List vendor invoices", Access: vendorStaff,
+			Query:    paged(data.SubscriptionSorts),
+			Response: pageOf("invoices", []data.Invoice{}),
+			Legacy:   []string{"GET vendors/{id}/invoices"},
+		})
+		api.handle("POST v1/invoices/{id}/pay", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.PayInvoiceHandler)))), op{
+			Summary: "Pay invoice", Access: adminOnly,
+			Response: utils.Envelope{"invoice": data.Invoice{}, "vendor": data.Vendor{}},
+			Legacy:   []string{"PUT invoices/{id}/pay"},
+		})
+		api.handle("POST v1/invoices/{id}/void", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.VoidInvoiceHandler)))), op{
+			Summary: "Void invoice", Access: adminOnly,
+			Response: utils.Envelope{"invoice": data.Invoice{}},
+			Legacy:   []string{"PUT invoices/{id}/void"},
+		})
+		// Background job routes
+		api.group("Jobs")
+		api.handle("GET v1/jobs", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.GetJobsHandler)))), op{
+			Summary: "List jobs", Access: adminOnly,
+			Response: utils.Envelope{"jobs": []jobInfo{}, "scheduler_enabled": false},
+			Legacy:   []string{"GET jobs"},
+		})
+		api.handle("GET v1/jobs/{name}/runs", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.GetJobRunsHandler)))), op{
+			Summary: "List job runs", Access: adminOnly,
+			Query:    paged(data.JobRunSorts),
+			Response: pageOf("runs", []data.JobRun{}),
+			Legacy:   []string{"GET jobs/{name}/runs"},
+		})
+		api.handle("POST v1/jobs/{name}/runs", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.TriggerJobHandler)))), op{
+			Summary: "Run job", Access: adminOnly, Status: http.StatusAccepted, Response: message,
+			Legacy: []string{"POST jobs/{name}/run"},
+		})
+		api.group("Users")
 		//change the user's role
-		sub.HandleFunc("PUT grantrole/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.GrantRole)))))
+		api.group("Roles")
+		api.handle("POST v1/users/{id}/roles", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.GrantRole)))), op{
+			Summary: "Grant role", Access: adminOnly, Body: grantRoleRequest{},
+			Response: utils.Envelope{"Updated user role": data.User_role{}},
+			Legacy:   []string{"PUT grantrole/{id}"},
+		})
 		//delete the user role
-		sub.HandleFunc("DELETE revokerole", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.RevokeRoleHandler)))))
-		sub.HandleFunc("GET userroles", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.IndexUserRoles)))))
-		sub.HandleFunc("GET userroles/{id}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.ShowUserRoleHandler)))))
+		api.handle("DELETE v1/users/{id}/roles/{role}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.RevokeRoleHandler)))), op{
+			Summary: "Revoke role", Description: "The legacy alias reads id and user_role from the body instead.", Access: adminOnly,
+			Legacy: []string{"DELETE revokerole"},
+		})
+		api.handle("GET v1/user-roles", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.IndexUserRoles)))), op{
+			Summary: "List user roles", Access: adminOnly,
+			Query:    paged(data.UserRoleSorts),
+			Response: pageOf("user_roles", []data.User_role{}),
+			Legacy:   []string{"GET userroles"},
+		})
+		api.handle("GET v1/users/{id}/roles", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.ShowUserRoleHandler)))), op{
+			Summary: "Get user role", Access: adminOnly,
+			Response: utils.Envelope{"user_roles": data.User_role{}},
+			Legacy:   []string{"GET userroles/{id}"},
+		})
 		// Auth middleware applied per route
-		sub.HandleFunc("GET me", app.AuthMiddleware(http.HandlerFunc(app.MeHandler)))
-		sub.HandleFunc("GET users/{id}/vendors", app.AuthMiddleware(http.HandlerFunc(app.GetUserVendor)))
-		sub.HandleFunc("POST orders", app.AuthMiddleware(http.HandlerFunc(app.CreateOrderHandler)))
-		sub.HandleFunc("DELETE orders/{id}", app.AuthMiddleware(http.HandlerFunc(app.DeleteOrderHandler)))
-		sub.HandleFunc("PUT orderscompleted/{id}", app.AuthMiddleware(http.HandlerFunc(app.UpdateOrderStatusHandler)))
-		sub.HandleFunc("GET orders", app.AuthMiddleware(app.AuthorizeUserUpdate(http.HandlerFunc(app.GetOrdersHandler))))
-		sub.HandleFunc("GET vendororders/{id}", app.AuthMiddleware(app.AuthorizeUserUpdate(http.HandlerFunc(app.GetVendorOrdersHandler))))
-		sub.HandleFunc("POST orderitems", app.AuthMiddleware(http.HandlerFunc(app.CreateOrderItemHandler)))
-		sub.HandleFunc("DELETE orderitems/{id}", app.AuthMiddleware(http.HandlerFunc(app.DeleteOrderItemHandler)))
+		api.group("Users")
+		api.handle("GET v1/me", app.AuthMiddleware(http.HandlerFunc(app.MeHandler)), op{
+			Summary: "Get me", Access: signedIn,
+			Response: utils.Envelope{"me": utils.Envelope{"user_info": data.User{}, "user_role": ""}},
+			Legacy:   []string{"GET me"},
+		})
+		api.handle("GET v1/users/{id}/vendors", app.AuthMiddleware(http.HandlerFunc(app.GetUserVendor)), op{
+			Summary: "List user vendors", Access: signedIn,
+			Query:    paged(data.VendorSorts(nil)),
+			Response: pageOf("vendor", []data.Vendor{}),
+			Legacy:   []string{"GET users/{id}/vendors", "GET uservendors/{id}"},
+		})
+		api.group("Orders")
+		api.handle("POST v1/orders", app.AuthMiddleware(http.HandlerFunc(app.CreateOrderHandler)), op{
+			Summary: "Create order", Access: signedIn, Body: orderRequest{}, Status: http.StatusCreated,
+			Response: utils.Envelope{"order": data.Order{}},
+			Legacy:   []string{"POST orders"},
+		})
+		api.handle("DELETE v1/orders/{id}", app.AuthMiddleware(http.HandlerFunc(app.DeleteOrderHandler)), op{
+			Summary: "Delete order", Access: signedIn, Response: message,
+			Legacy: []string{"DELETE orders/{id}"},
+		})
+		api.handle("PATCH v1/orders/{id}", app.AuthMiddleware(http.HandlerFunc(app.UpdateOrderStatusHandler)), op{
+			Summary: "Update order status", Access: signedIn, Body: orderStatusRequest{}, Response: message,
+			Legacy: []string{"PUT orderscompleted/{id}"},
+		})
+		api.handle("GET v1/orders", app.AuthMiddleware(app.AuthorizeUserUpdate(http.HandlerFunc(app.GetOrdersHandler))), op{
+			Summary: "List my orders", Access: signedIn,
+			Query:    paged(data.CustomerOrderSorts),
+			Response: pageOf("orders", []data.OrderDetails{}),
+			Legacy:   []string{"GET orders"},
+		})
+		api.handle("GET v1/vendors/{id}/orders", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.GetVendorOrdersHandler)))), op{
+			Summary: "List vendor orders", Access: vendorStaff,
+			Query:    paged(data.VendorOrderSorts, filtered(data.VendorOrderFilters, fieldsParam, includeParam("items", "customer"))...),
+			Response: pageOf("orders", []data.Order{}),
+			Legacy:   []string{"GET vendororders/{id}"},
+		})
+		//to review completed orders, reply to reviews and moderate them
+		api.group("Reviews")
+		reviewPage := pageOf("reviews", []data.Review{})
+		api.handle("POST v1/orders/{id}/review", app.AuthMiddleware(http.HandlerFunc(app.CreateReviewHandler)), op{
+			Summary: "Review order", Access: signedIn, Body: data.ReviewInput{}, JSON: true, Status: http.StatusCreated,
+			Response: utils.Envelope{"review": data.Review{}},
+			Legacy:   []string{"POST orders/{id}/review"},
+		})
+		api.handle("GET v1/vendors/{id}/reviews", app.AuthMiddleware(http.HandlerFunc(app.GetVendorReviewsHandler)), op{
+			Summary: "List vendor reviews", Access: signedIn, Query: paged(data.ReviewSorts), Response: reviewPage,
+			Legacy: []string{"GET vendors/{id}/reviews"},
+		})
+		api.handle("PUT v1/vendors/{id}/reviews/{review_id}/reply", app.AuthMiddleware(http.HandlerFunc(app.requireVendorPermission(http.HandlerFunc(app.ReplyReviewHandler)))), op{
+			Summary: "Reply to review", Access: vendorStaff, Body: reviewReplyRequest{},
+			Response: utils.Envelope{"review": data.Review{}},
+			Legacy:   []string{"PUT vendors/{id}/reviews/{review_id}/reply"},
+		})
+		api.handle("GET v1/reviews", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.GetReviewsHandler)))), op{
+			Summary: "List reviews for moderation", Access: adminOnly,
+			Query:    paged(data.ReviewSorts, param{Name: "status", Enum: []string{"visible", "flagged", "hidden"}}),
+			Response: reviewPage,
+			Legacy:   []string{"GET reviews"},
+		})
+		api.handle("POST v1/reviews/{id}/{action}", app.AuthMiddleware(http.HandlerFunc(app.requireAdmin(http.HandlerFunc(app.ModerateReviewHandler)))), op{
+			Summary: "Moderate review", Access: adminOnly, Body: moderationRequest{},
+			Response: utils.Envelope{"review": data.Review{}},
+			Legacy:   []string{"PUT reviews/{id}/{action}"},
+		})
+		api.group("Orders")
+		api.handle("POST v1/order-items", app.AuthMiddleware(http.HandlerFunc(app.CreateOrderItemHandler)), op{
+			Summary: "Add order item", Access: signedIn, Body: orderItemRequest{}, Status: http.StatusCreated,
+			Response: utils.Envelope{"order_item": data.OrderItem{}},
+			Legacy:   []string{"POST orderitems"},
+		})
+		api.handle("DELETE v1/order-items/{id}", app.AuthMiddleware(http.HandlerFunc(app.DeleteOrderItemHandler)), op{
+			Summary: "Delete order item", Access: signedIn, Response: message,
+			Legacy: []string{"DELETE orderitems/{id}"},
+		})
 		// add an item for a vendor
-		sub.HandleFunc("POST vendor/{id}/items", app.AuthMiddleware(app.requireVendorPermission(http.HandlerFunc(app.CreateItemHandler))))
+		api.group("Items")
+		api.handle("POST v1/vendors/{id}/items", app.AuthMiddleware(app.requireVendorPermission(http.HandlerFunc(app.CreateItemHandler))), op{
+			Summary: "Create item", Access: vendorStaff, Body: itemRequest{}, Upload: "img", Status: http.StatusCreated,
+			Response: utils.Envelope{"item": data.Item{}},
+			Legacy:   []string{"POST vendor/{id}/items"},
+		})
 		// delete an item for a vendor
-		sub.HandleFunc("DELETE vendor/{id}/items/{itemid}", app.AuthMiddleware(app.requireVendorPermission(http.HandlerFunc(app.DeleteItemHandler))))
+		api.handle("DELETE v1/vendors/{id}/items/{item_id}", app.AuthMiddleware(app.requireVendorPermission(http.HandlerFunc(app.DeleteItemHandler))), op{
+			Summary: "Delete item", Access: vendorStaff, Response: message,
+			Legacy: []string{"DELETE vendor/{id}/items/{item_id}"},
+		})
 		// get  items of a vendor
-		sub.HandleFunc("GET vendor/{id}/items/{itemid}", app.AuthMiddleware(http.HandlerFunc(app.GetItemHandler)))
-		sub.HandleFunc("GET vendor/{id}/items", app.AuthMiddleware(http.HandlerFunc(app.GetAllItemsHandler)))
+		api.handle("GET v1/vendors/{id}/items/{item_id}", app.AuthMiddleware(http.HandlerFunc(app.GetItemHandler)), op{
+			Summary: "Get item", Access: signedIn,
+			ETag:     true,
+			Query:    []param{fieldsParam, includeParam("vendor")},
+			Response: utils.Envelope{"item": data.Item{}},
+			Legacy:   []string{"GET vendor/{id}/items/{item_id}"},
+		})
+		api.handle("GET v1/vendors/{id}/items", app.AuthMiddleware(http.HandlerFunc(app.GetAllItemsHandler)), op{
+			Summary: "List items", Access: signedIn,
+			Query:    paged(data.ItemSorts, filtered(data.ItemFilters, searchParam, fieldsParam, includeParam("vendor"))...),
+			Response: pageOf("items", []data.Item{}),
+			Legacy:   []string{"GET vendor/{id}/items"},
+		})
 		// update  items of a vendor
-		sub.HandleFunc("GET vendor/{id}/itemscount", app.AuthMiddleware(http.HandlerFunc(app.GetAllItemsCountHandler)))
-		sub.HandleFunc("PUT vendor/{id}/items/{itemid}", app.AuthMiddleware(app.requireVendorPermission(http.HandlerFunc(app.UpdateItemHandler))))
-		sub.HandleFunc("POST cartitems", app.AuthMiddleware(http.HandlerFunc(app.CreateCartItemHandler)))
-		sub.HandleFunc("GET cartitems", app.AuthMiddleware(http.HandlerFunc(app.GetCartItemswithimage)))
-		sub.HandleFunc("DELETE cartitems/{id}", app.AuthMiddleware(http.HandlerFunc(app.DeleteCartItemHandler)))
-		sub.HandleFunc("PUT cartitems/{id}", app.AuthMiddleware((http.HandlerFunc(app.UpdateCartItemHandler))))
-		sub.HandleFunc("POST carts", app.AuthMiddleware(http.HandlerFunc(app.CreateCartHandler)))
-		sub.HandleFunc("DELETE carts/{id}", app.AuthMiddleware(app.requireAdmin(http.HandlerFunc(app.DeleteCartHandler))))
-		sub.HandleFunc("PUT carts/{id}", app.AuthMiddleware(app.AuthorizeUserUpdate(http.HandlerFunc(app.UpdateCartHandler))))
-		sub.HandleFunc("GET carts", app.AuthMiddleware(app.AuthorizeUserUpdate(http.HandlerFunc(app.GetCartHandler))))
-		sub.HandleFunc("POST checkout", app.AuthMiddleware(app.AuthorizeUserUpdate(http.HandlerFunc(app.CheckoutHandler))))
+		api.handle("GET v1/vendors/{id}/items/count", app.AuthMiddleware(http.HandlerFunc(app.GetAllItemsCountHandler)), op{
+			Summary: "Count items", Access: signedIn,
+			Response: utils.Envelope{"totalCount": 0},
+			Legacy:   []string{"GET vendor/{id}/itemscount"},
+		})
+		api.handle("PATCH v1/vendors/{id}/items/{item_id}", app.AuthMiddleware(app.requireVendorPermission(http.HandlerFunc(app.UpdateItemHandler))), op{
+			Summary: "Update item", Access: vendorStaff, Body: itemRequest{}, Upload: "img",
+			IfMatch:  true,
+			Response: utils.Envelope{"item": data.Item{}},
+			Legacy:   []string{"PUT vendor/{id}/items/{item_id}"},
+		})
+		api.group("Cart")
+		api.handle("POST v1/cart/items", app.AuthMiddleware(http.HandlerFunc(app.CreateCartItemHandler)), op{
+			Summary: "Add cart item", Access: signedIn, Body: cartItemRequest{}, Status: http.StatusCreated,
+			Response: utils.Envelope{"cart_item": data.CartItem{}},
+			Legacy:   []string{"POST cartitems"},
+		})
+		api.handle("GET v1/cart/items", app.AuthMiddleware(http.HandlerFunc(app.GetCartItemswithimage)), op{
+			Summary: "List cart items", Access: signedIn,
+			Query:    paged(data.CartItemSorts),
+			Response: pageOf("cart", []data.CartItemWithNameAndImg{}),
+			Legacy:   []string{"GET cartitems"},
+		})
+		api.handle("DELETE v1/cart/items/{id}", app.AuthMiddleware(http.HandlerFunc(app.DeleteCartItemHandler)), op{
+			Summary: "Remove cart item", Access: signedIn, Body: removeCartItemRequest{}, Response: message,
+			Legacy: []string{"DELETE cartitems/{id}"},
+		})
+		api.handle("PATCH v1/cart/items/{id}", app.AuthMiddleware((http.HandlerFunc(app.UpdateCartItemHandler))), op{
+			Summary: "Update cart item", Access: signedIn, Body: cartItemRequest{},
+			Response: utils.Envelope{"cart_item": data.CartItem{}},
+			Legacy:   []string{"PUT cartitems/{id}"},
+		})
+		api.handle("POST v1/cart", app.AuthMiddleware(http.HandlerFunc(app.CreateCartHandler)), op{
+			Summary: "Create cart", Access: signedIn, Status: http.StatusCreated,
+			Legacy: []string{"POST carts"},
+		})
+		api.handle("DELETE v1/carts/{id}", app.AuthMiddleware(app.requireAdmin(http.HandlerFunc(app.DeleteCartHandler))), op{
+			Summary: "Delete cart", Access: adminOnly, Response: message,
+			Legacy: []string{"DELETE carts/{id}"},
+		})
+		api.handle("PATCH v1/cart", app.AuthMiddleware(app.AuthorizeUserUpdate(http.HandlerFunc(app.UpdateCartHandler))), op{
+			Summary: "Update cart", Access: signedIn, Body: cartRequest{},
+			Response: utils.Envelope{"cart": data.Cart{}},
+			Legacy:   []string{"PUT carts/{id}"},
+		})
+		api.handle("GET v1/cart", app.AuthMiddleware(app.AuthorizeUserUpdate(http.HandlerFunc(app.GetCartHandler))), op{
+			Summary: "Get cart", Access: signedIn,
+			Response: utils.Envelope{"cart": new(data.Cart)},
+			Legacy:   []string{"GET carts"},
+		})
+		api.handle("POST v1/cart/checkout", app.AuthMiddleware(app.AuthorizeUserUpdate(http.HandlerFunc(app.CheckoutHandler))), op{
+			Summary: "Check out", Access: signedIn,
+			Response: utils.Envelope{"message": "", "order_id": uuid.UUID{}},
+			Legacy:   []string{"POST checkout"},
+		})
 	})
 
 	return r
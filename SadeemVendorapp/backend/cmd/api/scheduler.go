@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"project/internal/data"
+)
+
+var errUnknownJob = errors.New("unknown job")
+
+// clock lets the scheduler be driven by a fake time source.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// jobStore is the part of data.JobDB the scheduler needs: the advisory lock and run history.
+type jobStore interface {
+	TryLock(ctx context.Context, name string) (func(), bool, error)
+	HasRunSince(ctx context.Context, name string, since time.Time) (bool, error)
+	StartRun(ctx context.Context, name, triggeredBy, instance string, startedAt time.Time) (*data.JobRun, error)
+	FinishRun(ctx context.Context, run *data.JobRun, result string, runErr error, finishedAt time.Time) error
+}
+
+// jobFunc does the work of a job. now comes from the scheduler's clock; the returned string
+// is a short summary kept in the run history.
+type jobFunc func(ctx context.Context, now time.Time) (string, error)
+
+type job struct {
+	name     string
+	spec     string
+	schedule *schedule
+	run      jobFunc
+	next     time.Time
+	running  bool
+}
+
+// jobInfo is what the admin endpoints show about a registered job.
+type jobInfo struct {
+	Name     string       `json:"name"`
+	Schedule string       `json:"schedule"`
+	NextRun  time.Time    `json:"next_run"`
+	Running  bool         `json:"running"`
+	LastRun  *data.JobRun `json:"last_run"`
+}
+
+// scheduler runs jobs on cron-like schedules. Every replica runs a scheduler; a Postgres
+// advisory lock per job, plus a check that the scheduled slot hasn't already been run,
+// makes sure only one of them does the work.
+type scheduler struct {
+	clock    clock
+	store    jobStore
+	log      *log.Logger
+	instance string
+
+	mu    sync.Mutex
+	jobs  map[string]*job
+	ctx   context.Context
+	wake  chan struct{}
+	group sync.WaitGroup
+}
+
+func newScheduler(c clock, store jobStore, logger *log.Logger, instance string) *scheduler {
+	return &scheduler{
+		clock:    c,
+		store:    store,
+		log:      logger,
+		instance: instance,
+		jobs:     make(map[string]*job),
+		ctx:      context.Background(),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register adds a job. spec is a five-field cron expression or one of @hourly, @daily
+// and "@every <duration>".
+func (s *scheduler) Register(name, spec string, run jobFunc) error {
+	sched, err := parseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("job %s is already registered", name)
+	}
+	s.jobs[name] = &job{name: name, spec: spec, schedule: sched, run: run, next: sched.Next(s.clock.Now())}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start runs due jobs until ctx is cancelled.
+func (s *scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	for {
+		s.mu.Lock()
+		now := s.clock.Now()
+		var earliest time.Time
+		for _, j := range s.jobs {
+			if !j.next.After(now) {
+				scheduled := j.next
+				j.next = j.schedule.Next(now)
+				s.group.Add(1)
+				go func(j *job) {
+					defer s.group.Done()
+					s.runJob(ctx, j, "schedule", scheduled)
+				}(j)
+			}
+			if earliest.IsZero() || j.next.Before(earliest) {
+				earliest = j.next
+			}
+		}
+		s.mu.Unlock()
+
+		var timer <-chan time.Time
+		if !earliest.IsZero() {
+			timer = s.clock.After(earliest.Sub(now))
+		}
+		select {
+		case <-ctx.Done():
+			s.group.Wait()
+			return
+		case <-s.wake:
+		case <-timer:
+		}
+	}
+}
+
+// Trigger runs a job now, in the background, regardless of its schedule.
+func (s *scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	ctx := s.ctx
+	s.mu.Unlock()
+	if !ok {
+		return errUnknownJob
+	}
+
+	s.group.Add(1)
+	go func() {
+		defer s.group.Done()
+		s.runJob(ctx, j, "manual", time.Time{})
+	}()
+	return nil
+}
+
+// Jobs lists the registered jobs by name.
+func (s *scheduler) Jobs() []jobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]jobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, jobInfo{Name: j.name, Schedule: j.spec, NextRun: j.next, Running: j.running})
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].Name < jobs[b].Name })
+	return jobs
+}
+
+// Has reports whether a job with this name is registered.
+func (s *scheduler) Has(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.jobs[name]
+	return ok
+}
+
+// runJob runs j once if no other run of it is in progress, here or on another replica.
+// scheduled is the slot a scheduled run belongs to; it is zero for manual runs.
+func (s *scheduler) runJob(ctx context.Context, j *job, triggeredBy string, scheduled time.Time) {
+	s.mu.Lock()
+	if j.running {
+		s.mu.Unlock()
+		return
+	}
+	j.running = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		j.running = false
+		s.mu.Unlock()
+	}()
+
+	unlock, ok, err := s.store.TryLock(ctx, j.name)
+	if err != nil {
+		s.log.Printf("job %s: %v", j.name, err)
+		return
+	}
+	if !ok {
+		// Another replica is running it
+		return
+	}
+	defer unlock()
+
+	if !scheduled.IsZero() {
+		done, err := s.store.HasRunSince(ctx, j.name, scheduled)
+		if err != nil {
+			s.log.Printf("job %s: %v", j.name, err)
+			return
+		}
+		if done {
+			// Another replica already ran this slot
+			return
+		}
+	}
+
+	run, err := s.store.StartRun(ctx, j.name, triggeredBy, s.instance, s.clock.Now())
+	if err != nil {
+		s.log.Printf("job %s: %v", j.name, err)
+		return
+	}
+
+	result, runErr := s.safeRun(ctx, j)
+	if runErr != nil {
+		s.log.Printf("job %s failed: %v", j.name, runErr)
+	}
+	if err := s.store.FinishRun(context.Background(), run, result, runErr, s.clock.Now()); err != nil {
+		s.log.Printf("job %s: %v", j.name, err)
+	}
+}
+
+// safeRun turns a panicking job into a failed run instead of taking the server down.
+func (s *scheduler) safeRun(ctx context.Context, j *job) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return j.run(ctx, s.clock.Now())
+}
+
+// schedule is a parsed cron expression. every is set for "@every" schedules instead.
+type schedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+	every                         time.Duration
+}
+
+var scheduleDescriptors = map[string]string{
+	"@yearly":  "0 0 1 1 *",
+	"@monthly": "0 0 1 * *",
+	"@weekly":  "0 0 * * 0",
+	"@daily":   "0 0 * * *",
+	"@hourly":  "0 * * * *",
+}
+
+func parseSchedule(spec string) (*schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("invalid schedule %q", spec)
+		}
+		return &schedule{every: d}, nil
+	}
+	if expanded, ok := scheduleDescriptors[spec]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields", spec)
+	}
+
+	var s schedule
+	var err error
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	targets := [5]*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, field := range fields {
+		if *targets[i], err = parseScheduleField(field, bounds[i][0], bounds[i][1]); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return &s, nil
+}
+
+// parseScheduleField parses lists of "*", "n", "a-b" with an optional "/step" into a bit set.
+func parseScheduleField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, stepStr, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			part, step = base, n
+		}
+
+		low, high := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			a, b, _ := strings.Cut(part, "-")
+			var errA, errB error
+			low, errA = strconv.Atoi(a)
+			high, errB = strconv.Atoi(b)
+			if errA != nil || errB != nil {
+				return 0, fmt.Errorf("bad range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", part)
+			}
+			low, high = n, n
+			if step > 1 {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time after t that matches the schedule.
+func (s *schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Five years is enough to find any valid date, including 29 February
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return limit
+}
+
+// dayMatches follows cron: when both day of month and day of week are restricted, either may match.
+func (s *schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"project/internal/data"
+
+	"github.com/google/uuid"
+)
+
+// fakeClock only moves when Advance is called, firing the timers that fall due.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []fakeTimer
+	waiting chan struct{}
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waiting: make(chan struct{}, 100)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+	} else {
+		c.timers = append(c.timers, fakeTimer{c.now.Add(d), ch})
+	}
+	c.waiting <- struct{}{}
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+		} else {
+			timer.ch <- c.now
+		}
+	}
+	c.timers = pending
+}
+
+// fakeJobStore keeps job_runs in memory, with the same bookkeeping as data.JobDB.
+type fakeJobStore struct {
+	mu       sync.Mutex
+	runs     []*data.JobRun
+	locked   map[string]bool
+	finished chan data.JobRun
+}
+
+func newFakeJobStore() *fakeJobStore {
+	return &fakeJobStore{locked: map[string]bool{}, finished: make(chan data.JobRun, 100)}
+}
+
+func (s *fakeJobStore) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locked[name] {
+		return nil, false, nil
+	}
+	s.locked[name] = true
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.locked[name] = false
+	}, true, nil
+}
+
+func (s *fakeJobStore) HasRunSince(ctx context.Context, name string, since time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, run := range s.runs {
+		if run.JobName == name && !run.StartedAt.Before(since) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeJobStore) StartRun(ctx context.Context, name, triggeredBy, instance string, startedAt time.Time) (*data.JobRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run := &data.JobRun{ID: uuid.New(), JobName: name, TriggeredBy: triggeredBy, Instance: instance, Status: "running", StartedAt: startedAt}
+	s.runs = append(s.runs, run)
+	return run, nil
+}
+
+func (s *fakeJobStore) FinishRun(ctx context.Context, run *data.JobRun, result string, runErr error, finishedAt time.Time) error {
+	s.mu.Lock()
+	run.Status = "succeeded"
+	run.FinishedAt = &finishedAt
+	if result != "" {
+		run.Result = &result
+	}
+	if runErr != nil {
+		run.Status = "failed"
+		message := runErr.Error()
+		run.Error = &message
+	}
+	finished := *run
+	s.mu.Unlock()
+	s.finished <- finished
+	return nil
+}
+
+// nextFinished waits for the next run to finish.
+func (s *fakeJobStore) nextFinished(t *testing.T) data.JobRun {
+	t.Helper()
+	select {
+	case run := <-s.finished:
+		return run
+	case <-time.After(2 * time.Second):
+		t.Fatal("no job run finished")
+		return data.JobRun{}
+	}
+}
+
+func (s *fakeJobStore) assertNoRun(t *testing.T) {
+	t.Helper()
+	select {
+	case run := <-s.finished:
+		t.Fatalf("unexpected run %+v", run)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// waitIdle waits for the scheduler to set its next timer.
+func (c *fakeClock) waitIdle(t *testing.T) {
+	t.Helper()
+	select {
+	case <-c.waiting:
+	case <-time.After(2 * time.Second):
+		t.Fatal("scheduler never waited on the clock")
+	}
+}
+
+var schedulerEpoch = time.Date(2026, time.March, 2, 9, 59, 30, 0, time.UTC) // a Monday
+
+func TestParseSchedule(t *testing.T) {
+	for _, spec := range []string{"* * * * *", "*/15 * * * *", "0 9-17 * * 1-5", "30 2 1,15 * *", "@daily", "@every 90s"} {
+		if _, err := parseSchedule(spec); err != nil {
+			t.Errorf("parseSchedule(%q): %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 7", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@every 10ms", "@sometimes"} {
+		if _, err := parseSchedule(spec); err == nil {
+			t.Errorf("parseSchedule(%q) accepted", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", schedulerEpoch, at(time.March, 2, 10, 0)},
+		{"* * * * *", at(time.March, 2, 10, 0), at(time.March, 2, 10, 1)},
+		{"*/15 * * * *", at(time.March, 2, 10, 1), at(time.March, 2, 10, 15)},
+		{"*/15 * * * *", at(time.March, 2, 10, 45), at(time.March, 2, 11, 0)},
+		{"@hourly", at(time.March, 2, 23, 30), at(time.March, 3, 0, 0)},
+		{"@daily", at(time.March, 31, 12, 0), at(time.April, 1, 0, 0)},
+		{"0 9-17 * * 1-5", at(time.March, 6, 18, 0), at(time.March, 9, 9, 0)}, // Friday evening to Monday
+		{"30 2 1,15 * *", at(time.March, 2, 0, 0), at(time.March, 15, 2, 30)},
+		// Both day fields restricted: either matches, like cron
+		{"0 0 13 * 5", at(time.March, 2, 0, 0), at(time.March, 6, 0, 0)},
+		{"0 0 29 2 *", at(time.March, 2, 0, 0), time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", schedulerEpoch, schedulerEpoch.Add(90 * time.Second)},
+	}
+	for _, tt := range tests {
+		s, err := parseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.spec, err)
+		}
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q after %s = %s, want %s", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleMatchesDay(t *testing.T) {
+	s, _ := parseSchedule("0 0 * * 0,6")
+	for day := 1; day <= 7; day++ {
+		date := time.Date(2026, time.March, day, 0, 0, 0, 0, time.UTC)
+		weekend := date.Weekday() == time.Saturday || date.Weekday() == time.Sunday
+		if s.dayMatches(date) != weekend {
+			t.Errorf("%s: dayMatches = %v", date.Weekday(), !weekend)
+		}
+	}
+}
+
+func startScheduler(t *testing.T, clock *fakeClock, store *fakeJobStore) *scheduler {
+	t.Helper()
+	s := newScheduler(clock, store, log.New(io.Discard, "", 0), "test-1")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	go func() {
+		s.Start(ctx)
+		close(done)
+	}()
+	return s
+}
+
+func TestSchedulerRunsOnSchedule(t *testing.T) {
+	clock := newFakeClock(schedulerEpoch)
+	store := newFakeJobStore()
+	s := startScheduler(t, clock, store)
+
+	var calls []time.Time
+	var mu sync.Mutex
+	err := s.Register("tick", "*/15 * * * *", func(ctx context.Context, now time.Time) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, now)
+		return "ticked", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Register("tick", "@daily", nil); err == nil {
+		t.Error("registered the same job twice")
+	}
+	clock.waitIdle(t)
+	clock.waitIdle(t)
+
+	// Not due until 10:00
+	clock.Advance(29 * time.Second)
+	store.assertNoRun(t)
+
+	clock.Advance(time.Second)
+	run := store.nextFinished(t)
+	if run.JobName != "tick" || run.TriggeredBy != "schedule" || run.Instance != "test-1" || run.Status != "succeeded" {
+		t.Errorf("run = %+v", run)
+	}
+	if want := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC); !run.StartedAt.Equal(want) || !run.FinishedAt.Equal(want) {
+		t.Errorf("run from %s to %s, want %s", run.StartedAt, run.FinishedAt, want)
+	}
+	if run.Result == nil || *run.Result != "ticked" {
+		t.Errorf("result = %v", run.Result)
+	}
+	if next := s.Jobs()[0].NextRun; !next.Equal(time.Date(2026, time.March, 2, 10, 15, 0, 0, time.UTC)) {
+		t.Errorf("next run %s", next)
+	}
+}
+
+// TestSchedulerMissedRuns checks that slots missed while the scheduler wasn't woken, say
+// across a suspend, are caught up by one run rather than one per slot.
+func TestSchedulerMissedRuns(t *testing.T) {
+	clock := newFakeClock(schedulerEpoch)
+	store := newFakeJobStore()
+	s := startScheduler(t, clock, store)
+	s.Register("hourly", "@hourly", func(ctx context.Context, now time.Time) (string, error) { return "", nil })
+	clock.waitIdle(t)
+	clock.waitIdle(t)
+
+	clock.Advance(3*time.Hour + 10*time.Minute)
+	store.nextFinished(t)
+	store.assertNoRun(t)
+	if next := s.Jobs()[0].NextRun; !next.Equal(time.Date(2026, time.March, 2, 14, 0, 0, 0, time.UTC)) {
+		t.Errorf("next run %s, want the next slot after now", next)
+	}
+}
+
+func TestRunJobBookkeeping(t *testing.T) {
+	clock := newFakeClock(schedulerEpoch)
+	store := newFakeJobStore()
+	s := newScheduler(clock, store, log.New(io.Discard, "", 0), "test-1")
+	ctx := context.Background()
+	slot := time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)
+
+	failing := &job{name: "failing", run: func(ctx context.Context, now time.Time) (string, error) {
+		return "half done", errors.New("boom")
+	}}
+	s.runJob(ctx, failing, "manual", time.Time{})
+	run := store.nextFinished(t)
+	if run.Status != "failed" || run.Error == nil || *run.Error != "boom" || run.Result == nil || *run.Result != "half done" {
+		t.Errorf("failed run = %+v", run)
+	}
+
+	panicking := &job{name: "panicking", run: func(ctx context.Context, now time.Time) (string, error) { panic("oops") }}
+	s.runJob(ctx, panicking, "manual", time.Time{})
+	if run = store.nextFinished(t); run.Status != "failed" || run.Error == nil || *run.Error != "panic: oops" {
+		t.Errorf("panicking run = %+v", run)
+	}
+
+	// A slot another replica has already run is skipped
+	clock.Advance(30 * time.Second)
+	store.StartRun(ctx, "shared", "schedule", "test-2", slot)
+	shared := &job{name: "shared", run: func(ctx context.Context, now time.Time) (string, error) { return "", nil }}
+	s.runJob(ctx, shared, "schedule", slot)
+	store.assertNoRun(t)
+
+	// As is a job another replica holds the lock of
+	unlock, _, _ := store.TryLock(ctx, "locked")
+	s.runJob(ctx, &job{name: "locked", run: shared.run}, "manual", time.Time{})
+	store.assertNoRun(t)
+	unlock()
+	s.runJob(ctx, &job{name: "locked", run: shared.run}, "manual", time.Time{})
+	if run = store.nextFinished(t); run.TriggeredBy != "manual" || run.Status != "succeeded" || run.Result != nil {
+		t.Errorf("manual run = %+v", run)
+	}
+}
+
+func TestTriggerUnknownJob(t *testing.T) {
+	s := newScheduler(newFakeClock(schedulerEpoch), newFakeJobStore(), log.New(io.Discard, "", 0), "test-1")
+	if err := s.Trigger("missing"); !errors.Is(err, errUnknownJob) {
+		t.Errorf("Trigger = %v, want errUnknownJob", err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/i18n"
+	"project/utils/pagination"
+	"project/utils/validator"
+)
+
+// searchRequest is the query string of a search: q parsed into a search, and the groups wanted.
+type searchRequest struct {
+	Search     data.SearchQuery
+	Searchable bool
+	Type       string
+}
+
+func (input searchRequest) rules() []validator.Rule {
+	return []validator.Rule{
+		validator.Expect("q", input.Searchable, i18n.SearchQueryInvalid),
+		validator.String("q", input.Search.Raw).MaxLength(100, i18n.SearchQueryTooLong),
+		validator.String("type", input.Type).OneOf(i18n.SearchTypeInvalid, "all", "vendors", "items"),
+	}
+}
+
+// SearchHandler searches vendors and menu items at once. q is matched by word prefix in English
+// and Arabic, with a fuzzy fallback on names; type=vendors|items limits the groups returned.
+// Each group is paged on its own with the same limit; its cursors only page that group, so they
+// need type=vendors or type=items.
+func (app *application) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	input := searchRequest{Type: r.URL.Query().Get("type")}
+	input.Search, input.Searchable = data.ParseSearchQuery(r.URL.Query().Get("q"))
+	if input.Type == "" {
+		input.Type = "all"
+	}
+	search, searchType := input.Search, input.Type
+
+	v := validator.New()
+	v.Validate(input.rules()...)
+	v.Validate(validator.Expect("cursor", searchType != "all" || r.URL.Query().Get("cursor") == "", i18n.CursorNeedsType))
+	vendorPage := app.readPage(v, r, data.SearchSorts("v", search))
+	itemPage := app.readPage(v, r, data.SearchSorts("i", search))
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Only admins see hidden vendors and their items
+	role, _ := r.Context().Value(UserRoleKey).(string)
+	includeHidden := role == "1"
+
+	response := utils.Envelope{"query": search.Raw}
+	var vendors []data.VendorSearchResult
+	var items []data.ItemSearchResult
+	var vendorMeta, itemMeta pagination.Metadata
+	var err error
+	if searchType != "items" {
+		vendors, vendorMeta, err = app.Model.SearchDB.SearchVendors(r.Context(), search, includeHidden, vendorPage)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+	if searchType != "vendors" {
+		items, itemMeta, err = app.Model.SearchDB.SearchItems(r.Context(), search, includeHidden, itemPage)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+	if err = app.localizeSearch(r, vendors, items); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if searchType != "items" {
+		vendorMeta = app.signPage(vendorMeta)
+		response["vendors"] = utils.Envelope{"results": vendors, "metadata": vendorMeta}
+	}
+	if searchType != "vendors" {
+		itemMeta = app.signPage(itemMeta)
+		response["items"] = utils.Envelope{"results": items, "metadata": itemMeta}
+	}
+
+	// Link headers can only point at the pages of a single group
+	switch searchType {
+	case "vendors":
+		app.addPageLinks(w, r, vendorMeta)
+	case "items":
+		app.addPageLinks(w, r, itemMeta)
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, response)
+}
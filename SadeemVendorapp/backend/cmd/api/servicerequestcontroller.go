@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// isSeatedAt reports whether the user is the table's customer or has joined its open session.
+func (app *application) isSeatedAt(r *http.Request, table *data.Table, userID uuid.UUID) (bool, error) {
+	if table.CustomerID != nil && *table.CustomerID == userID {
+		return true, nil
+	}
+	session, err := app.Model.TableSessionDB.GetOpenSession(r.Context(), table.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, member := range session.Members {
+		if member == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type serviceRequestRequest struct {
+	Type string `json:"type"`
+	Note string `json:"note"`
+}
+
+// CreateServiceRequestHandler lets a seated customer call for a waiter, the bill, water or cleaning.
+func (app *application) CreateServiceRequestHandler(w http.ResponseWriter, r *http.Request) {
+	table, ok := app.vendorTableFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	customerID, err := uuid.Parse(r.Context().Value(UserIDKey).(string))
+	if err != nil {
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
+		return
+	}
+
+	var input serviceRequestRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	seated, err := app.isSeatedAt(r, table, customerID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !seated {
+		app.codedErrorResponse(w, r, http.StatusForbidden, i18n.Message{Code: i18n.NotSeated})
+		return
+	}
+
+	request := &data.ServiceRequest{
+		TableID:    table.ID,
+		VendorID:   table.VendorID,
+		CustomerID: customerID,
+		Type:       input.Type,
+	}
+	if input.Note != "" {
+		request.Note = &input.Note
+	}
+
+	v := validator.New()
+	data.ValidatingServiceRequest(v, request)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if err := app.Model.ServiceRequestDB.InsertServiceRequest(r.Context(), request); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusCreated, utils.Envelope{"service_request": request})
+}
+
+// GetServiceRequestsHandler lists a vendor's service requests, optionally filtered by status.
+func (app *application) GetServiceRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	v := validator.New()
+	v.Check(status == "" || validator.In(status, "pending", "acknowledged", "resolved", "cancelled"), "status", i18n.ServiceRequestStatusInvalid)
+	page := app.readPage(v, r, data.ServiceRequestSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	requests, meta, err := app.Model.ServiceRequestDB.GetVendorServiceRequests(r.Context(), vendorID, status, page)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	app.sendPage(w, r, "service_requests", requests, meta, nil)
+}
+
+// vendorServiceRequestFromPath loads the request in the URL and makes sure it belongs to the vendor in the URL.
+func (app *application) vendorServiceRequestFromPath(w http.ResponseWriter, r *http.Request) (*data.ServiceRequest, uuid.UUID, bool) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return nil, uuid.Nil, false
+	}
+
+	requestID, err := uuid.Parse(r.PathValue("request_id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "request_id")
+		return nil, uuid.Nil, false
+	}
+
+	staffID, err := uuid.Parse(r.Context().Value(UserIDKey).(string))
+	if err != nil {
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
+		return nil, uuid.Nil, false
+	}
+
+	request, err := app.Model.ServiceRequestDB.GetServiceRequest(r.Context(), requestID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return nil, uuid.Nil, false
+	}
+	if request.VendorID != vendorID {
+		app.notFoundResponse(w, r)
+		return nil, uuid.Nil, false
+	}
+	return request, staffID, true
+}
+
+// AcknowledgeServiceRequestHandler records that a staff member has seen the request.
+func (app *application) AcknowledgeServiceRequestHandler(w http.ResponseWriter, r *http.Request) {
+	request, staffID, ok := app.vendorServiceRequestFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	request, err := app.Model.ServiceRequestDB.AcknowledgeServiceRequest(r.Context(), request.ID, staffID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"service_request": request})
+}
+
+// ResolveServiceRequestHandler records that a staff member has handled the request.
+func (app *application) ResolveServiceRequestHandler(w http.ResponseWriter, r *http.Request) {
+	request, staffID, ok := app.vendorServiceRequestFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	request, err := app.Model.ServiceRequestDB.ResolveServiceRequest(r.Context(), request.ID, staffID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"service_request": request})
+}
+
+// TableServiceDoneHandler lets the seated customer withdraw all of the table's open requests.
+func (app *application) TableServiceDoneHandler(w http.ResponseWriter, r *http.Request) {
+	table, ok := app.vendorTableFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	customerID, err := uuid.Parse(r.Context().Value(UserIDKey).(string))
+	if err != nil {
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
+		return
+	}
+
+	seated, err := app.isSeatedAt(r, table, customerID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !seated {
+		app.codedErrorResponse(w, r, http.StatusForbidden, i18n.Message{Code: i18n.NotSeated})
+		return
+	}
+
+	if err := app.Model.ServiceRequestDB.CancelTableServiceRequests(r.Context(), table.ID); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "service requests cancelled"})
+}
+
+// ServiceResponseTimesHandler reports average response times for one vendor, optionally per staff member.
+func (app *application) ServiceResponseTimesHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+	app.responseTimesReport(w, r, &vendorID)
+}
+
+// AdminServiceResponseTimesHandler reports average response times across every vendor.
+func (app *application) AdminServiceResponseTimesHandler(w http.ResponseWriter, r *http.Request) {
+	app.responseTimesReport(w, r, nil)
+}
+
+func (app *application) responseTimesReport(w http.ResponseWriter, r *http.Request, vendorID *uuid.UUID) {
+	v := validator.New()
+	from := parseReportTime(v, r.URL.Query().Get("from"), "from")
+	to := parseReportTime(v, r.URL.Query().Get("to"), "to")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	vendors, err := app.Model.ServiceRequestDB.GetVendorResponseTimes(r.Context(), vendorID, false, from, to)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	staff, err := app.Model.ServiceRequestDB.GetVendorResponseTimes(r.Context(), vendorID, true, from, to)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"vendors": vendors, "staff": staff})
+}
+
+// parseReportTime parses an optional RFC 3339 report boundary.
+func parseReportTime(v *validator.Validator, value, key string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		v.AddError(key, i18n.TimestampInvalid)
+		return nil
+	}
+	return &t
+}
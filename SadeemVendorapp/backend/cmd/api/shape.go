@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"reflect"
+	"strings"
+)
+
+// shape is how a client asked for a resource: fields= names the JSON fields to send, all of
+// them when left out, and include= the related resources to embed.
+type shape struct {
+	fields   map[string]bool
+	included map[string]bool
+}
+
+// readShape reads the fields and include query parameters of a request for rows of row's type,
+// adding any problem to v. includes are the related resources the endpoint can embed.
+func readShape(v *validator.Validator, r *http.Request, row interface{}, includes ...string) shape {
+	s := shape{included: map[string]bool{}}
+	for _, name := range commaList(r.URL.Query().Get("include")) {
+		v.Check(validator.In(name, includes...), "include", i18n.IncludeInvalid, name)
+		s.included[name] = true
+	}
+
+	names := commaList(r.URL.Query().Get("fields"))
+	if len(names) == 0 {
+		return s
+	}
+	known := jsonFields(reflect.TypeOf(row))
+	s.fields = map[string]bool{}
+	for _, name := range names {
+		v.Check(known[name], "fields", i18n.FieldInvalid, name)
+		s.fields[name] = true
+	}
+	// Whatever is included is sent, even if fields leaves it out
+	for name := range s.included {
+		s.fields[name] = true
+	}
+	return s
+}
+
+// includes reports whether the client asked to embed name.
+func (s shape) includes(name string) bool {
+	return s.included[name]
+}
+
+// trim drops the fields the client didn't ask for from a row or a slice of rows. Without
+// fields= it returns rows as they are.
+func (s shape) trim(rows interface{}) interface{} {
+	if s.fields == nil {
+		return rows
+	}
+	js, err := json.Marshal(rows)
+	if err != nil {
+		return rows
+	}
+	// Numbers are kept as written so large integers survive the round trip
+	var out interface{}
+	decoder := json.NewDecoder(bytes.NewReader(js))
+	decoder.UseNumber()
+	if err = decoder.Decode(&out); err != nil {
+		return rows
+	}
+
+	trimRow := func(row interface{}) {
+		if fields, ok := row.(map[string]interface{}); ok {
+			for name := range fields {
+				if !s.fields[name] {
+					delete(fields, name)
+				}
+			}
+		}
+	}
+	if list, ok := out.([]interface{}); ok {
+		for _, row := range list {
+			trimRow(row)
+		}
+	} else {
+		trimRow(out)
+	}
+	return out
+}
+
+// jsonFields are the names t's fields are sent under.
+func jsonFields(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	fields := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch {
+		case !field.IsExported() || name == "-":
+		case name == "":
+			fields[field.Name] = true
+		default:
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// commaList splits a comma separated parameter, dropping empty entries.
+func commaList(s string) []string {
+	var list []string
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
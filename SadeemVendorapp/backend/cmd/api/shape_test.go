@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"project/internal/data"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"reflect"
+	"testing"
+)
+
+func TestShape(t *testing.T) {
+	vendor := data.Vendor{Name: "Cafe"}
+	items := []data.Item{{Name: "Tea", Price: 2, Vendor: &vendor}, {Name: "Cake", Price: 3}}
+
+	tests := []struct {
+		name      string
+		query     string
+		want      string
+		wantError map[string]i18n.Code
+	}{
+		{"everything", "", "", map[string]i18n.Code{}},
+		{"some fields", "?fields=name,price", `[{"name":"Tea","price":2},{"name":"Cake","price":3}]`, map[string]i18n.Code{}},
+		{"included resources are kept", "?fields=name&include=vendor", "", map[string]i18n.Code{}},
+		{"unknown field", "?fields=name,password", "", map[string]i18n.Code{"fields": i18n.FieldInvalid}},
+		{"not includable", "?include=customer", "", map[string]i18n.Code{"include": i18n.IncludeInvalid}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			s := readShape(v, httptest.NewRequest("GET", "/items"+tt.query, nil), data.Item{}, "vendor")
+
+			got := map[string]i18n.Code{}
+			for key, message := range v.Errors {
+				got[key] = message.Code
+			}
+			if !reflect.DeepEqual(got, tt.wantError) {
+				t.Errorf("errors = %v, want %v", got, tt.wantError)
+			}
+			if tt.want == "" {
+				return
+			}
+			js, _ := json.Marshal(s.trim(items))
+			if string(js) != tt.want {
+				t.Errorf("trim = %s, want %s", js, tt.want)
+			}
+		})
+	}
+
+	v := validator.New()
+	s := readShape(v, httptest.NewRequest("GET", "/items?fields=name&include=vendor", nil), data.Item{}, "vendor")
+	trimmed := s.trim(items[0]).(map[string]interface{})
+	if len(trimmed) != 2 || trimmed["vendor"] == nil || !s.includes("vendor") {
+		t.Errorf("trim = %v, want the name and the vendor", trimmed)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"net/http"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/validator"
+
+	"github.com/google/uuid"
+)
+
+// GetSubscriptionHandler shows whether the vendor is active, in its grace period or lapsed.
+func (app *application) GetSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	status, err := app.Model.SubscriptionDB.GetSubscriptionStatus(r.Context(), vendorID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"subscription": status})
+}
+
+// RenewSubscriptionHandler issues an invoice for renewing the vendor's subscription.
+// plan_id switches plans on payment; days defaults to the plan's billing period.
+func (app *application) RenewSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	var input planChangeRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	v := validator.New()
+	v.Validate(input.rules(false)...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	planID := input.PlanID
+
+	days := 0
+	if input.Days != nil {
+		days = *input.Days
+	}
+
+	if _, err := app.Model.VendorDB.GetVendor(vendorID, true); err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	invoice, err := app.Model.SubscriptionDB.CreateRenewalInvoice(r.Context(), vendorID, planID, days)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusCreated, utils.Envelope{"invoice": invoice})
+}
+
+func (app *application) GetVendorInvoicesHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	v := validator.New()
+	page := app.readPage(v, r, data.SubscriptionSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	invoices, meta, err := app.Model.SubscriptionDB.GetVendorInvoices(r.Context(), vendorID, page)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	app.sendPage(w, r, "invoices", invoices, meta, nil)
+}
+
+// GetSubscriptionHistoryHandler lists renewals, grace periods, lapses and reminders, newest first.
+func (app *application) GetSubscriptionHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	v := validator.New()
+	page := app.readPage(v, r, data.SubscriptionSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	history, meta, err := app.Model.SubscriptionDB.GetHistory(r.Context(), vendorID, page)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	app.sendPage(w, r, "history", history, meta, nil)
+}
+
+// MarkRemindersReadHandler marks the vendor's expiry reminders as seen, so they leave its
+// subscription status.
+func (app *application) MarkRemindersReadHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	if _, err = app.Model.SubscriptionDB.MarkRemindersNotified(r.Context(), vendorID); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "reminders marked as read"})
+}
+
+// PayInvoiceHandler records a payment and extends the vendor's subscription.
+func (app *application) PayInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	invoiceID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	if _, err := app.Model.SubscriptionDB.GetInvoice(r.Context(), invoiceID); err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	invoice, vendor, err := app.Model.SubscriptionDB.PayInvoice(r.Context(), invoiceID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"invoice": invoice, "vendor": vendor})
+}
+
+func (app *application) VoidInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	invoiceID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	if _, err := app.Model.SubscriptionDB.GetInvoice(r.Context(), invoiceID); err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	invoice, err := app.Model.SubscriptionDB.VoidInvoice(r.Context(), invoiceID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"invoice": invoice})
+}
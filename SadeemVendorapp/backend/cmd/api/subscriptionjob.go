@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reminderDays parses the comma separated -subscription-reminder-days flag.
+func (app *application) reminderDays() []int {
+	var days []int
+	for _, part := range strings.Split(app.cfg.subscription.reminderDays, ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(part))
+		if err == nil && d > 0 {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// syncSubscriptions flips vendor visibility, records grace periods and queues expiry reminders.
+// It runs as the subscription-visibility job.
+func (app *application) syncSubscriptions(ctx context.Context, now time.Time) (string, error) {
+	events, err := app.Model.SubscriptionDB.SyncVisibility(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, event := range events {
+		app.infoLog.Printf("vendor %s subscription %s (ends %s)", event.VendorID, event.Event, event.SubscriptionEnd.Format(time.RFC3339))
+	}
+
+	reminders, err := app.Model.SubscriptionDB.RecordReminders(ctx, app.reminderDays())
+	if err != nil {
+		return "", err
+	}
+	// There is no mail or push service; reminders wait in the vendor's subscription status
+	// until it marks them read
+	for _, reminder := range reminders {
+		app.infoLog.Printf("reminder queued: vendor %s subscription %s on %s", reminder.VendorID, *reminder.Note, reminder.SubscriptionEnd.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%d visibility events, %d reminders queued", len(events), len(reminders)), nil
+}
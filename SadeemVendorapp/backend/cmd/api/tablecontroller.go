@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"project/internal/data"
 	"project/utils"
+	"project/utils/i18n"
+	"project/utils/validator"
 
 	"github.com/google/uuid"
 )
@@ -31,22 +33,25 @@ func (app *application) GetTablesHandler(w http.ResponseWriter, r *http.Request)
 
 	vendorID, err := uuid.Parse(vendorIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid vendor ID"))
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	v := validator.New()
+	page := app.readPage(v, r, data.TableSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
 	// Get tables for the specific vendor
-	tables, err := app.Model.TableDB.GetVendorTables(r.Context(), vendorID)
+	tables, meta, err := app.Model.TableDB.GetVendorTablesPage(r.Context(), vendorID, page)
 	if err != nil {
-		if err == data.ErrRecordNotFound {
-			http.Error(w, "No tables found for the specified vendor", http.StatusNotFound)
-		} else {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		}
+		app.handleRetrievalError(w, r, err)
 		return
 	}
 
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"tables": tables})
+	app.sendPage(w, r, "tables", tables, meta, nil)
 }
 
 // GetTableHandler retrieves a single table by its ID and ensures it belongs to the vendor specified in the URL.
@@ -60,7 +65,7 @@ func (app *application) GetTableHandler(w http.ResponseWriter, r *http.Request)
 
 	tableID, err := uuid.Parse(tableIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid table ID"))
+		app.invalidIDResponse(w, r, "table_id")
 		return
 	}
 
@@ -74,8 +79,14 @@ func (app *application) GetTableHandler(w http.ResponseWriter, r *http.Request)
 		}
 		return
 	}
+	sendTagged(w, r, utils.Envelope{"table": table}, table.Version)
+}
 
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"table": table})
+// tableRequest is the body of table creation and updates and of a customer's service flag.
+type tableRequest struct {
+	Name           string `json:"name"`
+	IsAvailable    *bool  `json:"is_available"`
+	IsNeedsService *bool  `json:"is_needs_service"`
 }
 
 func (app *application) CreateTableHandler(w http.ResponseWriter, r *http.Request) {
@@ -88,58 +99,38 @@ func (app *application) CreateTableHandler(w http.ResponseWriter, r *http.Reques
 
 	vendorID, err := uuid.Parse(vendorIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid vendor ID"))
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
-	// Parse the form values
-	err = r.ParseForm()
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("failed to parse form"))
+	var input tableRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
 		return
 	}
 
-	name := r.FormValue("name")
-	isAvailableStr := r.FormValue("is_available")
-	isNeedsServiceStr := r.FormValue("is_needs_service")
-
-	// Parse boolean values
-	isAvailable, err := utils.ParseBoolOrDefault(isAvailableStr, true)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid is_available value"))
-		return
+	// Tables are available and need no service unless told otherwise
+	isAvailable, isNeedsService := true, false
+	if input.IsAvailable != nil {
+		isAvailable = *input.IsAvailable
 	}
-
-	isNeedsService, err := utils.ParseBoolOrDefault(isNeedsServiceStr, false)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid is_needs_service value"))
-		return
+	if input.IsNeedsService != nil {
+		isNeedsService = *input.IsNeedsService
 	}
 
 	// Create a new table entity
 	table := &data.Table{
 		ID:              uuid.New(),
-		Name:            name,
+		Name:            input.Name,
 		VendorID:        vendorID,
 		IsAvailable:     isAvailable,
 		IsNeedsServices: isNeedsService,
 	}
-	tableCount, err := app.Model.TableDB.CountTablesForVendor(r.Context(), vendorID)
-	if err != nil {
-
-		app.serverErrorResponse(w, r, err)
-		return
-	}
-
-	if tableCount >= 12 {
-		app.badRequestResponse(w, r, errors.New("vendor has reached the maximum number of tables (12)"))
-		return
-	}
 
-	// Insert the table into the database
+	// Insert the table into the database; the vendor's plan decides how many tables it may have
 	err = app.Model.TableDB.Insert(r.Context(), table)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		app.handleRetrievalError(w, r, err)
 		return
 	}
 
@@ -157,7 +148,7 @@ func (app *application) DeleteTableHandler(w http.ResponseWriter, r *http.Reques
 
 	tableID, err := uuid.Parse(tableIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid Table ID"))
+		app.invalidIDResponse(w, r, "table_id")
 		return
 	}
 
@@ -194,7 +185,18 @@ func (app *application) UpdateTableHandler(w http.ResponseWriter, r *http.Reques
 
 	tableID, err := uuid.Parse(tableIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid vendor ID"))
+		app.invalidIDResponse(w, r, "table_id")
+		return
+	}
+
+	version, ok := app.readIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var input tableRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
 		return
 	}
 
@@ -208,16 +210,22 @@ func (app *application) UpdateTableHandler(w http.ResponseWriter, r *http.Reques
 		}
 		return
 	}
-	if r.FormValue("name") != "" {
-		table.Name = r.FormValue("name")
+	if !app.checkVersion(w, r, version, table.Version) {
+		return
+	}
+	if input.Name != "" {
+		table.Name = input.Name
 	}
 
 	if err := app.Model.TableDB.Update(r.Context(), table); err != nil {
+		if errors.Is(err, data.ErrEditConflict) {
+			app.preconditionFailedResponse(w, r)
+			return
+		}
 		app.serverErrorResponse(w, r, err)
 		return
 	}
-
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"table": table})
+	sendTagged(w, r, utils.Envelope{"table": table}, table.Version)
 }
 func (app *application) UpdateTableNeedsServiceHandler(w http.ResponseWriter, r *http.Request) {
 	// Retrieve table ID and customer ID from URL path
@@ -230,13 +238,13 @@ func (app *application) UpdateTableNeedsServiceHandler(w http.ResponseWriter, r
 
 	tableID, err := uuid.Parse(tableIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid table ID"))
+		app.invalidIDResponse(w, r, "table_id")
 		return
 	}
 
 	customerID, err := uuid.Parse(customerIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid customer ID"))
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
 		return
 	}
 
@@ -254,16 +262,16 @@ func (app *application) UpdateTableNeedsServiceHandler(w http.ResponseWriter, r
 	}
 
 	if table.CustomerID != nil && *table.CustomerID != uuid.Nil && *table.CustomerID != customerID {
-		app.errorResponse(w, r, http.StatusConflict, "The table is not available! Try again later")
+		app.codedErrorResponse(w, r, http.StatusConflict, i18n.Message{Code: i18n.TableUnavailable})
 		return
 	}
 
-	isNeedsServiceStr := r.FormValue("is_needs_service")
-	isNeedsService, err := utils.ParseBoolOrDefault(isNeedsServiceStr, false)
-	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid is_needs_service value"))
+	var input tableRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
 		return
 	}
+	isNeedsService := input.IsNeedsService != nil && *input.IsNeedsService
 
 	table.IsNeedsServices = isNeedsService
 	err = app.Model.TableDB.AssignCustomer(r.Context(), tableID, customerID)
@@ -271,6 +279,19 @@ func (app *application) UpdateTableNeedsServiceHandler(w http.ResponseWriter, r
 		app.handleRetrievalError(w, r, err)
 		return
 	}
+	// Keep old clients that only send the flag working by raising a typed request for them
+	if isNeedsService {
+		request := &data.ServiceRequest{
+			TableID:    tableID,
+			VendorID:   table.VendorID,
+			CustomerID: customerID,
+			Type:       "call_waiter",
+		}
+		if err = app.Model.ServiceRequestDB.InsertServiceRequest(r.Context(), request); err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
 
 	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"table": table})
 }
@@ -285,13 +306,13 @@ func (app *application) FreeTableHandler(w http.ResponseWriter, r *http.Request)
 	// Parse the table ID and customer ID as UUIDs
 	tableID, err := uuid.Parse(tableIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid table ID"))
+		app.invalidIDResponse(w, r, "table_id")
 		return
 	}
 
 	customerID, err := uuid.Parse(customerIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid customer ID"))
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
 		return
 	}
 
@@ -307,7 +328,7 @@ func (app *application) FreeTableHandler(w http.ResponseWriter, r *http.Request)
 	}
 	zeroUUID := uuid.UUID{}
 	if table.CustomerID == nil || (table.CustomerID != nil && *table.CustomerID == zeroUUID) || *table.CustomerID != customerID {
-		app.errorResponse(w, r, http.StatusConflict, "The table is not available! Try again later.")
+		app.codedErrorResponse(w, r, http.StatusConflict, i18n.Message{Code: i18n.TableUnavailable})
 		return
 	}
 	// Free the table
@@ -315,6 +336,10 @@ func (app *application) FreeTableHandler(w http.ResponseWriter, r *http.Request)
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	if err := app.Model.TableSessionDB.CloseSession(r.Context(), tableID); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
 
 	// Delete user orders
 	if err := app.Model.OrderDB.DeleteUserOrders(customerID); err != nil {
@@ -331,7 +356,7 @@ func (app *application) FreeCustomerTableHandler(w http.ResponseWriter, r *http.
 
 	tableID, err := uuid.Parse(tableIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid table ID"))
+		app.invalidIDResponse(w, r, "table_id")
 		return
 	}
 
@@ -350,6 +375,10 @@ func (app *application) FreeCustomerTableHandler(w http.ResponseWriter, r *http.
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	if err := app.Model.TableSessionDB.CloseSession(r.Context(), tableID); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
 
 	// Delete user orders
 	if err := app.Model.OrderDB.DeleteUserOrders(*table.CustomerID); err != nil {
@@ -370,20 +399,14 @@ func (app *application) GetCustomertable(w http.ResponseWriter, r *http.Request)
 
 	customerID, err := uuid.Parse(customerIDStr)
 	if err != nil {
-		app.badRequestResponse(w, r, errors.New("invalid customer ID"))
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
 		return
 	}
 
 	table, err := app.Model.TableDB.GetCustomertable(r.Context(), customerID)
 	if err != nil {
-		if err != data.ErrUserHasNoTable {
-			app.handleRetrievalError(w, r, err)
-			return
-		} else {
-			utils.SendJSONResponse(w, http.StatusNotFound, utils.Envelope{"tables": "User has no tables"})
-			return
-		}
-
+		app.handleRetrievalError(w, r, err)
+		return
 	}
 
 	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"tables": table})
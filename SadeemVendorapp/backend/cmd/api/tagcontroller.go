@@ -0,0 +1,202 @@
+package main
+
+import (
+	"net/http"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// setVendorTags attaches the tags of the given vendors with one query.
+func (app *application) setVendorTags(r *http.Request, vendors []data.Vendor) error {
+	ids := make([]uuid.UUID, len(vendors))
+	for i := range vendors {
+		ids[i] = vendors[i].ID
+	}
+
+	tags, err := app.Model.TagDB.GetVendorTags(r.Context(), ids)
+	if err != nil {
+		return err
+	}
+	for i := range vendors {
+		vendors[i].Tags = tags[vendors[i].ID]
+	}
+	return nil
+}
+
+type tagRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Kind string `json:"kind"`
+}
+
+func (app *application) readTagRequest(w http.ResponseWriter, r *http.Request, tag *data.Tag) error {
+	var input tagRequest
+	if err := app.readRequest(w, r, &input); err != nil {
+		return err
+	}
+	tag.Name = strings.TrimSpace(input.Name)
+	tag.Slug = strings.ToLower(strings.TrimSpace(input.Slug))
+	tag.Kind = input.Kind
+	return nil
+}
+
+// GetTagsHandler lists the tags, optionally filtered with ?kind=cuisine|price|feature.
+func (app *application) GetTagsHandler(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("kind")
+	v := validator.New()
+	v.Check(kind == "" || validator.In(kind, data.TagKinds...), "kind", i18n.TagKindInvalid)
+	page := app.readPage(v, r, data.TagSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	tags, meta, err := app.Model.TagDB.GetTags(r.Context(), kind, page)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if err = app.localizeTags(r, tags); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.sendPage(w, r, "tags", tags, meta, nil)
+}
+
+func (app *application) CreateTagHandler(w http.ResponseWriter, r *http.Request) {
+	var tag data.Tag
+	if err := app.readTagRequest(w, r, &tag); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidatingTag(v, &tag)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err := app.Model.TagDB.InsertTag(r.Context(), &tag)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusCreated, utils.Envelope{"tag": tag})
+}
+
+// UpdateTagHandler changes a tag; fields left empty keep their current value.
+func (app *application) UpdateTagHandler(w http.ResponseWriter, r *http.Request) {
+	tagID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	tag, err := app.Model.TagDB.GetTag(r.Context(), tagID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	var input data.Tag
+	if err = app.readTagRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if input.Name != "" {
+		tag.Name = input.Name
+	}
+	if input.Slug != "" {
+		tag.Slug = input.Slug
+	}
+	if input.Kind != "" {
+		tag.Kind = input.Kind
+	}
+
+	v := validator.New()
+	data.ValidatingTag(v, tag)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.Model.TagDB.UpdateTag(r.Context(), tag)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"tag": tag})
+}
+
+func (app *application) DeleteTagHandler(w http.ResponseWriter, r *http.Request) {
+	tagID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	err = app.Model.TagDB.DeleteTag(r.Context(), tagID)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "tag deleted"})
+}
+
+type vendorTagsRequest struct {
+	TagIDs []string `json:"tag_ids"`
+}
+
+// SetVendorTagsHandler replaces a vendor's tags with tag_ids, a JSON array or a comma separated
+// form value. An empty tag_ids clears them.
+func (app *application) SetVendorTagsHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	var input vendorTagsRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	var tagIDs []uuid.UUID
+	var seen []string
+	for _, idStr := range input.TagIDs {
+		tagID, err := uuid.Parse(idStr)
+		if err != nil {
+			v.AddError("tag_ids", i18n.IDInvalid)
+			continue
+		}
+		tagIDs = append(tagIDs, tagID)
+		seen = append(seen, tagID.String())
+	}
+
+	v.Check(validator.Unique(seen), "tag_ids", i18n.TagsNotUnique)
+	v.Check(len(tagIDs) <= 20, "tag_ids", i18n.TooManyTags, 20)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	tags, err := app.Model.TagDB.SetVendorTags(r.Context(), vendorID, tagIDs)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"tags": tags})
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"net/http"
+	"project/internal/data"
+	"project/utils"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// GetVendorTranslationsHandler lists the translations of a vendor and a page of its items' ones.
+func (app *application) GetVendorTranslationsHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	v := validator.New()
+	page := app.readPage(v, r, data.ItemTranslationSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	vendor, items, meta, err := app.Model.TranslationDB.GetVendorTranslations(r.Context(), vendorID, page)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.sendPage(w, r, "items", items, meta, utils.Envelope{"vendor": vendor})
+}
+
+type translationRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// nameTranslationRequest is the body of item and tag translations, which only have a name.
+type nameTranslationRequest struct {
+	Name string `json:"name"`
+}
+
+// UpsertVendorTranslationHandler sets the vendor's name and description in {lang}. A field
+// left out falls back to the vendor's own value.
+func (app *application) UpsertVendorTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	var input translationRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	translation := data.VendorTranslation{VendorID: vendorID, Lang: r.PathValue("lang")}
+	if name := strings.TrimSpace(input.Name); name != "" {
+		translation.Name = &name
+	}
+	if description := strings.TrimSpace(input.Description); description != "" {
+		translation.Description = &description
+	}
+
+	v := validator.New()
+	data.ValidatingVendorTranslation(v, &translation)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.Model.TranslationDB.UpsertVendorTranslation(r.Context(), &translation)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"translation": translation})
+}
+
+func (app *application) DeleteVendorTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	err = app.Model.TranslationDB.DeleteVendorTranslation(r.Context(), vendorID, r.PathValue("lang"))
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "translation deleted"})
+}
+
+// UpsertItemTranslationHandler sets an item's name in {lang}.
+func (app *application) UpsertItemTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+	itemID, err := uuid.Parse(r.PathValue("item_id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "item_id")
+		return
+	}
+
+	var input nameTranslationRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	translation := data.ItemTranslation{
+		ItemID: itemID,
+		Lang:   r.PathValue("lang"),
+		Name:   strings.TrimSpace(input.Name),
+	}
+	v := validator.New()
+	data.ValidatingNameTranslation(v, translation.Lang, translation.Name, 255)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.Model.TranslationDB.UpsertItemTranslation(r.Context(), vendorID, &translation)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"translation": translation})
+}
+
+func (app *application) DeleteItemTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+	itemID, err := uuid.Parse(r.PathValue("item_id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "item_id")
+		return
+	}
+
+	err = app.Model.TranslationDB.DeleteItemTranslation(r.Context(), vendorID, itemID, r.PathValue("lang"))
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "translation deleted"})
+}
+
+// UpsertTagTranslationHandler sets a tag's name in {lang}.
+func (app *application) UpsertTagTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	tagID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	var input nameTranslationRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	translation := data.TagTranslation{
+		TagID: tagID,
+		Lang:  r.PathValue("lang"),
+		Name:  strings.TrimSpace(input.Name),
+	}
+	v := validator.New()
+	data.ValidatingNameTranslation(v, translation.Lang, translation.Name, 50)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.Model.TranslationDB.UpsertTagTranslation(r.Context(), &translation)
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"translation": translation})
+}
+
+func (app *application) DeleteTagTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	tagID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		app.invalidIDResponse(w, r, "id")
+		return
+	}
+
+	err = app.Model.TranslationDB.DeleteTagTranslation(r.Context(), tagID, r.PathValue("lang"))
+	if err != nil {
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+
+	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"message": "translation deleted"})
+}
+
+// MissingTranslationsHandler reports content with no translation in ?lang (by default the
+// language that isn't the stored one). Admins get every vendor and the tags, or one vendor
+// with ?vendor_id; vendors get their own content through vendors/{id}/translations/missing.
+func (app *application) MissingTranslationsHandler(w http.ResponseWriter, r *http.Request) {
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		for _, candidate := range data.Languages {
+			if candidate != app.defaultLang() {
+				lang = candidate
+				break
+			}
+		}
+	}
+	v := validator.New()
+	v.Check(validator.In(lang, data.Languages...), "lang", i18n.LangInvalid)
+
+	var vendorID *uuid.UUID
+	if idStr := r.PathValue("id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			app.invalidIDResponse(w, r, "id")
+			return
+		}
+		vendorID = &id
+	} else if idStr := r.URL.Query().Get("vendor_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		v.Check(err == nil, "vendor_id", i18n.IDInvalid)
+		vendorID = &id
+	}
+
+	page := app.readPage(v, r, data.MissingTranslationSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	missing, meta, err := app.Model.TranslationDB.MissingTranslations(r.Context(), lang, vendorID, page)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.sendPage(w, r, "missing", missing, meta, utils.Envelope{"lang": lang})
+}
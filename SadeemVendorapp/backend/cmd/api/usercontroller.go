@@ -1,10 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"project/internal/data"
 	"project/utils"
+	"project/utils/filter"
+	"project/utils/i18n"
 	"project/utils/validator"
 	"strconv"
 	"strings"
@@ -13,12 +16,28 @@ import (
 	_ "github.com/joho/godotenv/autoload"
 )
 
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (input loginRequest) rules() []validator.Rule {
+	return []validator.Rule{
+		validator.String("email", input.Email).Required(i18n.EmailRequired),
+		validator.String("password", input.Password).Required(i18n.PasswordRequired),
+	}
+}
+
 func (app *application) LoginHandler(w http.ResponseWriter, r *http.Request) {
-	email := r.FormValue("email")
-	password := r.FormValue("password")
+	var input loginRequest
+	if err := app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	email, password := input.Email, input.Password
 	v := validator.New()
-
-	data.ValidatingUser(v, &data.User{Email: email, Password: password}, "email", "password")
+	v.Validate(input.rules()...)
+	data.ValidatingUser(v, &data.User{Email: email, Password: password})
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
@@ -53,60 +72,23 @@ func (app *application) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 func (app *application) IndexUserHandler(w http.ResponseWriter, r *http.Request) {
-	// Retrieve query parameters for pagination, sorting, and searching
-	pageStr := r.URL.Query().Get("page")
-	pageSizeStr := r.URL.Query().Get("pageSize")
-	sortColumn := r.URL.Query().Get("sortColumn")
-	sortDirection := r.URL.Query().Get("sortDirection")
-	search := r.URL.Query().Get("search")
-
-	// Set default values for page and pageSize
-	page := 1
-	pageSize := 10
-
-	// Parse page and pageSize query parameters
-	if pageStr != "" {
-		parsedPage, err := strconv.Atoi(pageStr)
-		if err != nil || parsedPage < 1 {
-			page = 1
-		} else {
-			page = parsedPage
-		}
-	}
-
-	if pageSizeStr != "" {
-		parsedPageSize, err := strconv.Atoi(pageSizeStr)
-		if err != nil || parsedPageSize < 1 {
-			pageSize = 10
-		} else {
-			pageSize = parsedPageSize
-		}
-	}
-
-	// Validate sort direction
-	if sortDirection != "" && sortDirection != "ASC" && sortDirection != "DESC" {
-		sortDirection = "ASC" // Default sort direction
-	}
-
-	// Set default sort column to "created_at" if it's not provided or invalid
-	validSortColumns := map[string]bool{
-		"name":       true,
-		"created_at": true,
-		// Add other valid columns as needed
+	v := validator.New()
+	filters := utils.Filters{
+		Search:     r.URL.Query().Get("search"),
+		Conditions: filter.Parse(v, r.URL.Query(), data.UserFilters),
 	}
-
-	if !validSortColumns[sortColumn] {
-		sortColumn = "created_at"
+	page := app.readPage(v, r, data.UserSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
 	}
 
-	// Call the model method with the filters
-	users, err := app.Model.UserDB.GetUsers(sortColumn, sortDirection, page, pageSize, search)
+	users, meta, err := app.Model.UserDB.GetUsers(r.Context(), filters, page)
 	if err != nil {
 		app.handleRetrievalError(w, r, err)
 		return
 	}
-	// Send JSON response
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"users": users})
+	app.sendPage(w, r, "users", users, meta, nil)
 }
 
 func (app *application) ShowUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -119,28 +101,45 @@ func (app *application) ShowUserHandler(w http.ResponseWriter, r *http.Request)
 
 	user, err := app.Model.UserDB.GetUser(uuidcon)
 	if err != nil {
-		app.errorResponse(w, r, http.StatusNotFound, fmt.Sprintf("User with %v was not found", uuidcon))
+		app.handleRetrievalError(w, r, err)
 		return
 	}
+	sendTagged(w, r, utils.Envelope{"user": user}, user.Version)
+}
 
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"user": user})
+// userRequest is the body of signup and user updates. On update, empty fields keep their value.
+type userRequest struct {
+	Name     string `json:"name"`
+	Phone    string `json:"phone"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
 }
 
-func (app *application) SignupHandler(w http.ResponseWriter, r *http.Request) {
-	v := validator.New()
-	user := &data.User{
-		Name:     r.FormValue("name"),
-		Phone:    r.FormValue("phone"),
-		Email:    r.FormValue("email"),
-		Password: r.FormValue("password"),
+// signupRules are the fields a new account can not leave out.
+func (input userRequest) signupRules() []validator.Rule {
+	return []validator.Rule{
+		validator.String("name", input.Name).Required(i18n.NameRequired),
+		validator.String("phone", input.Phone).Required(i18n.PhoneRequired),
+		validator.String("email", input.Email).Required(i18n.EmailRequired),
+		validator.String("password", input.Password).Required(i18n.PasswordRequired),
 	}
+}
 
-	if user.Name == "" || user.Phone == "" || user.Email == "" || user.Password == "" {
-		app.errorResponse(w, r, http.StatusBadRequest, "Must fill all the fields")
+func (app *application) SignupHandler(w http.ResponseWriter, r *http.Request) {
+	var input userRequest
+	if err := app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
 		return
 	}
-
-	data.ValidatingUser(v, user, "name", "phone", "email", "password")
+	v := validator.New()
+	user := &data.User{
+		Name:     input.Name,
+		Phone:    input.Phone,
+		Email:    input.Email,
+		Password: input.Password,
+	}
+	v.Validate(input.signupRules()...)
+	data.ValidatingUser(v, user)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
@@ -148,7 +147,7 @@ func (app *application) SignupHandler(w http.ResponseWriter, r *http.Request) {
 
 	hashedPassword, err := utils.HashPassword(user.Password)
 	if err != nil {
-		app.errorResponse(w, r, http.StatusInternalServerError, "Error hashing password")
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 	user.Password = hashedPassword
@@ -157,7 +156,7 @@ func (app *application) SignupHandler(w http.ResponseWriter, r *http.Request) {
 		defer file.Close()
 		imageName, err := utils.SaveImageFile(file, "users", fileHeader.Filename)
 		if err != nil {
-			app.errorResponse(w, r, http.StatusBadRequest, "invalid image ")
+			app.codedErrorResponse(w, r, http.StatusBadRequest, i18n.Message{Code: i18n.ImageInvalid})
 			return
 		}
 		user.Img = &imageName
@@ -172,7 +171,7 @@ func (app *application) SignupHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if _, err = app.Model.UserRoleDB.GrantRole(user.ID, 3); err != nil {
-		app.badRequestResponse(w, r, err)
+		app.handleRetrievalError(w, r, err)
 		return
 	}
 
@@ -187,12 +186,25 @@ func (app *application) UpdateUserHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	version, ok := app.readIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var input userRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
 	user, err := app.Model.UserDB.GetUser(idint)
 	if err != nil {
-		app.errorResponse(w, r, http.StatusNotFound, "User not found")
+		app.handleRetrievalError(w, r, err)
+		return
+	}
+	if !app.checkVersion(w, r, version, user.Version) {
 		return
 	}
-	fmt.Println(user.Password)
 
 	var oldImg *string
 	if user.Img != nil {
@@ -200,20 +212,20 @@ func (app *application) UpdateUserHandler(w http.ResponseWriter, r *http.Request
 		oldImg = user.Img
 	}
 
-	if name := r.FormValue("name"); name != "" {
-		user.Name = name
+	if input.Name != "" {
+		user.Name = input.Name
 	}
 
-	if phone := r.FormValue("phone"); phone != "" {
-		user.Phone = phone
+	if input.Phone != "" {
+		user.Phone = input.Phone
 	}
 
-	if email := r.FormValue("email"); email != "" {
-		user.Email = email
+	if input.Email != "" {
+		user.Email = input.Email
 	}
 
 	// Only update the password if a new password is provided
-	if password := r.FormValue("password"); password != "" {
+	if password := input.Password; password != "" {
 		hashedPassword, err := utils.HashPassword(password)
 		if err != nil {
 			app.serverErrorResponse(w, r, err)
@@ -226,23 +238,27 @@ func (app *application) UpdateUserHandler(w http.ResponseWriter, r *http.Request
 		defer file.Close()
 		imageName, err := utils.SaveImageFile(file, "users", fileHeader.Filename)
 		if err != nil {
-			app.errorResponse(w, r, http.StatusInternalServerError, "Error saving image file: "+err.Error())
+			app.serverErrorResponse(w, r, fmt.Errorf("error saving image file: %w", err))
 			return
 		}
 		user.Img = &imageName
 	}
 
 	v := validator.New()
-	data.ValidatingUser(v, user, "name", "phone", "email", "password")
+	data.ValidatingUser(v, user)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
 	if err = app.Model.UserDB.Update(user); err != nil {
-		if user.Img != nil && *user.Img != *oldImg {
+		if user.Img != nil && (oldImg == nil || *user.Img != *oldImg) {
 			utils.DeleteImageFile(*user.Img)
 		}
+		if errors.Is(err, data.ErrEditConflict) {
+			app.preconditionFailedResponse(w, r)
+			return
+		}
 		app.handleRetrievalError(w, r, err)
 		return
 	}
@@ -250,8 +266,7 @@ func (app *application) UpdateUserHandler(w http.ResponseWriter, r *http.Request
 	if oldImg != nil && user.Img != nil && *oldImg != *user.Img {
 		utils.DeleteImageFile(*oldImg)
 	}
-
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{fmt.Sprintf("User %v", user.ID): "Updated successfully!"})
+	sendTagged(w, r, utils.Envelope{fmt.Sprintf("User %v", user.ID): "Updated successfully!"}, user.Version)
 }
 
 func (app *application) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -274,9 +289,7 @@ func (app *application) MeHandler(w http.ResponseWriter, r *http.Request) {
 
 	uuiduser, err := uuid.Parse(r.Context().Value(UserIDKey).(string))
 	if err != nil {
-
-		app.errorResponse(w, r, http.StatusUnauthorized, "Invalid user uuid")
-
+		app.jwtErrorResponse(w, r, utils.ErrInvalidClaims)
 		return
 	}
 	user, err := app.Model.UserDB.GetUser(uuiduser)
@@ -1,11 +1,12 @@
 package main
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"project/internal/data"
 	"project/utils"
+	"project/utils/i18n"
+	"project/utils/validator"
 	"strconv"
 
 	"github.com/google/uuid"
@@ -13,62 +14,86 @@ import (
 
 // IndexUserRoles handles the listing of user roles
 func (app *application) IndexUserRoles(w http.ResponseWriter, r *http.Request) {
-	userRoles, err := app.Model.UserRoleDB.GetUserRoles()
+	v := validator.New()
+	page := app.readPage(v, r, data.UserRoleSorts)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	userRoles, meta, err := app.Model.UserRoleDB.GetUserRoles(r.Context(), page)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"user_roles": userRoles})
+	app.sendPage(w, r, "user_roles", userRoles, meta, nil)
 }
 
 // ShowUserRoleHandler handles the retrieval of a user role by ID
 func (app *application) ShowUserRoleHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "Invalid ID")
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
 	userRoles, err := app.Model.UserRoleDB.GetUserRole(id)
 	if err != nil {
-		if errors.Is(err, data.ErrRecordNotFound) {
-			app.errorResponse(w, r, http.StatusNotFound, fmt.Sprintf("User role with ID %v was not found", id))
-			return
-		}
-		app.serverErrorResponse(w, r, err)
+		app.handleRetrievalError(w, r, err)
 		return
 	}
 	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"user_roles": userRoles})
 }
 
+// grantRoleRequest is the body of granting a role; vendorID is required for vendor owners (role 2).
+type grantRoleRequest struct {
+	Role     *int      `json:"role"`
+	VendorID uuid.UUID `json:"vendorID"`
+}
+
+func (input grantRoleRequest) rules() []validator.Rule {
+	return []validator.Rule{
+		validator.OptionalNumber("role", input.Role).Required(i18n.RoleRequired),
+		validator.Expect("vendorID", input.Role == nil || *input.Role != 2 || input.VendorID != uuid.Nil, i18n.VendorIDRequired),
+	}
+}
+
+type revokeRoleRequest struct {
+	ID       uuid.UUID `json:"id"`
+	UserRole *int      `json:"user_role"`
+}
+
+func (input revokeRoleRequest) rules() []validator.Rule {
+	return []validator.Rule{
+		validator.UUID("id", input.ID).Required(i18n.UserIDRequired),
+		validator.OptionalNumber("user_role", input.UserRole).Required(i18n.RoleRequired),
+	}
+}
+
 // UpdateUserRoleHandler handles the updating of a user's role
 func (app *application) GrantRole(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "Invalid ID")
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 
-	newRole, err := strconv.Atoi(r.FormValue("role"))
-	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "Invalid role ID")
+	var input grantRoleRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
 		return
 	}
+	v := validator.New()
+	v.Validate(input.rules()...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+	newRole := *input.Role
 
 	if newRole == 2 {
-		if r.FormValue("vendorID") == "" {
-			app.errorResponse(w, r, http.StatusBadRequest, "Must enter the vendor ID")
-			return
-		}
-		vendorID, err := uuid.Parse(r.FormValue("vendorID"))
-		if err != nil {
-			app.errorResponse(w, r, http.StatusBadRequest, err.Error())
-			return
-		}
-
 		vendoradmin := data.VendorAdmin{
 			UserID:   id,
-			VendorID: vendorID,
+			VendorID: input.VendorID,
 		}
 		_, err = app.Model.VendorAdminDB.InsertVendorAdmin(r.Context(), vendoradmin)
 		if err != nil {
@@ -80,14 +105,9 @@ func (app *application) GrantRole(w http.ResponseWriter, r *http.Request) {
 	user, err := app.Model.UserRoleDB.UpdateRole(id, newRole)
 	if err != nil {
 		if newRole == 2 {
-			vendor, err := uuid.Parse(r.FormValue("vendorID"))
+			err := app.Model.VendorAdminDB.DeleteVendorAdmin(r.Context(), id, input.VendorID)
 			if err != nil {
-				app.errorResponse(w, r, http.StatusBadRequest, err)
-				return
-			}
-			err = app.Model.VendorAdminDB.DeleteVendorAdmin(r.Context(), id, vendor)
-			if err != nil {
-				app.errorResponse(w, r, http.StatusBadRequest, err)
+				app.handleRetrievalError(w, r, err)
 				return
 			}
 
@@ -100,26 +120,37 @@ func (app *application) GrantRole(w http.ResponseWriter, r *http.Request) {
 	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"Updated user role": user})
 }
 
+// RevokeRoleHandler removes a role from a user. The /v1 route names the user and the role in
+// the path; the legacy route sends them in the body.
 func (app *application) RevokeRoleHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := uuid.Parse(r.FormValue("id"))
-	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "Invalid ID")
+	var input revokeRoleRequest
+	var err error
+	if r.PathValue("id") != "" {
+		if input.ID, err = uuid.Parse(r.PathValue("id")); err != nil {
+			app.invalidIDResponse(w, r, "id")
+			return
+		}
+		role, err := strconv.Atoi(r.PathValue("role"))
+		if err != nil {
+			app.invalidIDResponse(w, r, "role")
+			return
+		}
+		input.UserRole = &role
+	} else if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
 		return
 	}
-
-	role, err := strconv.Atoi(r.FormValue("user_role"))
-	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "Invalid role ID")
+	v := validator.New()
+	v.Validate(input.rules()...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
+	id := input.ID
+	role := *input.UserRole
 
 	err = app.Model.UserRoleDB.RevokeRole(id, role)
 	if err != nil {
-		if errors.Is(err, data.ErrRecordNotFound) {
-			app.errorResponse(w, r, http.StatusNotFound, fmt.Sprintf("user with ID %v's role already deleted", id))
-			return
-		}
-
 		app.handleRetrievalError(w, r, err)
 		return
 	}
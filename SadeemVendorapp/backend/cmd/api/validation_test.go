@@ -0,0 +1,76 @@
+package main
+
+import (
+	"project/internal/data"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRequestRules(t *testing.T) {
+	price, days, role := 12.5, 0, 2
+	tests := []struct {
+		name  string
+		rules []validator.Rule
+		want  map[string]i18n.Code
+	}{
+		{"signup needs every field", userRequest{Name: "Salem"}.signupRules(), map[string]i18n.Code{
+			"phone":    i18n.PhoneRequired,
+			"email":    i18n.EmailRequired,
+			"password": i18n.PasswordRequired,
+		}},
+		{"login", loginRequest{Email: "salem@example.com"}.rules(), map[string]i18n.Code{"password": i18n.PasswordRequired}},
+		{"new item needs price and quantity", itemRequest{}.rules(true), map[string]i18n.Code{
+			"price":    i18n.PriceRequired,
+			"quantity": i18n.QuantityRequired,
+		}},
+		{"item update", itemRequest{Price: &price}.rules(false), map[string]i18n.Code{}},
+		{"discount days", itemRequest{DiscountDays: &days}.rules(false), map[string]i18n.Code{"discount_days": i18n.DaysNotPositive}},
+		{"cart item", cartItemRequest{Quantity: -1}.rules(true), map[string]i18n.Code{
+			"item_id":  i18n.ItemIDRequired,
+			"quantity": i18n.QuantityNotPositive,
+		}},
+		{"cart item emptied", cartItemRequest{ItemID: uuid.New()}.rules(false), map[string]i18n.Code{}},
+		{"order status", orderStatusRequest{Status: "preparing"}.rules(), map[string]i18n.Code{"status": i18n.OrderStatusInvalid}},
+		{"vendor owner needs vendor", grantRoleRequest{Role: &role}.rules(), map[string]i18n.Code{"vendorID": i18n.VendorIDRequired}},
+		{"pause", pauseRequest{Minutes: maxPauseMinutes + 1}.rules(), map[string]i18n.Code{"minutes": i18n.MinutesOutOfRange}},
+		{"new plan", planRequest{}.rules(), map[string]i18n.Code{
+			"price":        i18n.PriceRequired,
+			"billing_days": i18n.BillingDaysRequired,
+		}},
+		{"search", searchRequest{Type: "all", Searchable: true}.rules(), map[string]i18n.Code{}},
+		{"search without words", searchRequest{Type: "menus"}.rules(), map[string]i18n.Code{
+			"q":    i18n.SearchQueryInvalid,
+			"type": i18n.SearchTypeInvalid,
+		}},
+		{"long search", searchRequest{Type: "items", Searchable: true, Search: data.SearchQuery{Raw: strings.Repeat("ب", 101)}}.rules(), map[string]i18n.Code{
+			"q": i18n.SearchQueryTooLong,
+		}},
+		{"empty reply", reviewReplyRequest{}.rules(), map[string]i18n.Code{"reply": i18n.ReplyRequired}},
+		{"long reply", reviewReplyRequest{Reply: strings.Repeat("a", 1001)}.rules(), map[string]i18n.Code{"reply": i18n.ReplyTooLong}},
+		{"hide without reason", moderationRequest{}.rules("hide"), map[string]i18n.Code{"reason": i18n.ReasonRequired}},
+		{"restore without reason", moderationRequest{}.rules("restore"), map[string]i18n.Code{}},
+		{"long reason", moderationRequest{Reason: strings.Repeat("a", 201)}.rules("flag"), map[string]i18n.Code{"reason": i18n.ReasonTooLong}},
+		{"assign plan", planChangeRequest{Days: &days}.rules(true), map[string]i18n.Code{
+			"plan_id": i18n.PlanIDRequired,
+			"days":    i18n.DaysNotPositive,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			v.Validate(tt.rules...)
+			got := map[string]i18n.Code{}
+			for key, message := range v.Errors {
+				got[key] = message.Code
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
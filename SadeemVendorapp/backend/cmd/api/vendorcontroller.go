@@ -1,12 +1,14 @@
 package main
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 	"project/internal/data"
 	"project/utils"
+	"project/utils/filter"
+	"project/utils/i18n"
+	"project/utils/pagination"
 	"project/utils/validator"
 	"strconv"
 	"strings"
@@ -15,141 +17,181 @@ import (
 )
 
 func (app *application) IndexVendorHandler(w http.ResponseWriter, r *http.Request) {
-	// Retrieve query parameters for pagination
-	pageStr := r.URL.Query().Get("page")
-	pageSizeStr := r.URL.Query().Get("pageSize")
 	sort := r.URL.Query().Get("sort")
 	search := r.URL.Query().Get("search")
 
-	// Set default values for page and pageSize
-	page := 1
-	pageSize := 10
-
-	// Parse page and pageSize query parameters
-	if pageStr != "" {
-		parsedPage, err := strconv.Atoi(pageStr)
-		if err != nil || parsedPage < 1 {
-			page = 1
-		} else {
-			page = parsedPage
+	// Multi-tag filter: a comma separated list of tag slugs, all of which must match
+	var tags []string
+	for _, slug := range strings.Split(r.URL.Query().Get("tags"), ",") {
+		if slug = strings.TrimSpace(slug); slug != "" {
+			tags = append(tags, slug)
 		}
 	}
 
-	if pageSizeStr != "" {
-		parsedPageSize, err := strconv.Atoi(pageSizeStr)
-		if err != nil || parsedPageSize < 1 {
-			pageSize = 10
-		} else {
-			pageSize = parsedPageSize
+	v := validator.New()
+
+	// Near me search: lat and lng together, radius in km (default 5)
+	var near *utils.Near
+	latStr, lngStr := r.URL.Query().Get("lat"), r.URL.Query().Get("lng")
+	if latStr != "" || lngStr != "" {
+		lat, latErr := strconv.ParseFloat(latStr, 64)
+		lng, lngErr := strconv.ParseFloat(lngStr, 64)
+		v.Check(latErr == nil && lngErr == nil, "lat", i18n.NearPointInvalid)
+		near = &utils.Near{Lat: lat, Lng: lng, RadiusKm: 5}
+		if radiusStr := r.URL.Query().Get("radius"); radiusStr != "" {
+			var radiusErr error
+			near.RadiusKm, radiusErr = strconv.ParseFloat(radiusStr, 64)
+			v.Check(radiusErr == nil, "radius", i18n.RadiusInvalid)
 		}
 	}
 
-	// Set default sort to "latest" if it's not provided or invalid
-	if sort == "" || !validator.In(sort, "latest", "name_asc", "name_desc") {
-		sort = "latest"
+	filters := utils.Filters{
+		Search:     search,
+		Near:       near,
+		Tags:       tags,
+		Conditions: filter.Parse(v, r.URL.Query(), data.VendorFilters),
 	}
 
-	filters := utils.Filters{
-		Page:         page,
-		PageSize:     pageSize,
-		Sort:         sort,
-		SortSafelist: []string{"latest", "name_asc", "name_desc"},
-		Search:       search,
+	// Retrieve user role from context
+	isAdmin, ok := r.Context().Value(UserRoleKey).(string)
+	if !ok {
+		isAdmin = ""
+	}
+	// Vendor owners list the vendors they administer, which are never filtered by distance
+	owner := isAdmin == "2"
+	if owner {
+		near, filters.Near = nil, nil
 	}
 
-	v := validator.New()
+	v.Check(sort != "distance" || near != nil, "sort", i18n.DistanceSortNeedsPoint)
 	utils.ValidateFilters(v, filters)
-
+	page := app.readPage(v, r, data.VendorSorts(near))
+	// Only admins see who runs a vendor, and owners who runs theirs
+	shape := readShape(v, r, data.Vendor{}, adminsIncludable(isAdmin == "1" || owner)...)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	// Retrieve user role from context
-	isAdmin, ok := r.Context().Value(UserRoleKey).(string)
-	if !ok {
-		isAdmin = ""
-	}
-
 	var vendors []data.Vendor
-	var count int
+	var meta pagination.Metadata
 	var err error
+	facets := []data.TagFacet{}
 
 	// Handle the user ID from context
 	userIDStr, _ := r.Context().Value(UserIDKey).(string)
 	userID, _ := uuid.Parse(userIDStr)
 
-	if isAdmin == "2" {
-		// Vendor owner is also considered admin in this case
-		vendors, err = app.Model.VendorDB.GetUserVendors(r.Context(), userID)
-
+	if owner {
+		vendors, meta, err = app.Model.VendorDB.GetUserVendorsPage(r.Context(), userID, filters, page)
 		if err != nil {
 			app.handleRetrievalError(w, r, err)
 			return
 		}
-		count = len(vendors) // Set count to the number of retrieved vendors
-
 	} else {
 		isVisible := isAdmin == "1" // Only admins (role 1) see all vendors; others see only visible ones
 
-		// Fetch vendors with pagination
-		vendorsPtr, totalCount, err := app.Model.VendorDB.GetVendors(filters, isVisible)
+		vendors, meta, err = app.Model.VendorDB.GetVendors(r.Context(), filters, isVisible, page)
 		if err != nil {
 			app.handleRetrievalError(w, r, err)
 			return
 		}
-		count = totalCount
-		vendors = *vendorsPtr
+
+		facets, err = app.Model.VendorDB.GetTagFacets(r.Context(), filters, isVisible)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
 	}
 
-	// Prepare response
-	response := utils.Envelope{
-		"Vendors":    vendors,
-		"TotalCount": count,
-		"Page":       page,
-		"PageSize":   pageSize,
+	if err = app.setOpeningStatus(r, vendors); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if err = app.setVendorTags(r, vendors); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
 	}
+	if err = app.localizeVendors(r, vendors); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if err = app.localizeTagFacets(r, facets); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if shape.includes("admins") {
+		if err = app.setVendorAdmins(r, vendors); err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	app.sendPage(w, r, "Vendors", shape.trim(vendors), meta, utils.Envelope{"Facets": facets})
+}
+
+// adminsIncludable is what vendor reads can embed: their admins, for those allowed to see them.
+func adminsIncludable(allowed bool) []string {
+	if allowed {
+		return []string{"admins"}
+	}
+	return nil
+}
 
-	utils.SendJSONResponse(w, http.StatusOK, response)
+// vendorRequest is the body of vendor creation and updates; the image comes as the "img"
+// multipart file. On update, empty fields keep their value.
+type vendorRequest struct {
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	SubscriptionDays *int     `json:"subscriptionDays"`
+	Address          *string  `json:"address"`
+	Latitude         *float64 `json:"latitude"`
+	Longitude        *float64 `json:"longitude"`
+	ClearLocation    bool     `json:"clear_location"`
 }
 
 func (app *application) CreateVendor(w http.ResponseWriter, r *http.Request) {
 	var vendor data.Vendor
 	var newImage *string
 
-	// Parse form values
-	vendor.Name = r.FormValue("name")
-	vendor.Description = r.FormValue("description")
+	var input vendorRequest
+	if err := app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	vendor.Name = input.Name
+	vendor.Description = input.Description
 
 	// Handle file upload
 	file, fileHeader, err := r.FormFile("img")
 	if err != nil && err != http.ErrMissingFile {
-		app.badRequestResponse(w, r, errors.New("invalid file"))
+		app.codedErrorResponse(w, r, http.StatusBadRequest, i18n.Message{Code: i18n.ImageInvalid})
 		return
 	} else if err == nil {
 		defer file.Close()
 		imageName, err := utils.SaveImageFile(file, "vendors", fileHeader.Filename)
 		if err != nil {
-			app.errorResponse(w, r, http.StatusInternalServerError, "Error saving image")
+			app.serverErrorResponse(w, r, err)
 			return
 		}
 		vendor.Img = &imageName
 		newImage = &imageName
 	}
 
-	if r.FormValue("subscriptionDays") != "" {
-		// Parse subscription days
-		subscriptionDaysStr := r.FormValue("subscriptionDays")
-		subscriptionDays, err := strconv.Atoi(subscriptionDaysStr)
-		if err != nil {
-			app.errorResponse(w, r, http.StatusBadRequest, " subscription days error")
-
-		}
-		vendor.SubscriptionDays = subscriptionDays
+	if input.SubscriptionDays != nil {
+		vendor.SubscriptionDays = *input.SubscriptionDays
 	} else {
 		vendor.SubscriptionDays = 30
 	}
 
+	if err = readVendorLocation(&input, &vendor); err != nil {
+		if newImage != nil {
+			utils.DeleteImageFile(*newImage)
+		}
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
 	// Validate the vendor data
 	v := validator.New()
 	data.ValidatingVendor(v, &vendor)
@@ -181,6 +223,16 @@ func (app *application) UpdateVendorHandler(w http.ResponseWriter, r *http.Reque
 		app.notFoundResponse(w, r)
 		return
 	}
+	version, ok := app.readIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var input vendorRequest
+	if err = app.readRequest(w, r, &input); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
 
 	// Retrieve the existing vendor
 	vendor, err := app.Model.VendorDB.GetVendor(id, true) // Adjusted to handle three return values
@@ -192,6 +244,9 @@ func (app *application) UpdateVendorHandler(w http.ResponseWriter, r *http.Reque
 		app.notFoundResponse(w, r)
 		return
 	}
+	if !app.checkVersion(w, r, version, vendor.Version) {
+		return
+	}
 
 	var oldImg *string
 	if vendor.Img != nil {
@@ -199,25 +254,25 @@ func (app *application) UpdateVendorHandler(w http.ResponseWriter, r *http.Reque
 		oldImg = vendor.Img
 	}
 
-	if r.FormValue("name") != "" {
-		vendor.Name = r.FormValue("name")
+	if input.Name != "" {
+		vendor.Name = input.Name
 	}
-	if r.FormValue("description") != "" {
-		vendor.Description = r.FormValue("description")
+	if input.Description != "" {
+		vendor.Description = input.Description
 	}
-	if r.FormValue("subscriptionDays") != "" {
-		vendor.SubscriptionDays, err = strconv.Atoi(r.FormValue("subscriptionDays"))
-		if err != nil {
-			app.errorResponse(w, r, http.StatusBadRequest, "Invalid subscription days")
-			return
-		}
+	if input.SubscriptionDays != nil {
+		vendor.SubscriptionDays = *input.SubscriptionDays
+	}
+	if err = readVendorLocation(&input, vendor); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
 	}
 
 	if file, fileHeader, err := r.FormFile("img"); err == nil {
 		defer file.Close()
 		imageName, err := utils.SaveImageFile(file, "users", fileHeader.Filename)
 		if err != nil {
-			app.errorResponse(w, r, http.StatusInternalServerError, "Error saving image file: "+err.Error())
+			app.serverErrorResponse(w, r, fmt.Errorf("error saving image file: %w", err))
 			return
 		}
 		vendor.Img = &imageName
@@ -235,18 +290,21 @@ func (app *application) UpdateVendorHandler(w http.ResponseWriter, r *http.Reque
 
 	err = app.Model.VendorDB.UpdateVendor(vendor)
 	if err != nil {
-		if vendor.Img != nil && oldImg != nil {
+		if vendor.Img != nil && (oldImg == nil || *vendor.Img != *oldImg) {
 			utils.DeleteImageFile(*vendor.Img)
 		}
+		if errors.Is(err, data.ErrEditConflict) {
+			app.preconditionFailedResponse(w, r)
+			return
+		}
 		app.serverErrorResponse(w, r, err)
 		return
 	}
-
 	if oldImg != nil && vendor.Img != nil && *oldImg != *vendor.Img {
 		utils.DeleteImageFile(*oldImg)
 	}
 
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"vendor": vendor})
+	sendTagged(w, r, utils.Envelope{"vendor": vendor}, vendor.Version)
 }
 
 func (app *application) DeleteVendorHandler(w http.ResponseWriter, r *http.Request) {
@@ -258,21 +316,15 @@ func (app *application) DeleteVendorHandler(w http.ResponseWriter, r *http.Reque
 	}
 	vendor, err := app.Model.VendorDB.DeleteVendor(iduu)
 	if err != nil {
-		switch {
-		case errors.Is(err, data.ErrRecordNotFound):
-			app.errorResponse(w, r, http.StatusNotFound, fmt.Sprintf("vendor with ID %s was not found", id))
-			return
-		default:
-			app.serverErrorResponse(w, r, err)
-			return
-		}
+		app.handleRetrievalError(w, r, err)
+		return
 	}
 	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"deleted vendor": vendor})
 }
 func (app *application) GetUserVendors(w http.ResponseWriter, r *http.Request) {
 	userUUID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.errorResponse(w, r, http.StatusBadRequest, "invalid vendor_id")
+		app.invalidIDResponse(w, r, "id")
 		return
 	}
 	vendor, err := app.Model.VendorDB.GetUserVendors(r.Context(), userUUID)
@@ -294,40 +346,66 @@ func (app *application) ShowVendorHandler(w http.ResponseWriter, r *http.Request
 	isAdminRole, ok := r.Context().Value(UserRoleKey).(string)
 	isAdmin := ok && (isAdminRole == "1" || isAdminRole == "2")
 
+	v := validator.New()
+	shape := readShape(v, r, data.Vendor{}, adminsIncludable(isAdminRole == "1")...)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
 	vendor, err := app.Model.VendorDB.GetVendor(id, isAdmin)
 	if err != nil {
-
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			app.errorResponse(w, r, http.StatusNotFound, fmt.Sprintf("Vendor with ID %s was not found", id))
-			return
-		default:
-			app.badRequestResponse(w, r, err)
-			return
-		}
+		app.handleRetrievalError(w, r, err)
+		return
 	}
 
 	// Check if the vendor is visible, unless the user is an admin
 	if !isAdmin && !vendor.IsVisible {
-		app.errorResponse(w, r, http.StatusNotFound, "Vendor not found or not visible")
+		app.notFoundResponse(w, r)
 		return
 	}
 
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"vendor": vendor})
-}
-func (app *application) GetVendorTablesHandler(w http.ResponseWriter, r *http.Request) {
-	vendorIDs := r.PathValue("id")
-	id, err := uuid.Parse(vendorIDs)
-	if err != nil {
-		app.notFoundResponse(w, r)
+	shown := []data.Vendor{*vendor}
+	if err = app.setOpeningStatus(r, shown); err != nil {
+		app.serverErrorResponse(w, r, err)
 		return
 	}
-	// Get vendor tables from the database
-	tables, err := app.Model.TableDB.GetVendorTables(r.Context(), id)
-	if err != nil {
-		app.handleRetrievalError(w, r, err)
+	if err = app.setVendorTags(r, shown); err != nil {
+		app.serverErrorResponse(w, r, err)
 		return
 	}
+	if err = app.localizeVendors(r, shown); err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if shape.includes("admins") {
+		if err = app.setVendorAdmins(r, shown); err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+	sendTagged(w, r, utils.Envelope{"vendor": shape.trim(shown[0])}, vendor.Version)
+}
 
-	utils.SendJSONResponse(w, http.StatusOK, utils.Envelope{"tables": tables})
+// readVendorLocation applies the optional address, latitude and longitude. Leaving out both
+// coordinates keeps the location unchanged; clear_location removes it.
+func readVendorLocation(input *vendorRequest, vendor *data.Vendor) error {
+	if input.Address != nil {
+		if address := strings.TrimSpace(*input.Address); address != "" {
+			vendor.Address = &address
+		}
+	}
+	if input.ClearLocation {
+		vendor.Latitude, vendor.Longitude = nil, nil
+		return nil
+	}
+
+	if input.Latitude == nil && input.Longitude == nil {
+		return nil
+	}
+	if input.Latitude == nil || input.Longitude == nil {
+		return i18n.Message{Code: i18n.LocationIncomplete}
+	}
+	vendor.Latitude, vendor.Longitude = input.Latitude, input.Longitude
+	return nil
 }
@@ -21,6 +21,14 @@ type Cart struct {
 	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
 }
 
+// vendorValue is what vendor_id is stored as: NULL for an empty cart's uuid.Nil.
+func (c *Cart) vendorValue() interface{} {
+	if c.VendorID == uuid.Nil {
+		return nil
+	}
+	return c.VendorID
+}
+
 type CartDB struct {
 	db *sqlx.DB
 }
@@ -53,7 +61,7 @@ func (db *CartDB) GetCart(userID uuid.UUID) (*Cart, error) {
 func (c *CartDB) InsertCart(cart *Cart) error {
 	query, args, err := QB.Insert("carts").
 		Columns("id", "vendor_id").
-		Values(cart.ID, cart.VendorID).
+		Values(cart.ID, cart.vendorValue()).
 		ToSql()
 	if err != nil {
 		return err
@@ -84,7 +92,7 @@ func (db *CartDB) UpdateCart(cart *Cart) error {
 	updateQuery := psql.Update("carts").
 		Set("total_price", cart.TotalPrice).
 		Set("quantity", cart.Quantity).
-		Set("vendor_id", cart.VendorID).
+		Set("vendor_id", cart.vendorValue()).
 		Set("updated_at", time.Now()).
 		Where(squirrel.Eq{"id": cart.ID})
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"project/utils/pagination"
 	"strings"
 
 	"github.com/Masterminds/squirrel"
@@ -123,32 +124,37 @@ func (m *CartItemDB) DeleteCartItems(cartID uuid.UUID) error {
 	_, err = m.db.Exec(query, args...)
 	return err
 }
-func (c *CartItemDB) GetCartItemswithimage(cartID uuid.UUID) ([]CartItemWithNameAndImg, error) {
-	var items []CartItemWithNameAndImg
 
-	query, args, err := QB.Select(
+// CartItemSorts are the orders the items of a cart can be read in, by name by default.
+var CartItemSorts = pagination.Sorts{
+	Default: "name",
+	Orders: map[string]pagination.Order{
+		"name": {pagination.Asc("COALESCE(it.name, items.name)").As("name"), pagination.Asc("cart_items.item_id")},
+	},
+}
+
+// GetCartItemswithimage lists one page of the cart's items with their names in lang, falling
+// back to the item's own name when it has no translation or lang is empty. page must be read
+// with CartItemSorts.
+func (c *CartItemDB) GetCartItemswithimage(ctx context.Context, cartID uuid.UUID, lang string, page pagination.Params) ([]CartItemWithNameAndImg, pagination.Metadata, error) {
+	list := QB.Select(
 		"cart_items.cart_id",
 		"cart_items.item_id",
 		"cart_items.quantity",
-		"items.name",
+		"COALESCE(it.name, items.name) AS name",
 		fmt.Sprintf("CASE WHEN NULLIF(img, '') IS NOT NULL THEN FORMAT('%s/%%s', img) ELSE NULL END AS img", Domain)).
 		From("cart_items").
 		Join("items ON cart_items.item_id = items.id").
-		Where(squirrel.Eq{"cart_id": cartID}).
-		ToSql()
-	if err != nil {
-		return nil, fmt.Errorf("error building query: %v", err)
-	}
-
-	err = c.db.Select(&items, query, args...)
+		LeftJoin("item_translations it ON it.item_id = items.id AND it.lang = ?", lang).
+		Where(squirrel.Eq{"cart_id": cartID})
+	count := QB.Select("COUNT(*)").
+		From("cart_items").
+		Where(squirrel.Eq{"cart_id": cartID})
+	items, meta, err := selectPage[CartItemWithNameAndImg](ctx, c.db, list, count, page)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("no items found for cart ID: %s", cartID)
-		}
-		return nil, fmt.Errorf("error while querying cart items: %v", err)
+		return nil, pagination.Metadata{}, fmt.Errorf("error while querying cart items: %v", err)
 	}
-
-	return items, nil
+	return items, meta, nil
 }
 
 func (c *CartItemDB) ItemExistsInCart(cartID, itemID uuid.UUID) (bool, error) {
@@ -0,0 +1,68 @@
+package data
+
+import (
+	"net/http"
+	"project/utils/i18n"
+)
+
+// Error is a failure the API can explain to clients: a stable code, the HTTP status it
+// answers with and, for invalid input, the message of every offending field.
+type Error struct {
+	i18n.Message
+	Status int
+	Fields map[string]i18n.Message
+	err    error
+}
+
+func newError(status int, code i18n.Code) *Error {
+	return &Error{Message: i18n.Message{Code: code}, Status: status}
+}
+
+func (e *Error) Error() string {
+	return e.Text(i18n.DefaultLanguage)
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// With returns a more specific error that keeps e's status and still matches e with errors.Is.
+func (e *Error) With(code i18n.Code, args ...interface{}) *Error {
+	return &Error{Message: i18n.Message{Code: code, Args: args}, Status: e.Status, err: e}
+}
+
+// ValidationError reports the fields a validator rejected.
+func ValidationError(fields map[string]i18n.Message) *Error {
+	return &Error{
+		Message: i18n.Message{Code: i18n.FailedValidation},
+		Status:  http.StatusUnprocessableEntity,
+		Fields:  fields,
+	}
+}
+
+var (
+	ErrRecordNotFound        = newError(http.StatusNotFound, i18n.RecordNotFound)
+	ErrDuplicatedKey         = newError(http.StatusConflict, i18n.EmailTaken)
+	ErrDuplicatedRole        = newError(http.StatusConflict, i18n.DuplicatedRole)
+	ErrHasRole               = newError(http.StatusConflict, i18n.HasRole)
+	ErrHasNoRoles            = newError(http.StatusConflict, i18n.HasNoRoles)
+	ErrForeignKeyViolation   = newError(http.StatusNotFound, i18n.VendorIDIncorrect)
+	ErrUserNotFound          = newError(http.StatusConflict, i18n.EmailNotFound)
+	ErrUserAlreadyhaveatable = newError(http.StatusConflict, i18n.UserHasTable)
+	ErrUserHasNoTable        = newError(http.StatusNotFound, i18n.UserHasNoTable)
+	ErrItemAlreadyInserted   = newError(http.StatusConflict, i18n.ItemAlreadyInserted)
+	ErrInvalidQuantity       = newError(http.StatusConflict, i18n.QuantityUnavailable)
+	ErrTableTokenExpired     = newError(http.StatusGone, i18n.TableTokenExpired)
+	ErrTableOccupied         = newError(http.StatusConflict, i18n.TableOccupied)
+	ErrServiceRequestClosed  = newError(http.StatusConflict, i18n.ServiceRequestClosed)
+	ErrPlanLimitReached      = newError(http.StatusForbidden, i18n.PlanLimitReached)
+	ErrDuplicatedPlan        = newError(http.StatusConflict, i18n.DuplicatedPlan)
+	ErrInvoiceNotPending     = newError(http.StatusConflict, i18n.InvoiceNotPending)
+	ErrDuplicatedTag         = newError(http.StatusConflict, i18n.DuplicatedTag)
+	ErrAlreadyReviewed       = newError(http.StatusConflict, i18n.AlreadyReviewed)
+	ErrAlreadyReplied        = newError(http.StatusConflict, i18n.AlreadyReplied)
+	ErrOrderNotCompleted     = newError(http.StatusConflict, i18n.OrderNotCompleted)
+	ErrNotPurchaser          = newError(http.StatusForbidden, i18n.NotPurchaser)
+	ErrItemNotInOrder        = newError(http.StatusBadRequest, i18n.ItemNotInOrder)
+	ErrEditConflict          = newError(http.StatusConflict, i18n.EditConflict)
+)
@@ -0,0 +1,316 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var TableShapes = []string{"square", "round", "rectangle"}
+
+// FloorArea is a named part of a vendor's room, such as "indoor" or "terrace".
+type FloorArea struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	VendorID  uuid.UUID `db:"vendor_id" json:"vendor_id"`
+	Name      string    `db:"name" json:"name"`
+	SortOrder int       `db:"sort_order" json:"sort_order"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// FloorPlan is the full layout of a vendor's room.
+type FloorPlan struct {
+	Areas  []FloorArea `json:"areas"`
+	Tables []Table     `json:"tables"`
+}
+
+// TablePlacement is the layout of one table as sent by the floor-plan editor.
+// Area refers to an area by name so new areas and their tables can be saved together.
+type TablePlacement struct {
+	TableID  uuid.UUID `json:"table_id"`
+	Area     string    `json:"area"`
+	PosX     float64   `json:"pos_x"`
+	PosY     float64   `json:"pos_y"`
+	Shape    string    `json:"shape"`
+	Rotation int       `json:"rotation"`
+	Capacity int       `json:"capacity"`
+}
+
+// FloorPlanInput is the payload of a bulk layout save.
+type FloorPlanInput struct {
+	Areas []struct {
+		Name      string `json:"name"`
+		SortOrder int    `json:"sort_order"`
+	} `json:"areas"`
+	Tables []TablePlacement `json:"tables"`
+}
+
+// LiveTable merges a table's layout with what is happening at it right now.
+type LiveTable struct {
+	Table
+	Session         *TableSession    `json:"session"`
+	OpenOrders      []Order          `json:"open_orders"`
+	PendingRequests []ServiceRequest `json:"pending_requests"`
+}
+
+// LiveFloor is the floor plan plus current occupancy, for drawing the room on a tablet.
+type LiveFloor struct {
+	Areas  []FloorArea `json:"areas"`
+	Tables []LiveTable `json:"tables"`
+}
+
+type FloorPlanDB struct {
+	db *sqlx.DB
+}
+
+func ValidatingFloorPlan(v *validator.Validator, input *FloorPlanInput) {
+	names := make([]string, 0, len(input.Areas))
+	for _, area := range input.Areas {
+		v.Check(area.Name != "", "areas", i18n.AreaNameRequired)
+		v.Check(len(area.Name) <= 40, "areas", i18n.AreaNameTooLong, 40)
+		names = append(names, area.Name)
+	}
+	v.Check(validator.Unique(names), "areas", i18n.AreaNamesNotUnique)
+
+	tableIDs := make([]string, 0, len(input.Tables))
+	for _, table := range input.Tables {
+		tableIDs = append(tableIDs, table.TableID.String())
+		v.Check(table.TableID != uuid.Nil, "tables", i18n.TableIDRequired)
+		v.Check(table.Area == "" || validator.In(table.Area, names...), "tables", i18n.TableAreaUnknown)
+		v.Check(table.PosX >= 0 && table.PosY >= 0, "tables", i18n.TablePositionNegative)
+		v.Check(validator.In(table.Shape, TableShapes...), "tables", i18n.TableShapeInvalid)
+		v.Check(table.Rotation >= 0 && table.Rotation < 360, "tables", i18n.TableRotationOutOfRange)
+		v.Check(table.Capacity > 0 && table.Capacity <= 50, "tables", i18n.TableCapacityOutOfRange)
+	}
+	v.Check(validator.Unique(tableIDs), "tables", i18n.TablePlacedTwice)
+}
+
+// GetFloorPlan returns the vendor's areas and the layout of all of its tables.
+func (f *FloorPlanDB) GetFloorPlan(ctx context.Context, vendorID uuid.UUID) (*FloorPlan, error) {
+	plan := &FloorPlan{Areas: []FloorArea{}, Tables: []Table{}}
+
+	query, args, err := QB.Select(floorAreaColumns...).From("floor_areas").
+		Where(squirrel.Eq{"vendor_id": vendorID}).
+		OrderBy("sort_order ASC", "name ASC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = f.db.SelectContext(ctx, &plan.Areas, query, args...); err != nil {
+		return nil, err
+	}
+
+	query, args, err = QB.Select(tableColumns...).From("tables").
+		Where(squirrel.Eq{"vendor_id": vendorID}).
+		OrderBy("name ASC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = f.db.SelectContext(ctx, &plan.Tables, query, args...); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// SaveFloorPlan replaces the vendor's areas and table layout in a single transaction.
+// Areas missing from the input are removed; tables missing from the input keep their layout
+// but lose their area.
+func (f *FloorPlanDB) SaveFloorPlan(ctx context.Context, vendorID uuid.UUID, input *FloorPlanInput) (*FloorPlan, error) {
+	tx, err := f.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	areaIDs := make(map[string]uuid.UUID, len(input.Areas))
+	names := make([]string, 0, len(input.Areas))
+	for _, area := range input.Areas {
+		var id uuid.UUID
+		query, args, err := QB.Insert("floor_areas").
+			Columns("vendor_id", "name", "sort_order").
+			Values(vendorID, area.Name, area.SortOrder).
+			Suffix("ON CONFLICT (vendor_id, name) DO UPDATE SET sort_order = EXCLUDED.sort_order, updated_at = CURRENT_TIMESTAMP RETURNING id").
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err = tx.GetContext(ctx, &id, query, args...); err != nil {
+			return nil, fmt.Errorf("error while saving floor area: %v", err)
+		}
+		areaIDs[area.Name] = id
+		names = append(names, area.Name)
+	}
+
+	deleteAreas := QB.Delete("floor_areas").Where(squirrel.Eq{"vendor_id": vendorID})
+	if len(names) > 0 {
+		deleteAreas = deleteAreas.Where(squirrel.NotEq{"name": names})
+	}
+	query, args, err := deleteAreas.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("error while removing floor areas: %v", err)
+	}
+
+	for _, placement := range input.Tables {
+		var areaID *uuid.UUID
+		if id, ok := areaIDs[placement.Area]; ok {
+			areaID = &id
+		}
+		query, args, err := QB.Update("tables").
+			Set("area_id", areaID).
+			Set("pos_x", placement.PosX).
+			Set("pos_y", placement.PosY).
+			Set("shape", placement.Shape).
+			Set("rotation", placement.Rotation).
+			Set("capacity", placement.Capacity).
+			Where(squirrel.Eq{"id": placement.TableID, "vendor_id": vendorID}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("error while saving table layout: %v", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if rowsAffected == 0 {
+			// Either the table doesn't exist or it belongs to another vendor
+			return nil, ErrRecordNotFound
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return f.GetFloorPlan(ctx, vendorID)
+}
+
+// GetLiveFloor merges the floor plan with open sessions, orders still being prepared and
+// pending service requests. Each kind of data is loaded with one query for the whole vendor.
+func (f *FloorPlanDB) GetLiveFloor(ctx context.Context, vendorID uuid.UUID) (*LiveFloor, error) {
+	plan, err := f.GetFloorPlan(ctx, vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open sessions and their members
+	sessions := []TableSession{}
+	query, args, err := QB.Select(prefixColumns("s", tableSessionColumns)...).
+		From("table_sessions s").
+		Join("tables t ON t.id = s.table_id").
+		Where(squirrel.Eq{"t.vendor_id": vendorID, "s.closed_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = f.db.SelectContext(ctx, &sessions, query, args...); err != nil {
+		return nil, err
+	}
+
+	var members []struct {
+		SessionID uuid.UUID `db:"session_id"`
+		UserID    uuid.UUID `db:"user_id"`
+	}
+	query, args, err = QB.Select("m.session_id", "m.user_id").
+		From("table_session_members m").
+		Join("table_sessions s ON s.id = m.session_id").
+		Join("tables t ON t.id = s.table_id").
+		Where(squirrel.Eq{"t.vendor_id": vendorID, "s.closed_at": nil}).
+		OrderBy("m.joined_at ASC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = f.db.SelectContext(ctx, &members, query, args...); err != nil {
+		return nil, err
+	}
+
+	// Orders still being prepared, linked to tables through the seated customer
+	var orders []struct {
+		TableID uuid.UUID `db:"table_id"`
+		Order
+	}
+	query, args, err = QB.Select(append([]string{"t.id AS table_id"}, prefixColumns("o", ordersColumns)...)...).
+		From("orders o").
+		Join("tables t ON t.customer_id = o.customer_id AND t.vendor_id = o.vendor_id").
+		Where(squirrel.Eq{"o.vendor_id": vendorID, "o.status": "preparing"}).
+		OrderBy("o.created_at ASC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = f.db.SelectContext(ctx, &orders, query, args...); err != nil {
+		return nil, err
+	}
+
+	requests := []ServiceRequest{}
+	query, args, err = QB.Select(serviceRequestColumns...).
+		From("service_requests").
+		Where(squirrel.Eq{"vendor_id": vendorID}).
+		Where(openServiceRequest).
+		OrderBy("created_at ASC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = f.db.SelectContext(ctx, &requests, query, args...); err != nil {
+		return nil, err
+	}
+
+	sessionByTable := make(map[uuid.UUID]*TableSession, len(sessions))
+	sessionByID := make(map[uuid.UUID]*TableSession, len(sessions))
+	for i := range sessions {
+		sessions[i].Members = []uuid.UUID{}
+		sessionByTable[sessions[i].TableID] = &sessions[i]
+		sessionByID[sessions[i].ID] = &sessions[i]
+	}
+	for _, member := range members {
+		if session, ok := sessionByID[member.SessionID]; ok {
+			session.Members = append(session.Members, member.UserID)
+		}
+	}
+
+	live := &LiveFloor{Areas: plan.Areas, Tables: make([]LiveTable, 0, len(plan.Tables))}
+	index := make(map[uuid.UUID]int, len(plan.Tables))
+	for i, table := range plan.Tables {
+		index[table.ID] = i
+		live.Tables = append(live.Tables, LiveTable{
+			Table:           table,
+			Session:         sessionByTable[table.ID],
+			OpenOrders:      []Order{},
+			PendingRequests: []ServiceRequest{},
+		})
+	}
+	for _, order := range orders {
+		if i, ok := index[order.TableID]; ok {
+			live.Tables[i].OpenOrders = append(live.Tables[i].OpenOrders, order.Order)
+		}
+	}
+	for _, request := range requests {
+		if i, ok := index[request.TableID]; ok {
+			live.Tables[i].PendingRequests = append(live.Tables[i].PendingRequests, request)
+		}
+	}
+	return live, nil
+}
+
+// prefixColumns qualifies plain column names with a table alias.
+func prefixColumns(alias string, columns []string) []string {
+	result := make([]string, len(columns))
+	for i, column := range columns {
+		result[i] = alias + "." + column
+	}
+	return result
+}
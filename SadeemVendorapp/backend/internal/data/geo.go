@@ -0,0 +1,48 @@
+package data
+
+import (
+	"math"
+	"project/utils"
+
+	"github.com/Masterminds/squirrel"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	// kmPerDegree is the length of one degree of latitude
+	kmPerDegree = earthRadiusKm * math.Pi / 180
+)
+
+// distanceExpr is the haversine great-circle distance in kilometres between the row's
+// latitude/longitude and a point given as (lat, lat, lng) arguments.
+// LEAST guards asin against rounding just above 1 for antipodal points.
+const distanceExpr = `(2 * 6371.0 * asin(LEAST(1, sqrt(
+	power(sin(radians(latitude - ?) / 2), 2) +
+	cos(radians(?)) * cos(radians(latitude)) * power(sin(radians(longitude - ?) / 2), 2)
+))))`
+
+// distanceColumn selects the distance to the point as distance_km.
+func distanceColumn(near *utils.Near) squirrel.Sqlizer {
+	return squirrel.Expr(distanceExpr+" AS distance_km", near.Lat, near.Lat, near.Lng)
+}
+
+// nearCondition keeps rows within the radius. The bounding box comes first so Postgres can
+// use vendors_location_idx; the exact distance check only runs on the rows inside it.
+func nearCondition(near *utils.Near) squirrel.Sqlizer {
+	latDelta := near.RadiusKm / kmPerDegree
+	conditions := squirrel.And{
+		squirrel.Expr("latitude BETWEEN ? AND ?", near.Lat-latDelta, near.Lat+latDelta),
+	}
+
+	// Longitude degrees shrink towards the poles; near a pole or across the antimeridian
+	// the box would wrap, so only the latitude band is used there
+	cosLat := math.Cos(near.Lat * math.Pi / 180)
+	if cosLat > 0.01 {
+		lngDelta := latDelta / cosLat
+		if near.Lng-lngDelta >= -180 && near.Lng+lngDelta <= 180 {
+			conditions = append(conditions, squirrel.Expr("longitude BETWEEN ? AND ?", near.Lng-lngDelta, near.Lng+lngDelta))
+		}
+	}
+
+	return append(conditions, squirrel.Expr(distanceExpr+" <= ?", near.Lat, near.Lat, near.Lng, near.RadiusKm))
+}
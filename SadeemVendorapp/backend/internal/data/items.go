@@ -1,11 +1,15 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"os"
 	"project/utils"
+	"project/utils/filter"
+	"project/utils/i18n"
+	"project/utils/pagination"
 	"project/utils/validator"
 	"strings"
 	"time"
@@ -25,43 +29,40 @@ type Item struct {
 	DiscountExpiry *time.Time `db:"discount_expiry" json:"discount_expiry"`
 	Quantity       int        `db:"quantity" json:"quantity"`
 	Img            *string    `db:"img" json:"img"`
+	RatingAvg      float64    `db:"rating_avg" json:"rating_avg"`
+	RatingCount    int        `db:"rating_count" json:"rating_count"`
+	Version        int        `db:"version" json:"version"`
 	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+	Vendor         *Vendor    `db:"-" json:"vendor,omitempty"`
 }
 type ItemDB struct {
 	db *sqlx.DB
 }
 
 // ValidatingItem performs validations on the Item struct based on provided fields.
-func ValidatingItem(v *validator.Validator, item *Item, fields ...string) {
-	for _, field := range fields {
-		switch field {
-		case "quantity":
-			if item.Quantity < 0 {
-				v.Check(item.Quantity >= 0, "quantity", "Quantity must be non-negative")
-			}
-		case "name":
-			if item.Name != "" {
-				v.Check(len(item.Name) <= 20, "name", "Name must be less than 50 characters")
-			}
-		case "price":
-			if item.Price <= 0 {
-				v.Check(item.Price > 0, "price", "Price must be greater than zero")
-			}
-		case "discount":
-			if item.Discount != 0 {
-				v.Check(item.Discount > 0, "discount", "Discount must not be negative")
-				v.Check(item.Discount < item.Price, "discount", "Discount must be less than the price!")
-			}
-		case "discount_expiry":
-			if item.Discount != 0 && item.DiscountExpiry == nil {
-				v.Check(item.DiscountExpiry != nil, "discount_expiry", "Discount expiry date is required when a discount is provided correctly!")
-			}
-		}
-	}
+func ValidatingItem(v *validator.Validator, item *Item) {
+	v.Validate(
+		validator.Number("quantity", item.Quantity).Min(0, i18n.QuantityNegative),
+		validator.String("name", item.Name).MaxLength(20, i18n.NameTooLong),
+		validator.Number("price", item.Price).Required(i18n.PriceNotPositive).Above(0, i18n.PriceNotPositive),
+		validator.Number("discount", item.Discount).Above(0, i18n.DiscountNotPositive).Below(item.Price, i18n.DiscountAbovePrice),
+		validator.Expect("discount_expiry", item.Discount == 0 || item.DiscountExpiry != nil, i18n.DiscountExpiryRequired),
+	)
 }
 
 func (i *ItemDB) InsertItem(item *Item) error {
+	ctx := context.Background()
+	tx, err := i.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = checkPlanLimit(ctx, tx, item.VendorID, PlanResourceItems); err != nil {
+		return err
+	}
+
 	query, args, err := QB.Insert("items").
 		Columns("vendor_id", "name", "price", "img", "discount", "discount_expiry", "quantity").
 		Values(item.VendorID, item.Name, item.Price, item.Img, item.Discount, item.DiscountExpiry, item.Quantity).
@@ -70,11 +71,29 @@ func (i *ItemDB) InsertItem(item *Item) error {
 	if err != nil {
 		return err
 	}
-	err = i.db.QueryRowx(query, args...).StructScan(item)
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(item)
 	if err != nil {
 		return fmt.Errorf("error while inserting item: %v", err)
 	}
-	return nil
+	return tx.Commit()
+}
+
+// ExpireDiscounts clears every discount whose expiry has passed.
+func (i *ItemDB) ExpireDiscounts(ctx context.Context, now time.Time) (int64, error) {
+	query, args, err := QB.Update("items").
+		Set("discount", 0).
+		Set("discount_expiry", nil).
+		Set("updated_at", now).
+		Where(squirrel.Lt{"discount_expiry": now}).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	result, err := i.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error while expiring discounts: %v", err)
+	}
+	return result.RowsAffected()
 }
 
 func (i *ItemDB) DeleteItem(itemID uuid.UUID) error {
@@ -118,36 +137,44 @@ func (i *ItemDB) DeleteItem(itemID uuid.UUID) error {
 	}
 	return nil
 }
-func (i *ItemDB) GetAllItems(vendorID uuid.UUID, filters utils.Filters) ([]Item, error) {
-	var items []Item
-	queryBuilder := QB.Select(itemsColumns...).
-		From("items").
-		Where(squirrel.Eq{"vendor_id": vendorID})
 
-	// Add search functionality if needed
-	if filters.Search != "" {
-		queryBuilder = queryBuilder.Where(squirrel.Like{"name": "%" + filters.Search + "%"})
-	}
-
-	// Sorting
-	if filters.Sort != "" {
-		queryBuilder = queryBuilder.OrderBy(filters.Sort)
-	}
+// ItemSorts are the fields a vendor's items can be sorted by, such as sort=-price,name.
+var ItemSorts = pagination.Sorts{
+	Default: "created_at",
+	Fields: map[string]pagination.Column{
+		"created_at": pagination.Asc("created_at"),
+		"name":       pagination.Asc("name"),
+		"price":      pagination.Asc("price"),
+		"quantity":   pagination.Asc("quantity"),
+	},
+	Tie: pagination.Asc("id"),
+}
 
-	offset := (filters.Page - 1) * filters.PageSize
-	queryBuilder = queryBuilder.Limit(uint64(filters.PageSize)).Offset(uint64(offset))
+// ItemFilters are the fields a vendor's items can be filtered on, such as price[gte]=5.
+var ItemFilters = filter.Fields{
+	"name":       filter.Text("name"),
+	"price":      filter.Number("price"),
+	"quantity":   filter.Integer("quantity"),
+	"discount":   filter.Number("discount"),
+	"created_at": filter.Time("created_at"),
+	"updated_at": filter.Time("updated_at"),
+}
 
-	// Build SQL query
-	query, args, err := queryBuilder.ToSql()
-	if err != nil {
-		return nil, err
+// GetAllItems lists one page of the vendor's items matching filters.
+func (i *ItemDB) GetAllItems(ctx context.Context, vendorID uuid.UUID, filters utils.Filters, page pagination.Params) ([]Item, pagination.Metadata, error) {
+	conditions := squirrel.And{squirrel.Eq{"vendor_id": vendorID}}
+	if filters.Search != "" {
+		conditions = append(conditions, squirrel.Like{"name": "%" + filters.Search + "%"})
 	}
+	conditions = append(conditions, filters.Conditions...)
 
-	err = i.db.Select(&items, query, args...)
+	list := QB.Select(itemsColumns...).From("items").Where(conditions)
+	count := QB.Select("COUNT(*)").From("items").Where(conditions)
+	items, meta, err := selectPage[Item](ctx, i.db, list, count, page)
 	if err != nil {
-		return nil, fmt.Errorf("error while retrieving items: %v", err)
+		return nil, meta, fmt.Errorf("error while retrieving items: %v", err)
 	}
-	return items, nil
+	return items, meta, nil
 }
 
 func (i *ItemDB) GetItem(itemID uuid.UUID) (*Item, error) {
@@ -169,6 +196,9 @@ func (i *ItemDB) GetItem(itemID uuid.UUID) (*Item, error) {
 
 	return &item, nil
 }
+
+// UpdateItem saves item if it is still at item.Version, and sets the version it is at now.
+// ErrEditConflict means it was changed or deleted since it was read.
 func (i *ItemDB) UpdateItem(item *Item) error {
 	query, args, err := QB.Update("items").
 		SetMap(map[string]interface{}{
@@ -180,13 +210,17 @@ func (i *ItemDB) UpdateItem(item *Item) error {
 			"quantity":        item.Quantity,
 			"updated_at":      time.Now(),
 		}).
-		Where(squirrel.Eq{"id": item.ID}).
+		Where(squirrel.Eq{"id": item.ID, "version": item.Version}).
+		Suffix("RETURNING version").
 		ToSql()
 	if err != nil {
 		return err
 	}
-	_, err = i.db.Exec(query, args...)
+	err = i.db.QueryRow(query, args...).Scan(&item.Version)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrEditConflict
+		}
 		return fmt.Errorf("error while updating item: %v", err)
 	}
 	return nil
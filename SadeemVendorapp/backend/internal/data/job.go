@@ -0,0 +1,165 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"project/utils/pagination"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// JobRun is one execution of a background job.
+type JobRun struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	JobName     string     `db:"job_name" json:"job_name"`
+	TriggeredBy string     `db:"triggered_by" json:"triggered_by"`
+	Instance    string     `db:"instance" json:"instance"`
+	Status      string     `db:"status" json:"status"`
+	Result      *string    `db:"result" json:"result"`
+	Error       *string    `db:"error" json:"error"`
+	StartedAt   time.Time  `db:"started_at" json:"started_at"`
+	FinishedAt  *time.Time `db:"finished_at" json:"finished_at"`
+}
+
+type JobDB struct {
+	db *sqlx.DB
+}
+
+// jobLockKey maps a job name to the bigint key of its advisory lock.
+func jobLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("job:" + name))
+	return int64(h.Sum64())
+}
+
+// TryLock takes the job's session-level advisory lock on a dedicated connection, so only one
+// replica runs the job at a time. When ok is true the caller must call unlock once the job is done.
+func (j *JobDB) TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error) {
+	conn, err := j.db.Connx(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := jobLockKey(name)
+	if err = conn.GetContext(ctx, &ok, "SELECT pg_try_advisory_lock($1)", key); err != nil || !ok {
+		conn.Close()
+		return nil, false, err
+	}
+
+	unlock = func() {
+		// The job's context may already be cancelled; the lock must still be released
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+		conn.Close()
+	}
+	return unlock, true, nil
+}
+
+// StartRun records that a job has started on this instance.
+func (j *JobDB) StartRun(ctx context.Context, name, triggeredBy, instance string, startedAt time.Time) (*JobRun, error) {
+	var run JobRun
+	query, args, err := QB.Insert("job_runs").
+		Columns("job_name", "triggered_by", "instance", "started_at").
+		Values(name, triggeredBy, instance, startedAt).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(jobRunColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = j.db.QueryRowxContext(ctx, query, args...).StructScan(&run)
+	if err != nil {
+		return nil, fmt.Errorf("error while inserting job run: %v", err)
+	}
+	return &run, nil
+}
+
+// FinishRun stores the outcome of a run. A nil runErr marks it as succeeded.
+func (j *JobDB) FinishRun(ctx context.Context, run *JobRun, result string, runErr error, finishedAt time.Time) error {
+	run.Status = "succeeded"
+	run.FinishedAt = &finishedAt
+	if result != "" {
+		run.Result = &result
+	}
+	if runErr != nil {
+		run.Status = "failed"
+		message := runErr.Error()
+		run.Error = &message
+	}
+
+	query, args, err := QB.Update("job_runs").
+		Set("status", run.Status).
+		Set("result", run.Result).
+		Set("error", run.Error).
+		Set("finished_at", finishedAt).
+		Where(squirrel.Eq{"id": run.ID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = j.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error while finishing job run: %v", err)
+	}
+	return nil
+}
+
+// JobRunSorts are the orders a job's run history can be read in.
+var JobRunSorts = pagination.Sorts{
+	Default: "latest",
+	Orders: map[string]pagination.Order{
+		"latest": {pagination.Desc("started_at"), pagination.Desc("id")},
+		"oldest": {pagination.Asc("started_at"), pagination.Asc("id")},
+	},
+}
+
+// GetRuns returns one page of a job's runs.
+func (j *JobDB) GetRuns(ctx context.Context, name string, page pagination.Params) ([]JobRun, pagination.Metadata, error) {
+	list := QB.Select(jobRunColumns...).From("job_runs").Where(squirrel.Eq{"job_name": name})
+	count := QB.Select("COUNT(*)").From("job_runs").Where(squirrel.Eq{"job_name": name})
+	return selectPage[JobRun](ctx, j.db, list, count, page)
+}
+
+// GetLastRuns returns the latest run of every job that has run at least once, keyed by job name.
+func (j *JobDB) GetLastRuns(ctx context.Context) (map[string]JobRun, error) {
+	var runs []JobRun
+	query, args, err := QB.Select(jobRunColumns...).
+		Options("DISTINCT ON (job_name)").
+		From("job_runs").
+		OrderBy("job_name", "started_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = j.db.SelectContext(ctx, &runs, query, args...); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	last := make(map[string]JobRun, len(runs))
+	for _, run := range runs {
+		last[run.JobName] = run
+	}
+	return last, nil
+}
+
+// HasRunSince reports whether the job has started a run at or after since, on any instance.
+func (j *JobDB) HasRunSince(ctx context.Context, name string, since time.Time) (bool, error) {
+	var exists bool
+	query, args, err := QB.Select("1").From("job_runs").
+		Where(squirrel.Eq{"job_name": name}).
+		Where(squirrel.GtOrEq{"started_at": since}).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+	if err = j.db.GetContext(ctx, &exists, query, args...); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
@@ -1,7 +1,6 @@
 package data
 
 import (
-	"errors"
 	"fmt"
 	"os"
 
@@ -11,18 +10,6 @@ import (
 )
 
 var (
-	ErrRecordNotFound        = errors.New("record not found")
-	ErrDuplicatedKey         = errors.New("user already have the value")
-	ErrDuplicatedRole        = errors.New("user Already have the role")
-	ErrHasRole               = errors.New("user Already has a role")
-	ErrHasNoRoles            = errors.New("user Has no roles")
-	ErrForeignKeyViolation   = errors.New("foreign key constraint violation")
-	ErrUserNotFound          = errors.New("user Not Found")
-	ErrUserAlreadyhaveatable = errors.New("user already have a table")
-	ErrUserHasNoTable        = errors.New("user has no table")
-	ErrItemAlreadyInserted   = errors.New("item already inserted! ")
-	ErrInvalidQuantity       = errors.New("requested quantity is not available")
-
 	QB     = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 	Domain = os.Getenv("DOMAIN")
 
@@ -32,6 +19,7 @@ var (
 		"email",
 		"password",
 		"phone",
+		"version",
 		"created_at",
 		"updated_at",
 		fmt.Sprintf("CASE WHEN NULLIF(img, '') IS NOT NULL THEN FORMAT('%s/%%s', img) ELSE NULL END AS img", Domain),
@@ -43,6 +31,18 @@ var (
 		"subscription_end",
 		"subscription_days",
 		"is_visible",
+		"plan_id",
+		"grace_days",
+		"timezone",
+		"paused_until",
+		"pause_reason",
+		"address",
+		"latitude",
+		"longitude",
+		"rating_avg",
+		"rating_count",
+		"order_count",
+		"version",
 		"created_at",
 		"updated_at",
 		fmt.Sprintf("CASE WHEN NULLIF(img, '') IS NOT NULL THEN FORMAT('%s/%%s', img) ELSE NULL END AS img", Domain),
@@ -51,7 +51,44 @@ var (
 		"user_id",
 		"role_id",
 	}
-	tableColumns     = []string{"id", "name", "vendor_id", "customer_id", "is_available", "is_needs_service"}
+	tableSessionColumns = []string{"id", "table_id", "opened_by", "opened_at", "closed_at"}
+	floorAreaColumns    = []string{"id", "vendor_id", "name", "sort_order", "created_at", "updated_at"}
+	invoiceColumns      = []string{
+		"id", "vendor_id", "plan_id", "amount", "days", "status",
+		"period_start", "period_end", "created_at", "paid_at",
+	}
+	subscriptionHistoryColumns = []string{
+		"id", "vendor_id", "event", "is_visible", "subscription_end", "invoice_id", "note", "created_at", "notified_at",
+	}
+	openingIntervalColumns = []string{
+		"id", "vendor_id", "weekday", "to_char(opens_at, 'HH24:MI') AS opens_at", "to_char(closes_at, 'HH24:MI') AS closes_at",
+	}
+	hourExceptionColumns = []string{
+		"id", "vendor_id", "to_char(day, 'YYYY-MM-DD') AS day", "is_closed",
+		"to_char(opens_at, 'HH24:MI') AS opens_at", "to_char(closes_at, 'HH24:MI') AS closes_at", "note",
+	}
+	tagColumns    = []string{"id", "name", "slug", "kind", "created_at", "updated_at"}
+	reviewColumns = []string{
+		"id", "order_id", "vendor_id", "customer_id", "rating", "comment", "reply", "replied_by", "replied_at",
+		"status", "moderation_reason", "moderated_by", "moderated_at", "created_at", "updated_at",
+	}
+	vendorTranslationColumns = []string{"vendor_id", "lang", "name", "description", "updated_at"}
+	itemTranslationColumns   = []string{"item_id", "lang", "name", "updated_at"}
+	tagTranslationColumns    = []string{"tag_id", "lang", "name", "updated_at"}
+	jobRunColumns            = []string{
+		"id", "job_name", "triggered_by", "instance", "status", "result", "error", "started_at", "finished_at",
+	}
+	planColumns = []string{
+		"id", "name", "max_tables", "max_items", "max_staff", "max_api_keys",
+		"price", "billing_days", "is_default", "created_at", "updated_at",
+	}
+	serviceRequestColumns = []string{
+		"id", "table_id", "vendor_id", "customer_id", "type", "note",
+		"created_at", "acknowledged_at", "acknowledged_by", "resolved_at", "resolved_by",
+		"cancelled_at",
+	}
+	tableColumns = []string{"id", "name", "vendor_id", "customer_id", "is_available", "is_needs_service", "qr_token_version",
+		"area_id", "pos_x", "pos_y", "shape", "rotation", "capacity", "version"}
 	cartItemsColumns = []string{
 		"cart_id", "item_id", "quantity",
 	}
@@ -76,6 +113,9 @@ var (
 		"quantity",
 		"discount",
 		"discount_expiry",
+		"rating_avg",
+		"rating_count",
+		"version",
 		"created_at",
 		"updated_at",
 		fmt.Sprintf("CASE WHEN NULLIF(img, '') IS NOT NULL THEN FORMAT('%s/%%s', img) ELSE NULL END AS img", Domain),
@@ -83,17 +123,28 @@ var (
 )
 
 type Model struct {
-	UserDB        UserDB
-	TableDB       TableDB
-	VendorDB      VendorDB
-	UserRoleDB    UserRoleDB
-	VendorAdminDB VendorAdminDB
-	CartItemDB    CartItemDB
-	CartDB        CartDB
-	OrderItemDB   OrderItemDB
-	OrderDB       OrderDB
-	ItemDB        ItemDB
-	TransactionDB Transaction
+	UserDB           UserDB
+	TableDB          TableDB
+	VendorDB         VendorDB
+	UserRoleDB       UserRoleDB
+	VendorAdminDB    VendorAdminDB
+	CartItemDB       CartItemDB
+	CartDB           CartDB
+	OrderItemDB      OrderItemDB
+	OrderDB          OrderDB
+	ItemDB           ItemDB
+	TransactionDB    Transaction
+	TableSessionDB   TableSessionDB
+	ServiceRequestDB ServiceRequestDB
+	FloorPlanDB      FloorPlanDB
+	PlanDB           PlanDB
+	SubscriptionDB   SubscriptionDB
+	JobDB            JobDB
+	OpeningHoursDB   OpeningHoursDB
+	TagDB            TagDB
+	ReviewDB         ReviewDB
+	SearchDB         SearchDB
+	TranslationDB    TranslationDB
 }
 
 func NewModels(db *sqlx.DB) Model {
@@ -102,16 +153,27 @@ func NewModels(db *sqlx.DB) Model {
 		return Model{}
 	}
 	return Model{
-		UserDB:        UserDB{db},
-		TableDB:       TableDB{db},
-		VendorDB:      VendorDB{db},
-		UserRoleDB:    UserRoleDB{db},
-		VendorAdminDB: VendorAdminDB{db},
-		CartItemDB:    CartItemDB{db},
-		CartDB:        CartDB{db},
-		OrderItemDB:   OrderItemDB{db},
-		OrderDB:       OrderDB{db},
-		ItemDB:        ItemDB{db},
-		TransactionDB: Transaction{tx},
+		UserDB:           UserDB{db},
+		TableDB:          TableDB{db},
+		VendorDB:         VendorDB{db},
+		UserRoleDB:       UserRoleDB{db},
+		VendorAdminDB:    VendorAdminDB{db},
+		CartItemDB:       CartItemDB{db},
+		CartDB:           CartDB{db},
+		OrderItemDB:      OrderItemDB{db},
+		OrderDB:          OrderDB{db},
+		ItemDB:           ItemDB{db},
+		TransactionDB:    Transaction{tx},
+		TableSessionDB:   TableSessionDB{db},
+		ServiceRequestDB: ServiceRequestDB{db},
+		FloorPlanDB:      FloorPlanDB{db},
+		PlanDB:           PlanDB{db},
+		SubscriptionDB:   SubscriptionDB{db},
+		JobDB:            JobDB{db},
+		OpeningHoursDB:   OpeningHoursDB{db},
+		TagDB:            TagDB{db},
+		ReviewDB:         ReviewDB{db},
+		SearchDB:         SearchDB{db},
+		TranslationDB:    TranslationDB{db},
 	}
 }
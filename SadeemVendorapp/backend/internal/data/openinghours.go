@@ -0,0 +1,447 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// OpeningInterval is one weekly opening span. Weekday follows time.Weekday (0 is Sunday).
+// A ClosesAt at or before OpensAt runs past midnight into the next day.
+type OpeningInterval struct {
+	ID       uuid.UUID `db:"id" json:"id"`
+	VendorID uuid.UUID `db:"vendor_id" json:"-"`
+	Weekday  int       `db:"weekday" json:"weekday"`
+	OpensAt  string    `db:"opens_at" json:"opens_at"`
+	ClosesAt string    `db:"closes_at" json:"closes_at"`
+}
+
+// HourException overrides the weekly hours on one date, for holidays and special hours.
+type HourException struct {
+	ID       uuid.UUID `db:"id" json:"id"`
+	VendorID uuid.UUID `db:"vendor_id" json:"-"`
+	Day      string    `db:"day" json:"day"`
+	IsClosed bool      `db:"is_closed" json:"is_closed"`
+	OpensAt  *string   `db:"opens_at" json:"opens_at"`
+	ClosesAt *string   `db:"closes_at" json:"closes_at"`
+	Note     *string   `db:"note" json:"note"`
+}
+
+// OpeningHours is everything needed to tell whether a vendor is open.
+type OpeningHours struct {
+	Timezone    string            `json:"timezone"`
+	Weekly      []OpeningInterval `json:"weekly"`
+	Exceptions  []HourException   `json:"exceptions"`
+	PausedUntil *time.Time        `json:"paused_until"`
+	PauseReason *string           `json:"pause_reason"`
+}
+
+// OpenStatus says whether a vendor takes orders right now.
+// Reason is "open", "closed" or "paused".
+type OpenStatus struct {
+	IsOpen      bool       `json:"is_open"`
+	Reason      string     `json:"reason"`
+	ClosesAt    *time.Time `json:"closes_at,omitempty"`
+	NextOpen    *time.Time `json:"next_open,omitempty"`
+	PauseReason *string    `json:"pause_reason,omitempty"`
+}
+
+type OpeningHoursDB struct {
+	db *sqlx.DB
+}
+
+const (
+	clockLayout = "15:04"
+	dayLayout   = "2006-01-02"
+)
+
+func ValidatingOpeningInterval(v *validator.Validator, interval *OpeningInterval) {
+	v.Check(interval.Weekday >= 0 && interval.Weekday <= 6, "weekday", i18n.WeekdayOutOfRange)
+	_, opensErr := time.Parse(clockLayout, interval.OpensAt)
+	_, closesErr := time.Parse(clockLayout, interval.ClosesAt)
+	v.Check(opensErr == nil && closesErr == nil, "hours", i18n.TimeFormatInvalid)
+	v.Check(interval.OpensAt != interval.ClosesAt, "hours", i18n.HoursSameTime)
+}
+
+func ValidatingHourException(v *validator.Validator, exception *HourException) {
+	_, err := time.Parse(dayLayout, exception.Day)
+	v.Check(err == nil, "day", i18n.DayFormatInvalid)
+	if !exception.IsClosed {
+		v.Check(exception.OpensAt != nil && exception.ClosesAt != nil, "hours", i18n.HoursRequired)
+		if exception.OpensAt != nil && exception.ClosesAt != nil {
+			_, opensErr := time.Parse(clockLayout, *exception.OpensAt)
+			_, closesErr := time.Parse(clockLayout, *exception.ClosesAt)
+			v.Check(opensErr == nil && closesErr == nil, "hours", i18n.TimeFormatInvalid)
+			v.Check(*exception.OpensAt != *exception.ClosesAt, "hours", i18n.HoursSameTime)
+		}
+	}
+	if exception.Note != nil {
+		v.Check(len(*exception.Note) <= 100, "note", i18n.NoteTooLong, 100)
+	}
+}
+
+func ValidatingTimezone(v *validator.Validator, timezone string) {
+	_, err := time.LoadLocation(timezone)
+	v.Check(timezone != "" && err == nil, "timezone", i18n.TimezoneInvalid)
+}
+
+// GetOpeningHours loads the hours of several vendors at once, keyed by vendor ID.
+// Only exceptions from the vendor's own yesterday onwards are loaded; older ones can't
+// affect the status.
+func (o *OpeningHoursDB) GetOpeningHours(ctx context.Context, vendorIDs []uuid.UUID) (map[uuid.UUID]*OpeningHours, error) {
+	hours := make(map[uuid.UUID]*OpeningHours, len(vendorIDs))
+	if len(vendorIDs) == 0 {
+		return hours, nil
+	}
+
+	var vendors []struct {
+		ID          uuid.UUID  `db:"id"`
+		Timezone    string     `db:"timezone"`
+		PausedUntil *time.Time `db:"paused_until"`
+		PauseReason *string    `db:"pause_reason"`
+	}
+	query, args, err := QB.Select("id", "timezone", "paused_until", "pause_reason").
+		From("vendors").
+		Where(squirrel.Eq{"id": vendorIDs}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = o.db.SelectContext(ctx, &vendors, query, args...); err != nil {
+		return nil, err
+	}
+	for _, vendor := range vendors {
+		hours[vendor.ID] = &OpeningHours{
+			Timezone:    vendor.Timezone,
+			Weekly:      []OpeningInterval{},
+			Exceptions:  []HourException{},
+			PausedUntil: vendor.PausedUntil,
+			PauseReason: vendor.PauseReason,
+		}
+	}
+
+	if len(vendors) == 0 {
+		return hours, nil
+	}
+
+	var weekly []OpeningInterval
+	query, args, err = QB.Select(openingIntervalColumns...).
+		From("vendor_opening_hours").
+		Where(squirrel.Eq{"vendor_id": vendorIDs}).
+		OrderBy("weekday", "opens_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = o.db.SelectContext(ctx, &weekly, query, args...); err != nil {
+		return nil, err
+	}
+	for _, interval := range weekly {
+		if h, ok := hours[interval.VendorID]; ok {
+			h.Weekly = append(h.Weekly, interval)
+		}
+	}
+
+	// The database's CURRENT_DATE is a day off from vendors far enough east or west of it
+	now := time.Now()
+	since := map[string][]uuid.UUID{}
+	for _, vendor := range vendors {
+		day := localYesterday(vendor.Timezone, now)
+		since[day] = append(since[day], vendor.ID)
+	}
+	days := make([]string, 0, len(since))
+	for day := range since {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	window := squirrel.Or{}
+	for _, day := range days {
+		window = append(window, squirrel.And{squirrel.Eq{"vendor_id": since[day]}, squirrel.GtOrEq{"day": day}})
+	}
+
+	var exceptions []HourException
+	query, args, err = QB.Select(hourExceptionColumns...).
+		From("vendor_hour_exceptions").
+		Where(window).
+		OrderBy("day", "opens_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = o.db.SelectContext(ctx, &exceptions, query, args...); err != nil {
+		return nil, err
+	}
+	for _, exception := range exceptions {
+		if h, ok := hours[exception.VendorID]; ok {
+			h.Exceptions = append(h.Exceptions, exception)
+		}
+	}
+	return hours, nil
+}
+
+// GetVendorOpeningHours loads one vendor's hours.
+func (o *OpeningHoursDB) GetVendorOpeningHours(ctx context.Context, vendorID uuid.UUID) (*OpeningHours, error) {
+	hours, err := o.GetOpeningHours(ctx, []uuid.UUID{vendorID})
+	if err != nil {
+		return nil, err
+	}
+	h, ok := hours[vendorID]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+	return h, nil
+}
+
+// SetWeeklyHours replaces the vendor's time zone and weekly schedule in one transaction.
+func (o *OpeningHoursDB) SetWeeklyHours(ctx context.Context, vendorID uuid.UUID, timezone string, weekly []OpeningInterval) error {
+	tx, err := o.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Update("vendors").
+		Set("timezone", timezone).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": vendorID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error while updating vendor timezone: %v", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	query, args, err = QB.Delete("vendor_opening_hours").Where(squirrel.Eq{"vendor_id": vendorID}).ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	if len(weekly) > 0 {
+		insert := QB.Insert("vendor_opening_hours").Columns("vendor_id", "weekday", "opens_at", "closes_at")
+		for _, interval := range weekly {
+			insert = insert.Values(vendorID, interval.Weekday, interval.OpensAt, interval.ClosesAt)
+		}
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("error while inserting opening hours: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (o *OpeningHoursDB) InsertException(ctx context.Context, exception *HourException) error {
+	query, args, err := QB.Insert("vendor_hour_exceptions").
+		Columns("vendor_id", "day", "is_closed", "opens_at", "closes_at", "note").
+		Values(exception.VendorID, exception.Day, exception.IsClosed, exception.OpensAt, exception.ClosesAt, exception.Note).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(hourExceptionColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	err = o.db.QueryRowxContext(ctx, query, args...).StructScan(exception)
+	if err != nil {
+		return fmt.Errorf("error while inserting hour exception: %v", err)
+	}
+	return nil
+}
+
+func (o *OpeningHoursDB) DeleteException(ctx context.Context, vendorID, exceptionID uuid.UUID) error {
+	query, args, err := QB.Delete("vendor_hour_exceptions").
+		Where(squirrel.Eq{"id": exceptionID, "vendor_id": vendorID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := o.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// PauseOrdering stops the vendor from taking orders until the given time. A nil until resumes ordering.
+func (o *OpeningHoursDB) PauseOrdering(ctx context.Context, vendorID uuid.UUID, until *time.Time, reason *string) error {
+	query, args, err := QB.Update("vendors").
+		Set("paused_until", until).
+		Set("pause_reason", reason).
+		Where(squirrel.Eq{"id": vendorID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := o.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// location loads a vendor's time zone, falling back to UTC.
+func location(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// localYesterday is the date before now's in the time zone.
+func localYesterday(timezone string, now time.Time) string {
+	local := now.In(location(timezone))
+	return time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, time.UTC).Format(dayLayout)
+}
+
+// span is one concrete opening in absolute time.
+type span struct {
+	start, end time.Time
+}
+
+// spansOn returns the openings that start on the given local date. Exceptions for the
+// date replace the weekly hours; without weekly hours a date with no exception is open all day.
+func (h *OpeningHours) spansOn(year int, month time.Month, day int, loc *time.Location) []span {
+	date := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	key := date.Format(dayLayout)
+
+	var clocks [][2]string
+	hasException := false
+	for _, exception := range h.Exceptions {
+		if exception.Day != key {
+			continue
+		}
+		hasException = true
+		if exception.IsClosed {
+			return nil
+		}
+		if exception.OpensAt != nil && exception.ClosesAt != nil {
+			clocks = append(clocks, [2]string{*exception.OpensAt, *exception.ClosesAt})
+		}
+	}
+	if !hasException && len(h.Weekly) == 0 {
+		return []span{{date, time.Date(year, month, day+1, 0, 0, 0, 0, loc)}}
+	}
+	if !hasException {
+		for _, interval := range h.Weekly {
+			if interval.Weekday == int(date.Weekday()) {
+				clocks = append(clocks, [2]string{interval.OpensAt, interval.ClosesAt})
+			}
+		}
+	}
+
+	spans := make([]span, 0, len(clocks))
+	for _, c := range clocks {
+		opens, err1 := time.Parse(clockLayout, c[0])
+		closes, err2 := time.Parse(clockLayout, c[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		start := time.Date(year, month, day, opens.Hour(), opens.Minute(), 0, 0, loc)
+		end := time.Date(year, month, day, closes.Hour(), closes.Minute(), 0, 0, loc)
+		if !end.After(start) {
+			// Overnight
+			end = time.Date(year, month, day+1, closes.Hour(), closes.Minute(), 0, 0, loc)
+		}
+		spans = append(spans, span{start, end})
+	}
+	return spans
+}
+
+// Status evaluates the hours at now in the vendor's time zone. A vendor that hasn't set any
+// hours is always open, so existing vendors keep working until they configure a schedule.
+// ClosesAt is left out when the vendor stays open for the whole two weeks looked at.
+func (h *OpeningHours) Status(now time.Time) OpenStatus {
+	loc := location(h.Timezone)
+	local := now.In(loc)
+
+	paused := h.PausedUntil != nil && h.PausedUntil.After(now)
+	if len(h.Weekly) == 0 && len(h.Exceptions) == 0 {
+		if paused {
+			return OpenStatus{Reason: "paused", NextOpen: h.PausedUntil, PauseReason: h.PauseReason}
+		}
+		return OpenStatus{IsOpen: true, Reason: "open"}
+	}
+
+	// Collect spans from yesterday (overnight openings) to two weeks ahead
+	var spans []span
+	for offset := -1; offset <= 14; offset++ {
+		spans = append(spans, h.spansOn(local.Year(), local.Month(), local.Day()+offset, loc)...)
+	}
+
+	openAt := func(t time.Time) (time.Time, bool) {
+		var closes time.Time
+		found := false
+		for _, s := range spans {
+			if !t.Before(s.start) && t.Before(s.end) && s.end.After(closes) {
+				closes, found = s.end, true
+			}
+		}
+		// Run on into openings that start as this one ends, such as the hours after midnight
+		for extended := found; extended; {
+			extended = false
+			for _, s := range spans {
+				if !s.start.After(closes) && s.end.After(closes) {
+					closes, extended = s.end, true
+				}
+			}
+		}
+		return closes, found
+	}
+	nextOpenAfter := func(t time.Time) *time.Time {
+		if _, open := openAt(t); open {
+			return &t
+		}
+		var next *time.Time
+		for i := range spans {
+			if spans[i].start.After(t) && (next == nil || spans[i].start.Before(*next)) {
+				next = &spans[i].start
+			}
+		}
+		return next
+	}
+
+	if paused {
+		return OpenStatus{Reason: "paused", NextOpen: nextOpenAfter(*h.PausedUntil), PauseReason: h.PauseReason}
+	}
+	if closes, open := openAt(now); open {
+		status := OpenStatus{IsOpen: true, Reason: "open"}
+		if closes.Before(time.Date(local.Year(), local.Month(), local.Day()+15, 0, 0, 0, 0, loc)) {
+			status.ClosesAt = &closes
+		}
+		return status
+	}
+	return OpenStatus{Reason: "closed", NextOpen: nextOpenAfter(now)}
+}
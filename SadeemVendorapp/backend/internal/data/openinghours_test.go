@@ -0,0 +1,125 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+// every opens the vendor on each weekday from opens to closes.
+func every(opens, closes string) []OpeningInterval {
+	weekly := make([]OpeningInterval, 7)
+	for day := range weekly {
+		weekly[day] = OpeningInterval{Weekday: day, OpensAt: opens, ClosesAt: closes}
+	}
+	return weekly
+}
+
+func closedOn(day string) HourException {
+	return HourException{Day: day, IsClosed: true}
+}
+
+func openOn(day, opens, closes string) HourException {
+	return HourException{Day: day, OpensAt: &opens, ClosesAt: &closes}
+}
+
+func utc(value string) *time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestOpeningHoursStatus(t *testing.T) {
+	// Riyadh is UTC+3 all year; New York moves its clocks on 8 March and 1 November 2026
+	tests := []struct {
+		name  string
+		hours OpeningHours
+		now   string
+		want  OpenStatus
+	}{
+		{"missing schedule", OpeningHours{Timezone: "Asia/Riyadh"}, "2026-03-02T07:00:00Z",
+			OpenStatus{IsOpen: true, Reason: "open"}},
+		{"missing schedule while paused", OpeningHours{Timezone: "Asia/Riyadh", PausedUntil: utc("2026-03-02T08:00:00Z")}, "2026-03-02T07:00:00Z",
+			OpenStatus{Reason: "paused", NextOpen: utc("2026-03-02T08:00:00Z")}},
+		{"within hours", OpeningHours{Timezone: "Asia/Riyadh", Weekly: every("09:00", "17:00")}, "2026-03-02T07:00:00Z",
+			OpenStatus{IsOpen: true, Reason: "open", ClosesAt: utc("2026-03-02T14:00:00Z")}},
+		{"before opening", OpeningHours{Timezone: "Asia/Riyadh", Weekly: every("09:00", "17:00")}, "2026-03-02T05:00:00Z",
+			OpenStatus{Reason: "closed", NextOpen: utc("2026-03-02T06:00:00Z")}},
+		{"after closing", OpeningHours{Timezone: "Asia/Riyadh", Weekly: every("09:00", "17:00")}, "2026-03-02T14:00:00Z",
+			OpenStatus{Reason: "closed", NextOpen: utc("2026-03-03T06:00:00Z")}},
+		{"overnight from the day before", OpeningHours{Timezone: "Asia/Riyadh", Weekly: []OpeningInterval{{Weekday: 5, OpensAt: "20:00", ClosesAt: "02:00"}}}, "2026-03-06T22:00:00Z",
+			OpenStatus{IsOpen: true, Reason: "open", ClosesAt: utc("2026-03-06T23:00:00Z")}},
+		{"overnight once closed", OpeningHours{Timezone: "Asia/Riyadh", Weekly: []OpeningInterval{{Weekday: 5, OpensAt: "20:00", ClosesAt: "02:00"}}}, "2026-03-07T00:00:00Z",
+			OpenStatus{Reason: "closed", NextOpen: utc("2026-03-13T17:00:00Z")}},
+		{"runs on past midnight", OpeningHours{Timezone: "Asia/Riyadh", Weekly: []OpeningInterval{{Weekday: 1, OpensAt: "18:00", ClosesAt: "00:00"}, {Weekday: 2, OpensAt: "00:00", ClosesAt: "02:00"}}}, "2026-03-02T20:00:00Z",
+			OpenStatus{IsOpen: true, Reason: "open", ClosesAt: utc("2026-03-02T23:00:00Z")}},
+		{"closed exception", OpeningHours{Timezone: "Asia/Riyadh", Weekly: every("09:00", "17:00"), Exceptions: []HourException{closedOn("2026-03-02")}}, "2026-03-02T07:00:00Z",
+			OpenStatus{Reason: "closed", NextOpen: utc("2026-03-03T06:00:00Z")}},
+		{"special hours exception", OpeningHours{Timezone: "Asia/Riyadh", Weekly: every("09:00", "17:00"), Exceptions: []HourException{openOn("2026-03-02", "12:00", "14:00")}}, "2026-03-02T07:00:00Z",
+			OpenStatus{Reason: "closed", NextOpen: utc("2026-03-02T09:00:00Z")}},
+		{"exception cuts an overnight opening short", OpeningHours{Timezone: "Asia/Riyadh", Weekly: every("20:00", "02:00"), Exceptions: []HourException{closedOn("2026-03-03")}}, "2026-03-02T22:00:00Z",
+			OpenStatus{IsOpen: true, Reason: "open", ClosesAt: utc("2026-03-02T23:00:00Z")}},
+		{"only an exception, another day", OpeningHours{Timezone: "Asia/Riyadh", Exceptions: []HourException{closedOn("2026-03-05")}}, "2026-03-02T07:00:00Z",
+			OpenStatus{IsOpen: true, Reason: "open", ClosesAt: utc("2026-03-04T21:00:00Z")}},
+		{"only an exception, that day", OpeningHours{Timezone: "Asia/Riyadh", Exceptions: []HourException{closedOn("2026-03-05")}}, "2026-03-05T07:00:00Z",
+			OpenStatus{Reason: "closed", NextOpen: utc("2026-03-05T21:00:00Z")}},
+		{"only special hours", OpeningHours{Timezone: "Asia/Riyadh", Exceptions: []HourException{openOn("2026-03-05", "10:00", "12:00")}}, "2026-03-05T06:00:00Z",
+			OpenStatus{Reason: "closed", NextOpen: utc("2026-03-05T07:00:00Z")}},
+		{"paused within hours", OpeningHours{Timezone: "Asia/Riyadh", Weekly: every("09:00", "17:00"), PausedUntil: utc("2026-03-02T17:00:00Z")}, "2026-03-02T07:00:00Z",
+			OpenStatus{Reason: "paused", NextOpen: utc("2026-03-03T06:00:00Z")}},
+		{"pause over", OpeningHours{Timezone: "Asia/Riyadh", Weekly: every("09:00", "17:00"), PausedUntil: utc("2026-03-02T06:30:00Z")}, "2026-03-02T07:00:00Z",
+			OpenStatus{IsOpen: true, Reason: "open", ClosesAt: utc("2026-03-02T14:00:00Z")}},
+		{"opening on standard time", OpeningHours{Timezone: "America/New_York", Weekly: every("09:00", "17:00")}, "2026-03-07T14:30:00Z",
+			OpenStatus{IsOpen: true, Reason: "open", ClosesAt: utc("2026-03-07T22:00:00Z")}},
+		{"opening on daylight time", OpeningHours{Timezone: "America/New_York", Weekly: every("09:00", "17:00")}, "2026-03-08T12:30:00Z",
+			OpenStatus{Reason: "closed", NextOpen: utc("2026-03-08T13:00:00Z")}},
+		{"overnight as clocks go forward", OpeningHours{Timezone: "America/New_York", Weekly: []OpeningInterval{{Weekday: 6, OpensAt: "22:00", ClosesAt: "03:00"}}}, "2026-03-08T06:30:00Z",
+			OpenStatus{IsOpen: true, Reason: "open", ClosesAt: utc("2026-03-08T07:00:00Z")}},
+		{"overnight as clocks go back", OpeningHours{Timezone: "America/New_York", Weekly: []OpeningInterval{{Weekday: 6, OpensAt: "22:00", ClosesAt: "03:00"}}}, "2026-11-01T07:30:00Z",
+			OpenStatus{IsOpen: true, Reason: "open", ClosesAt: utc("2026-11-01T08:00:00Z")}},
+		{"open around the clock", OpeningHours{Timezone: "Asia/Riyadh", Weekly: append(every("00:00", "12:00"), every("12:00", "00:00")...)}, "2026-03-02T07:00:00Z",
+			OpenStatus{IsOpen: true, Reason: "open"}},
+		{"unknown time zone is UTC", OpeningHours{Timezone: "Mars/Olympus", Weekly: every("09:00", "17:00")}, "2026-03-02T08:00:00Z",
+			OpenStatus{Reason: "closed", NextOpen: utc("2026-03-02T09:00:00Z")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.hours.Status(*utc(tt.now))
+			if got.IsOpen != tt.want.IsOpen || got.Reason != tt.want.Reason {
+				t.Errorf("status = %v %s, want %v %s", got.IsOpen, got.Reason, tt.want.IsOpen, tt.want.Reason)
+			}
+			if !sameTime(got.ClosesAt, tt.want.ClosesAt) {
+				t.Errorf("closes at %v, want %v", got.ClosesAt, tt.want.ClosesAt)
+			}
+			if !sameTime(got.NextOpen, tt.want.NextOpen) {
+				t.Errorf("next open %v, want %v", got.NextOpen, tt.want.NextOpen)
+			}
+		})
+	}
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func TestLocalYesterday(t *testing.T) {
+	now := *utc("2026-03-02T11:00:00Z")
+	tests := map[string]string{
+		"UTC":                "2026-03-01",
+		"Pacific/Kiritimati": "2026-03-02", // UTC+14, already 3 March
+		"Pacific/Pago_Pago":  "2026-03-01", // UTC-11, still 2 March
+		"Mars/Olympus":       "2026-03-01",
+	}
+	for timezone, want := range tests {
+		if got := localYesterday(timezone, now); got != want {
+			t.Errorf("localYesterday(%s) = %s, want %s", timezone, got, want)
+		}
+	}
+	if got := localYesterday("Pacific/Pago_Pago", *utc("2026-03-02T05:00:00Z")); got != "2026-02-28" {
+		t.Errorf("localYesterday west of UTC after its midnight = %s, want 2026-02-28", got)
+	}
+}
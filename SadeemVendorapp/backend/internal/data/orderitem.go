@@ -1,6 +1,7 @@
 package data
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -50,3 +51,24 @@ func (o *OrderItemDB) DeleteOrderItem(orderItemID uuid.UUID) error {
 	}
 	return nil
 }
+
+// GetOrdersItems loads the items of several orders at once, keyed by order ID.
+func (o *OrderItemDB) GetOrdersItems(ctx context.Context, orderIDs []uuid.UUID) (map[uuid.UUID][]OrderItem, error) {
+	items := make(map[uuid.UUID][]OrderItem, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return items, nil
+	}
+
+	var rows []OrderItem
+	query, args, err := QB.Select(orderItemsColumns...).From("order_items").Where(squirrel.Eq{"order_id": orderIDs}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = o.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		items[row.OrderID] = append(items[row.OrderID], row)
+	}
+	return items, nil
+}
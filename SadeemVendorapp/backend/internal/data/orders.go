@@ -1,9 +1,13 @@
 package data
 
 import (
+	"context"
 	"fmt"
+	"project/utils"
+	"project/utils/filter"
+	"project/utils/i18n"
+	"project/utils/pagination"
 	"project/utils/validator"
-	"strconv"
 	"strings"
 	"time"
 
@@ -14,63 +18,81 @@ import (
 )
 
 type OrderDetails struct {
-	ID             uuid.UUID `json:"id"`
-	TotalOrderCost float64   `json:"total_order_cost"`
-	VendorName     string    `json:"vendor_name"`
-	VendorID       uuid.UUID `json:"-"`
-	UserName       string    `json:"user_name"`
-	ItemNames      []string  `json:"item_names"`
-	ItemPrices     []float64 `json:"item_prices"`
-	ItemQuantities []int     `json:"item_quantities"` // New field for item quantities
-	Status         string    `json:"status"`
-	TableID        uuid.UUID `json:"table_id"`
-	TableName      string    `json:"table_name"`
+	ID             uuid.UUID       `db:"id" json:"id"`
+	TotalOrderCost float64         `db:"total_order_cost" json:"total_order_cost"`
+	VendorName     string          `db:"vendor_name" json:"vendor_name"`
+	VendorID       uuid.UUID       `db:"vendor_id" json:"-"`
+	UserName       string          `db:"user_name" json:"user_name"`
+	ItemNames      pq.StringArray  `db:"item_names" json:"item_names"`
+	ItemPrices     pq.Float64Array `db:"item_prices" json:"item_prices"`
+	ItemQuantities pq.Int64Array   `db:"item_quantities" json:"item_quantities"`
+	Status         string          `db:"status" json:"status"`
+	TableID        uuid.UUID       `db:"table_id" json:"table_id"`
+	TableName      string          `db:"table_name" json:"table_name"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
 }
 
 // Order represents an order.
 type Order struct {
-	ID             uuid.UUID `db:"id" json:"id"`
-	TotalOrderCost float64   `db:"total_order_cost" json:"total_order_cost"`
-	CustomerID     uuid.UUID `db:"customer_id" json:"customer_id"`
-	VendorID       uuid.UUID `db:"vendor_id" json:"vendor_id"`
-	Status         string    `db:"status" json:"status"`
-	CreatedAt      time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+	ID             uuid.UUID    `db:"id" json:"id"`
+	TotalOrderCost float64      `db:"total_order_cost" json:"total_order_cost"`
+	CustomerID     uuid.UUID    `db:"customer_id" json:"customer_id"`
+	VendorID       uuid.UUID    `db:"vendor_id" json:"vendor_id"`
+	Status         string       `db:"status" json:"status"`
+	CreatedAt      time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time    `db:"updated_at" json:"updated_at"`
+	Items          []OrderItem  `db:"-" json:"items,omitempty"`
+	Customer       *UserSummary `db:"-" json:"customer,omitempty"`
 }
 
 type OrderDB struct {
 	db *sqlx.DB
 }
 
-func ValidatingOrder(v *validator.Validator, order *Order, fields ...string) {
-	for _, field := range fields {
-		switch field {
-		case "customer_id":
-			v.Check(order.CustomerID != uuid.Nil, "customer_id", "Customer ID is required")
-		case "vendor_id":
-			v.Check(order.VendorID != uuid.Nil, "vendor_id", "Vendor ID is required")
-		case "status":
-			v.Check(order.Status != "", "status", "Order status is required")
-			v.Check(order.Status == "completed " || order.Status == "preparing", "status", "Order must be of 2 status")
+// OrderStatuses are the statuses an order moves through.
+var OrderStatuses = []string{"preparing", "completed"}
 
-		case "total_order_cost":
-			v.Check(order.TotalOrderCost >= 0, "total_order_cost", "Total order cost must be a non-negative number")
-		}
-	}
+func ValidatingOrder(v *validator.Validator, order *Order) {
+	v.Validate(
+		validator.UUID("customer_id", order.CustomerID).Required(i18n.CustomerIDRequired),
+		validator.UUID("vendor_id", order.VendorID).Required(i18n.VendorIDRequired),
+		validator.String("status", order.Status).Required(i18n.OrderStatusRequired).OneOf(i18n.OrderStatusInvalid, OrderStatuses...),
+		validator.Number("total_order_cost", order.TotalOrderCost).Min(0, i18n.TotalCostNegative),
+	)
+}
+
+// CustomerOrderSorts are the orders a customer's own orders can be read in; latest first by
+// default.
+var CustomerOrderSorts = pagination.Sorts{
+	Default: "latest",
+	Orders: map[string]pagination.Order{
+		"latest": {pagination.Desc("o.created_at"), pagination.Desc("o.id")},
+		"oldest": {pagination.Asc("o.created_at"), pagination.Asc("o.id")},
+	},
 }
-func (o *OrderDB) GetOrders(customerID uuid.UUID, tableID uuid.UUID) ([]OrderDetails, error) {
-	query, args, err := QB.Select(
+
+// GetOrders lists one page of the customer's orders at the table, only those from vendorID
+// when it is set, with vendor and item names in lang. Names without a translation, or every
+// name when lang is empty, are the stored ones. page must be read with CustomerOrderSorts.
+func (o *OrderDB) GetOrders(ctx context.Context, customerID, tableID uuid.UUID, vendorID *uuid.UUID, lang string, page pagination.Params) ([]OrderDetails, pagination.Metadata, error) {
+	conditions := squirrel.And{squirrel.Eq{"o.customer_id": customerID, "t.id": tableID}}
+	if vendorID != nil {
+		conditions = append(conditions, squirrel.Eq{"o.vendor_id": *vendorID})
+	}
+
+	list := QB.Select(
 		"o.id",
 		"o.total_order_cost",
-		"v.name AS vendor_name",
+		"COALESCE(vt.name, v.name) AS vendor_name",
 		"v.id AS vendor_id",
 		"c.name AS user_name",
-		"array_agg(i.name) AS item_names",
-		"array_agg(i.price::text) AS item_prices",
-		"array_agg(oi.quantity) AS item_quantities", // Aggregate item quantities
+		"array_agg(COALESCE(it.name, i.name)) AS item_names",
+		"array_agg(i.price) AS item_prices",
+		"array_agg(oi.quantity) AS item_quantities",
 		"o.status",
 		"t.id AS table_id",
 		"t.name AS table_name",
+		"o.created_at",
 	).
 		From("orders o").
 		Join("vendors v ON o.vendor_id = v.id").
@@ -78,67 +100,21 @@ func (o *OrderDB) GetOrders(customerID uuid.UUID, tableID uuid.UUID) ([]OrderDet
 		Join("order_items oi ON o.id = oi.order_id").
 		Join("items i ON oi.item_id = i.id").
 		Join("tables t ON o.customer_id = t.customer_id").
-		Where(squirrel.Eq{"o.customer_id": customerID, "t.id": tableID}).
-		GroupBy("o.id, o.total_order_cost, v.name, v.id, c.name, o.status, t.id, t.name").
-		ToSql()
-	if err != nil {
-		return nil, err
-	}
-
-	rows, err := o.db.Queryx(query, args...)
+		LeftJoin("vendor_translations vt ON vt.vendor_id = v.id AND vt.lang = ?", lang).
+		LeftJoin("item_translations it ON it.item_id = i.id AND it.lang = ?", lang).
+		Where(conditions).
+		GroupBy("o.id, o.total_order_cost, v.name, vt.name, v.id, c.name, o.status, t.id, t.name, o.created_at")
+	count := QB.Select("COUNT(DISTINCT o.id)").
+		From("orders o").
+		Join("order_items oi ON o.id = oi.order_id").
+		Join("items i ON oi.item_id = i.id").
+		Join("tables t ON o.customer_id = t.customer_id").
+		Where(conditions)
+	orders, meta, err := selectPage[OrderDetails](ctx, o.db, list, count, page)
 	if err != nil {
-		return nil, err
+		return nil, pagination.Metadata{}, fmt.Errorf("error while listing orders: %v", err)
 	}
-	defer rows.Close()
-
-	var orders []OrderDetails
-	for rows.Next() {
-		var order OrderDetails
-		var itemPricesStr []string
-		var itemQuantitiesStr []string
-
-		err := rows.Scan(
-			&order.ID,
-			&order.TotalOrderCost,
-			&order.VendorName,
-			&order.VendorID,
-			&order.UserName,
-			pq.Array(&order.ItemNames),
-			pq.Array(&itemPricesStr),
-			pq.Array(&itemQuantitiesStr), // Scan item quantities
-			&order.Status,
-			&order.TableID,
-			&order.TableName,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		// Convert item prices from strings to floats
-		var itemPrices []float64
-		for _, priceStr := range itemPricesStr {
-			price, err := strconv.ParseFloat(priceStr, 64)
-			if err != nil {
-				return nil, err
-			}
-			itemPrices = append(itemPrices, price)
-		}
-		order.ItemPrices = itemPrices
-
-		// Convert item quantities from strings to ints
-		var itemQuantities []int
-		for _, quantityStr := range itemQuantitiesStr {
-			quantity, err := strconv.Atoi(quantityStr)
-			if err != nil {
-				return nil, err
-			}
-			itemQuantities = append(itemQuantities, quantity)
-		}
-		order.ItemQuantities = itemQuantities
-
-		orders = append(orders, order)
-	}
-	return orders, nil
+	return orders, meta, nil
 }
 
 func (o *OrderDB) InsertOrder(order *Order) error {
@@ -169,31 +145,38 @@ func (o *OrderDB) DeleteOrder(orderID uuid.UUID) error {
 	}
 	return nil
 }
-func (o *OrderDB) GetVendorOrders(vendorID uuid.UUID) ([]Order, error) {
-	query, args, err := QB.Select(strings.Join(ordersColumns, ",")).
-		From("orders").
-		Where(squirrel.Eq{"vendor_id": vendorID}).
-		OrderBy("created_at ASC").
-		ToSql()
-	if err != nil {
-		return nil, err
-	}
-	rows, err := o.db.Queryx(query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
 
-	var orders []Order
-	for rows.Next() {
-		var order Order
-		err := rows.StructScan(&order)
-		if err != nil {
-			return nil, err
-		}
-		orders = append(orders, order)
-	}
-	return orders, nil
+// VendorOrderSorts are the orders a vendor's order list can be read in; oldest first by
+// default, so the kitchen works through them in turn.
+var VendorOrderSorts = pagination.Sorts{
+	Default: "oldest",
+	Orders: map[string]pagination.Order{
+		"oldest": {pagination.Asc("created_at"), pagination.Asc("id")},
+		"latest": {pagination.Desc("created_at"), pagination.Desc("id")},
+	},
+	Fields: map[string]pagination.Column{
+		"created_at":       pagination.Asc("created_at"),
+		"total_order_cost": pagination.Asc("total_order_cost"),
+		"status":           pagination.Asc("status"),
+	},
+	Tie: pagination.Asc("id"),
+}
+
+// VendorOrderFilters are the fields a vendor's orders can be filtered on, such as
+// status=preparing.
+var VendorOrderFilters = filter.Fields{
+	"status":           filter.OneOf("status", OrderStatuses...),
+	"total_order_cost": filter.Number("total_order_cost"),
+	"customer_id":      filter.UUID("customer_id"),
+	"created_at":       filter.Time("created_at"),
+}
+
+// GetVendorOrders lists one page of a vendor's orders that match the filters' conditions.
+func (o *OrderDB) GetVendorOrders(ctx context.Context, vendorID uuid.UUID, filters utils.Filters, page pagination.Params) ([]Order, pagination.Metadata, error) {
+	conditions := append(squirrel.And{squirrel.Eq{"vendor_id": vendorID}}, filters.Conditions...)
+	list := QB.Select(ordersColumns...).From("orders").Where(conditions)
+	count := QB.Select("COUNT(*)").From("orders").Where(conditions)
+	return selectPage[Order](ctx, o.db, list, count, page)
 }
 
 // UpdateOrder updates the order status to "completed".
@@ -204,6 +187,7 @@ func (o *OrderDB) UpdateOrder(orderID uuid.UUID, status string) error {
 
 	query, args, err := QB.Update("orders").
 		Set("status", status).
+		Set("updated_at", time.Now()).
 		Where(squirrel.Eq{"id": orderID}).
 		ToSql()
 	if err != nil {
@@ -217,31 +201,20 @@ func (o *OrderDB) UpdateOrder(orderID uuid.UUID, status string) error {
 	return nil
 }
 
-// DeleteCompletedOrder deletes the order if its status is "completed".
-func (o *OrderDB) DeleteCompletedOrder(orderID uuid.UUID) error {
-	// Get the order by ID
-	order, err := o.GetOrder(orderID)
-	if err != nil {
-		return fmt.Errorf("error while getting order: %v", err)
-	}
-
-	// Check if the order is completed
-	if order.Status != "completed" {
-		return fmt.Errorf("order is not completed")
-	}
-
-	// Delete the order
+// DeleteCompletedOrdersBefore removes orders that were completed before the given time.
+func (o *OrderDB) DeleteCompletedOrdersBefore(ctx context.Context, before time.Time) (int64, error) {
 	query, args, err := QB.Delete("orders").
-		Where(squirrel.Eq{"id": orderID}).
+		Where(squirrel.Eq{"status": "completed"}).
+		Where(squirrel.Lt{"updated_at": before}).
 		ToSql()
 	if err != nil {
-		return err
+		return 0, err
 	}
-	_, err = o.db.Exec(query, args...)
+	result, err := o.db.ExecContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("error while deleting order: %v", err)
+		return 0, fmt.Errorf("error while deleting completed orders: %v", err)
 	}
-	return nil
+	return result.RowsAffected()
 }
 
 func (o *OrderDB) GetOrder(orderID uuid.UUID) (*Order, error) {
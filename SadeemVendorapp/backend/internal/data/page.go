@@ -0,0 +1,34 @@
+package data
+
+import (
+	"context"
+	"project/utils/pagination"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+// selectPage reads one page of list and counts every row of count, which must select
+// COUNT(*) under the same conditions.
+func selectPage[T any](ctx context.Context, db sqlx.QueryerContext, list, count squirrel.SelectBuilder, page pagination.Params) ([]T, pagination.Metadata, error) {
+	query, args, err := page.Apply(list).ToSql()
+	if err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+	rows := []T{}
+	if err = sqlx.SelectContext(ctx, db, &rows, query, args...); err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+
+	var total int
+	query, args, err = count.ToSql()
+	if err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+	if err = sqlx.GetContext(ctx, db, &total, query, args...); err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+
+	rows, meta := pagination.Page(rows, page, total)
+	return rows, meta, nil
+}
@@ -0,0 +1,351 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"project/utils/i18n"
+	"project/utils/pagination"
+	"project/utils/validator"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Plan is a subscription tier. A nil limit means unlimited.
+type Plan struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	MaxTables   *int      `db:"max_tables" json:"max_tables"`
+	MaxItems    *int      `db:"max_items" json:"max_items"`
+	MaxStaff    *int      `db:"max_staff" json:"max_staff"`
+	MaxAPIKeys  *int      `db:"max_api_keys" json:"max_api_keys"`
+	Price       float64   `db:"price" json:"price"`
+	BillingDays int       `db:"billing_days" json:"billing_days"`
+	IsDefault   bool      `db:"is_default" json:"is_default"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// PlanUsage is a vendor's effective plan next to what it currently uses.
+type PlanUsage struct {
+	Plan            *Plan     `json:"plan"`
+	SubscriptionEnd time.Time `json:"subscription_end"`
+	Expired         bool      `json:"expired"`
+	Tables          int       `json:"tables"`
+	Items           int       `json:"items"`
+	Staff           int       `json:"staff"`
+}
+
+// Limited resources and the plan column that caps each of them. API keys have a limit
+// on the plan but no resource yet, so nothing counts against it.
+const (
+	PlanResourceTables = "tables"
+	PlanResourceItems  = "items"
+	PlanResourceStaff  = "staff"
+)
+
+var planLimits = map[string]struct {
+	column string
+	table  string
+	code   i18n.Code
+}{
+	PlanResourceTables: {"max_tables", "tables", i18n.PlanLimitTables},
+	PlanResourceItems:  {"max_items", "items", i18n.PlanLimitItems},
+	PlanResourceStaff:  {"max_staff", "vendor_admins", i18n.PlanLimitStaff},
+}
+
+type PlanDB struct {
+	db *sqlx.DB
+}
+
+func ValidatingPlan(v *validator.Validator, plan *Plan) {
+	v.Validate(
+		validator.String("name", plan.Name).Required(i18n.NameRequired).MaxLength(50, i18n.NameTooLong),
+		validator.OptionalNumber("max_tables", plan.MaxTables).Min(0, i18n.LimitNegative),
+		validator.OptionalNumber("max_items", plan.MaxItems).Min(0, i18n.LimitNegative),
+		validator.OptionalNumber("max_staff", plan.MaxStaff).Min(0, i18n.LimitNegative),
+		validator.OptionalNumber("max_api_keys", plan.MaxAPIKeys).Min(0, i18n.LimitNegative),
+		validator.Number("price", plan.Price).Min(0, i18n.PriceNegative),
+		validator.Number("billing_days", plan.BillingDays).Required(i18n.DaysNotPositive).Above(0, i18n.DaysNotPositive).Max(1000, i18n.DaysTooMany),
+	)
+}
+
+func (p *PlanDB) InsertPlan(ctx context.Context, plan *Plan) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if plan.IsDefault {
+		if err = clearDefaultPlan(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	query, args, err := QB.Insert("plans").
+		Columns("name", "max_tables", "max_items", "max_staff", "max_api_keys", "price", "billing_days", "is_default").
+		Values(plan.Name, plan.MaxTables, plan.MaxItems, plan.MaxStaff, plan.MaxAPIKeys, plan.Price, plan.BillingDays, plan.IsDefault).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(planColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(plan)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrDuplicatedPlan
+		}
+		return fmt.Errorf("error while inserting plan: %v", err)
+	}
+	return tx.Commit()
+}
+
+// PlanSorts are the orders the plan list can be read in.
+var PlanSorts = pagination.Sorts{
+	Default: "price",
+	Orders: map[string]pagination.Order{
+		"price": {pagination.Asc("price"), pagination.Asc("name"), pagination.Asc("id")},
+		"name":  {pagination.Asc("name"), pagination.Asc("id")},
+	},
+}
+
+func (p *PlanDB) GetPlans(ctx context.Context, page pagination.Params) ([]Plan, pagination.Metadata, error) {
+	list := QB.Select(planColumns...).From("plans")
+	count := QB.Select("COUNT(*)").From("plans")
+	return selectPage[Plan](ctx, p.db, list, count, page)
+}
+
+func (p *PlanDB) GetPlan(ctx context.Context, id uuid.UUID) (*Plan, error) {
+	var plan Plan
+	query, args, err := QB.Select(planColumns...).From("plans").Where(squirrel.Eq{"id": id}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = p.db.GetContext(ctx, &plan, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// UpdatePlan changes a plan's limits. Lowering a limit doesn't remove anything a vendor
+// already has; it only stops them from adding more.
+func (p *PlanDB) UpdatePlan(ctx context.Context, plan *Plan) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if plan.IsDefault {
+		if err = clearDefaultPlan(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	query, args, err := QB.Update("plans").
+		Set("name", plan.Name).
+		Set("max_tables", plan.MaxTables).
+		Set("max_items", plan.MaxItems).
+		Set("max_staff", plan.MaxStaff).
+		Set("max_api_keys", plan.MaxAPIKeys).
+		Set("price", plan.Price).
+		Set("billing_days", plan.BillingDays).
+		// The default can only move by marking another plan as default
+		Set("is_default", squirrel.Expr("is_default OR ?", plan.IsDefault)).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": plan.ID}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(planColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(plan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrRecordNotFound
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrDuplicatedPlan
+		}
+		return fmt.Errorf("error while updating plan: %v", err)
+	}
+	return tx.Commit()
+}
+
+// DeletePlan removes a plan; its vendors fall back to the default plan. The default plan itself can't be deleted.
+func (p *PlanDB) DeletePlan(ctx context.Context, id uuid.UUID) error {
+	query, args, err := QB.Delete("plans").
+		Where(squirrel.Eq{"id": id, "is_default": false}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := p.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// AssignPlan moves a vendor to a plan and extends its subscription by days, counted from
+// subscription_end when it is still running and from now otherwise.
+func (p *PlanDB) AssignPlan(ctx context.Context, vendorID, planID uuid.UUID, days int) (*Vendor, error) {
+	var vendor Vendor
+	query, args, err := QB.Update("vendors").
+		Set("plan_id", planID).
+		Set("subscription_days", days).
+		Set("subscription_end", squirrel.Expr("GREATEST(subscription_end, CURRENT_TIMESTAMP) + make_interval(days => ?)", days)).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": vendorID}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(vendors_columns, ","))).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = p.db.QueryRowxContext(ctx, query, args...).StructScan(&vendor)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("error while assigning plan: %v", err)
+	}
+	return &vendor, nil
+}
+
+// GetVendorUsage returns the vendor's effective plan and how much of it is in use.
+func (p *PlanDB) GetVendorUsage(ctx context.Context, vendorID uuid.UUID) (*PlanUsage, error) {
+	var row struct {
+		SubscriptionEnd time.Time `db:"subscription_end"`
+		Expired         bool      `db:"expired"`
+		Tables          int       `db:"tables"`
+		Items           int       `db:"items"`
+		Staff           int       `db:"staff"`
+	}
+	query, args, err := QB.Select(
+		"v.subscription_end",
+		"v.subscription_end + make_interval(days => v.grace_days) < CURRENT_TIMESTAMP AS expired",
+		"(SELECT COUNT(*) FROM tables WHERE vendor_id = v.id) AS tables",
+		"(SELECT COUNT(*) FROM items WHERE vendor_id = v.id) AS items",
+		"(SELECT COUNT(*) FROM vendor_admins WHERE vendor_id = v.id) AS staff",
+	).From("vendors v").Where(squirrel.Eq{"v.id": vendorID}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = p.db.GetContext(ctx, &row, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	usage := &PlanUsage{
+		SubscriptionEnd: row.SubscriptionEnd,
+		Expired:         row.Expired,
+		Tables:          row.Tables,
+		Items:           row.Items,
+		Staff:           row.Staff,
+	}
+	var plan Plan
+	query, args, err = QB.Select(prefixColumns("p", planColumns)...).
+		From("plans p").
+		Where(effectivePlanCondition, vendorID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = p.db.GetContext(ctx, &plan, query, args...)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		usage.Plan = &plan
+	}
+	return usage, nil
+}
+
+// effectivePlanCondition picks the vendor's own plan while its subscription or grace period runs
+// and the default plan otherwise.
+const effectivePlanCondition = `p.id = (
+	SELECT CASE WHEN v.plan_id IS NOT NULL AND v.subscription_end + make_interval(days => v.grace_days) >= CURRENT_TIMESTAMP
+		THEN v.plan_id ELSE (SELECT id FROM plans WHERE is_default) END
+	FROM vendors v WHERE v.id = ?)`
+
+// checkPlanLimit locks the vendor row and fails with ErrPlanLimitReached when adding one more
+// resource would go over the vendor's plan. Concurrent inserts for the same vendor wait on the
+// lock, so the count can't change between the check and the insert that follows it in tx.
+func checkPlanLimit(ctx context.Context, tx *sqlx.Tx, vendorID uuid.UUID, resource string) error {
+	limit, ok := planLimits[resource]
+	if !ok {
+		return fmt.Errorf("unknown plan resource %q", resource)
+	}
+
+	var locked uuid.UUID
+	query, args, err := QB.Select("id").From("vendors").Where(squirrel.Eq{"id": vendorID}).Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return err
+	}
+	err = tx.GetContext(ctx, &locked, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrForeignKeyViolation
+		}
+		return err
+	}
+
+	var max sql.NullInt64
+	query, args, err = QB.Select("p."+limit.column).From("plans p").Where(effectivePlanCondition, vendorID).ToSql()
+	if err != nil {
+		return err
+	}
+	err = tx.GetContext(ctx, &max, query, args...)
+	if err == sql.ErrNoRows || (err == nil && !max.Valid) {
+		// No default plan or an unlimited plan
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	query, args, err = QB.Select("COUNT(*)").From(limit.table).Where(squirrel.Eq{"vendor_id": vendorID}).ToSql()
+	if err != nil {
+		return err
+	}
+	if err = tx.GetContext(ctx, &count, query, args...); err != nil {
+		return err
+	}
+	if count >= max.Int64 {
+		return ErrPlanLimitReached.With(limit.code, max.Int64)
+	}
+	return nil
+}
+
+func clearDefaultPlan(ctx context.Context, tx *sqlx.Tx) error {
+	query, args, err := QB.Update("plans").Set("is_default", false).Where(squirrel.Eq{"is_default": true}).ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	return err
+}
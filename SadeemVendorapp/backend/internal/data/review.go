@@ -0,0 +1,381 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"project/utils/i18n"
+	"project/utils/pagination"
+	"project/utils/validator"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Review is a customer's rating of a completed order. Hidden reviews are left out of the
+// public list and of the vendor and item ratings; flagged ones stay visible until an admin decides.
+type Review struct {
+	ID               uuid.UUID    `db:"id" json:"id"`
+	OrderID          *uuid.UUID   `db:"order_id" json:"order_id"`
+	VendorID         uuid.UUID    `db:"vendor_id" json:"vendor_id"`
+	CustomerID       uuid.UUID    `db:"customer_id" json:"customer_id"`
+	Rating           int          `db:"rating" json:"rating"`
+	Comment          *string      `db:"comment" json:"comment"`
+	Reply            *string      `db:"reply" json:"reply"`
+	RepliedBy        *uuid.UUID   `db:"replied_by" json:"-"`
+	RepliedAt        *time.Time   `db:"replied_at" json:"replied_at"`
+	Status           string       `db:"status" json:"status"`
+	ModerationReason *string      `db:"moderation_reason" json:"moderation_reason,omitempty"`
+	ModeratedBy      *uuid.UUID   `db:"moderated_by" json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time   `db:"moderated_at" json:"moderated_at,omitempty"`
+	CreatedAt        time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time    `db:"updated_at" json:"updated_at"`
+	Items            []ReviewItem `db:"-" json:"items"`
+}
+
+type ReviewItem struct {
+	ReviewID uuid.UUID `db:"review_id" json:"-"`
+	ItemID   uuid.UUID `db:"item_id" json:"item_id"`
+	Name     string    `db:"name" json:"name"`
+	Rating   int       `db:"rating" json:"rating"`
+}
+
+// ReviewInput is the body a customer sends to review an order.
+type ReviewInput struct {
+	Rating  int     `json:"rating"`
+	Comment *string `json:"comment"`
+	Items   []struct {
+		ItemID uuid.UUID `json:"item_id"`
+		Rating int       `json:"rating"`
+	} `json:"items"`
+}
+
+// Moderation actions and the status each one leaves the review in.
+var ReviewModerationStatus = map[string]string{
+	"hide":    "hidden",
+	"flag":    "flagged",
+	"restore": "visible",
+}
+
+type ReviewDB struct {
+	db *sqlx.DB
+}
+
+func ValidatingReview(v *validator.Validator, input *ReviewInput) {
+	v.Check(input.Rating >= 1 && input.Rating <= 5, "rating", i18n.RatingOutOfRange)
+	if input.Comment != nil {
+		v.Check(len(*input.Comment) <= 1000, "comment", i18n.CommentTooLong, 1000)
+	}
+	ids := make([]string, len(input.Items))
+	for i, item := range input.Items {
+		v.Check(item.Rating >= 1 && item.Rating <= 5, "items", i18n.ItemRatingOutOfRange)
+		ids[i] = item.ItemID.String()
+	}
+	v.Check(validator.Unique(ids), "items", i18n.ItemRatedTwice)
+}
+
+// InsertReview stores the review of a completed order. Only the customer who placed the order
+// may review it, once, and only items that were part of it can be rated.
+func (r *ReviewDB) InsertReview(ctx context.Context, customerID, orderID uuid.UUID, input *ReviewInput) (*Review, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var order Order
+	query, args, err := QB.Select(ordersColumns...).From("orders").
+		Where(squirrel.Eq{"id": orderID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.GetContext(ctx, &order, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	if order.CustomerID != customerID {
+		return nil, ErrNotPurchaser
+	}
+	if order.Status != "completed" {
+		return nil, ErrOrderNotCompleted
+	}
+
+	itemIDs := make([]uuid.UUID, len(input.Items))
+	for i, item := range input.Items {
+		itemIDs[i] = item.ItemID
+	}
+	if len(itemIDs) > 0 {
+		var ordered int
+		query, args, err = QB.Select("COUNT(DISTINCT item_id)").From("order_items").
+			Where(squirrel.Eq{"order_id": orderID, "item_id": itemIDs}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err = tx.GetContext(ctx, &ordered, query, args...); err != nil {
+			return nil, err
+		}
+		if ordered != len(itemIDs) {
+			return nil, ErrItemNotInOrder
+		}
+	}
+
+	var review Review
+	query, args, err = QB.Insert("reviews").
+		Columns("order_id", "vendor_id", "customer_id", "rating", "comment").
+		Values(orderID, order.VendorID, customerID, input.Rating, input.Comment).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(reviewColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&review); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil, ErrAlreadyReviewed
+		}
+		return nil, fmt.Errorf("error while inserting review: %v", err)
+	}
+
+	if len(input.Items) > 0 {
+		insert := QB.Insert("review_items").Columns("review_id", "item_id", "rating")
+		for _, item := range input.Items {
+			insert = insert.Values(review.ID, item.ItemID, item.Rating)
+		}
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return nil, fmt.Errorf("error while inserting review items: %v", err)
+		}
+	}
+
+	if err = refreshRatings(ctx, tx, review.VendorID, itemIDs); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err = r.loadReviewItems(ctx, []*Review{&review}); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// ReviewSorts are the orders reviews can be read in.
+var ReviewSorts = pagination.Sorts{
+	Default: "latest",
+	Orders: map[string]pagination.Order{
+		"latest":  {pagination.Desc("created_at"), pagination.Asc("id")},
+		"oldest":  {pagination.Asc("created_at"), pagination.Asc("id")},
+		"highest": {pagination.Desc("rating"), pagination.Desc("created_at"), pagination.Asc("id")},
+		"lowest":  {pagination.Asc("rating"), pagination.Desc("created_at"), pagination.Asc("id")},
+	},
+}
+
+// GetReviews lists one page of reviews. A nil vendorID lists every vendor's reviews and an
+// empty statuses list means any status.
+func (r *ReviewDB) GetReviews(ctx context.Context, vendorID *uuid.UUID, statuses []string, page pagination.Params) ([]Review, pagination.Metadata, error) {
+	conditions := squirrel.And{}
+	if vendorID != nil {
+		conditions = append(conditions, squirrel.Eq{"vendor_id": *vendorID})
+	}
+	if len(statuses) > 0 {
+		conditions = append(conditions, squirrel.Eq{"status": statuses})
+	}
+
+	list := QB.Select(reviewColumns...).From("reviews").Where(conditions)
+	count := QB.Select("COUNT(*)").From("reviews").Where(conditions)
+	reviews, meta, err := selectPage[Review](ctx, r.db, list, count, page)
+	if err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+
+	refs := make([]*Review, len(reviews))
+	for i := range reviews {
+		refs[i] = &reviews[i]
+	}
+	if err = r.loadReviewItems(ctx, refs); err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+	return reviews, meta, nil
+}
+
+// loadReviewItems fills in the item ratings of the given reviews with one query.
+func (r *ReviewDB) loadReviewItems(ctx context.Context, reviews []*Review) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+	byID := make(map[uuid.UUID]*Review, len(reviews))
+	ids := make([]uuid.UUID, len(reviews))
+	for i, review := range reviews {
+		review.Items = []ReviewItem{}
+		byID[review.ID] = review
+		ids[i] = review.ID
+	}
+
+	var items []ReviewItem
+	query, args, err := QB.Select("ri.review_id", "ri.item_id", "i.name", "ri.rating").
+		From("review_items ri").
+		Join("items i ON i.id = ri.item_id").
+		Where(squirrel.Eq{"ri.review_id": ids}).
+		OrderBy("i.name").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err = r.db.SelectContext(ctx, &items, query, args...); err != nil {
+		return err
+	}
+	for _, item := range items {
+		byID[item.ReviewID].Items = append(byID[item.ReviewID].Items, item)
+	}
+	return nil
+}
+
+// Reply stores the vendor's answer to a review. A review can only be answered once.
+func (r *ReviewDB) Reply(ctx context.Context, vendorID, reviewID, userID uuid.UUID, reply string) (*Review, error) {
+	var review Review
+	query, args, err := QB.Update("reviews").
+		Set("reply", reply).
+		Set("replied_by", userID).
+		Set("replied_at", time.Now()).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": reviewID, "vendor_id": vendorID, "reply": nil}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(reviewColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&review)
+	if err == sql.ErrNoRows {
+		var exists bool
+		query, args, err = QB.Select("1").From("reviews").
+			Where(squirrel.Eq{"id": reviewID, "vendor_id": vendorID}).
+			Prefix("SELECT EXISTS (").
+			Suffix(")").
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err = r.db.GetContext(ctx, &exists, query, args...); err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrAlreadyReplied
+		}
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while replying to review: %v", err)
+	}
+
+	if err = r.loadReviewItems(ctx, []*Review{&review}); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// Moderate hides, flags or restores a review and recomputes the ratings it counts towards.
+func (r *ReviewDB) Moderate(ctx context.Context, reviewID, adminID uuid.UUID, action string, reason *string) (*Review, error) {
+	status, ok := ReviewModerationStatus[action]
+	if !ok {
+		return nil, fmt.Errorf("unknown moderation action %q", action)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var review Review
+	query, args, err := QB.Update("reviews").
+		Set("status", status).
+		Set("moderation_reason", reason).
+		Set("moderated_by", adminID).
+		Set("moderated_at", time.Now()).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": reviewID}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(reviewColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.QueryRowxContext(ctx, query, args...).StructScan(&review); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("error while moderating review: %v", err)
+	}
+
+	var itemIDs []uuid.UUID
+	query, args, err = QB.Select("item_id").From("review_items").Where(squirrel.Eq{"review_id": reviewID}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.SelectContext(ctx, &itemIDs, query, args...); err != nil {
+		return nil, err
+	}
+	if err = refreshRatings(ctx, tx, review.VendorID, itemIDs); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err = r.loadReviewItems(ctx, []*Review{&review}); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// refreshRatings recomputes the stored rating aggregates of a vendor and some of its items,
+// so listings can sort by rating without scanning reviews.
+func refreshRatings(ctx context.Context, tx *sqlx.Tx, vendorID uuid.UUID, itemIDs []uuid.UUID) error {
+	query, args, err := QB.Update("vendors v").
+		Set("rating_avg", squirrel.Expr("COALESCE(s.avg, 0)")).
+		Set("rating_count", squirrel.Expr("s.count")).
+		FromSelect(QB.Select("ROUND(AVG(rating), 2) AS avg", "COUNT(*) AS count").
+			From("reviews").
+			Where(squirrel.Eq{"vendor_id": vendorID}).
+			Where("status <> 'hidden'"), "s").
+		Where(squirrel.Eq{"v.id": vendorID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("error while updating vendor rating: %v", err)
+	}
+
+	if len(itemIDs) == 0 {
+		return nil
+	}
+	query, args, err = QB.Update("items i").
+		Set("rating_avg", squirrel.Expr("COALESCE(s.avg, 0)")).
+		Set("rating_count", squirrel.Expr("COALESCE(s.count, 0)")).
+		FromSelect(QB.Select("x.id", "ROUND(AVG(ri.rating), 2) AS avg", "COUNT(ri.rating) AS count").
+			From("items x").
+			LeftJoin("(review_items ri JOIN reviews r ON r.id = ri.review_id AND r.status <> 'hidden') ON ri.item_id = x.id").
+			Where(squirrel.Eq{"x.id": itemIDs}).
+			GroupBy("x.id"), "s").
+		Where("i.id = s.id").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("error while updating item ratings: %v", err)
+	}
+	return nil
+}
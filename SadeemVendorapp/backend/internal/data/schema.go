@@ -0,0 +1,157 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// tableModel ties a table to the columns this package selects from it and the struct they
+// are scanned into. computed names struct fields filled by expressions, not columns.
+type tableModel struct {
+	table    string
+	columns  []string
+	row      interface{}
+	computed []string
+}
+
+var tableModels = []tableModel{
+	{table: "users", columns: user_columns, row: User{}},
+	{table: "vendors", columns: vendors_columns, row: Vendor{}, computed: []string{"distance_km"}},
+	{table: "user_roles", columns: user_roles, row: User_role{}},
+	{table: "vendor_admins", row: VendorAdmin{}},
+	{table: "table_sessions", columns: tableSessionColumns, row: TableSession{}},
+	{table: "floor_areas", columns: floorAreaColumns, row: FloorArea{}},
+	{table: "subscription_invoices", columns: invoiceColumns, row: Invoice{}},
+	{table: "subscription_history", columns: subscriptionHistoryColumns, row: SubscriptionEvent{}},
+	{table: "vendor_opening_hours", columns: openingIntervalColumns, row: OpeningInterval{}},
+	{table: "vendor_hour_exceptions", columns: hourExceptionColumns, row: HourException{}},
+	{table: "tags", columns: tagColumns, row: Tag{}},
+	{table: "reviews", columns: reviewColumns, row: Review{}},
+	{table: "vendor_translations", columns: vendorTranslationColumns, row: VendorTranslation{}},
+	{table: "item_translations", columns: itemTranslationColumns, row: ItemTranslation{}},
+	{table: "tag_translations", columns: tagTranslationColumns, row: TagTranslation{}},
+	{table: "job_runs", columns: jobRunColumns, row: JobRun{}},
+	{table: "plans", columns: planColumns, row: Plan{}},
+	{table: "service_requests", columns: serviceRequestColumns, row: ServiceRequest{}},
+	{table: "tables", columns: tableColumns, row: Table{}},
+	{table: "cart_items", columns: cartItemsColumns, row: CartItem{}},
+	{table: "carts", columns: cartsColumns, row: Cart{}},
+	{table: "order_items", columns: orderItemsColumns, row: OrderItem{}},
+	{table: "orders", columns: ordersColumns, row: Order{}},
+	{table: "items", columns: itemsColumns, row: Item{}},
+}
+
+// schemaQueryer is what VerifySchema reads the schema through: a *sql.DB, *sql.Tx or *sqlx.DB.
+type schemaQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// VerifySchema compares the tables of the current schema with the column lists and struct
+// tags of this package and describes every mismatch, none meaning the two agree.
+func VerifySchema(ctx context.Context, db schemaQueryer) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT table_name, column_name, is_nullable = 'YES'
+		FROM information_schema.columns WHERE table_schema = current_schema()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// schema maps each table to its columns and whether they are nullable
+	schema := map[string]map[string]bool{}
+	for rows.Next() {
+		var table, column string
+		var nullable bool
+		if err = rows.Scan(&table, &column, &nullable); err != nil {
+			return nil, err
+		}
+		if schema[table] == nil {
+			schema[table] = map[string]bool{}
+		}
+		schema[table][column] = nullable
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return schemaDrift(schema), nil
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// schemaDrift lists where schema disagrees with tableModels: missing tables, selected columns
+// the table or struct lacks, struct tags without a column, and nullable columns scanned
+// into fields that cannot hold NULL.
+func schemaDrift(schema map[string]map[string]bool) []string {
+	var drift []string
+	for _, model := range tableModels {
+		columns, ok := schema[model.table]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("table %s is missing", model.table))
+			continue
+		}
+		rowType := reflect.TypeOf(model.row)
+		fields := map[string]reflect.StructField{}
+		var tags []string
+		for i := 0; i < rowType.NumField(); i++ {
+			field := rowType.Field(i)
+			tag, _, _ := strings.Cut(field.Tag.Get("db"), ",")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			fields[tag] = field
+			tags = append(tags, tag)
+		}
+
+		selected := model.columns
+		if selected == nil {
+			selected = tags
+		}
+		reported := map[string]bool{}
+		for _, expr := range selected {
+			name := selectedName(expr)
+			reported[name] = true
+			nullable, exists := columns[name]
+			if !exists {
+				drift = append(drift, fmt.Sprintf("%s.%s is selected but the table has no such column", model.table, name))
+				continue
+			}
+			field, tagged := fields[name]
+			if !tagged {
+				drift = append(drift, fmt.Sprintf("%s.%s is selected but %s has no field tagged for it", model.table, name, rowType.Name()))
+				continue
+			}
+			if nullable && !holdsNull(field.Type) {
+				drift = append(drift, fmt.Sprintf("%s.%s is nullable but scanned into %s.%s of type %s",
+					model.table, name, rowType.Name(), field.Name, field.Type))
+			}
+		}
+		for _, tag := range tags {
+			if _, exists := columns[tag]; exists || reported[tag] || slices.Contains(model.computed, tag) {
+				continue
+			}
+			drift = append(drift, fmt.Sprintf("%s.%s is tagged on %s.%s but the table has no such column",
+				model.table, tag, rowType.Name(), fields[tag].Name))
+		}
+	}
+	return drift
+}
+
+// selectedName is the column a select expression yields: its alias, if it has one.
+func selectedName(expr string) string {
+	if i := strings.LastIndex(strings.ToUpper(expr), " AS "); i >= 0 {
+		return strings.TrimSpace(expr[i+4:])
+	}
+	return expr
+}
+
+// holdsNull reports whether a scan of NULL into t can succeed.
+func holdsNull(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	}
+	return reflect.PointerTo(t).Implements(scannerType)
+}
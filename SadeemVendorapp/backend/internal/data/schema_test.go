@@ -0,0 +1,76 @@
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// modelSchema is the schema tableModels expects, every column NOT NULL.
+func modelSchema() map[string]map[string]bool {
+	schema := map[string]map[string]bool{}
+	for _, model := range tableModels {
+		columns := map[string]bool{}
+		rowType := reflect.TypeOf(model.row)
+		for i := 0; i < rowType.NumField(); i++ {
+			tag, _, _ := strings.Cut(rowType.Field(i).Tag.Get("db"), ",")
+			if tag != "" && tag != "-" {
+				columns[tag] = false
+			}
+		}
+		for _, name := range model.computed {
+			delete(columns, name)
+		}
+		schema[model.table] = columns
+	}
+	return schema
+}
+
+func TestSchemaDrift(t *testing.T) {
+	if drift := schemaDrift(modelSchema()); len(drift) > 0 {
+		t.Fatalf("drift against the models' own schema: %v", drift)
+	}
+
+	schema := modelSchema()
+	delete(schema, "plans")
+	delete(schema["items"], "discount_expiry")
+	schema["items"]["discount_expires_at"] = true
+	schema["vendors"]["description"] = true
+	schema["vendors"]["img"] = true
+	schema["carts"]["vendor_id"] = true
+	delete(schema["vendor_admins"], "vendor_id")
+
+	want := []string{
+		"table plans is missing",
+		"items.discount_expiry is selected but the table has no such column",
+		"vendors.description is nullable but scanned into Vendor.Description of type string",
+		"vendor_admins.vendor_id is selected but the table has no such column",
+	}
+	drift := schemaDrift(schema)
+	for _, w := range want {
+		found := false
+		for _, d := range drift {
+			found = found || d == w
+		}
+		if !found {
+			t.Errorf("drift %q not reported", w)
+		}
+	}
+	// img is a pointer and uuid.UUID scans NULL, so neither is drift
+	if len(drift) != len(want) {
+		t.Errorf("drift = %q, want only %q", drift, want)
+	}
+}
+
+func TestSelectedName(t *testing.T) {
+	tests := map[string]string{
+		"id": "id",
+		"to_char(opens_at, 'HH24:MI') AS opens_at":                            "opens_at",
+		"CASE WHEN NULLIF(img, '') IS NOT NULL THEN img ELSE NULL END AS img": "img",
+	}
+	for expr, want := range tests {
+		if got := selectedName(expr); got != want {
+			t.Errorf("selectedName(%q) = %q, want %q", expr, got, want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"project/utils/pagination"
+	"strings"
+	"unicode"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// VendorSearchResult is a vendor matched by the unified search.
+type VendorSearchResult struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description *string   `db:"description" json:"description"`
+	Img         *string   `db:"img" json:"img"`
+	RatingAvg   float64   `db:"rating_avg" json:"rating_avg"`
+	Rank        float64   `db:"rank" json:"rank"`
+}
+
+// ItemSearchResult is a menu item matched by the unified search.
+type ItemSearchResult struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	VendorID   uuid.UUID `db:"vendor_id" json:"vendor_id"`
+	VendorName string    `db:"vendor_name" json:"vendor_name"`
+	Name       string    `db:"name" json:"name"`
+	Price      float64   `db:"price" json:"price"`
+	Img        *string   `db:"img" json:"img"`
+	Rank       float64   `db:"rank" json:"rank"`
+}
+
+// SearchQuery is a parsed search string.
+type SearchQuery struct {
+	// Raw is the trimmed text, used for the trigram match
+	Raw string
+	// TSQuery matches every word as a prefix, e.g. "chick:* & burg:*"
+	TSQuery string
+}
+
+// maxSearchWords keeps pathological queries cheap.
+const maxSearchWords = 8
+
+// ParseSearchQuery splits text into words and builds a prefix tsquery from them. Anything but
+// letters and digits separates words, so user input can't inject tsquery syntax. ok is false
+// when nothing searchable is left.
+func ParseSearchQuery(text string) (query SearchQuery, ok bool) {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return SearchQuery{}, false
+	}
+	if len(words) > maxSearchWords {
+		words = words[:maxSearchWords]
+	}
+	for i, word := range words {
+		words[i] = strings.ToLower(word) + ":*"
+	}
+	return SearchQuery{
+		Raw:     strings.Join(strings.Fields(text), " "),
+		TSQuery: strings.Join(words, " & "),
+	}, true
+}
+
+type SearchDB struct {
+	db *sqlx.DB
+}
+
+// searchTerms joins the English and Arabic prefix queries as q.en and q.ar.
+func searchTerms(search SearchQuery) squirrel.Sqlizer {
+	return squirrel.Expr("CROSS JOIN (SELECT to_tsquery('english', ?) AS en, to_tsquery('arabic', ?) AS ar) q", search.TSQuery, search.TSQuery)
+}
+
+// searchMatch matches the full-text vector in either language, or a name close enough to the
+// raw text to be a misspelling of it.
+func searchMatch(alias string, search SearchQuery) squirrel.Sqlizer {
+	return squirrel.Expr(fmt.Sprintf("(%[1]s.search_vector @@ q.en OR %[1]s.search_vector @@ q.ar OR %[1]s.name %% ?)", alias), search.Raw)
+}
+
+// searchRank is the relevance of a row: its full-text rank in both languages plus the
+// similarity of its name.
+func searchRank(alias string, search SearchQuery) pagination.Column {
+	return pagination.Desc(fmt.Sprintf("(ts_rank(%[1]s.search_vector, q.en) + ts_rank(%[1]s.search_vector, q.ar) + similarity(%[1]s.name, ?))", alias), search.Raw).As("rank")
+}
+
+// SearchSorts ranks results by relevance, the only order a search is read in.
+func SearchSorts(alias string, search SearchQuery) pagination.Sorts {
+	return pagination.Sorts{
+		Default: "rank",
+		Orders:  map[string]pagination.Order{"rank": {searchRank(alias, search), pagination.Asc(alias + ".id")}},
+	}
+}
+
+// SearchVendors ranks vendors by full-text relevance plus name similarity. page must be read
+// with SearchSorts("v", search).
+func (s *SearchDB) SearchVendors(ctx context.Context, search SearchQuery, includeHidden bool, page pagination.Params) ([]VendorSearchResult, pagination.Metadata, error) {
+	conditions := squirrel.And{searchMatch("v", search)}
+	if !includeHidden {
+		conditions = append(conditions, visibleCondition("v"))
+	}
+
+	rank := searchRank("v", search)
+	list := QB.Select("v.id", "v.name", "v.description", "v.rating_avg",
+		fmt.Sprintf("CASE WHEN NULLIF(v.img, '') IS NOT NULL THEN FORMAT('%s/%%s', v.img) ELSE NULL END AS img", Domain)).
+		Column(rank.Expr+" AS rank", rank.Args...).
+		From("vendors v").
+		JoinClause(searchTerms(search)).
+		Where(conditions)
+	count := QB.Select("COUNT(*)").
+		From("vendors v").
+		JoinClause(searchTerms(search)).
+		Where(conditions)
+	results, meta, err := selectPage[VendorSearchResult](ctx, s.db, list, count, page)
+	if err != nil {
+		return nil, pagination.Metadata{}, fmt.Errorf("error while searching vendors: %v", err)
+	}
+	return results, meta, nil
+}
+
+// SearchItems ranks menu items the same way. Items of hidden vendors are left out unless
+// includeHidden is set. page must be read with SearchSorts("i", search).
+func (s *SearchDB) SearchItems(ctx context.Context, search SearchQuery, includeHidden bool, page pagination.Params) ([]ItemSearchResult, pagination.Metadata, error) {
+	conditions := squirrel.And{searchMatch("i", search)}
+	if !includeHidden {
+		conditions = append(conditions, visibleCondition("v"))
+	}
+
+	rank := searchRank("i", search)
+	list := QB.Select("i.id", "i.vendor_id", "v.name AS vendor_name", "i.name", "i.price",
+		fmt.Sprintf("CASE WHEN NULLIF(i.img, '') IS NOT NULL THEN FORMAT('%s/%%s', i.img) ELSE NULL END AS img", Domain)).
+		Column(rank.Expr+" AS rank", rank.Args...).
+		From("items i").
+		Join("vendors v ON v.id = i.vendor_id").
+		JoinClause(searchTerms(search)).
+		Where(conditions)
+	count := QB.Select("COUNT(*)").
+		From("items i").
+		Join("vendors v ON v.id = i.vendor_id").
+		JoinClause(searchTerms(search)).
+		Where(conditions)
+	results, meta, err := selectPage[ItemSearchResult](ctx, s.db, list, count, page)
+	if err != nil {
+		return nil, pagination.Metadata{}, fmt.Errorf("error while searching items: %v", err)
+	}
+	return results, meta, nil
+}
@@ -0,0 +1,282 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"project/utils/i18n"
+	"project/utils/pagination"
+	"project/utils/validator"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var ServiceRequestTypes = []string{"call_waiter", "bring_bill", "water", "cleaning"}
+
+// ServiceRequest is a customer's call for service at a table.
+type ServiceRequest struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	TableID        uuid.UUID  `db:"table_id" json:"table_id"`
+	VendorID       uuid.UUID  `db:"vendor_id" json:"vendor_id"`
+	CustomerID     uuid.UUID  `db:"customer_id" json:"customer_id"`
+	Type           string     `db:"type" json:"type"`
+	Note           *string    `db:"note" json:"note,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	AcknowledgedAt *time.Time `db:"acknowledged_at" json:"acknowledged_at"`
+	AcknowledgedBy *uuid.UUID `db:"acknowledged_by" json:"acknowledged_by"`
+	ResolvedAt     *time.Time `db:"resolved_at" json:"resolved_at"`
+	ResolvedBy     *uuid.UUID `db:"resolved_by" json:"resolved_by"`
+	CancelledAt    *time.Time `db:"cancelled_at" json:"cancelled_at"`
+}
+
+// openServiceRequest matches requests neither resolved by staff nor cancelled by the customer.
+var openServiceRequest = squirrel.Eq{"resolved_at": nil, "cancelled_at": nil}
+
+// ResponseTimeReport holds average response times in seconds.
+type ResponseTimeReport struct {
+	VendorID           uuid.UUID  `db:"vendor_id" json:"vendor_id"`
+	StaffID            *uuid.UUID `db:"staff_id" json:"staff_id,omitempty"`
+	StaffName          *string    `db:"staff_name" json:"staff_name,omitempty"`
+	Requests           int        `db:"requests" json:"requests"`
+	AvgAcknowledgeSecs *float64   `db:"avg_acknowledge_secs" json:"avg_acknowledge_secs"`
+	AvgResolveSecs     *float64   `db:"avg_resolve_secs" json:"avg_resolve_secs"`
+	UnresolvedRequests int        `db:"unresolved_requests" json:"unresolved_requests"`
+}
+
+type ServiceRequestDB struct {
+	db *sqlx.DB
+}
+
+func ValidatingServiceRequest(v *validator.Validator, request *ServiceRequest) {
+	v.Validate(
+		validator.String("type", request.Type).Required(i18n.ServiceRequestTypeInvalid).OneOf(i18n.ServiceRequestTypeInvalid, ServiceRequestTypes...),
+		validator.OptionalString("note", request.Note).MaxLength(200, i18n.NoteTooLong),
+	)
+}
+
+// InsertServiceRequest stores the request and flags the table as needing service.
+func (s *ServiceRequestDB) InsertServiceRequest(ctx context.Context, request *ServiceRequest) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Insert("service_requests").
+		Columns("table_id", "vendor_id", "customer_id", "type", "note").
+		Values(request.TableID, request.VendorID, request.CustomerID, request.Type, request.Note).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(serviceRequestColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(request)
+	if err != nil {
+		return fmt.Errorf("error while inserting service request: %v", err)
+	}
+
+	if err = syncTableNeedsService(ctx, tx, request.TableID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *ServiceRequestDB) GetServiceRequest(ctx context.Context, id uuid.UUID) (*ServiceRequest, error) {
+	var request ServiceRequest
+	query, args, err := QB.Select(serviceRequestColumns...).
+		From("service_requests").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.GetContext(ctx, &request, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// ServiceRequestSorts are the orders a vendor's service requests can be read in.
+var ServiceRequestSorts = pagination.Sorts{
+	Default: "latest",
+	Orders: map[string]pagination.Order{
+		"latest": {pagination.Desc("created_at"), pagination.Desc("id")},
+		"oldest": {pagination.Asc("created_at"), pagination.Asc("id")},
+	},
+}
+
+// GetVendorServiceRequests lists one page of a vendor's requests. status may be
+// "pending", "acknowledged", "resolved", "cancelled" or empty for all of them.
+func (s *ServiceRequestDB) GetVendorServiceRequests(ctx context.Context, vendorID uuid.UUID, status string, page pagination.Params) ([]ServiceRequest, pagination.Metadata, error) {
+	conditions := squirrel.And{squirrel.Eq{"vendor_id": vendorID}}
+	switch status {
+	case "pending":
+		conditions = append(conditions, openServiceRequest, squirrel.Eq{"acknowledged_at": nil})
+	case "acknowledged":
+		conditions = append(conditions, openServiceRequest, squirrel.NotEq{"acknowledged_at": nil})
+	case "resolved":
+		conditions = append(conditions, squirrel.NotEq{"resolved_at": nil})
+	case "cancelled":
+		conditions = append(conditions, squirrel.NotEq{"cancelled_at": nil})
+	}
+
+	list := QB.Select(serviceRequestColumns...).From("service_requests").Where(conditions)
+	count := QB.Select("COUNT(*)").From("service_requests").Where(conditions)
+	return selectPage[ServiceRequest](ctx, s.db, list, count, page)
+}
+
+// AcknowledgeServiceRequest marks the request as seen by a staff member.
+func (s *ServiceRequestDB) AcknowledgeServiceRequest(ctx context.Context, id, staffID uuid.UUID) (*ServiceRequest, error) {
+	var request ServiceRequest
+	query, args, err := QB.Update("service_requests").
+		Set("acknowledged_at", time.Now()).
+		Set("acknowledged_by", staffID).
+		Where(squirrel.Eq{"id": id, "acknowledged_at": nil}).
+		Where(openServiceRequest).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(serviceRequestColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.QueryRowxContext(ctx, query, args...).StructScan(&request)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrServiceRequestClosed
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// ResolveServiceRequest closes the request. Unacknowledged requests are acknowledged by the same staff member.
+func (s *ServiceRequestDB) ResolveServiceRequest(ctx context.Context, id, staffID uuid.UUID) (*ServiceRequest, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var request ServiceRequest
+	query, args, err := QB.Update("service_requests").
+		Set("acknowledged_at", squirrel.Expr("COALESCE(acknowledged_at, ?)", now)).
+		Set("acknowledged_by", squirrel.Expr("COALESCE(acknowledged_by, ?)", staffID)).
+		Set("resolved_at", now).
+		Set("resolved_by", staffID).
+		Where(squirrel.Eq{"id": id}).
+		Where(openServiceRequest).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(serviceRequestColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(&request)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrServiceRequestClosed
+		}
+		return nil, err
+	}
+
+	if err = syncTableNeedsService(ctx, tx, request.TableID); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// CancelTableServiceRequests withdraws every open request at a table, when the customer no
+// longer needs help. Cancelled requests don't count towards response times.
+func (s *ServiceRequestDB) CancelTableServiceRequests(ctx context.Context, tableID uuid.UUID) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Update("service_requests").
+		Set("cancelled_at", time.Now()).
+		Where(squirrel.Eq{"table_id": tableID}).
+		Where(openServiceRequest).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("error while cancelling service requests: %v", err)
+	}
+
+	if err = syncTableNeedsService(ctx, tx, tableID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetVendorResponseTimes reports average acknowledge and resolve times of the requests customers
+// didn't cancel. When vendorID is nil every vendor is included; byStaff splits the numbers per
+// staff member who handled the request.
+func (s *ServiceRequestDB) GetVendorResponseTimes(ctx context.Context, vendorID *uuid.UUID, byStaff bool, from, to *time.Time) ([]ResponseTimeReport, error) {
+	reports := []ResponseTimeReport{}
+	queryBuilder := QB.Select(
+		"sr.vendor_id",
+		"COUNT(*) AS requests",
+		"AVG(EXTRACT(EPOCH FROM (sr.acknowledged_at - sr.created_at)))::float8 AS avg_acknowledge_secs",
+		"AVG(EXTRACT(EPOCH FROM (sr.resolved_at - sr.created_at)))::float8 AS avg_resolve_secs",
+		"COUNT(*) FILTER (WHERE sr.resolved_at IS NULL) AS unresolved_requests",
+	).From("service_requests sr").
+		Where(squirrel.Eq{"sr.cancelled_at": nil})
+
+	if byStaff {
+		queryBuilder = queryBuilder.
+			Column("COALESCE(sr.resolved_by, sr.acknowledged_by) AS staff_id").
+			Column("u.name AS staff_name").
+			LeftJoin("users u ON u.id = COALESCE(sr.resolved_by, sr.acknowledged_by)").
+			Where(squirrel.NotEq{"sr.acknowledged_by": nil}).
+			GroupBy("sr.vendor_id", "COALESCE(sr.resolved_by, sr.acknowledged_by)", "u.name")
+	} else {
+		queryBuilder = queryBuilder.GroupBy("sr.vendor_id")
+	}
+
+	if vendorID != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"sr.vendor_id": *vendorID})
+	}
+	if from != nil {
+		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{"sr.created_at": *from})
+	}
+	if to != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Lt{"sr.created_at": *to})
+	}
+
+	query, args, err := queryBuilder.OrderBy("sr.vendor_id").ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.SelectContext(ctx, &reports, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// syncTableNeedsService keeps the legacy is_needs_service flag in line with the open requests.
+func syncTableNeedsService(ctx context.Context, tx *sqlx.Tx, tableID uuid.UUID) error {
+	query, args, err := QB.Update("tables").
+		Set("is_needs_service", squirrel.Expr("EXISTS (SELECT 1 FROM service_requests WHERE table_id = ? AND resolved_at IS NULL AND cancelled_at IS NULL)", tableID)).
+		Where(squirrel.Eq{"id": tableID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, query, args...)
+	return err
+}
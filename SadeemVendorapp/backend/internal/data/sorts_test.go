@@ -0,0 +1,53 @@
+package data
+
+import (
+	"project/utils"
+	"project/utils/pagination"
+	"testing"
+)
+
+// TestSortsReadTheirRows takes a cursor from the row type of every list in every order it
+// can be read in, which panics when a sort column has no row field to keep its value. Lists
+// sorted by their fields are checked one field at a time.
+func TestSortsReadTheirRows(t *testing.T) {
+	check := func(name string, sorts pagination.Sorts, page func(pagination.Params)) {
+		orders := map[string]pagination.Order{}
+		for sort, order := range sorts.Orders {
+			orders[sort] = order
+		}
+		for field, column := range sorts.Fields {
+			orders[field] = pagination.Order{column, sorts.Tie}
+		}
+		for sort, order := range orders {
+			t.Run(name+"/"+sort, func(t *testing.T) {
+				defer func() {
+					if err := recover(); err != nil {
+						t.Error(err)
+					}
+				}()
+				page(pagination.Params{Limit: 1, Sort: sort, Order: order})
+			})
+		}
+	}
+
+	check("users", UserSorts, func(p pagination.Params) { pagination.Page(make([]User, 2), p, 2) })
+	check("vendors", VendorSorts(&utils.Near{}), func(p pagination.Params) { pagination.Page(make([]Vendor, 2), p, 2) })
+	check("items", ItemSorts, func(p pagination.Params) { pagination.Page(make([]Item, 2), p, 2) })
+	check("tables", TableSorts, func(p pagination.Params) { pagination.Page(make([]Table, 2), p, 2) })
+	check("service requests", ServiceRequestSorts, func(p pagination.Params) { pagination.Page(make([]ServiceRequest, 2), p, 2) })
+	check("vendor admins", VendorAdminSorts, func(p pagination.Params) { pagination.Page(make([]VendorAdminUser, 2), p, 2) })
+	check("tags", TagSorts, func(p pagination.Params) { pagination.Page(make([]Tag, 2), p, 2) })
+	check("item translations", ItemTranslationSorts, func(p pagination.Params) { pagination.Page(make([]ItemTranslation, 2), p, 2) })
+	check("missing translations", MissingTranslationSorts, func(p pagination.Params) { pagination.Page(make([]MissingTranslation, 2), p, 2) })
+	check("plans", PlanSorts, func(p pagination.Params) { pagination.Page(make([]Plan, 2), p, 2) })
+	check("invoices", SubscriptionSorts, func(p pagination.Params) { pagination.Page(make([]Invoice, 2), p, 2) })
+	check("subscription history", SubscriptionSorts, func(p pagination.Params) { pagination.Page(make([]SubscriptionEvent, 2), p, 2) })
+	check("job runs", JobRunSorts, func(p pagination.Params) { pagination.Page(make([]JobRun, 2), p, 2) })
+	check("user roles", UserRoleSorts, func(p pagination.Params) { pagination.Page(make([]User_role, 2), p, 2) })
+	check("cart items", CartItemSorts, func(p pagination.Params) { pagination.Page(make([]CartItemWithNameAndImg, 2), p, 2) })
+	check("customer orders", CustomerOrderSorts, func(p pagination.Params) { pagination.Page(make([]OrderDetails, 2), p, 2) })
+	check("vendor orders", VendorOrderSorts, func(p pagination.Params) { pagination.Page(make([]Order, 2), p, 2) })
+	check("reviews", ReviewSorts, func(p pagination.Params) { pagination.Page(make([]Review, 2), p, 2) })
+	check("vendor search", SearchSorts("v", SearchQuery{}), func(p pagination.Params) { pagination.Page(make([]VendorSearchResult, 2), p, 2) })
+	check("item search", SearchSorts("i", SearchQuery{}), func(p pagination.Params) { pagination.Page(make([]ItemSearchResult, 2), p, 2) })
+}
@@ -0,0 +1,451 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"project/utils/pagination"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Invoice is a bill for extending a vendor's subscription by Days.
+type Invoice struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	VendorID    uuid.UUID  `db:"vendor_id" json:"vendor_id"`
+	PlanID      *uuid.UUID `db:"plan_id" json:"plan_id"`
+	Amount      float64    `db:"amount" json:"amount"`
+	Days        int        `db:"days" json:"days"`
+	Status      string     `db:"status" json:"status"`
+	PeriodStart *time.Time `db:"period_start" json:"period_start"`
+	PeriodEnd   *time.Time `db:"period_end" json:"period_end"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	PaidAt      *time.Time `db:"paid_at" json:"paid_at"`
+}
+
+// SubscriptionEvent is one row of a vendor's subscription history.
+type SubscriptionEvent struct {
+	ID              uuid.UUID  `db:"id" json:"id"`
+	VendorID        uuid.UUID  `db:"vendor_id" json:"vendor_id"`
+	Event           string     `db:"event" json:"event"`
+	IsVisible       bool       `db:"is_visible" json:"is_visible"`
+	SubscriptionEnd time.Time  `db:"subscription_end" json:"subscription_end"`
+	InvoiceID       *uuid.UUID `db:"invoice_id" json:"invoice_id"`
+	Note            *string    `db:"note" json:"note"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	NotifiedAt      *time.Time `db:"notified_at" json:"notified_at"`
+}
+
+// SubscriptionStatus describes where a vendor is in its billing cycle.
+// Status is "active", "grace" (past subscription_end but still visible) or "lapsed".
+type SubscriptionStatus struct {
+	VendorID        uuid.UUID `db:"id" json:"vendor_id"`
+	Status          string    `db:"status" json:"status"`
+	IsVisible       bool      `db:"is_visible" json:"is_visible"`
+	SubscriptionEnd time.Time `db:"subscription_end" json:"subscription_end"`
+	GraceUntil      time.Time `db:"grace_until" json:"grace_until"`
+	Plan            *Plan     `db:"-" json:"plan"`
+	PendingInvoices []Invoice `db:"-" json:"pending_invoices"`
+	// Reminders are the expiry reminders the vendor has not marked as seen yet.
+	Reminders []SubscriptionEvent `db:"-" json:"reminders"`
+}
+
+type SubscriptionDB struct {
+	db *sqlx.DB
+}
+
+const graceEndExpr = "subscription_end + make_interval(days => grace_days)"
+
+// visibleCondition matches the vendors of the given table alias, "" for none, whose subscription
+// or grace period still runs. Lists check it instead of is_visible, so a vendor drops out the
+// moment it lapses rather than when the subscription-visibility job next catches up.
+func visibleCondition(alias string) squirrel.Sqlizer {
+	if alias != "" {
+		alias += "."
+	}
+	return squirrel.Expr(fmt.Sprintf("%[1]ssubscription_end + make_interval(days => %[1]sgrace_days) >= CURRENT_TIMESTAMP", alias))
+}
+
+// GetSubscriptionStatus returns the vendor's billing state, its plan and any unpaid invoices.
+func (s *SubscriptionDB) GetSubscriptionStatus(ctx context.Context, vendorID uuid.UUID) (*SubscriptionStatus, error) {
+	var status SubscriptionStatus
+	query, args, err := QB.Select(
+		"id",
+		"is_visible",
+		"subscription_end",
+		graceEndExpr+" AS grace_until",
+		fmt.Sprintf(`CASE WHEN subscription_end >= CURRENT_TIMESTAMP THEN 'active'
+			WHEN %s >= CURRENT_TIMESTAMP THEN 'grace' ELSE 'lapsed' END AS status`, graceEndExpr),
+	).From("vendors").Where(squirrel.Eq{"id": vendorID}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.GetContext(ctx, &status, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	plan, err := s.renewalPlan(ctx, vendorID)
+	if err != nil && err != ErrRecordNotFound {
+		return nil, err
+	}
+	status.Plan = plan
+
+	status.PendingInvoices = []Invoice{}
+	query, args, err = QB.Select(invoiceColumns...).From("subscription_invoices").
+		Where(squirrel.Eq{"vendor_id": vendorID, "status": "pending"}).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.db.SelectContext(ctx, &status.PendingInvoices, query, args...); err != nil {
+		return nil, err
+	}
+
+	status.Reminders = []SubscriptionEvent{}
+	query, args, err = QB.Select(subscriptionHistoryColumns...).From("subscription_history").
+		Where(pendingReminders).
+		Where(squirrel.Eq{"vendor_id": vendorID}).
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.db.SelectContext(ctx, &status.Reminders, query, args...); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// pendingReminders matches the reminders no one has marked as seen.
+var pendingReminders = squirrel.Eq{"event": "reminder", "notified_at": nil}
+
+// MarkRemindersNotified marks the vendor's pending reminders as seen and returns how many there were.
+func (s *SubscriptionDB) MarkRemindersNotified(ctx context.Context, vendorID uuid.UUID) (int64, error) {
+	query, args, err := QB.Update("subscription_history").
+		Set("notified_at", squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(pendingReminders).
+		Where(squirrel.Eq{"vendor_id": vendorID}).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error while marking subscription reminders: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetExpiredVendors lists the vendors whose subscription has ended, soonest expired first.
+// With lapsedOnly it leaves out those still in their grace period.
+func (s *SubscriptionDB) GetExpiredVendors(ctx context.Context, lapsedOnly bool) ([]Vendor, error) {
+	ended := "subscription_end < CURRENT_TIMESTAMP"
+	if lapsedOnly {
+		ended = graceEndExpr + " < CURRENT_TIMESTAMP"
+	}
+	query, args, err := QB.Select(vendors_columns...).From("vendors").
+		Where(ended).
+		OrderBy("subscription_end", "id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	vendors := []Vendor{}
+	if err = s.db.SelectContext(ctx, &vendors, query, args...); err != nil {
+		return nil, err
+	}
+	return vendors, nil
+}
+
+// renewalPlan returns the plan a renewal is billed for: the vendor's own plan, or the default plan.
+func (s *SubscriptionDB) renewalPlan(ctx context.Context, vendorID uuid.UUID) (*Plan, error) {
+	var plan Plan
+	query, args, err := QB.Select(prefixColumns("p", planColumns)...).
+		From("plans p").
+		Where("p.id = (SELECT COALESCE(v.plan_id, (SELECT id FROM plans WHERE is_default)) FROM vendors v WHERE v.id = ?)", vendorID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.GetContext(ctx, &plan, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// CreateRenewalInvoice bills the vendor for days more of a plan. A nil planID renews the
+// vendor's current plan; days <= 0 means one billing period of that plan.
+func (s *SubscriptionDB) CreateRenewalInvoice(ctx context.Context, vendorID uuid.UUID, planID *uuid.UUID, days int) (*Invoice, error) {
+	var plan *Plan
+	var err error
+	if planID != nil {
+		plan, err = (&PlanDB{s.db}).GetPlan(ctx, *planID)
+	} else {
+		plan, err = s.renewalPlan(ctx, vendorID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if days <= 0 {
+		days = plan.BillingDays
+	}
+	// Prices are per billing period, so longer or shorter renewals are charged pro rata
+	amount := math.Round(plan.Price*float64(days)/float64(plan.BillingDays)*100) / 100
+
+	var invoice Invoice
+	query, args, err := QB.Insert("subscription_invoices").
+		Columns("vendor_id", "plan_id", "amount", "days").
+		Values(vendorID, plan.ID, amount, days).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(invoiceColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.QueryRowxContext(ctx, query, args...).StructScan(&invoice)
+	if err != nil {
+		return nil, fmt.Errorf("error while inserting invoice: %v", err)
+	}
+	return &invoice, nil
+}
+
+func (s *SubscriptionDB) GetInvoice(ctx context.Context, id uuid.UUID) (*Invoice, error) {
+	var invoice Invoice
+	query, args, err := QB.Select(invoiceColumns...).From("subscription_invoices").Where(squirrel.Eq{"id": id}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.GetContext(ctx, &invoice, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// SubscriptionSorts are the orders invoices and subscription history can be read in.
+var SubscriptionSorts = pagination.Sorts{
+	Default: "latest",
+	Orders: map[string]pagination.Order{
+		"latest": {pagination.Desc("created_at"), pagination.Desc("id")},
+		"oldest": {pagination.Asc("created_at"), pagination.Asc("id")},
+	},
+}
+
+func (s *SubscriptionDB) GetVendorInvoices(ctx context.Context, vendorID uuid.UUID, page pagination.Params) ([]Invoice, pagination.Metadata, error) {
+	list := QB.Select(invoiceColumns...).From("subscription_invoices").Where(squirrel.Eq{"vendor_id": vendorID})
+	count := QB.Select("COUNT(*)").From("subscription_invoices").Where(squirrel.Eq{"vendor_id": vendorID})
+	return selectPage[Invoice](ctx, s.db, list, count, page)
+}
+
+// PayInvoice marks a pending invoice as paid and extends the subscription by its days.
+// A vendor still in its grace period is extended from subscription_end so the grace days
+// are paid for; a lapsed vendor is extended from now.
+func (s *SubscriptionDB) PayInvoice(ctx context.Context, id uuid.UUID) (*Invoice, *Vendor, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var invoice Invoice
+	query, args, err := QB.Update("subscription_invoices").
+		Set("status", "paid").
+		Set("paid_at", now).
+		Where(squirrel.Eq{"id": id, "status": "pending"}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(invoiceColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(&invoice)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrInvoiceNotPending
+		}
+		return nil, nil, err
+	}
+
+	var vendor Vendor
+	query, args, err = QB.Update("vendors").
+		Set("subscription_end", squirrel.Expr(fmt.Sprintf(
+			"CASE WHEN %s >= ? THEN subscription_end ELSE ? END + make_interval(days => ?)", graceEndExpr), now, now, invoice.Days)).
+		Set("subscription_days", invoice.Days).
+		Set("plan_id", squirrel.Expr("COALESCE(?, plan_id)", invoice.PlanID)).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": invoice.VendorID}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(vendors_columns, ","))).
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(&vendor)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while extending subscription: %v", err)
+	}
+
+	periodStart := vendor.SubscriptionEnd.AddDate(0, 0, -invoice.Days)
+	query, args, err = QB.Update("subscription_invoices").
+		Set("period_start", periodStart).
+		Set("period_end", vendor.SubscriptionEnd).
+		Where(squirrel.Eq{"id": invoice.ID}).
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, nil, err
+	}
+	invoice.PeriodStart = &periodStart
+	invoice.PeriodEnd = &vendor.SubscriptionEnd
+
+	query, args, err = QB.Insert("subscription_history").
+		Columns("vendor_id", "event", "is_visible", "subscription_end", "invoice_id").
+		Values(vendor.ID, "renewed", vendor.IsVisible, vendor.SubscriptionEnd, invoice.ID).
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, nil, fmt.Errorf("error while writing subscription history: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return &invoice, &vendor, nil
+}
+
+// VoidInvoice cancels a pending invoice.
+func (s *SubscriptionDB) VoidInvoice(ctx context.Context, id uuid.UUID) (*Invoice, error) {
+	var invoice Invoice
+	query, args, err := QB.Update("subscription_invoices").
+		Set("status", "void").
+		Where(squirrel.Eq{"id": id, "status": "pending"}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(invoiceColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.QueryRowxContext(ctx, query, args...).StructScan(&invoice)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvoiceNotPending
+		}
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (s *SubscriptionDB) GetHistory(ctx context.Context, vendorID uuid.UUID, page pagination.Params) ([]SubscriptionEvent, pagination.Metadata, error) {
+	list := QB.Select(subscriptionHistoryColumns...).From("subscription_history").Where(squirrel.Eq{"vendor_id": vendorID})
+	count := QB.Select("COUNT(*)").From("subscription_history").Where(squirrel.Eq{"vendor_id": vendorID})
+	return selectPage[SubscriptionEvent](ctx, s.db, list, count, page)
+}
+
+// SyncVisibility hides vendors whose grace period has run out and shows vendors that were
+// renewed, returning the history rows the record_visibility_change trigger wrote for them. It
+// also records the start of each grace period once. Lists check visibleCondition themselves;
+// this keeps is_visible and the history in step, since the update_visibility trigger only runs
+// when a row is written.
+func (s *SubscriptionDB) SyncVisibility(ctx context.Context) ([]SubscriptionEvent, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	visibleExpr := graceEndExpr + " >= CURRENT_TIMESTAMP"
+	var changed []uuid.UUID
+	query, args, err := QB.Update("vendors").
+		Set("is_visible", squirrel.Expr(visibleExpr)).
+		Where(fmt.Sprintf("is_visible <> (%s)", visibleExpr)).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.SelectContext(ctx, &changed, query, args...); err != nil {
+		return nil, fmt.Errorf("error while syncing vendor visibility: %v", err)
+	}
+
+	events := []SubscriptionEvent{}
+	if len(changed) > 0 {
+		query, args, err = QB.Select(subscriptionHistoryColumns...).Options("DISTINCT ON (vendor_id)").
+			From("subscription_history").
+			Where(squirrel.Eq{"vendor_id": changed, "event": []string{"lapsed", "restored"}}).
+			OrderBy("vendor_id", "created_at DESC").
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err = tx.SelectContext(ctx, &events, query, args...); err != nil {
+			return nil, fmt.Errorf("error while reading subscription history: %v", err)
+		}
+	}
+
+	var graceStarted []SubscriptionEvent
+	query = fmt.Sprintf(`INSERT INTO subscription_history (vendor_id, event, is_visible, subscription_end)
+		SELECT id, 'grace_started', is_visible, subscription_end FROM vendors
+		WHERE subscription_end < CURRENT_TIMESTAMP AND %s
+		ON CONFLICT DO NOTHING
+		RETURNING %s`, visibleExpr, strings.Join(subscriptionHistoryColumns, ", "))
+	if err = tx.SelectContext(ctx, &graceStarted, query); err != nil {
+		return nil, fmt.Errorf("error while recording grace periods: %v", err)
+	}
+	events = append(events, graceStarted...)
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// RecordReminders writes a "reminder" history row for every vendor whose subscription ends
+// within one of the given numbers of days. Each vendor gets at most one reminder per window
+// and subscription period. The rows are pending notifications: the vendor sees them in its
+// subscription status until MarkRemindersNotified.
+func (s *SubscriptionDB) RecordReminders(ctx context.Context, days []int) ([]SubscriptionEvent, error) {
+	windows := append([]int(nil), days...)
+	sort.Ints(windows)
+
+	events := []SubscriptionEvent{}
+	previous := 0
+	for _, d := range windows {
+		if d <= previous {
+			continue
+		}
+		var recorded []SubscriptionEvent
+		query := fmt.Sprintf(`INSERT INTO subscription_history (vendor_id, event, is_visible, subscription_end, note)
+			SELECT id, 'reminder', is_visible, subscription_end, $1 FROM vendors
+			WHERE subscription_end > CURRENT_TIMESTAMP + make_interval(days => $2)
+			AND subscription_end <= CURRENT_TIMESTAMP + make_interval(days => $3)
+			ON CONFLICT DO NOTHING
+			RETURNING %s`, strings.Join(subscriptionHistoryColumns, ", "))
+		err := s.db.SelectContext(ctx, &recorded, query, fmt.Sprintf("expires within %d days", d), previous, d)
+		if err != nil {
+			return nil, fmt.Errorf("error while recording subscription reminders: %v", err)
+		}
+		events = append(events, recorded...)
+		previous = d
+	}
+	return events, nil
+}
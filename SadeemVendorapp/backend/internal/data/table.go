@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"project/utils/pagination"
 	"strings"
 
 	"github.com/Masterminds/squirrel"
@@ -19,6 +20,14 @@ type Table struct {
 	CustomerID      *uuid.UUID `db:"customer_id,omitempty" json:"customer_id,omitempty"`
 	IsAvailable     bool       `db:"is_available" json:"is_available"`
 	IsNeedsServices bool       `db:"is_needs_service" json:"is_needs_service"`
+	QRTokenVersion  int        `db:"qr_token_version" json:"-"`
+	AreaID          *uuid.UUID `db:"area_id" json:"area_id"`
+	PosX            float64    `db:"pos_x" json:"pos_x"`
+	PosY            float64    `db:"pos_y" json:"pos_y"`
+	Shape           string     `db:"shape" json:"shape"`
+	Rotation        int        `db:"rotation" json:"rotation"`
+	Capacity        int        `db:"capacity" json:"capacity"`
+	Version         int        `db:"version" json:"version"`
 }
 
 // TableDB wraps a sqlx.DB connection pool.
@@ -61,8 +70,18 @@ func (db *TableDB) GetTable(ctx context.Context, id uuid.UUID) (*Table, error) {
 	return &table, nil
 }
 
-// Insert inserts a new table into the database.
+// Insert inserts a new table into the database, within the limits of the vendor's plan.
 func (db *TableDB) Insert(ctx context.Context, table *Table) error {
+	tx, err := db.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = checkPlanLimit(ctx, tx, table.VendorID, PlanResourceTables); err != nil {
+		return err
+	}
+
 	query, args, err := QB.
 		Insert("tables").
 		Columns("name", "vendor_id", "customer_id", "is_available", "is_needs_service").
@@ -73,11 +92,11 @@ func (db *TableDB) Insert(ctx context.Context, table *Table) error {
 		return err
 	}
 
-	err = db.DB.QueryRowxContext(ctx, query, args...).StructScan(table)
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(table)
 	if err != nil {
 		return err
 	}
-	return nil
+	return tx.Commit()
 }
 
 // DeleteTable deletes a table by its ID.
@@ -98,31 +117,27 @@ func (db *TableDB) DeleteTable(ctx context.Context, id uuid.UUID) (*Table, error
 }
 
 // Update updates an existing table in the database.
+// Update saves table if it is still at table.Version, and reads it back. ErrEditConflict
+// means it was changed or deleted since it was read.
 func (db *TableDB) Update(ctx context.Context, table *Table) error {
 	query, args, err := QB.Update("tables").
 		Set("name", table.Name).
 		Set("is_available", table.IsAvailable).
 		Set("is_needs_service", table.IsNeedsServices).
-		Where(squirrel.Eq{"id": table.ID}).
+		Where(squirrel.Eq{"id": table.ID, "version": table.Version}).
 		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(tableColumns, ", "))).
 		ToSql()
 	if err != nil {
 		return err
 	}
 
-	result, err := db.DB.ExecContext(ctx, query, args...)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
+	err = db.DB.QueryRowxContext(ctx, query, args...).StructScan(table)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrEditConflict
+		}
 		return err
 	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("table not found")
-	}
 	return nil
 }
 
@@ -144,6 +159,22 @@ func (db *TableDB) CountTablesForVendor(ctx context.Context, vendorID uuid.UUID)
 
 	return count, nil
 }
+
+// TableSorts are the orders a vendor's tables can be read in.
+var TableSorts = pagination.Sorts{
+	Default: "name",
+	Orders: map[string]pagination.Order{
+		"name": {pagination.Asc("name"), pagination.Asc("id")},
+	},
+}
+
+// GetVendorTablesPage lists one page of the vendor's tables.
+func (v *TableDB) GetVendorTablesPage(ctx context.Context, vendorID uuid.UUID, page pagination.Params) ([]Table, pagination.Metadata, error) {
+	list := QB.Select(tableColumns...).From("tables").Where(squirrel.Eq{"vendor_id": vendorID})
+	count := QB.Select("COUNT(*)").From("tables").Where(squirrel.Eq{"vendor_id": vendorID})
+	return selectPage[Table](ctx, v.DB, list, count, page)
+}
+
 func (v *TableDB) GetVendorTables(ctx context.Context, vendorID uuid.UUID) ([]Table, error) {
 	var tables []Table
 
@@ -264,3 +295,24 @@ func (db *TableDB) GetCustomertable(ctx context.Context, customerid uuid.UUID) (
 	}
 	return &table, nil
 }
+
+// RotateQRToken bumps the table's QR token version so that previously printed codes stop working.
+func (db *TableDB) RotateQRToken(ctx context.Context, tableID uuid.UUID) (*Table, error) {
+	var table Table
+	query, args, err := QB.Update("tables").
+		Set("qr_token_version", squirrel.Expr("qr_token_version + 1")).
+		Where(squirrel.Eq{"id": tableID}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(tableColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = db.DB.QueryRowxContext(ctx, query, args...).StructScan(&table)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &table, nil
+}
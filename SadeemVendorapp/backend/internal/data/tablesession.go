@@ -0,0 +1,212 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// TableSession is an open (or past) seating at a table, shared by everyone who scanned its code.
+type TableSession struct {
+	ID       uuid.UUID   `db:"id" json:"id"`
+	TableID  uuid.UUID   `db:"table_id" json:"table_id"`
+	OpenedBy uuid.UUID   `db:"opened_by" json:"opened_by"`
+	OpenedAt time.Time   `db:"opened_at" json:"opened_at"`
+	ClosedAt *time.Time  `db:"closed_at" json:"closed_at,omitempty"`
+	Members  []uuid.UUID `db:"-" json:"members"`
+}
+
+// TableSessionDB wraps a sqlx.DB connection pool for table sessions.
+type TableSessionDB struct {
+	db *sqlx.DB
+}
+
+// OpenOrJoin seats userID at the table. If the table is free a new session is opened and the
+// user becomes the table's customer. A table someone else holds can only be joined with the
+// ID of its open session, which its members share; without it ErrTableOccupied is returned.
+func (s *TableSessionDB) OpenOrJoin(ctx context.Context, tableID, userID, sessionID uuid.UUID) (*TableSession, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the table row so two people scanning at once can't both open a session
+	var table Table
+	query, args, err := QB.Select(tableColumns...).From("tables").
+		Where(squirrel.Eq{"id": tableID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(&table)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	session, err := s.openSession(ctx, tx, tableID)
+	if err != nil && err != ErrRecordNotFound {
+		return nil, err
+	}
+	if session != nil {
+		if session.Members, err = s.members(ctx, tx, session.ID); err != nil {
+			return nil, err
+		}
+	}
+	if !maySit(&table, session, userID, sessionID) {
+		return nil, ErrTableOccupied
+	}
+
+	if session == nil {
+		// The user may only occupy one table at a time
+		var seated int
+		query, args, err = QB.Select("COUNT(*)").From("tables").
+			Where(squirrel.Eq{"customer_id": userID}).
+			Where(squirrel.NotEq{"id": tableID}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err = tx.GetContext(ctx, &seated, query, args...); err != nil {
+			return nil, err
+		}
+		if seated > 0 {
+			return nil, ErrUserAlreadyhaveatable
+		}
+
+		session = &TableSession{}
+		query, args, err = QB.Insert("table_sessions").
+			Columns("table_id", "opened_by").
+			Values(tableID, userID).
+			Suffix(fmt.Sprintf("RETURNING %s", strings.Join(tableSessionColumns, ", "))).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err = tx.QueryRowxContext(ctx, query, args...).StructScan(session); err != nil {
+			return nil, err
+		}
+
+		query, args, err = QB.Update("tables").
+			Set("customer_id", userID).
+			Set("is_available", false).
+			Where(squirrel.Eq{"id": tableID}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	query, args, err = QB.Insert("table_session_members").
+		Columns("session_id", "user_id").
+		Values(session.ID, userID).
+		Suffix("ON CONFLICT (session_id, user_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	if session.Members, err = s.members(ctx, tx, session.ID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetOpenSession returns the session currently open at the table.
+func (s *TableSessionDB) GetOpenSession(ctx context.Context, tableID uuid.UUID) (*TableSession, error) {
+	session, err := s.openSession(ctx, s.db, tableID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Members, err = s.members(ctx, s.db, session.ID); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// CloseSession closes the table's open session, if any.
+func (s *TableSessionDB) CloseSession(ctx context.Context, tableID uuid.UUID) error {
+	query, args, err := QB.Update("table_sessions").
+		Set("closed_at", time.Now()).
+		Where(squirrel.Eq{"table_id": tableID, "closed_at": nil}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error while closing table session: %v", err)
+	}
+	return nil
+}
+
+// maySit reports whether userID may take or join the table: it is free, theirs, they are
+// already in its session, or they know the session's ID.
+func maySit(table *Table, session *TableSession, userID, sessionID uuid.UUID) bool {
+	if session == nil {
+		return table.CustomerID == nil || *table.CustomerID == userID
+	}
+	if (table.CustomerID != nil && *table.CustomerID == userID) || session.ID == sessionID {
+		return true
+	}
+	for _, member := range session.Members {
+		if member == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TableSessionDB) openSession(ctx context.Context, q sqlx.QueryerContext, tableID uuid.UUID) (*TableSession, error) {
+	var session TableSession
+	query, args, err := QB.Select(tableSessionColumns...).From("table_sessions").
+		Where(squirrel.Eq{"table_id": tableID, "closed_at": nil}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = q.QueryRowxContext(ctx, query, args...).StructScan(&session)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *TableSessionDB) members(ctx context.Context, q sqlx.QueryerContext, sessionID uuid.UUID) ([]uuid.UUID, error) {
+	var members []uuid.UUID
+	query, args, err := QB.Select("user_id").From("table_session_members").
+		Where(squirrel.Eq{"session_id": sessionID}).
+		OrderBy("joined_at ASC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = sqlx.SelectContext(ctx, q, &members, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
@@ -0,0 +1,37 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMaySit(t *testing.T) {
+	holder, member, stranger := uuid.New(), uuid.New(), uuid.New()
+	session := &TableSession{ID: uuid.New(), Members: []uuid.UUID{holder, member}}
+	free := &Table{}
+	held := &Table{CustomerID: &holder}
+
+	tests := []struct {
+		name      string
+		table     *Table
+		session   *TableSession
+		user      uuid.UUID
+		sessionID uuid.UUID
+		want      bool
+	}{
+		{"free table", free, nil, stranger, uuid.Nil, true},
+		{"claimed without a session", held, nil, stranger, uuid.Nil, false},
+		{"own claim without a session", held, nil, holder, uuid.Nil, true},
+		{"holder rescans", held, session, holder, uuid.Nil, true},
+		{"member rescans", held, session, member, uuid.Nil, true},
+		{"stranger without the session", held, session, stranger, uuid.Nil, false},
+		{"stranger with another session", held, session, stranger, uuid.New(), false},
+		{"stranger with the session", held, session, stranger, session.ID, true},
+	}
+	for _, tt := range tests {
+		if got := maySit(tt.table, tt.session, tt.user, tt.sessionID); got != tt.want {
+			t.Errorf("%s: maySit = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"project/utils"
+	"project/utils/i18n"
+	"project/utils/pagination"
+	"project/utils/validator"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Tag describes a vendor for discovery: a cuisine, a price range or a feature such as
+// "outdoor seating". Tags are managed by admins and assigned to vendors.
+type Tag struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Slug      string    `db:"slug" json:"slug"`
+	Kind      string    `db:"kind" json:"kind"`
+	CreatedAt time.Time `db:"created_at" json:"-"`
+	UpdatedAt time.Time `db:"updated_at" json:"-"`
+}
+
+// TagFacet is how many vendors in a result set carry a tag.
+type TagFacet struct {
+	Tag
+	Count int `db:"count" json:"count"`
+}
+
+var TagKinds = []string{"cuisine", "price", "feature"}
+
+var slugRX = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type TagDB struct {
+	db *sqlx.DB
+}
+
+func ValidatingTag(v *validator.Validator, tag *Tag) {
+	v.Validate(
+		validator.String("name", tag.Name).Required(i18n.NameRequired).MaxLength(50, i18n.NameTooLong),
+		validator.String("slug", tag.Slug).Required(i18n.SlugInvalid).Matches(slugRX, i18n.SlugInvalid).MaxLength(50, i18n.SlugTooLong),
+		validator.String("kind", tag.Kind).Required(i18n.TagKindInvalid).OneOf(i18n.TagKindInvalid, TagKinds...),
+	)
+}
+
+func (t *TagDB) InsertTag(ctx context.Context, tag *Tag) error {
+	query, args, err := QB.Insert("tags").
+		Columns("name", "slug", "kind").
+		Values(tag.Name, tag.Slug, tag.Kind).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(tagColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	err = t.db.QueryRowxContext(ctx, query, args...).StructScan(tag)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrDuplicatedTag
+		}
+		return fmt.Errorf("error while inserting tag: %v", err)
+	}
+	return nil
+}
+
+// TagSorts are the orders the tag list can be read in.
+var TagSorts = pagination.Sorts{
+	Default: "kind",
+	Orders: map[string]pagination.Order{
+		"kind": {pagination.Asc("kind"), pagination.Asc("name"), pagination.Asc("id")},
+		"name": {pagination.Asc("name"), pagination.Asc("id")},
+	},
+}
+
+// GetTags lists one page of the tags, optionally of one kind.
+func (t *TagDB) GetTags(ctx context.Context, kind string, page pagination.Params) ([]Tag, pagination.Metadata, error) {
+	conditions := squirrel.And{}
+	if kind != "" {
+		conditions = append(conditions, squirrel.Eq{"kind": kind})
+	}
+	list := QB.Select(tagColumns...).From("tags").Where(conditions)
+	count := QB.Select("COUNT(*)").From("tags").Where(conditions)
+	return selectPage[Tag](ctx, t.db, list, count, page)
+}
+
+func (t *TagDB) GetTag(ctx context.Context, id uuid.UUID) (*Tag, error) {
+	var tag Tag
+	query, args, err := QB.Select(tagColumns...).From("tags").Where(squirrel.Eq{"id": id}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = t.db.GetContext(ctx, &tag, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (t *TagDB) UpdateTag(ctx context.Context, tag *Tag) error {
+	query, args, err := QB.Update("tags").
+		Set("name", tag.Name).
+		Set("slug", tag.Slug).
+		Set("kind", tag.Kind).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": tag.ID}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(tagColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	err = t.db.QueryRowxContext(ctx, query, args...).StructScan(tag)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrRecordNotFound
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrDuplicatedTag
+		}
+		return fmt.Errorf("error while updating tag: %v", err)
+	}
+	return nil
+}
+
+// DeleteTag removes a tag and unassigns it from every vendor.
+func (t *TagDB) DeleteTag(ctx context.Context, id uuid.UUID) error {
+	query, args, err := QB.Delete("tags").Where(squirrel.Eq{"id": id}).ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := t.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetVendorTags replaces the vendor's tags. Unknown tag IDs return ErrRecordNotFound.
+func (t *TagDB) SetVendorTags(ctx context.Context, vendorID uuid.UUID, tagIDs []uuid.UUID) ([]Tag, error) {
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Delete("vendor_tags").Where(squirrel.Eq{"vendor_id": vendorID}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	if len(tagIDs) > 0 {
+		insert := QB.Insert("vendor_tags").Columns("vendor_id", "tag_id")
+		for _, tagID := range tagIDs {
+			insert = insert.Values(vendorID, tagID)
+		}
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+				return nil, ErrRecordNotFound
+			}
+			return nil, fmt.Errorf("error while assigning tags: %v", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	tags, err := t.GetVendorTags(ctx, []uuid.UUID{vendorID})
+	if err != nil {
+		return nil, err
+	}
+	if tags[vendorID] == nil {
+		return []Tag{}, nil
+	}
+	return tags[vendorID], nil
+}
+
+// GetVendorTags loads the tags of several vendors at once, keyed by vendor ID.
+func (t *TagDB) GetVendorTags(ctx context.Context, vendorIDs []uuid.UUID) (map[uuid.UUID][]Tag, error) {
+	tags := make(map[uuid.UUID][]Tag, len(vendorIDs))
+	if len(vendorIDs) == 0 {
+		return tags, nil
+	}
+
+	var rows []struct {
+		VendorID uuid.UUID `db:"vendor_id"`
+		Tag
+	}
+	query, args, err := QB.Select(append([]string{"vt.vendor_id"}, prefixColumns("t", tagColumns)...)...).
+		From("vendor_tags vt").
+		Join("tags t ON t.id = vt.tag_id").
+		Where(squirrel.Eq{"vt.vendor_id": vendorIDs}).
+		OrderBy("t.kind", "t.name").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = t.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tags[row.VendorID] = append(tags[row.VendorID], row.Tag)
+	}
+	return tags, nil
+}
+
+// hasAllTags keeps vendors that carry every one of the given tag slugs. Repeated slugs count
+// once, or no vendor could reach the count.
+func hasAllTags(slugs []string) squirrel.Sqlizer {
+	slugs = slices.Clone(slugs)
+	slices.Sort(slugs)
+	slugs = slices.Compact(slugs)
+	sub, args, _ := QB.Select("vt.vendor_id").
+		From("vendor_tags vt").
+		Join("tags t ON t.id = vt.tag_id").
+		Where(squirrel.Eq{"t.slug": slugs}).
+		GroupBy("vt.vendor_id").
+		Having("COUNT(DISTINCT t.id) = ?", len(slugs)).
+		PlaceholderFormat(squirrel.Question).
+		ToSql()
+	return squirrel.Expr("id IN ("+sub+")", args...)
+}
+
+// GetTagFacets counts, for every tag, the vendors that match the filters (ignoring paging),
+// so the client can show "Italian (12)" next to each option.
+func (v *VendorDB) GetTagFacets(ctx context.Context, filters utils.Filters, isVisible bool) ([]TagFacet, error) {
+	facets := []TagFacet{}
+	matching, args, err := QB.Select("id").From("vendors").
+		Where(vendorConditions(filters, isVisible)).
+		PlaceholderFormat(squirrel.Question).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	query, args, err := QB.Select(append(prefixColumns("t", tagColumns), "COUNT(*) AS count")...).
+		From("vendor_tags vt").
+		Join("tags t ON t.id = vt.tag_id").
+		Where("vt.vendor_id IN ("+matching+")", args...).
+		GroupBy("t.id").
+		OrderBy("t.kind", "count DESC", "t.name").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = v.db.SelectContext(ctx, &facets, query, args...); err != nil {
+		return nil, err
+	}
+	return facets, nil
+}
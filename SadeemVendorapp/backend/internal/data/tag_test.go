@@ -0,0 +1,26 @@
+package data
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHasAllTags(t *testing.T) {
+	tests := []struct {
+		slugs    []string
+		wantArgs string
+	}{
+		{[]string{"vegan"}, "[vegan 1]"},
+		{[]string{"vegan", "halal"}, "[halal vegan 2]"},
+		{[]string{"vegan", "halal", "vegan"}, "[halal vegan 2]"},
+	}
+	for _, tt := range tests {
+		_, args, err := hasAllTags(tt.slugs).ToSql()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(args) != tt.wantArgs {
+			t.Errorf("hasAllTags(%v) args = %v, want %v", tt.slugs, args, tt.wantArgs)
+		}
+	}
+}
@@ -37,6 +37,18 @@ func (t *Transaction) InsertOrder(order *Order) error {
 		return err
 	}
 
+	if _, err = t.tx.Exec(query, args...); err != nil {
+		return err
+	}
+
+	// Popularity counter for vendor discovery; orders themselves are cleaned up later
+	query, args, err = QB.Update("vendors").
+		Set("order_count", squirrel.Expr("order_count + 1")).
+		Where(squirrel.Eq{"id": order.VendorID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
 	_, err = t.tx.Exec(query, args...)
 	return err
 }
@@ -55,6 +67,31 @@ func (t *Transaction) InsertOrderItem(orderItem *OrderItem) error {
 	return err
 }
 
+// DecrementStock takes quantity of the item out of stock. ErrInvalidQuantity means there
+// isn't that much left.
+func (t *Transaction) DecrementStock(itemID uuid.UUID, quantity int) error {
+	query, args, err := QB.Update("items").
+		Set("quantity", squirrel.Expr("quantity - ?", quantity)).
+		Where(squirrel.Eq{"id": itemID}).
+		Where(squirrel.GtOrEq{"quantity": quantity}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := t.tx.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrInvalidQuantity
+	}
+	return nil
+}
+
 // DeleteCart deletes a cart from the database.
 func (t *Transaction) DeleteCart(cartID uuid.UUID) error {
 	query, args, err := QB.Delete("carts").
@@ -0,0 +1,463 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"project/utils/i18n"
+	"project/utils/pagination"
+	"project/utils/validator"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Languages content can be translated into.
+var Languages = []string{"en", "ar"}
+
+// VendorTranslation is a vendor's name and description in one language. A nil field falls
+// back to the vendor's own column.
+type VendorTranslation struct {
+	VendorID    uuid.UUID `db:"vendor_id" json:"-"`
+	Lang        string    `db:"lang" json:"lang"`
+	Name        *string   `db:"name" json:"name"`
+	Description *string   `db:"description" json:"description"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type ItemTranslation struct {
+	ItemID    uuid.UUID `db:"item_id" json:"item_id"`
+	Lang      string    `db:"lang" json:"lang"`
+	Name      string    `db:"name" json:"name"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type TagTranslation struct {
+	TagID     uuid.UUID `db:"tag_id" json:"tag_id"`
+	Lang      string    `db:"lang" json:"lang"`
+	Name      string    `db:"name" json:"name"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// MissingTranslation is a translatable field that has no value in the requested language.
+type MissingTranslation struct {
+	EntityType string     `db:"entity_type" json:"entity_type"`
+	EntityID   uuid.UUID  `db:"entity_id" json:"entity_id"`
+	VendorID   *uuid.UUID `db:"vendor_id" json:"vendor_id,omitempty"`
+	Field      string     `db:"field" json:"field"`
+	Value      string     `db:"value" json:"value"`
+}
+
+type TranslationDB struct {
+	db *sqlx.DB
+}
+
+func ValidatingVendorTranslation(v *validator.Validator, translation *VendorTranslation) {
+	v.Check(validator.In(translation.Lang, Languages...), "lang", i18n.LangInvalid)
+	v.Check(translation.Name != nil || translation.Description != nil, "translation", i18n.TranslationEmpty)
+	if translation.Name != nil {
+		v.Check(*translation.Name != "", "name", i18n.NameRequired)
+		v.Check(len(*translation.Name) <= 255, "name", i18n.NameTooLong, 255)
+	}
+	if translation.Description != nil {
+		v.Check(len(*translation.Description) <= 1000, "description", i18n.DescriptionTooLong, 1000)
+	}
+}
+
+func ValidatingNameTranslation(v *validator.Validator, lang, name string, maxLen int) {
+	v.Check(validator.In(lang, Languages...), "lang", i18n.LangInvalid)
+	v.Check(name != "", "name", i18n.NameRequired)
+	v.Check(len(name) <= maxLen, "name", i18n.NameTooLong, maxLen)
+}
+
+// LocalizeVendors swaps in the vendors' translated names and descriptions, and their tags' names.
+func (t *TranslationDB) LocalizeVendors(ctx context.Context, lang string, vendors []Vendor) error {
+	if len(vendors) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(vendors))
+	var tags []*Tag
+	for i := range vendors {
+		ids[i] = vendors[i].ID
+		for j := range vendors[i].Tags {
+			tags = append(tags, &vendors[i].Tags[j])
+		}
+	}
+
+	var translations []VendorTranslation
+	query, args, err := QB.Select(vendorTranslationColumns...).From("vendor_translations").
+		Where(squirrel.Eq{"vendor_id": ids, "lang": lang}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err = t.db.SelectContext(ctx, &translations, query, args...); err != nil {
+		return err
+	}
+	byVendor := make(map[uuid.UUID]VendorTranslation, len(translations))
+	for _, translation := range translations {
+		byVendor[translation.VendorID] = translation
+	}
+	for i := range vendors {
+		translation, ok := byVendor[vendors[i].ID]
+		if !ok {
+			continue
+		}
+		if translation.Name != nil {
+			vendors[i].Name = *translation.Name
+		}
+		if translation.Description != nil {
+			vendors[i].Description = *translation.Description
+		}
+	}
+
+	return t.localizeTags(ctx, lang, tags)
+}
+
+// LocalizeItems swaps in the items' translated names.
+func (t *TranslationDB) LocalizeItems(ctx context.Context, lang string, items []Item) error {
+	ids := make([]uuid.UUID, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+	names, err := t.names(ctx, "item_translations", "item_id", lang, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if name, ok := names[items[i].ID]; ok {
+			items[i].Name = name
+		}
+	}
+	return nil
+}
+
+// LocalizeReviews swaps in the translated names of the reviewed items, keeping them in name order.
+func (t *TranslationDB) LocalizeReviews(ctx context.Context, lang string, reviews []Review) error {
+	var ids []uuid.UUID
+	for _, review := range reviews {
+		for _, item := range review.Items {
+			ids = append(ids, item.ItemID)
+		}
+	}
+	names, err := t.names(ctx, "item_translations", "item_id", lang, ids)
+	if err != nil {
+		return err
+	}
+	for _, review := range reviews {
+		for j := range review.Items {
+			if name, ok := names[review.Items[j].ItemID]; ok {
+				review.Items[j].Name = name
+			}
+		}
+		sort.SliceStable(review.Items, func(a, b int) bool { return review.Items[a].Name < review.Items[b].Name })
+	}
+	return nil
+}
+
+// LocalizeTags swaps in the tags' translated names.
+func (t *TranslationDB) LocalizeTags(ctx context.Context, lang string, tags []Tag) error {
+	refs := make([]*Tag, len(tags))
+	for i := range tags {
+		refs[i] = &tags[i]
+	}
+	return t.localizeTags(ctx, lang, refs)
+}
+
+// LocalizeTagFacets swaps in the facets' translated tag names.
+func (t *TranslationDB) LocalizeTagFacets(ctx context.Context, lang string, facets []TagFacet) error {
+	refs := make([]*Tag, len(facets))
+	for i := range facets {
+		refs[i] = &facets[i].Tag
+	}
+	return t.localizeTags(ctx, lang, refs)
+}
+
+func (t *TranslationDB) localizeTags(ctx context.Context, lang string, tags []*Tag) error {
+	ids := make([]uuid.UUID, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+	names, err := t.names(ctx, "tag_translations", "tag_id", lang, ids)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if name, ok := names[tag.ID]; ok {
+			tag.Name = name
+		}
+	}
+	return nil
+}
+
+// LocalizeSearch swaps in translated names in search results.
+func (t *TranslationDB) LocalizeSearch(ctx context.Context, lang string, vendors []VendorSearchResult, items []ItemSearchResult) error {
+	vendorIDs := make([]uuid.UUID, 0, len(vendors)+len(items))
+	for _, vendor := range vendors {
+		vendorIDs = append(vendorIDs, vendor.ID)
+	}
+	itemIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		itemIDs[i] = item.ID
+		vendorIDs = append(vendorIDs, item.VendorID)
+	}
+
+	var translations []VendorTranslation
+	if len(vendorIDs) > 0 {
+		query, args, err := QB.Select(vendorTranslationColumns...).From("vendor_translations").
+			Where(squirrel.Eq{"vendor_id": vendorIDs, "lang": lang}).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		if err = t.db.SelectContext(ctx, &translations, query, args...); err != nil {
+			return err
+		}
+	}
+	byVendor := make(map[uuid.UUID]VendorTranslation, len(translations))
+	for _, translation := range translations {
+		byVendor[translation.VendorID] = translation
+	}
+	for i := range vendors {
+		if translation, ok := byVendor[vendors[i].ID]; ok {
+			if translation.Name != nil {
+				vendors[i].Name = *translation.Name
+			}
+			if translation.Description != nil {
+				vendors[i].Description = translation.Description
+			}
+		}
+	}
+
+	names, err := t.names(ctx, "item_translations", "item_id", lang, itemIDs)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if name, ok := names[items[i].ID]; ok {
+			items[i].Name = name
+		}
+		if translation, ok := byVendor[items[i].VendorID]; ok && translation.Name != nil {
+			items[i].VendorName = *translation.Name
+		}
+	}
+	return nil
+}
+
+// names loads translated names from one of the name-only translation tables.
+func (t *TranslationDB) names(ctx context.Context, table, idColumn, lang string, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	names := make(map[uuid.UUID]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+
+	var rows []struct {
+		ID   uuid.UUID `db:"id"`
+		Name string    `db:"name"`
+	}
+	query, args, err := QB.Select(idColumn+" AS id", "name").From(table).
+		Where(squirrel.Eq{idColumn: ids, "lang": lang}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = t.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		names[row.ID] = row.Name
+	}
+	return names, nil
+}
+
+// ItemTranslationSorts are the orders a vendor's item translations can be read in.
+var ItemTranslationSorts = pagination.Sorts{
+	Default: "item",
+	Orders: map[string]pagination.Order{
+		"item": {pagination.Asc("it.item_id"), pagination.Asc("it.lang")},
+	},
+}
+
+// GetVendorTranslations returns every translation of a vendor, one per language, and one
+// page of the translations of its items.
+func (t *TranslationDB) GetVendorTranslations(ctx context.Context, vendorID uuid.UUID, page pagination.Params) ([]VendorTranslation, []ItemTranslation, pagination.Metadata, error) {
+	vendor := []VendorTranslation{}
+	query, args, err := QB.Select(vendorTranslationColumns...).From("vendor_translations").
+		Where(squirrel.Eq{"vendor_id": vendorID}).
+		OrderBy("lang").
+		ToSql()
+	if err != nil {
+		return nil, nil, pagination.Metadata{}, err
+	}
+	if err = t.db.SelectContext(ctx, &vendor, query, args...); err != nil {
+		return nil, nil, pagination.Metadata{}, err
+	}
+
+	list := QB.Select(prefixColumns("it", itemTranslationColumns)...).
+		From("item_translations it").
+		Join("items i ON i.id = it.item_id").
+		Where(squirrel.Eq{"i.vendor_id": vendorID})
+	count := QB.Select("COUNT(*)").
+		From("item_translations it").
+		Join("items i ON i.id = it.item_id").
+		Where(squirrel.Eq{"i.vendor_id": vendorID})
+	items, meta, err := selectPage[ItemTranslation](ctx, t.db, list, count, page)
+	if err != nil {
+		return nil, nil, pagination.Metadata{}, err
+	}
+	return vendor, items, meta, nil
+}
+
+// UpsertVendorTranslation creates or replaces a vendor's translation in one language.
+func (t *TranslationDB) UpsertVendorTranslation(ctx context.Context, translation *VendorTranslation) error {
+	query, args, err := QB.Insert("vendor_translations").
+		Columns("vendor_id", "lang", "name", "description").
+		Values(translation.VendorID, translation.Lang, translation.Name, translation.Description).
+		Suffix("ON CONFLICT (vendor_id, lang) DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description, updated_at = NOW()").
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(vendorTranslationColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	err = t.db.QueryRowxContext(ctx, query, args...).StructScan(translation)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return ErrRecordNotFound
+		}
+		return fmt.Errorf("error while saving vendor translation: %v", err)
+	}
+	return nil
+}
+
+// UpsertItemTranslation creates or replaces an item's name in one language. The item must belong to the vendor.
+func (t *TranslationDB) UpsertItemTranslation(ctx context.Context, vendorID uuid.UUID, translation *ItemTranslation) error {
+	query, args, err := QB.Insert("item_translations").
+		Columns("item_id", "lang", "name").
+		Select(QB.Select("id").Column("?", translation.Lang).Column("?", translation.Name).
+			From("items").
+			Where(squirrel.Eq{"id": translation.ItemID, "vendor_id": vendorID})).
+		Suffix("ON CONFLICT (item_id, lang) DO UPDATE SET name = EXCLUDED.name, updated_at = NOW()").
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(itemTranslationColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return t.scanUpsert(ctx, translation, "item", query, args)
+}
+
+func (t *TranslationDB) UpsertTagTranslation(ctx context.Context, translation *TagTranslation) error {
+	query, args, err := QB.Insert("tag_translations").
+		Columns("tag_id", "lang", "name").
+		Values(translation.TagID, translation.Lang, translation.Name).
+		Suffix("ON CONFLICT (tag_id, lang) DO UPDATE SET name = EXCLUDED.name, updated_at = NOW()").
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(tagTranslationColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return t.scanUpsert(ctx, translation, "tag", query, args)
+}
+
+func (t *TranslationDB) scanUpsert(ctx context.Context, dest interface{}, entity, query string, args []interface{}) error {
+	err := t.db.QueryRowxContext(ctx, query, args...).StructScan(dest)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrRecordNotFound
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return ErrRecordNotFound
+		}
+		return fmt.Errorf("error while saving %s translation: %v", entity, err)
+	}
+	return nil
+}
+
+func (t *TranslationDB) DeleteVendorTranslation(ctx context.Context, vendorID uuid.UUID, lang string) error {
+	return t.delete(ctx, QB.Delete("vendor_translations").Where(squirrel.Eq{"vendor_id": vendorID, "lang": lang}))
+}
+
+func (t *TranslationDB) DeleteItemTranslation(ctx context.Context, vendorID, itemID uuid.UUID, lang string) error {
+	return t.delete(ctx, QB.Delete("item_translations").
+		Where(squirrel.Eq{"item_id": itemID, "lang": lang}).
+		Where("item_id IN (SELECT id FROM items WHERE vendor_id = ?)", vendorID))
+}
+
+func (t *TranslationDB) DeleteTagTranslation(ctx context.Context, tagID uuid.UUID, lang string) error {
+	return t.delete(ctx, QB.Delete("tag_translations").Where(squirrel.Eq{"tag_id": tagID, "lang": lang}))
+}
+
+func (t *TranslationDB) delete(ctx context.Context, builder squirrel.DeleteBuilder) error {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := t.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// MissingTranslationSorts are the orders the missing translations report can be read in.
+var MissingTranslationSorts = pagination.Sorts{
+	Default: "entity",
+	Orders: map[string]pagination.Order{
+		"entity": {pagination.Asc("entity_type"), pagination.Asc("value"), pagination.Asc("entity_id"), pagination.Asc("field")},
+	},
+}
+
+// MissingTranslations lists one page of the vendor names and descriptions, item names and tag names that
+// have no translation in lang. A non-nil vendorID limits the report to that vendor and its items
+// (tags are shared and only reported for the full report).
+func (t *TranslationDB) MissingTranslations(ctx context.Context, lang string, vendorID *uuid.UUID, page pagination.Params) ([]MissingTranslation, pagination.Metadata, error) {
+	vendorNames := QB.Select("'vendor' AS entity_type", "v.id AS entity_id", "v.id AS vendor_id", "'name' AS field", "v.name AS value").
+		From("vendors v").
+		LeftJoin("vendor_translations vt ON vt.vendor_id = v.id AND vt.lang = ?", lang).
+		Where("vt.name IS NULL")
+	vendorDescriptions := QB.Select("'vendor'", "v.id", "v.id", "'description'", "v.description").
+		From("vendors v").
+		LeftJoin("vendor_translations vt ON vt.vendor_id = v.id AND vt.lang = ?", lang).
+		Where("vt.description IS NULL").
+		Where("COALESCE(v.description, '') <> ''")
+	itemNames := QB.Select("'item'", "i.id", "i.vendor_id", "'name'", "i.name").
+		From("items i").
+		LeftJoin("item_translations it ON it.item_id = i.id AND it.lang = ?", lang).
+		Where("it.item_id IS NULL")
+	if vendorID != nil {
+		vendorNames = vendorNames.Where(squirrel.Eq{"v.id": *vendorID})
+		vendorDescriptions = vendorDescriptions.Where(squirrel.Eq{"v.id": *vendorID})
+		itemNames = itemNames.Where(squirrel.Eq{"i.vendor_id": *vendorID})
+	}
+	parts := []squirrel.SelectBuilder{vendorNames, vendorDescriptions, itemNames}
+	if vendorID == nil {
+		parts = append(parts, QB.Select("'tag'", "t.id", "NULL::uuid", "'name'", "t.name").
+			From("tags t").
+			LeftJoin("tag_translations tt ON tt.tag_id = t.id AND tt.lang = ?", lang).
+			Where("tt.tag_id IS NULL"))
+	}
+
+	// The parts are read as one table, so the page is sorted and counted across all of them
+	union := parts[0]
+	for _, part := range parts[1:] {
+		partSQL, partArgs, err := part.PlaceholderFormat(squirrel.Question).ToSql()
+		if err != nil {
+			return nil, pagination.Metadata{}, err
+		}
+		union = union.Suffix("UNION ALL "+partSQL, partArgs...)
+	}
+	list := QB.Select("*").FromSelect(union, "missing")
+	count := QB.Select("COUNT(*)").FromSelect(union, "missing")
+	return selectPage[MissingTranslation](ctx, t.db, list, count, page)
+}
@@ -1,11 +1,15 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"os"
 	"project/utils"
+	"project/utils/filter"
+	"project/utils/i18n"
+	"project/utils/pagination"
 	"project/utils/validator"
 	"strings"
 	"time"
@@ -21,83 +25,92 @@ type User struct {
 	Email      string    `db:"email"      json:"email"`
 	Phone      string    `db:"phone"      json:"phone"`
 	Img        *string   `db:"img"        json:"img"`
+	Version    int       `db:"version"    json:"version"`
 	Password   string    `db:"password"   json:"-"`
 	Created_at time.Time `db:"created_at" json:"created_at"`
 	Updated_at time.Time `db:"updated_at" json:"updated_at"`
 }
 
+// UserSummary is the part of a user shown to other users, such as the customer of an order.
+type UserSummary struct {
+	ID   uuid.UUID `db:"id"   json:"id"`
+	Name string    `db:"name" json:"name"`
+}
+
 type UserDB struct {
 	db *sqlx.DB
 }
 
-func ValidatingUser(v *validator.Validator, user *User, fields ...string) {
-	for _, field := range fields {
-		switch field {
-		case "name":
-			if user.Name != "" {
-				v.Check(len(user.Name) <= 20, "name", "Name must be less than 20")
-				v.Check(len(user.Name) >= 3, "name", "Name must be more than 3")
-
-			}
-		case "phone":
-			if user.Phone != "" {
-				v.Check(validator.Matches(user.Phone, validator.PhoneRX), "phone", "Invalid phone number")
-			}
-		case "email":
-
-			if user.Email != "" {
-				v.Check(validator.Matches(user.Email, validator.EmailRX), "email", "Invalid email format")
-			}
-
-		case "password":
-
-			if user.Password != "" {
-				v.Check(len(user.Password) >= 8, "password", "Password too short")
-			}
-
-		}
-	}
+func ValidatingUser(v *validator.Validator, user *User) {
+	v.Validate(
+		validator.String("name", user.Name).MinLength(3, i18n.NameTooShort).MaxLength(20, i18n.NameTooLong),
+		validator.String("phone", user.Phone).Matches(validator.PhoneRX, i18n.PhoneInvalid),
+		validator.String("email", user.Email).Matches(validator.EmailRX, i18n.EmailInvalid),
+		validator.String("password", user.Password).MinLength(8, i18n.PasswordTooShort),
+	)
 }
-func (u *UserDB) GetUsers(sortColumn, sortDirection string, page, pageSize int, searchTerm string) (*[]User, error) {
-	var users []User
 
-	// Construct query builder
-	queryBuilder := QB.Select(strings.Join(user_columns, ",")).From("users")
+// UserSorts are the orders the user list can be read in, by name or spelled out with its fields.
+var UserSorts = pagination.Sorts{
+	Default: "latest",
+	Orders: map[string]pagination.Order{
+		"latest":    {pagination.Desc("created_at"), pagination.Desc("id")},
+		"oldest":    {pagination.Asc("created_at"), pagination.Asc("id")},
+		"name_asc":  {pagination.Asc("name"), pagination.Asc("id")},
+		"name_desc": {pagination.Desc("name"), pagination.Desc("id")},
+	},
+	Fields: map[string]pagination.Column{
+		"name":       pagination.Asc("name"),
+		"email":      pagination.Asc("email"),
+		"created_at": pagination.Asc("created_at"),
+	},
+	Tie: pagination.Asc("id"),
+}
 
-	// Apply search filter
-	if searchTerm != "" {
-		queryBuilder = queryBuilder.Where("name ILIKE ?", "%"+searchTerm+"%")
-	}
+// UserFilters are the fields the user list can be filtered on, such as email[contains]=@sadeem.
+var UserFilters = filter.Fields{
+	"name":       filter.Text("name"),
+	"email":      filter.Text("email"),
+	"phone":      filter.Text("phone"),
+	"created_at": filter.Time("created_at"),
+}
 
-	// Apply sorting
-	if sortDirection == "" {
-		sortDirection = "ASC" // Default sort direction
-	}
-	validSortColumns := map[string]bool{"name": true, "created_at": true}
-	if !validSortColumns[sortColumn] {
-		sortColumn = "created_at" // Default sort column
+// GetUserSummaries loads the summaries of several users at once, keyed by ID. IDs of no user
+// are left out.
+func (u *UserDB) GetUserSummaries(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]UserSummary, error) {
+	users := make(map[uuid.UUID]UserSummary, len(ids))
+	if len(ids) == 0 {
+		return users, nil
 	}
-	queryBuilder = queryBuilder.OrderBy(sortColumn + " " + sortDirection)
-
-	// Apply pagination
-	queryBuilder = queryBuilder.Limit(uint64(pageSize)).Offset(uint64((page - 1) * pageSize))
 
-	// Build and execute query
-	query, args, err := queryBuilder.ToSql()
+	var rows []UserSummary
+	query, args, err := QB.Select("id", "name").From("users").Where(squirrel.Eq{"id": ids}).ToSql()
 	if err != nil {
 		return nil, err
 	}
-
-	err = u.db.Select(&users, query, args...)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, ErrRecordNotFound
-		}
+	if err = u.db.SelectContext(ctx, &rows, query, args...); err != nil {
 		return nil, err
 	}
+	for _, row := range rows {
+		users[row.ID] = row
+	}
+	return users, nil
+}
+
+// GetUsers lists one page of the users whose name contains the filters' search and that
+// match their conditions.
+func (u *UserDB) GetUsers(ctx context.Context, filters utils.Filters, page pagination.Params) ([]User, pagination.Metadata, error) {
+	conditions := squirrel.And{}
+	if filters.Search != "" {
+		conditions = append(conditions, squirrel.ILike{"name": "%" + filters.Search + "%"})
+	}
+	conditions = append(conditions, filters.Conditions...)
 
-	return &users, nil
+	list := QB.Select(user_columns...).From("users").Where(conditions)
+	count := QB.Select("COUNT(*)").From("users").Where(conditions)
+	return selectPage[User](ctx, u.db, list, count, page)
 }
+
 func (u *UserDB) GetUser(id uuid.UUID) (*User, error) {
 	var user User
 
@@ -205,8 +218,8 @@ func (u *UserDB) Update(user *User) error {
 		Set("phone", &user.Phone).
 		Set("password", &user.Password).
 		Set("updated_at", time.Now()).
-		Where(squirrel.Eq{"id ": user.ID}).
-		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(user_columns, ", "))).
+		Where(squirrel.Eq{"id": user.ID, "version": user.Version}).
+		Suffix("RETURNING version").
 		ToSql()
 
 	if err != nil {
@@ -214,22 +227,12 @@ func (u *UserDB) Update(user *User) error {
 		return err
 	}
 
-	result, err := u.db.Exec(query, args...)
-	if err != nil {
-		return err
-
-	}
-	rowsaffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-
-	}
-	if rowsaffected == 0 {
-		return ErrRecordNotFound
+	// No row means someone saved the user since it was read
+	err = u.db.QueryRow(query, args...).Scan(&user.Version)
+	if err == sql.ErrNoRows {
+		return ErrEditConflict
 	}
-
-	return nil
-
+	return err
 }
 func (u *UserDB) GetUserByEmail(email string) (*User, error) {
 	// Construct SQL query
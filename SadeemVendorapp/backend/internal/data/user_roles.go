@@ -1,9 +1,12 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"project/utils/i18n"
+	"project/utils/pagination"
 	"project/utils/validator"
 	"strings"
 
@@ -22,9 +25,9 @@ type UserRoleDB struct {
 }
 
 func ValidatingUserRole(v *validator.Validator, roles int) {
-	v.Check(roles >= 1 && roles <= 3, "role", "Role not found")
+	v.Check(roles >= 1 && roles <= 3, "role", i18n.RoleNotFound)
 
-	v.Check(roles == 1, "role", "You don't not have the required role to perform this operation")
+	v.Check(roles == 1, "role", i18n.RoleNotAllowed)
 }
 func (r *UserRoleDB) GrantRole(user uuid.UUID, role int) (*User_role, error) {
 
@@ -146,21 +149,18 @@ func (r *UserRoleDB) GetUserRole(id uuid.UUID) (*User_role, error) {
 	return &userRole, nil
 }
 
-func (r *UserRoleDB) GetUserRoles() (*[]User_role, error) {
-	var users_roles []User_role
-	query, args, err := QB.Select(strings.Join(user_roles, ",")).From("user_roles").ToSql()
-	if err != nil {
-
-		return nil, err
-	}
-	err = r.db.Select(&users_roles, query, args...)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, ErrRecordNotFound
-		}
-
-		return nil, err
-	}
+// UserRoleSorts are the orders the user role list can be read in. The key is
+// (user_id, role_id), so both orders end on the pair.
+var UserRoleSorts = pagination.Sorts{
+	Default: "user",
+	Orders: map[string]pagination.Order{
+		"user": {pagination.Asc("user_id"), pagination.Asc("role_id")},
+		"role": {pagination.Asc("role_id"), pagination.Asc("user_id")},
+	},
+}
 
-	return &users_roles, nil
+func (r *UserRoleDB) GetUserRoles(ctx context.Context, page pagination.Params) ([]User_role, pagination.Metadata, error) {
+	list := QB.Select(user_roles...).From("user_roles")
+	count := QB.Select("COUNT(*)").From("user_roles")
+	return selectPage[User_role](ctx, r.db, list, count, page)
 }
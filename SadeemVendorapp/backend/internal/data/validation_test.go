@@ -0,0 +1,192 @@
+package data
+
+import (
+	"project/utils/i18n"
+	"project/utils/validator"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// errorCodes is the code of every failing field.
+func errorCodes(v *validator.Validator) map[string]i18n.Code {
+	codes := map[string]i18n.Code{}
+	for key, message := range v.Errors {
+		codes[key] = message.Code
+	}
+	return codes
+}
+
+func TestValidatingUser(t *testing.T) {
+	tests := []struct {
+		name string
+		user User
+		want map[string]i18n.Code
+	}{
+		{"valid", User{Name: "Salem", Phone: "+218912345678", Email: "salem@example.com", Password: "password"}, map[string]i18n.Code{}},
+		{"empty fields are left alone", User{}, map[string]i18n.Code{}},
+		{"login fields only", User{Email: "salem@example.com", Password: "password"}, map[string]i18n.Code{}},
+		{"name too short", User{Name: "Al"}, map[string]i18n.Code{"name": i18n.NameTooShort}},
+		{"name too long", User{Name: strings.Repeat("a", 21)}, map[string]i18n.Code{"name": i18n.NameTooLong}},
+		{"arabic name", User{Name: "سالم"}, map[string]i18n.Code{}},
+		{"everything wrong", User{Name: "Al", Phone: "0912345678", Email: "salem", Password: "short"}, map[string]i18n.Code{
+			"name":     i18n.NameTooShort,
+			"phone":    i18n.PhoneInvalid,
+			"email":    i18n.EmailInvalid,
+			"password": i18n.PasswordTooShort,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidatingUser(v, &tt.user)
+			if got := errorCodes(v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatingItem(t *testing.T) {
+	expiry := time.Now().Add(24 * time.Hour)
+	tests := []struct {
+		name string
+		item Item
+		want map[string]i18n.Code
+	}{
+		{"valid", Item{Name: "Latte", Price: 10, Quantity: 3}, map[string]i18n.Code{}},
+		{"valid discount", Item{Name: "Latte", Price: 10, Discount: 8, DiscountExpiry: &expiry}, map[string]i18n.Code{}},
+		{"no price", Item{Name: "Latte"}, map[string]i18n.Code{"price": i18n.PriceNotPositive}},
+		{"negative price", Item{Price: -1}, map[string]i18n.Code{"price": i18n.PriceNotPositive}},
+		{"negative quantity", Item{Price: 10, Quantity: -1}, map[string]i18n.Code{"quantity": i18n.QuantityNegative}},
+		{"name too long", Item{Name: strings.Repeat("a", 21), Price: 10}, map[string]i18n.Code{"name": i18n.NameTooLong}},
+		{"discount not below price", Item{Price: 10, Discount: 10, DiscountExpiry: &expiry}, map[string]i18n.Code{"discount": i18n.DiscountAbovePrice}},
+		{"negative discount", Item{Price: 10, Discount: -1, DiscountExpiry: &expiry}, map[string]i18n.Code{"discount": i18n.DiscountNotPositive}},
+		{"discount without expiry", Item{Price: 10, Discount: 5}, map[string]i18n.Code{"discount_expiry": i18n.DiscountExpiryRequired}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidatingItem(v, &tt.item)
+			if got := errorCodes(v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatingVendor(t *testing.T) {
+	tests := []struct {
+		name   string
+		vendor Vendor
+		want   map[string]i18n.Code
+	}{
+		{"valid", Vendor{Name: "Cafe", Description: "Fresh coffee", SubscriptionDays: 30, Latitude: ptr(32.9), Longitude: ptr(13.2)}, map[string]i18n.Code{}},
+		{"subscription only", Vendor{SubscriptionDays: 1000}, map[string]i18n.Code{}},
+		{"no subscription", Vendor{}, map[string]i18n.Code{"subscription": i18n.DaysNotPositive}},
+		{"negative subscription", Vendor{SubscriptionDays: -5}, map[string]i18n.Code{"subscription": i18n.DaysNotPositive}},
+		{"long subscription", Vendor{SubscriptionDays: 1001}, map[string]i18n.Code{"subscription": i18n.DaysTooMany}},
+		{"short name and description", Vendor{Name: "Ca", Description: "Hot", SubscriptionDays: 30}, map[string]i18n.Code{
+			"name":        i18n.NameTooShort,
+			"description": i18n.DescriptionTooShort,
+		}},
+		{"address too long", Vendor{SubscriptionDays: 30, Address: ptr(strings.Repeat("a", 201))}, map[string]i18n.Code{"address": i18n.AddressTooLong}},
+		{"half a location", Vendor{SubscriptionDays: 30, Latitude: ptr(32.9)}, map[string]i18n.Code{"location": i18n.LocationIncomplete}},
+		{"location out of range", Vendor{SubscriptionDays: 30, Latitude: ptr(91.0), Longitude: ptr(-181.0)}, map[string]i18n.Code{
+			"latitude":  i18n.LatitudeOutOfRange,
+			"longitude": i18n.LongitudeOutOfRange,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidatingVendor(v, &tt.vendor)
+			if got := errorCodes(v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatingOrder(t *testing.T) {
+	valid := Order{CustomerID: uuid.New(), VendorID: uuid.New(), Status: "preparing", TotalOrderCost: 25}
+	tests := []struct {
+		name  string
+		order func(o *Order)
+		want  map[string]i18n.Code
+	}{
+		{"valid", func(o *Order) {}, map[string]i18n.Code{}},
+		{"completed", func(o *Order) { o.Status = "completed" }, map[string]i18n.Code{}},
+		{"unknown status", func(o *Order) { o.Status = "cancelled" }, map[string]i18n.Code{"status": i18n.OrderStatusInvalid}},
+		{"missing fields", func(o *Order) { *o = Order{} }, map[string]i18n.Code{
+			"customer_id": i18n.CustomerIDRequired,
+			"vendor_id":   i18n.VendorIDRequired,
+			"status":      i18n.OrderStatusRequired,
+		}},
+		{"negative cost", func(o *Order) { o.TotalOrderCost = -1 }, map[string]i18n.Code{"total_order_cost": i18n.TotalCostNegative}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := valid
+			tt.order(&order)
+			v := validator.New()
+			ValidatingOrder(v, &order)
+			if got := errorCodes(v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatingPlan(t *testing.T) {
+	tests := []struct {
+		name string
+		plan Plan
+		want map[string]i18n.Code
+	}{
+		{"valid", Plan{Name: "Basic", MaxTables: ptr(0), BillingDays: 30}, map[string]i18n.Code{}},
+		{"unlimited", Plan{Name: "Pro", Price: 100, BillingDays: 365}, map[string]i18n.Code{}},
+		{"missing name and period", Plan{}, map[string]i18n.Code{"name": i18n.NameRequired, "billing_days": i18n.DaysNotPositive}},
+		{"negative limits", Plan{Name: "Basic", MaxItems: ptr(-1), MaxAPIKeys: ptr(-1), Price: -1, BillingDays: 1001}, map[string]i18n.Code{
+			"max_items":    i18n.LimitNegative,
+			"max_api_keys": i18n.LimitNegative,
+			"price":        i18n.PriceNegative,
+			"billing_days": i18n.DaysTooMany,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidatingPlan(v, &tt.plan)
+			if got := errorCodes(v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatingTag(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  Tag
+		want map[string]i18n.Code
+	}{
+		{"valid", Tag{Name: "Sea food", Slug: "sea-food", Kind: "cuisine"}, map[string]i18n.Code{}},
+		{"empty", Tag{}, map[string]i18n.Code{"name": i18n.NameRequired, "slug": i18n.SlugInvalid, "kind": i18n.TagKindInvalid}},
+		{"bad slug and kind", Tag{Name: "Sea food", Slug: "Sea Food", Kind: "mood"}, map[string]i18n.Code{"slug": i18n.SlugInvalid, "kind": i18n.TagKindInvalid}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidatingTag(v, &tt.tag)
+			if got := errorCodes(v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
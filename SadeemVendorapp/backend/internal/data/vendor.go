@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"os"
 	"project/utils"
+	"project/utils/filter"
+	"project/utils/i18n"
+	"project/utils/pagination"
 	"project/utils/validator"
 	"strings"
 	"time"
@@ -17,15 +20,31 @@ import (
 )
 
 type Vendor struct {
-	ID               uuid.UUID `db:"id" json:"id"`
-	Name             string    `db:"name" json:"name"`
-	Img              *string   `db:"img" json:"img"`
-	Description      string    `db:"description" json:"description"`
-	CreatedAt        time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
-	SubscriptionEnd  time.Time `db:"subscription_end" json:"subscription_end"`
-	SubscriptionDays int       `db:"subscription_days" json:"-"`
-	IsVisible        bool      `db:"is_visible" json:"is_visible"`
+	ID               uuid.UUID         `db:"id" json:"id"`
+	Name             string            `db:"name" json:"name"`
+	Img              *string           `db:"img" json:"img"`
+	Description      string            `db:"description" json:"description"`
+	CreatedAt        time.Time         `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time         `db:"updated_at" json:"updated_at"`
+	SubscriptionEnd  time.Time         `db:"subscription_end" json:"subscription_end"`
+	SubscriptionDays int               `db:"subscription_days" json:"-"`
+	IsVisible        bool              `db:"is_visible" json:"is_visible"`
+	PlanID           *uuid.UUID        `db:"plan_id" json:"plan_id"`
+	GraceDays        int               `db:"grace_days" json:"grace_days"`
+	Timezone         string            `db:"timezone" json:"timezone"`
+	PausedUntil      *time.Time        `db:"paused_until" json:"paused_until"`
+	PauseReason      *string           `db:"pause_reason" json:"pause_reason"`
+	Address          *string           `db:"address" json:"address"`
+	Latitude         *float64          `db:"latitude" json:"latitude"`
+	Longitude        *float64          `db:"longitude" json:"longitude"`
+	DistanceKm       *float64          `db:"distance_km" json:"distance_km,omitempty"`
+	RatingAvg        float64           `db:"rating_avg" json:"rating_avg"`
+	RatingCount      int               `db:"rating_count" json:"rating_count"`
+	OrderCount       int               `db:"order_count" json:"order_count"`
+	Version          int               `db:"version" json:"version"`
+	Tags             []Tag             `db:"-" json:"tags,omitempty"`
+	OpeningStatus    *OpenStatus       `db:"-" json:"opening_status,omitempty"`
+	Admins           []VendorAdminUser `db:"-" json:"admins,omitempty"`
 }
 
 type VendorDB struct {
@@ -33,29 +52,23 @@ type VendorDB struct {
 }
 
 func ValidatingVendor(v *validator.Validator, vendor *Vendor) {
-	if vendor.Name != "" {
-		v.Check(vendor.Name != "", "name", "Name can not be empty")
-		v.Check(len(vendor.Name) >= 3, "name", "Name can't be less than 3 letters")
-		v.Check(len(vendor.Name) <= 20, "name", "Name can't be larger than 20 letters ")
-	}
-	if vendor.Description != "" {
-		v.Check(vendor.Description != "", "description", "description can't be empty ")
-		v.Check(len(vendor.Description) >= 5, "description", "description can't be less than 5 letters ")
-		v.Check(len(vendor.Description) <= 60, "description", "description can't be larger than 60 letters ")
-
-	}
-	if vendor.SubscriptionDays >= 0 {
-		v.Check(vendor.SubscriptionDays <= 1000, "subscription", "sub days must be less than 1000")
-		v.Check(vendor.SubscriptionDays > 0, "subscription", "must be more then 0 days")
-
-	}
+	v.Validate(
+		validator.String("name", vendor.Name).MinLength(3, i18n.NameTooShort).MaxLength(20, i18n.NameTooLong),
+		validator.String("description", vendor.Description).MinLength(5, i18n.DescriptionTooShort).MaxLength(60, i18n.DescriptionTooLong),
+		validator.Number("subscription", vendor.SubscriptionDays).Required(i18n.DaysNotPositive).Max(1000, i18n.DaysTooMany).Above(0, i18n.DaysNotPositive),
+		validator.OptionalString("address", vendor.Address).MaxLength(200, i18n.AddressTooLong),
+		validator.Expect("location", (vendor.Latitude == nil) == (vendor.Longitude == nil), i18n.LocationIncomplete),
+		validator.OptionalNumber("latitude", vendor.Latitude).Min(-90, i18n.LatitudeOutOfRange).Max(90, i18n.LatitudeOutOfRange),
+		validator.OptionalNumber("longitude", vendor.Longitude).Min(-180, i18n.LongitudeOutOfRange).Max(180, i18n.LongitudeOutOfRange),
+	)
 }
+
 func (v *VendorDB) InsertVendor(vendor *Vendor) error {
 	vendor.SubscriptionEnd = time.Now().AddDate(0, 0, vendor.SubscriptionDays)
 
 	query, args, err := QB.Insert("vendors").
-		Columns("name", "img", "description", "subscription_end", "subscription_days").
-		Values(vendor.Name, vendor.Img, vendor.Description, vendor.SubscriptionEnd, vendor.SubscriptionDays).
+		Columns("name", "img", "description", "subscription_end", "subscription_days", "address", "latitude", "longitude").
+		Values(vendor.Name, vendor.Img, vendor.Description, vendor.SubscriptionEnd, vendor.SubscriptionDays, vendor.Address, vendor.Latitude, vendor.Longitude).
 		Suffix(fmt.Sprintf("RETURNING %s", fmt.Sprint(strings.Join(vendors_columns, ",")))).ToSql()
 	if err != nil {
 		return err
@@ -100,6 +113,9 @@ func (v *VendorDB) DeleteVendor(id uuid.UUID) (*Vendor, error) {
 	}
 	return &vendor, nil
 }
+
+// UpdateVendor saves vendor if it is still at vendor.Version, and reads it back.
+// ErrEditConflict means it was changed or deleted since it was read.
 func (v *VendorDB) UpdateVendor(vendor *Vendor) error {
 	var newSubscriptionEnd time.Time
 	if vendor.SubscriptionEnd.After(time.Now()) {
@@ -116,8 +132,11 @@ func (v *VendorDB) UpdateVendor(vendor *Vendor) error {
 		Set("description", vendor.Description).
 		Set("subscription_end", newSubscriptionEnd).
 		Set("subscription_days", vendor.SubscriptionDays).
+		Set("address", vendor.Address).
+		Set("latitude", vendor.Latitude).
+		Set("longitude", vendor.Longitude).
 		Set("updated_at", time.Now()).
-		Where(squirrel.Eq{"id": vendor.ID}).
+		Where(squirrel.Eq{"id": vendor.ID, "version": vendor.Version}).
 		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(vendors_columns, ","))).
 		ToSql()
 	if err != nil {
@@ -127,68 +146,93 @@ func (v *VendorDB) UpdateVendor(vendor *Vendor) error {
 	// Execute the query
 	err = v.db.QueryRowx(query, args...).StructScan(vendor)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrEditConflict
+		}
 		return fmt.Errorf("UpdateVendor: %v", err)
 	}
 
 	return nil
 }
-func (v *VendorDB) GetVendors(filters utils.Filters, isVisible bool) (*[]Vendor, int, error) {
-	var vendors []Vendor
-	offset := (filters.Page - 1) * filters.PageSize
 
-	// Create query builder
-	queryBuilder := QB.Select(strings.Join(vendors_columns, ",")).From("vendors")
+// VendorFilters are the fields the vendor list can be filtered on, such as rating_avg[gte]=4.
+var VendorFilters = filter.Fields{
+	"name":         filter.Text("name"),
+	"rating_avg":   filter.Number("rating_avg"),
+	"rating_count": filter.Integer("rating_count"),
+	"order_count":  filter.Integer("order_count"),
+	"created_at":   filter.Time("created_at"),
+}
+
+// VendorSorts are the orders the vendor list can be read in, by name or spelled out with its
+// fields. Sorting by distance needs near, and is the default with it.
+func VendorSorts(near *utils.Near) pagination.Sorts {
+	sorts := pagination.Sorts{
+		Default: "latest",
+		Orders: map[string]pagination.Order{
+			"latest":    {pagination.Desc("created_at"), pagination.Desc("id")},
+			"name_asc":  {pagination.Asc("name"), pagination.Asc("id")},
+			"name_desc": {pagination.Desc("name"), pagination.Desc("id")},
+			"rating":    {pagination.Desc("rating_avg"), pagination.Desc("rating_count"), pagination.Asc("id")},
+			"popular":   {pagination.Desc("order_count"), pagination.Asc("id")},
+		},
+		Fields: map[string]pagination.Column{
+			"name":         pagination.Asc("name"),
+			"created_at":   pagination.Asc("created_at"),
+			"rating_avg":   pagination.Asc("rating_avg"),
+			"rating_count": pagination.Asc("rating_count"),
+			"order_count":  pagination.Asc("order_count"),
+		},
+		Tie: pagination.Asc("id"),
+	}
+	if near != nil {
+		sorts.Default = "distance"
+		sorts.Orders["distance"] = pagination.Order{
+			pagination.Asc(distanceExpr, near.Lat, near.Lat, near.Lng).As("distance_km"),
+			pagination.Asc("id"),
+		}
+	}
+	return sorts
+}
+
+// GetVendors lists one page of the vendors matching filters.
+func (v *VendorDB) GetVendors(ctx context.Context, filters utils.Filters, isVisible bool, page pagination.Params) ([]Vendor, pagination.Metadata, error) {
+	// The list, the count and the facets share the same conditions
+	conditions := vendorConditions(filters, isVisible)
+
+	list := QB.Select(vendors_columns...).From("vendors").Where(conditions)
+	if filters.Near != nil {
+		list = list.Column(distanceColumn(filters.Near))
+	}
+	count := QB.Select("COUNT(*)").From("vendors").Where(conditions)
+	return selectPage[Vendor](ctx, v.db, list, count, page)
+}
+
+// vendorConditions turns the index filters into a WHERE clause on vendors.
+func vendorConditions(filters utils.Filters, isVisible bool) squirrel.And {
+	conditions := squirrel.And{}
 
 	// Apply visibility filter
 	if !isVisible {
-		queryBuilder = queryBuilder.Where(squirrel.Eq{"is_visible": true})
+		conditions = append(conditions, visibleCondition(""))
 	}
 
 	// Apply search filter
 	if filters.Search != "" {
 		searchTerm := "%" + filters.Search + "%"
-		queryBuilder = queryBuilder.Where("name ILIKE ?", searchTerm)
+		conditions = append(conditions, squirrel.Expr("name ILIKE ?", searchTerm))
 	}
 
-	// Apply sorting
-	switch filters.Sort {
-	case "latest":
-		queryBuilder = queryBuilder.OrderBy("created_at DESC")
-	case "name_asc":
-		queryBuilder = queryBuilder.OrderBy("name ASC")
-	case "name_desc":
-		queryBuilder = queryBuilder.OrderBy("name DESC")
-	default:
-		queryBuilder = queryBuilder.OrderBy("created_at DESC")
+	// Apply the near me filter
+	if filters.Near != nil {
+		conditions = append(conditions, nearCondition(filters.Near))
 	}
 
-	// Apply pagination
-	query, args, err := queryBuilder.Limit(uint64(filters.PageSize)).Offset(uint64(offset)).ToSql()
-	if err != nil {
-		return nil, 0, err
+	// Apply the tag filter
+	if len(filters.Tags) > 0 {
+		conditions = append(conditions, hasAllTags(filters.Tags))
 	}
-
-	err = v.db.Select(&vendors, query, args...)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, 0, ErrRecordNotFound
-		}
-		return nil, 0, err
-	}
-
-	// Get the total number of vendors
-	var totalVendorsCount int
-	countQuery, _, err := QB.Select("COUNT(*)").From("vendors").
-		Where("subscription_end >= CURRENT_TIMESTAMP").ToSql()
-	if err != nil {
-		return nil, 0, err
-	}
-	err = v.db.Get(&totalVendorsCount, countQuery)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return &vendors, totalVendorsCount, nil
+	return append(conditions, filters.Conditions...)
 }
 
 func (v *VendorDB) GetVendor(id uuid.UUID, isVisible bool) (*Vendor, error) {
@@ -196,7 +240,7 @@ func (v *VendorDB) GetVendor(id uuid.UUID, isVisible bool) (*Vendor, error) {
 	queryBuilder := QB.Select(strings.Join(vendors_columns, ",")).From("vendors").Where(squirrel.Eq{"id": id})
 
 	if !isVisible {
-		queryBuilder = queryBuilder.Where("is_visible = TRUE")
+		queryBuilder = queryBuilder.Where(visibleCondition(""))
 	}
 
 	query, args, err := queryBuilder.ToSql()
@@ -214,6 +258,37 @@ func (v *VendorDB) GetVendor(id uuid.UUID, isVisible bool) (*Vendor, error) {
 
 	return &vendor, nil
 }
+
+// GetUserVendorsPage lists one page of the vendors the user administers that match the filters,
+// hidden ones included.
+func (v *VendorDB) GetUserVendorsPage(ctx context.Context, userID uuid.UUID, filters utils.Filters, page pagination.Params) ([]Vendor, pagination.Metadata, error) {
+	administered := append(squirrel.And{squirrel.Expr("id IN (SELECT vendor_id FROM vendor_admins WHERE user_id = ?)", userID)}, vendorConditions(filters, true)...)
+	list := QB.Select(vendors_columns...).From("vendors").Where(administered)
+	count := QB.Select("COUNT(*)").From("vendors").Where(administered)
+	return selectPage[Vendor](ctx, v.db, list, count, page)
+}
+
+// GetVendorsByID loads several vendors at once, keyed by ID. IDs of no vendor are left out.
+func (v *VendorDB) GetVendorsByID(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]Vendor, error) {
+	vendors := make(map[uuid.UUID]Vendor, len(ids))
+	if len(ids) == 0 {
+		return vendors, nil
+	}
+
+	var rows []Vendor
+	query, args, err := QB.Select(vendors_columns...).From("vendors").Where(squirrel.Eq{"id": ids}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = v.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		vendors[row.ID] = row
+	}
+	return vendors, nil
+}
+
 func (v *VendorDB) GetUserVendors(ctx context.Context, userID uuid.UUID) ([]Vendor, error) {
 	var vendors []Vendor
 	query, args, err := QB.Select(
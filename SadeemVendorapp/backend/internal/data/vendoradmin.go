@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"project/utils/pagination"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
@@ -28,7 +29,18 @@ type VendorAdminDB struct {
 }
 
 // InsertVendorAdmin inserts a new vendor admin record into the database.
+// Staff accounts count against the vendor's plan.
 func (v *VendorAdminDB) InsertVendorAdmin(ctx context.Context, vendor VendorAdmin) (*VendorAdmin, error) {
+	tx, err := v.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = checkPlanLimit(ctx, tx, vendor.VendorID, PlanResourceStaff); err != nil {
+		return nil, err
+	}
+
 	query, args, err := QB.Insert("vendor_admins").Columns("user_id", "vendor_id").
 		Values(vendor.UserID, vendor.VendorID).
 		Suffix("RETURNING user_id, vendor_id").ToSql()
@@ -36,7 +48,7 @@ func (v *VendorAdminDB) InsertVendorAdmin(ctx context.Context, vendor VendorAdmi
 		return nil, err
 	}
 
-	err = v.db.QueryRowxContext(ctx, query, args...).StructScan(&vendor)
+	err = tx.QueryRowxContext(ctx, query, args...).StructScan(&vendor)
 	if err != nil {
 		// Check for unique constraint violation (PostgreSQL error code for unique violation is 23505)
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
@@ -47,6 +59,9 @@ func (v *VendorAdminDB) InsertVendorAdmin(ctx context.Context, vendor VendorAdmi
 		}
 		return nil, err
 	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
 	return &vendor, nil
 }
 
@@ -71,6 +86,25 @@ func (v *VendorAdminDB) GetVendorAdmin(ctx context.Context, userID, vendorID uui
 	}
 	return &vendorAdmin, nil
 }
+
+// VendorAdminSorts are the orders a vendor's admins can be read in.
+var VendorAdminSorts = pagination.Sorts{
+	Default: "email",
+	Orders: map[string]pagination.Order{
+		"email": {pagination.Asc("users.email"), pagination.Asc("vendor_admins.user_id")},
+	},
+}
+
+// GetVendorAdminsPage lists one page of a vendor's admins.
+func (v *VendorAdminDB) GetVendorAdminsPage(ctx context.Context, vendorID uuid.UUID, page pagination.Params) ([]VendorAdminUser, pagination.Metadata, error) {
+	list := QB.Select("vendor_admins.user_id, vendor_admins.vendor_id, users.email").
+		From("vendor_admins").
+		Join("users ON vendor_admins.user_id = users.id").
+		Where(squirrel.Eq{"vendor_admins.vendor_id": vendorID})
+	count := QB.Select("COUNT(*)").From("vendor_admins").Where(squirrel.Eq{"vendor_id": vendorID})
+	return selectPage[VendorAdminUser](ctx, v.db, list, count, page)
+}
+
 func (v *VendorAdminDB) GetVendorAdmins(ctx context.Context, vendorID uuid.UUID) ([]VendorAdminUser, error) {
 	vendorinfo := []VendorAdminUser{}
 	query, args, err := QB.Select("vendor_admins.user_id, vendor_admins.vendor_id, users.email").
@@ -92,6 +126,32 @@ func (v *VendorAdminDB) GetVendorAdmins(ctx context.Context, vendorID uuid.UUID)
 	return vendorinfo, nil
 }
 
+// GetVendorsAdmins loads the admins of several vendors at once, keyed by vendor ID.
+func (v *VendorAdminDB) GetVendorsAdmins(ctx context.Context, vendorIDs []uuid.UUID) (map[uuid.UUID][]VendorAdminUser, error) {
+	admins := make(map[uuid.UUID][]VendorAdminUser, len(vendorIDs))
+	if len(vendorIDs) == 0 {
+		return admins, nil
+	}
+
+	var rows []VendorAdminUser
+	query, args, err := QB.Select("vendor_admins.user_id, vendor_admins.vendor_id, users.email").
+		From("vendor_admins").
+		Join("users ON vendor_admins.user_id = users.id").
+		Where(squirrel.Eq{"vendor_admins.vendor_id": vendorIDs}).
+		OrderBy("users.email").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err = v.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		admins[row.VendorID] = append(admins[row.VendorID], row)
+	}
+	return admins, nil
+}
+
 // UpdateVendorAdmin updates an existing vendor admin record in the database.
 func (v *VendorAdminDB) UpdateVendorAdmin(ctx context.Context, vendor VendorAdmin) (*VendorAdmin, error) {
 	query, args, err := QB.Update("vendor_admins").
@@ -130,7 +190,7 @@ func (v *VendorAdminDB) DeleteVendorAdmin(ctx context.Context, userID, vendorID
 		return err
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("vendor admin not found")
+		return ErrRecordNotFound
 	}
 	return nil
 }
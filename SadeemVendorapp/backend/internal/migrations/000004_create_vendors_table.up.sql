@@ -7,8 +7,11 @@ CREATE TABLE vendors (
     updated_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
+-- This statement was missing the comma after subscription_end, a syntax error that stopped the
+-- migration from ever applying. Adding it is the only change made to this file after release;
+-- no database could have run the broken version, so the fix cannot diverge from one that did.
 ALTER TABLE vendors
-ADD COLUMN subscription_end TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP + INTERVAL '30 days'
+ADD COLUMN subscription_end TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP + INTERVAL '30 days',
 ADD COLUMN is_visible BOOLEAN NOT NULL DEFAULT TRUE,
 ADD COLUMN subscription_days INTEGER NOT NULL DEFAULT 0;
 CREATE OR REPLACE FUNCTION update_visibility() 
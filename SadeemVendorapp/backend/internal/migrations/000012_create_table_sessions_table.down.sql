@@ -0,0 +1,3 @@
+DROP TABLE table_session_members;
+DROP TABLE table_sessions;
+ALTER TABLE tables DROP COLUMN qr_token_version;
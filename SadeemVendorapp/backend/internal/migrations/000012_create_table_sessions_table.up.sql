@@ -0,0 +1,40 @@
+ALTER TABLE tables ADD COLUMN qr_token_version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE table_sessions (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    table_id    uuid NOT NULL,
+    opened_by   uuid NOT NULL,
+    opened_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at   TIMESTAMP DEFAULT NULL,
+
+    CONSTRAINT fk_table_id
+    FOREIGN KEY (table_id)
+        REFERENCES tables (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_opened_by
+    FOREIGN KEY (opened_by)
+        REFERENCES users (id)
+        ON DELETE CASCADE
+);
+
+-- a table can only have one open session at a time
+CREATE UNIQUE INDEX table_sessions_open_idx ON table_sessions (table_id) WHERE closed_at IS NULL;
+
+CREATE TABLE table_session_members (
+    session_id  uuid NOT NULL,
+    user_id     uuid NOT NULL,
+    joined_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (session_id, user_id),
+
+    CONSTRAINT fk_session_id
+    FOREIGN KEY (session_id)
+        REFERENCES table_sessions (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_user_id
+    FOREIGN KEY (user_id)
+        REFERENCES users (id)
+        ON DELETE CASCADE
+);
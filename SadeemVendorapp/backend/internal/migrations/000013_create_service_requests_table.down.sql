@@ -0,0 +1,2 @@
+DROP TABLE service_requests;
+DROP TYPE service_request_type;
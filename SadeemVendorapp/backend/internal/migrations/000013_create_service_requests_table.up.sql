@@ -0,0 +1,43 @@
+CREATE TYPE service_request_type AS ENUM ('call_waiter', 'bring_bill', 'water', 'cleaning');
+
+CREATE TABLE service_requests (
+    id               uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    table_id         uuid NOT NULL,
+    vendor_id        uuid NOT NULL,
+    customer_id      uuid NOT NULL,
+    type             service_request_type NOT NULL,
+    note             TEXT,
+    created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    acknowledged_at  TIMESTAMP DEFAULT NULL,
+    acknowledged_by  uuid DEFAULT NULL,
+    resolved_at      TIMESTAMP DEFAULT NULL,
+    resolved_by      uuid DEFAULT NULL,
+
+    CONSTRAINT fk_table_id
+    FOREIGN KEY (table_id)
+        REFERENCES tables (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_customer_id
+    FOREIGN KEY (customer_id)
+        REFERENCES users (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_acknowledged_by
+    FOREIGN KEY (acknowledged_by)
+        REFERENCES users (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_resolved_by
+    FOREIGN KEY (resolved_by)
+        REFERENCES users (id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX service_requests_vendor_idx ON service_requests (vendor_id, created_at);
+CREATE INDEX service_requests_open_idx ON service_requests (table_id) WHERE resolved_at IS NULL;
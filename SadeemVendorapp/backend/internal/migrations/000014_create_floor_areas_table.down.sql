@@ -0,0 +1,8 @@
+ALTER TABLE tables
+DROP COLUMN capacity,
+DROP COLUMN rotation,
+DROP COLUMN shape,
+DROP COLUMN pos_y,
+DROP COLUMN pos_x,
+DROP COLUMN area_id;
+DROP TABLE floor_areas;
@@ -0,0 +1,30 @@
+CREATE TABLE floor_areas (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id   uuid NOT NULL,
+    name        VARCHAR(255) NOT NULL,
+    sort_order  INT NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uq_floor_areas_vendor_name UNIQUE (vendor_id, name),
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE
+);
+
+ALTER TABLE tables
+ADD COLUMN area_id  uuid DEFAULT NULL,
+ADD COLUMN pos_x    DOUBLE PRECISION NOT NULL DEFAULT 0,
+ADD COLUMN pos_y    DOUBLE PRECISION NOT NULL DEFAULT 0,
+ADD COLUMN shape    VARCHAR(20) NOT NULL DEFAULT 'square',
+ADD COLUMN rotation INT NOT NULL DEFAULT 0,
+ADD COLUMN capacity INT NOT NULL DEFAULT 4,
+ADD CONSTRAINT fk_area_id
+    FOREIGN KEY (area_id)
+        REFERENCES floor_areas (id)
+        ON DELETE SET NULL,
+ADD CONSTRAINT ck_shape CHECK (shape IN ('square', 'round', 'rectangle')),
+ADD CONSTRAINT ck_rotation CHECK (rotation >= 0 AND rotation < 360),
+ADD CONSTRAINT ck_capacity CHECK (capacity > 0);
@@ -0,0 +1,3 @@
+ALTER TABLE vendors DROP CONSTRAINT IF EXISTS fk_vendor_plan;
+ALTER TABLE vendors DROP COLUMN IF EXISTS plan_id;
+DROP TABLE IF EXISTS plans;
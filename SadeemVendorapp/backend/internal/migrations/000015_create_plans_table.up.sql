@@ -0,0 +1,26 @@
+CREATE TABLE plans (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name          VARCHAR(50) NOT NULL UNIQUE,
+    max_tables    INTEGER CHECK (max_tables IS NULL OR max_tables >= 0),
+    max_items     INTEGER CHECK (max_items IS NULL OR max_items >= 0),
+    max_staff     INTEGER CHECK (max_staff IS NULL OR max_staff >= 0),
+    max_api_keys  INTEGER CHECK (max_api_keys IS NULL OR max_api_keys >= 0),
+    price         NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (price >= 0),
+    billing_days  INTEGER NOT NULL DEFAULT 30 CHECK (billing_days > 0),
+    is_default    BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Vendors without a plan, or whose subscription has ended, get the default plan's limits
+CREATE UNIQUE INDEX plans_single_default_idx ON plans (is_default) WHERE is_default;
+
+-- A NULL limit means unlimited. Basic keeps the old 12-table cap.
+INSERT INTO plans (name, max_tables, max_items, max_staff, max_api_keys, price, billing_days, is_default) VALUES
+    ('Basic', 12, 100, 3, 1, 0, 30, TRUE),
+    ('Pro', 40, 500, 15, 5, 0, 30, FALSE),
+    ('Enterprise', NULL, NULL, NULL, NULL, 0, 30, FALSE);
+
+ALTER TABLE vendors
+ADD COLUMN plan_id uuid,
+ADD CONSTRAINT fk_vendor_plan FOREIGN KEY (plan_id) REFERENCES plans (id) ON DELETE SET NULL;
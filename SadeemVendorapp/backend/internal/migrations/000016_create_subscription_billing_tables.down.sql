@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS subscription_history;
+DROP TABLE IF EXISTS subscription_invoices;
+DROP TYPE IF EXISTS invoice_status;
+
+CREATE OR REPLACE FUNCTION update_visibility()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.subscription_end < CURRENT_TIMESTAMP THEN
+        NEW.is_visible := FALSE;
+    ELSE
+        NEW.is_visible := TRUE;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE vendors DROP COLUMN IF EXISTS grace_days;
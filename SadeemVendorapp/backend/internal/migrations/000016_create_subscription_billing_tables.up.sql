@@ -0,0 +1,68 @@
+ALTER TABLE vendors
+ADD COLUMN grace_days INTEGER NOT NULL DEFAULT 3 CHECK (grace_days >= 0);
+
+-- A vendor stays visible until its grace period has run out, not just until subscription_end
+CREATE OR REPLACE FUNCTION update_visibility()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.subscription_end + make_interval(days => NEW.grace_days) < CURRENT_TIMESTAMP THEN
+        NEW.is_visible := FALSE;
+    ELSE
+        NEW.is_visible := TRUE;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TYPE invoice_status AS ENUM ('pending', 'paid', 'void');
+
+CREATE TABLE subscription_invoices (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id     uuid NOT NULL,
+    plan_id       uuid,
+    amount        NUMERIC(10, 2) NOT NULL CHECK (amount >= 0),
+    days          INTEGER NOT NULL CHECK (days > 0),
+    status        invoice_status NOT NULL DEFAULT 'pending',
+    period_start  TIMESTAMP,
+    period_end    TIMESTAMP,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    paid_at       TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_plan_id
+    FOREIGN KEY (plan_id)
+        REFERENCES plans (id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX subscription_invoices_vendor_idx ON subscription_invoices (vendor_id, created_at);
+
+CREATE TABLE subscription_history (
+    id                uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id         uuid NOT NULL,
+    event             VARCHAR(20) NOT NULL CHECK (event IN ('renewed', 'grace_started', 'lapsed', 'restored', 'reminder')),
+    is_visible        BOOLEAN NOT NULL,
+    subscription_end  TIMESTAMP NOT NULL,
+    invoice_id        uuid,
+    note              TEXT,
+    created_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_invoice_id
+    FOREIGN KEY (invoice_id)
+        REFERENCES subscription_invoices (id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX subscription_history_vendor_idx ON subscription_history (vendor_id, created_at);
+-- One reminder of each kind per subscription period, and one grace notice per period
+CREATE UNIQUE INDEX subscription_history_reminder_idx ON subscription_history (vendor_id, subscription_end, note) WHERE event = 'reminder';
+CREATE UNIQUE INDEX subscription_history_grace_idx ON subscription_history (vendor_id, subscription_end) WHERE event = 'grace_started';
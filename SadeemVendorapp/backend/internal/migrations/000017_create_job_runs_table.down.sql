@@ -0,0 +1,32 @@
+DROP TABLE IF EXISTS job_runs;
+
+CREATE OR REPLACE FUNCTION update_discount_on_expiry()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.discount_expires_at IS NOT NULL AND OLD.discount_expires_at < NOW() THEN
+        NEW.discount := 0;
+        NEW.discount_expires_at := NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER discount_expiry_trigger
+BEFORE UPDATE ON items
+FOR EACH ROW
+EXECUTE FUNCTION update_discount_on_expiry();
+
+CREATE OR REPLACE FUNCTION delete_completed_order_trigger()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (NEW.status = 'completed' AND EXTRACT(EPOCH FROM (NOW() - NEW.updated_at)) > 1800) THEN
+        DELETE FROM orders WHERE id = NEW.id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER delete_completed_order_trigger
+AFTER UPDATE OF status ON orders
+FOR EACH ROW
+EXECUTE PROCEDURE delete_completed_order_trigger();
@@ -0,0 +1,21 @@
+CREATE TABLE job_runs (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_name      VARCHAR(100) NOT NULL,
+    triggered_by  VARCHAR(20) NOT NULL CHECK (triggered_by IN ('schedule', 'manual')),
+    instance      VARCHAR(255) NOT NULL,
+    status        VARCHAR(20) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    result        TEXT,
+    error         TEXT,
+    started_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at   TIMESTAMP
+);
+
+CREATE INDEX job_runs_job_idx ON job_runs (job_name, started_at);
+
+-- Discount expiry and completed-order cleanup only ran when a row happened to be updated.
+-- They are now scheduled jobs in the API (see cmd/api/jobs.go). update_visibility stays so a
+-- renewal shows the vendor straight away; the subscription job handles time passing.
+DROP TRIGGER IF EXISTS discount_expiry_trigger ON items;
+DROP FUNCTION IF EXISTS update_discount_on_expiry();
+DROP TRIGGER IF EXISTS delete_completed_order_trigger ON orders;
+DROP FUNCTION IF EXISTS delete_completed_order_trigger();
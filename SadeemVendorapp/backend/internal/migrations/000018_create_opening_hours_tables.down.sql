@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS vendor_hour_exceptions;
+DROP TABLE IF EXISTS vendor_opening_hours;
+
+ALTER TABLE vendors
+DROP COLUMN IF EXISTS pause_reason,
+DROP COLUMN IF EXISTS paused_until,
+DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,42 @@
+ALTER TABLE vendors
+ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+ADD COLUMN paused_until TIMESTAMPTZ,
+ADD COLUMN pause_reason VARCHAR(200);
+
+-- A closing time at or before the opening time runs past midnight into the next day
+CREATE TABLE vendor_opening_hours (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id   uuid NOT NULL,
+    weekday     SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    opens_at    TIME NOT NULL,
+    closes_at   TIME NOT NULL,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX vendor_opening_hours_vendor_idx ON vendor_opening_hours (vendor_id, weekday);
+
+-- Dated exceptions replace the weekly hours for that day: either closed all day
+-- or one or more special intervals
+CREATE TABLE vendor_hour_exceptions (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id   uuid NOT NULL,
+    day         DATE NOT NULL,
+    is_closed   BOOLEAN NOT NULL DEFAULT FALSE,
+    opens_at    TIME,
+    closes_at   TIME,
+    note        VARCHAR(100),
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_exception_hours
+    CHECK (is_closed OR (opens_at IS NOT NULL AND closes_at IS NOT NULL))
+);
+
+CREATE INDEX vendor_hour_exceptions_vendor_idx ON vendor_hour_exceptions (vendor_id, day);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS vendors_location_idx;
+
+ALTER TABLE vendors
+DROP CONSTRAINT IF EXISTS chk_vendor_coordinates,
+DROP COLUMN IF EXISTS longitude,
+DROP COLUMN IF EXISTS latitude,
+DROP COLUMN IF EXISTS address;
@@ -0,0 +1,12 @@
+ALTER TABLE vendors
+ADD COLUMN address VARCHAR(200),
+ADD COLUMN latitude DOUBLE PRECISION,
+ADD COLUMN longitude DOUBLE PRECISION,
+ADD CONSTRAINT chk_vendor_coordinates CHECK (
+    (latitude IS NULL AND longitude IS NULL)
+    OR (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+-- Nearby search narrows candidates with a latitude/longitude bounding box before
+-- computing the great-circle distance, so a plain btree is enough (no PostGIS)
+CREATE INDEX vendors_location_idx ON vendors (latitude, longitude) WHERE latitude IS NOT NULL;
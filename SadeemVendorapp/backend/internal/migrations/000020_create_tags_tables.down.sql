@@ -0,0 +1,7 @@
+ALTER TABLE vendors
+DROP COLUMN IF EXISTS order_count,
+DROP COLUMN IF EXISTS rating_count,
+DROP COLUMN IF EXISTS rating_avg;
+
+DROP TABLE IF EXISTS vendor_tags;
+DROP TABLE IF EXISTS tags;
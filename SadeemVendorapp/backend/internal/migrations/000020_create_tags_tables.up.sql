@@ -0,0 +1,37 @@
+CREATE TABLE tags (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(50) NOT NULL,
+    slug        VARCHAR(50) NOT NULL UNIQUE,
+    kind        VARCHAR(20) NOT NULL CHECK (kind IN ('cuisine', 'price', 'feature')),
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE vendor_tags (
+    vendor_id   uuid NOT NULL,
+    tag_id      uuid NOT NULL,
+
+    PRIMARY KEY (vendor_id, tag_id),
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_tag_id
+    FOREIGN KEY (tag_id)
+        REFERENCES tags (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX vendor_tags_tag_idx ON vendor_tags (tag_id);
+
+-- Sort keys for discovery. rating_* is kept up to date by reviews; order_count is
+-- bumped at checkout because completed orders are cleaned up after a while
+ALTER TABLE vendors
+ADD COLUMN rating_avg NUMERIC(3, 2) NOT NULL DEFAULT 0,
+ADD COLUMN rating_count INT NOT NULL DEFAULT 0,
+ADD COLUMN order_count INT NOT NULL DEFAULT 0;
+
+UPDATE vendors v
+SET order_count = (SELECT COUNT(*) FROM orders o WHERE o.vendor_id = v.id);
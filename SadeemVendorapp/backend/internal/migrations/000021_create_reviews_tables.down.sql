@@ -0,0 +1,8 @@
+ALTER TABLE items
+DROP COLUMN IF EXISTS rating_count,
+DROP COLUMN IF EXISTS rating_avg;
+
+DROP TABLE IF EXISTS review_items;
+DROP TABLE IF EXISTS reviews;
+
+UPDATE vendors SET rating_avg = 0, rating_count = 0;
@@ -0,0 +1,71 @@
+-- Orders are deleted once the table is freed, so a review keeps its own vendor and
+-- customer and only loses the order link when the order goes away
+CREATE TABLE reviews (
+    id                  uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id            uuid UNIQUE,
+    vendor_id           uuid NOT NULL,
+    customer_id         uuid NOT NULL,
+    rating              SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment             VARCHAR(1000),
+    reply               VARCHAR(1000),
+    replied_by          uuid,
+    replied_at          TIMESTAMPTZ,
+    status              VARCHAR(10) NOT NULL DEFAULT 'visible' CHECK (status IN ('visible', 'flagged', 'hidden')),
+    moderation_reason   VARCHAR(200),
+    moderated_by        uuid,
+    moderated_at        TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_order_id
+    FOREIGN KEY (order_id)
+        REFERENCES orders (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_customer_id
+    FOREIGN KEY (customer_id)
+        REFERENCES users (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_replied_by
+    FOREIGN KEY (replied_by)
+        REFERENCES users (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_moderated_by
+    FOREIGN KEY (moderated_by)
+        REFERENCES users (id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX reviews_vendor_idx ON reviews (vendor_id, created_at DESC);
+CREATE INDEX reviews_status_idx ON reviews (status) WHERE status <> 'visible';
+
+CREATE TABLE review_items (
+    review_id   uuid NOT NULL,
+    item_id     uuid NOT NULL,
+    rating      SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+
+    PRIMARY KEY (review_id, item_id),
+
+    CONSTRAINT fk_review_id
+    FOREIGN KEY (review_id)
+        REFERENCES reviews (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_item_id
+    FOREIGN KEY (item_id)
+        REFERENCES items (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX review_items_item_idx ON review_items (item_id);
+
+ALTER TABLE items
+ADD COLUMN rating_avg NUMERIC(3, 2) NOT NULL DEFAULT 0,
+ADD COLUMN rating_count INT NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS items_name_trgm_idx;
+DROP INDEX IF EXISTS vendors_name_trgm_idx;
+DROP INDEX IF EXISTS items_search_idx;
+DROP INDEX IF EXISTS vendors_search_idx;
+
+ALTER TABLE items DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE vendors DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,24 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Names are indexed under both the English and the Arabic configuration since a vendor's
+-- name and menu can be in either language. Names weigh more than descriptions.
+ALTER TABLE vendors
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('arabic', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+    setweight(to_tsvector('arabic', coalesce(description, '')), 'B')
+) STORED;
+
+ALTER TABLE items
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('arabic', coalesce(name, '')), 'A')
+) STORED;
+
+CREATE INDEX vendors_search_idx ON vendors USING GIN (search_vector);
+CREATE INDEX items_search_idx ON items USING GIN (search_vector);
+
+-- Trigram indexes back the fuzzy match for misspelled names
+CREATE INDEX vendors_name_trgm_idx ON vendors USING GIN (name gin_trgm_ops);
+CREATE INDEX items_name_trgm_idx ON items USING GIN (name gin_trgm_ops);
@@ -0,0 +1,44 @@
+-- The vendors, items and tags columns hold content in the default language; these tables
+-- hold the other languages. A missing row or NULL field falls back to the default.
+CREATE TABLE vendor_translations (
+    vendor_id    uuid NOT NULL,
+    lang         VARCHAR(5) NOT NULL CHECK (lang IN ('en', 'ar')),
+    name         VARCHAR(255),
+    description  TEXT,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (vendor_id, lang),
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE item_translations (
+    item_id     uuid NOT NULL,
+    lang        VARCHAR(5) NOT NULL CHECK (lang IN ('en', 'ar')),
+    name        VARCHAR(255) NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (item_id, lang),
+
+    CONSTRAINT fk_item_id
+    FOREIGN KEY (item_id)
+        REFERENCES items (id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE tag_translations (
+    tag_id      uuid NOT NULL,
+    lang        VARCHAR(5) NOT NULL CHECK (lang IN ('en', 'ar')),
+    name        VARCHAR(50) NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (tag_id, lang),
+
+    CONSTRAINT fk_tag_id
+    FOREIGN KEY (tag_id)
+        REFERENCES tags (id)
+        ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS tables_bump_version ON tables;
+DROP TRIGGER IF EXISTS items_bump_version ON items;
+DROP TRIGGER IF EXISTS vendors_bump_version ON vendors;
+DROP TRIGGER IF EXISTS users_bump_version ON users;
+DROP FUNCTION IF EXISTS bump_version();
+
+ALTER TABLE tables DROP COLUMN IF EXISTS version;
+ALTER TABLE items DROP COLUMN IF EXISTS version;
+ALTER TABLE vendors DROP COLUMN IF EXISTS version;
+ALTER TABLE users DROP COLUMN IF EXISTS version;
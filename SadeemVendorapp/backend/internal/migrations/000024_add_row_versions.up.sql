@@ -0,0 +1,20 @@
+-- Versions back ETags and optimistic concurrency: updates from the API name the version they
+-- were made against in their WHERE clause. The trigger bumps it on every update, so writes
+-- from jobs and other triggers change the ETag too.
+ALTER TABLE users ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE vendors ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE items ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE tables ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION bump_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_bump_version BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER vendors_bump_version BEFORE UPDATE ON vendors FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER items_bump_version BEFORE UPDATE ON items FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER tables_bump_version BEFORE UPDATE ON tables FOR EACH ROW EXECUTE FUNCTION bump_version();
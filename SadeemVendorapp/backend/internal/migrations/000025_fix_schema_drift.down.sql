@@ -0,0 +1,25 @@
+ALTER TABLE carts DROP CONSTRAINT ck_vendor_id;
+ALTER TABLE carts
+ADD CONSTRAINT ck_vendor_id
+CHECK ((vendor_id IS NULL AND quantity = 0) OR (vendor_id IS NOT NULL AND quantity > 0)) NOT VALID;
+
+ALTER TABLE tables
+ALTER COLUMN is_available DROP NOT NULL,
+ALTER COLUMN is_needs_service DROP NOT NULL;
+
+ALTER TABLE vendors
+ALTER COLUMN description DROP NOT NULL,
+ALTER COLUMN description DROP DEFAULT;
+
+ALTER TABLE items ALTER COLUMN discount DROP NOT NULL;
+
+-- The up only renamed the column where the old name was present
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'items' AND column_name = 'discount_expiry')
+       AND NOT EXISTS (SELECT 1 FROM information_schema.columns
+                       WHERE table_schema = current_schema() AND table_name = 'items' AND column_name = 'discount_expires_at') THEN
+        ALTER TABLE items RENAME COLUMN discount_expiry TO discount_expires_at;
+    END IF;
+END $$;
@@ -0,0 +1,33 @@
+-- Repairs for databases migrated before the schema was checked against internal/data. The one
+-- edit to an older migration is the syntax fix in 000004, which could never have applied before.
+
+-- 000006 created discount_expires_at but the code has always read discount_expiry. Databases
+-- patched by hand may already have the new name.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'items' AND column_name = 'discount_expires_at') THEN
+        ALTER TABLE items RENAME COLUMN discount_expires_at TO discount_expiry;
+    END IF;
+END $$;
+
+UPDATE items SET discount = 0 WHERE discount IS NULL;
+ALTER TABLE items ALTER COLUMN discount SET NOT NULL;
+
+UPDATE vendors SET description = '' WHERE description IS NULL;
+ALTER TABLE vendors
+ALTER COLUMN description SET DEFAULT '',
+ALTER COLUMN description SET NOT NULL;
+
+UPDATE tables SET is_available = TRUE WHERE is_available IS NULL;
+UPDATE tables SET is_needs_service = FALSE WHERE is_needs_service IS NULL;
+ALTER TABLE tables
+ALTER COLUMN is_available SET NOT NULL,
+ALTER COLUMN is_needs_service SET NOT NULL;
+
+-- A cart is created for the vendor of its first item before the item is counted, so a
+-- vendor with quantity 0 is valid; only a cart with items needs a vendor.
+ALTER TABLE carts DROP CONSTRAINT ck_vendor_id;
+ALTER TABLE carts
+ADD CONSTRAINT ck_vendor_id
+CHECK (vendor_id IS NOT NULL OR quantity = 0);
@@ -0,0 +1,8 @@
+DROP INDEX service_requests_open_idx;
+CREATE INDEX service_requests_open_idx ON service_requests (table_id) WHERE resolved_at IS NULL;
+
+UPDATE service_requests
+SET resolved_at = cancelled_at, resolved_by = customer_id
+WHERE cancelled_at IS NOT NULL;
+
+ALTER TABLE service_requests DROP COLUMN cancelled_at;
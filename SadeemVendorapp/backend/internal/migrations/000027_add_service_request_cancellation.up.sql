@@ -0,0 +1,10 @@
+-- A customer withdrawing a request cancels it; only staff resolve requests
+ALTER TABLE service_requests ADD COLUMN cancelled_at TIMESTAMP DEFAULT NULL;
+
+-- Withdrawals used to be stored as the customer resolving their own request
+UPDATE service_requests
+SET cancelled_at = resolved_at, resolved_at = NULL, resolved_by = NULL
+WHERE resolved_by = customer_id;
+
+DROP INDEX service_requests_open_idx;
+CREATE INDEX service_requests_open_idx ON service_requests (table_id) WHERE resolved_at IS NULL AND cancelled_at IS NULL;
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS record_visibility_change ON vendors;
+DROP FUNCTION IF EXISTS record_visibility_change();
+
+DROP INDEX IF EXISTS subscription_history_pending_idx;
+ALTER TABLE subscription_history DROP COLUMN notified_at;
@@ -0,0 +1,26 @@
+-- Reminders are notifications for the vendor: notified_at stays NULL until the vendor has seen
+-- them. Reminders for periods that already ended are past being useful.
+ALTER TABLE subscription_history ADD COLUMN notified_at TIMESTAMP DEFAULT NULL;
+
+UPDATE subscription_history
+SET notified_at = created_at
+WHERE event = 'reminder' AND subscription_end < CURRENT_TIMESTAMP;
+
+CREATE INDEX subscription_history_pending_idx ON subscription_history (vendor_id) WHERE event = 'reminder' AND notified_at IS NULL;
+
+-- update_visibility changes is_visible on any write to a vendor, so the history of lapses and
+-- restorations is recorded here rather than by whoever wrote the row
+CREATE OR REPLACE FUNCTION record_visibility_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO subscription_history (vendor_id, event, is_visible, subscription_end)
+    VALUES (NEW.id, CASE WHEN NEW.is_visible THEN 'restored' ELSE 'lapsed' END, NEW.is_visible, NEW.subscription_end);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_visibility_change
+AFTER UPDATE ON vendors
+FOR EACH ROW
+WHEN (OLD.is_visible IS DISTINCT FROM NEW.is_visible)
+EXECUTE FUNCTION record_visibility_change();
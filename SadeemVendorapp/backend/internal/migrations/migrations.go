@@ -0,0 +1,307 @@
+// Package migrations holds the schema migrations, embedded in the binary, and applies them.
+// Progress is kept in the same schema_migrations table the migrate CLI used, so databases it
+// migrated carry on where they were.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockKey names the advisory lock held while migrating, so two instances never migrate at once.
+const lockKey = 7_315_200_024
+
+// Migration is one step of the schema, from the files NNNNNN_name.up.sql and .down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var fileRX = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// All lists the embedded migrations in order.
+func All() ([]Migration, error) {
+	return Load(files)
+}
+
+// Load reads the migrations of fsys. Every version needs both an up and a down file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileRX.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	all := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %06d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		all = append(all, *m)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	return all, nil
+}
+
+// ErrDirty means a migration failed halfway under the migrate CLI; fix the schema by hand and
+// force the version it is at.
+var ErrDirty = errors.New("database is dirty; fix it by hand and force a version")
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Status is where a database stands: the version it is at, 0 for none, and the migrations
+// it has still to apply.
+type Status struct {
+	Version int         `json:"version"`
+	Dirty   bool        `json:"dirty"`
+	Latest  int         `json:"latest"`
+	Pending []Migration `json:"-"`
+}
+
+// Behind reports whether the database lacks migrations the binary has.
+func (s Status) Behind() bool {
+	return s.Version < s.Latest
+}
+
+// Status reads the version of the database.
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return Status{}, err
+	}
+	version, dirty, err := readVersion(ctx, m.db)
+	if err != nil {
+		return Status{}, err
+	}
+	status := Status{Version: version, Dirty: dirty}
+	for _, migration := range m.migrations {
+		status.Latest = migration.Version
+		if migration.Version > version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// Up applies up to n pending migrations, all of them when n is 0, and returns those applied.
+func (m *Migrator) Up(ctx context.Context, n int) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, version int) error {
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if n > 0 && len(applied) == n {
+				break
+			}
+			if err := apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %06d_%s up: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts up to n applied migrations, all of them when n is 0, and returns those reverted.
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, version int) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if n > 0 && len(reverted) == n {
+				break
+			}
+			previous := 0
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := apply(ctx, conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("migration %06d_%s down: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force records the database as clean at version without running anything, after a failed
+// migration was fixed by hand.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	conn, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.unlock(conn)
+	return setVersion(ctx, conn, version)
+}
+
+// Scratch applies every migration to a new, empty schema and runs fn with that schema first
+// on the search path. It all happens in one transaction that is rolled back, so the database
+// is left as it was.
+func (m *Migrator) Scratch(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	schema := fmt.Sprintf("migrations_scratch_%d", time.Now().UnixNano())
+	if _, err = tx.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		return err
+	}
+	// public stays on the path for extensions already installed there
+	if _, err = tx.ExecContext(ctx, "SET LOCAL search_path TO "+schema+", public"); err != nil {
+		return err
+	}
+	for _, migration := range m.migrations {
+		if strings.TrimSpace(migration.Up) == "" {
+			continue
+		}
+		if _, err = tx.ExecContext(ctx, migration.Up); err != nil {
+			return fmt.Errorf("migration %06d_%s up: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return fn(tx)
+}
+
+// locked runs fn holding the migration lock on one connection, with the version the database
+// is at. A dirty database is refused.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, version int) error) error {
+	conn, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer m.unlock(conn)
+
+	version, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return ErrDirty
+	}
+	return fn(conn, version)
+}
+
+// lock takes the advisory lock, waiting for any other migration to finish. Advisory locks
+// belong to a session, so everything under it runs on the connection it returns.
+func (m *Migrator) lock(ctx context.Context) (*sql.Conn, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err = m.ensureTable(ctx, conn); err != nil {
+		m.unlock(conn)
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (m *Migrator) unlock(conn *sql.Conn) {
+	conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+	conn.Close()
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, db execer) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	return err
+}
+
+func readVersion(ctx context.Context, db execer) (int, bool, error) {
+	var version int
+	var dirty bool
+	err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+func setVersion(ctx context.Context, db execer, version int) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	// Like the migrate CLI, no row means no migration applied
+	if version == 0 {
+		return nil
+	}
+	_, err := db.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)", version)
+	return err
+}
+
+// apply runs one migration file and records version in the same transaction, so a failing
+// migration leaves the schema as it was rather than dirty.
+func apply(ctx context.Context, conn *sql.Conn, script string, version int) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(script) != "" {
+		if _, err = tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+	if err = setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,140 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"project/internal/data"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	all, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range all {
+		if m.Version != i+1 {
+			t.Errorf("migration %d is %06d_%s; versions must have no gaps", i+1, m.Version, m.Name)
+		}
+	}
+}
+
+// scratchDB creates an empty database next to the one TEST_DATABASE_URL names and drops it
+// when the test ends. Tests that need it are skipped without TEST_DATABASE_URL.
+func scratchDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	name := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	if _, err = admin.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP DATABASE IF EXISTS " + name) })
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Path = "/" + name
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestUpThenDown applies every up migration to a scratch database, then every down one, and
+// checks the down migrations leave nothing behind.
+func TestUpThenDown(t *testing.T) {
+	db := scratchDB(t)
+	ctx := context.Background()
+	all, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(db, all)
+
+	applied, err := m.Up(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(all) {
+		t.Errorf("applied %d migrations, want %d", len(applied), len(all))
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Behind() || status.Dirty || len(status.Pending) > 0 {
+		t.Errorf("status after up = %+v", status)
+	}
+	if applied, err = m.Up(ctx, 0); err != nil || len(applied) != 0 {
+		t.Errorf("second up applied %d, err %v; want nothing", len(applied), err)
+	}
+
+	reverted, err := m.Down(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != len(all) {
+		t.Errorf("reverted %d migrations, want %d", len(reverted), len(all))
+	}
+	if status, err = m.Status(ctx); err != nil || status.Version != 0 {
+		t.Errorf("version after down = %d, err %v", status.Version, err)
+	}
+
+	var left []string
+	rows, err := db.Query(`SELECT table_name FROM information_schema.tables
+		WHERE table_schema = 'public' AND table_name <> 'schema_migrations'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		left = append(left, name)
+	}
+	if len(left) > 0 {
+		t.Errorf("tables left after down: %v", left)
+	}
+}
+
+// TestSchemaMatchesData applies the migrations to a scratch schema and checks the result is
+// what internal/data reads.
+func TestSchemaMatchesData(t *testing.T) {
+	db := scratchDB(t)
+	all, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	err = New(db, all).Scratch(ctx, func(tx *sql.Tx) error {
+		drift, err := data.VerifySchema(ctx, tx)
+		for _, mismatch := range drift {
+			t.Error(mismatch)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,205 @@
+// Package filter turns query parameters such as price[gte]=5&created_at[lt]=2026-01-01 into
+// WHERE conditions. Only fields a list safelists can be filtered on: their columns come from
+// the safelist and every value the client sends is a placeholder argument, so nothing in the
+// query string reaches the SQL text.
+package filter
+
+import (
+	"math"
+	"net/url"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+// Operator compares a field with the value of a filter.
+type Operator string
+
+const (
+	Eq       Operator = "eq"
+	Ne       Operator = "ne"
+	Gt       Operator = "gt"
+	Gte      Operator = "gte"
+	Lt       Operator = "lt"
+	Lte      Operator = "lte"
+	In       Operator = "in"
+	Contains Operator = "contains"
+)
+
+// maxInValues bounds the list of an in filter.
+const maxInValues = 50
+
+var comparisons = map[Operator]string{Gt: " > ?", Gte: " >= ?", Lt: " < ?", Lte: " <= ?"}
+
+// Field is a column clients may filter on. parse turns one value of the query string into the
+// argument compared with the column, and is false when the value isn't of the field's type.
+type Field struct {
+	Column    string
+	Operators []Operator
+	parse     func(string) (interface{}, bool)
+}
+
+// Number is a decimal column. NaN and the infinities, which ParseFloat accepts, are not numbers
+// any column holds.
+func Number(column string) Field {
+	return Field{Column: column, Operators: []Operator{Eq, Ne, Gt, Gte, Lt, Lte, In}, parse: func(s string) (interface{}, bool) {
+		n, err := strconv.ParseFloat(s, 64)
+		return n, err == nil && !math.IsNaN(n) && !math.IsInf(n, 0)
+	}}
+}
+
+// Integer is an INT or BIGINT column, which Postgres won't compare with a fractional argument.
+func Integer(column string) Field {
+	return Field{Column: column, Operators: []Operator{Eq, Ne, Gt, Gte, Lt, Lte, In}, parse: func(s string) (interface{}, bool) {
+		n, err := strconv.ParseInt(s, 10, 64)
+		return n, err == nil
+	}}
+}
+
+// Time accepts RFC 3339 times and dates such as 2026-01-31, which are midnight UTC.
+func Time(column string) Field {
+	return Field{Column: column, Operators: []Operator{Gt, Gte, Lt, Lte}, parse: func(s string) (interface{}, bool) {
+		for _, layout := range []string{time.RFC3339, time.DateOnly} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, true
+			}
+		}
+		return nil, false
+	}}
+}
+
+func Text(column string) Field {
+	return Field{Column: column, Operators: []Operator{Eq, Ne, In, Contains}, parse: func(s string) (interface{}, bool) {
+		return s, s != ""
+	}}
+}
+
+func UUID(column string) Field {
+	return Field{Column: column, Operators: []Operator{Eq, Ne, In}, parse: func(s string) (interface{}, bool) {
+		id, err := uuid.Parse(s)
+		return id, err == nil
+	}}
+}
+
+// OneOf is a text field that only holds values.
+func OneOf(column string, values ...string) Field {
+	return Field{Column: column, Operators: []Operator{Eq, Ne, In}, parse: func(s string) (interface{}, bool) {
+		return s, validator.In(s, values...)
+	}}
+}
+
+// Fields are the fields a list can be filtered on, by the name clients use for them.
+type Fields map[string]Field
+
+// Names lists the fields in order.
+func (f Fields) Names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// param matches a filter with an operator, such as price[gte].
+var param = regexp.MustCompile(`^([a-z_]+)\[([a-z]+)\]$`)
+
+// Parse reads the filters of query: name=value compares with eq and name[op]=value with op.
+// Parameters that aren't filters are left to the handler, but a bracketed one must name a
+// field and one of its operators. Problems are added to v under the parameter's name.
+func Parse(v *validator.Validator, query url.Values, fields Fields) squirrel.And {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	conditions := squirrel.And{}
+	for _, key := range keys {
+		name, operator := key, Eq
+		if match := param.FindStringSubmatch(key); match != nil {
+			name, operator = match[1], Operator(match[2])
+		} else if strings.ContainsAny(key, "[]") {
+			v.AddError(key, i18n.FilterInvalid)
+			continue
+		}
+		field, ok := fields[name]
+		if !ok {
+			if name != key {
+				v.AddError(key, i18n.FilterInvalid)
+			}
+			continue
+		}
+		if !operatorIn(operator, field.Operators) {
+			v.AddError(key, i18n.FilterOperatorInvalid)
+			continue
+		}
+		for _, value := range query[key] {
+			condition, ok := field.condition(operator, value)
+			if !ok {
+				v.AddError(key, i18n.FilterValueInvalid)
+				break
+			}
+			conditions = append(conditions, condition)
+		}
+	}
+	return conditions
+}
+
+// condition compares the field's column with value.
+func (f Field) condition(operator Operator, value string) (squirrel.Sqlizer, bool) {
+	switch operator {
+	case In:
+		values := strings.Split(value, ",")
+		if len(values) > maxInValues {
+			return nil, false
+		}
+		args := make([]interface{}, len(values))
+		for i, value := range values {
+			arg, ok := f.parse(value)
+			if !ok {
+				return nil, false
+			}
+			args[i] = arg
+		}
+		return squirrel.Eq{f.Column: args}, true
+	case Contains:
+		if value == "" {
+			return nil, false
+		}
+		return squirrel.ILike{f.Column: "%" + escapeLike(value) + "%"}, true
+	}
+
+	arg, ok := f.parse(value)
+	if !ok {
+		return nil, false
+	}
+	switch operator {
+	case Eq:
+		return squirrel.Eq{f.Column: arg}, true
+	case Ne:
+		return squirrel.NotEq{f.Column: arg}, true
+	}
+	return squirrel.Expr(f.Column+comparisons[operator], arg), true
+}
+
+func operatorIn(operator Operator, operators []Operator) bool {
+	for _, o := range operators {
+		if o == operator {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeLike makes the LIKE wildcards of s match themselves.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,126 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var testFields = Fields{
+	"name":       Text("name"),
+	"price":      Number("price"),
+	"quantity":   Integer("quantity"),
+	"created_at": Time("created_at"),
+	"vendor_id":  UUID("vendor_id"),
+	"status":     OneOf("status", "pending", "ready"),
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantSQL   string
+		wantArgs  string
+		wantError map[string]i18n.Code
+	}{
+		{"none", "search=cafe&limit=5", "", "[]", map[string]i18n.Code{}},
+		{"bare name is eq", "name=Tea", "name = ?", "[Tea]", map[string]i18n.Code{}},
+		{"range", "price[gte]=5&price[lt]=10.5", "price >= ? AND price < ?", "[5 10.5]", map[string]i18n.Code{}},
+		{"date", "created_at[lt]=2026-01-31", "created_at < ?", "[2026-01-31 00:00:00 +0000 UTC]", map[string]i18n.Code{}},
+		{"in", "status[in]=pending,ready", "status IN (?,?)", "[pending ready]", map[string]i18n.Code{}},
+		{"contains escapes wildcards", "name[contains]=50%25_off", "name ILIKE ?", `[%50\%\_off%]`, map[string]i18n.Code{}},
+		{"ne", "vendor_id[ne]=8f4e2a1c-1d3b-4c5e-9f7a-0b1c2d3e4f5a", "vendor_id <> ?", "[8f4e2a1c-1d3b-4c5e-9f7a-0b1c2d3e4f5a]", map[string]i18n.Code{}},
+		{"unknown field", "id[eq]=1", "", "[]", map[string]i18n.Code{"id[eq]": i18n.FilterInvalid}},
+		{"malformed", "price[gte=1", "", "[]", map[string]i18n.Code{"price[gte": i18n.FilterInvalid}},
+		{"operator of another type", "price[contains]=5", "", "[]", map[string]i18n.Code{"price[contains]": i18n.FilterOperatorInvalid}},
+		{"not a number", "price[gt]=cheap", "", "[]", map[string]i18n.Code{"price[gt]": i18n.FilterValueInvalid}},
+		{"nan", "price[gt]=NaN", "", "[]", map[string]i18n.Code{"price[gt]": i18n.FilterValueInvalid}},
+		{"infinity", "price[lt]=+Inf", "", "[]", map[string]i18n.Code{"price[lt]": i18n.FilterValueInvalid}},
+		{"overflows to infinity", "price[lt]=1e400", "", "[]", map[string]i18n.Code{"price[lt]": i18n.FilterValueInvalid}},
+		{"integer", "quantity[gte]=3&quantity[in]=-1,0", "quantity >= ? AND quantity IN (?,?)", "[3 -1 0]", map[string]i18n.Code{}},
+		{"fractional integer", "quantity[gt]=1.5", "", "[]", map[string]i18n.Code{"quantity[gt]": i18n.FilterValueInvalid}},
+		{"integer in exponent form", "quantity=1e3", "", "[]", map[string]i18n.Code{"quantity": i18n.FilterValueInvalid}},
+		{"integer out of range", "quantity=9223372036854775808", "", "[]", map[string]i18n.Code{"quantity": i18n.FilterValueInvalid}},
+		{"not a status", "status=lost", "", "[]", map[string]i18n.Code{"status": i18n.FilterValueInvalid}},
+		{"not a time", "created_at[gt]=yesterday", "", "[]", map[string]i18n.Code{"created_at[gt]": i18n.FilterValueInvalid}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			v := validator.New()
+			conditions := Parse(v, query, testFields)
+
+			got := map[string]i18n.Code{}
+			for key, message := range v.Errors {
+				got[key] = message.Code
+			}
+			if !reflect.DeepEqual(got, tt.wantError) {
+				t.Errorf("errors = %v, want %v", got, tt.wantError)
+			}
+			sql, args, err := conditions.ToSql()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantSQL == "" {
+				tt.wantSQL = "1=1"
+			}
+			if sql != "("+tt.wantSQL+")" {
+				t.Errorf("sql = %q, want %q", sql, tt.wantSQL)
+			}
+			if fmt.Sprint(args) != tt.wantArgs {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+// safeSQL is all the SQL Parse may write for testFields: their columns, operators and
+// placeholders, or the 1=1 of no conditions. Anything else in the SQL came from the query
+// string.
+var safeSQL = regexp.MustCompile(`^\(1=1\)$|\b(name|price|quantity|created_at|vendor_id|status|IN|ILIKE|AND)\b|<>|>=|<=|[=<>?(),\s]`)
+
+// FuzzParse checks that no query string puts anything into the SQL of the conditions but the
+// safelisted columns and placeholders, and no argument that isn't of its field's type.
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"price[gte]=5&price[lt]=10",
+		"name[contains]=%27;DROP TABLE items;--",
+		"status[in]=pending,ready",
+		"name)%20OR%201=1--[eq]=x",
+		"created_at[gt]=2026-01-01T00:00:00Z",
+		"price[in]=1,2,3&vendor_id[ne]=8f4e2a1c-1d3b-4c5e-9f7a-0b1c2d3e4f5a",
+		"price[gt]=NaN&price[lt]=-Inf&price=1e400",
+		"quantity[in]=1,2.5,0x10&quantity[gte]=-9223372036854775808",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		query, err := url.ParseQuery(raw)
+		if err != nil {
+			return
+		}
+		sql, args, err := Parse(validator.New(), query, testFields).ToSql()
+		if err != nil {
+			t.Fatalf("ToSql: %v", err)
+		}
+		if rest := safeSQL.ReplaceAllString(sql, ""); rest != "" {
+			t.Errorf("query %q wrote %q into the SQL %q", raw, rest, sql)
+		}
+		if placeholders := regexp.MustCompile(`\?`).FindAllStringIndex(sql, -1); len(placeholders) != len(args) {
+			t.Errorf("sql %q has %d placeholders for %d args", sql, len(placeholders), len(args))
+		}
+		// Only price takes floats, and never one that isn't finite
+		for _, arg := range args {
+			n, ok := arg.(float64)
+			if ok && (math.IsNaN(n) || math.IsInf(n, 0) || !strings.Contains(sql, "price")) {
+				t.Errorf("query %q passed the float %v to the SQL %q", raw, n, sql)
+			}
+		}
+	})
+}
@@ -1,21 +1,34 @@
 package utils
 
-import "project/utils/validator"
+import (
+	"project/utils/i18n"
+	"project/utils/validator"
 
+	"github.com/Masterminds/squirrel"
+)
+
+// Filters narrow a list down; paging and sorting it is left to the pagination package.
+// Conditions are the field filters of the query string, read with the filter package.
 type Filters struct {
-	Page         int
-	PageSize     int
-	Sort         string
-	SortSafelist []string
-	Search       string
+	Search     string
+	Near       *Near
+	Tags       []string
+	Conditions squirrel.And
+}
+
+// Near restricts results to a radius around a point. RadiusKm is in kilometres.
+type Near struct {
+	Lat      float64
+	Lng      float64
+	RadiusKm float64
 }
 
 func ValidateFilters(v *validator.Validator, f Filters) {
-	// Check that the page and page_size parameters contain sensible values.
-	v.Check(f.Page > 0, "page", "must be greater than zero")
-	v.Check(f.Page <= 10_000_000, "page", "must be a maximum of 10 million")
-	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")
-	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")
-	// Check that the sort parameter matches a value in the safelist.
-	v.Check(validator.In(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
+	v.Check(len(f.Tags) <= 10, "tags", i18n.TooManyTags, 10)
+	if f.Near != nil {
+		v.Check(f.Near.Lat >= -90 && f.Near.Lat <= 90, "lat", i18n.LatitudeOutOfRange)
+		v.Check(f.Near.Lng >= -180 && f.Near.Lng <= 180, "lng", i18n.LongitudeOutOfRange)
+		v.Check(f.Near.RadiusKm > 0, "radius", i18n.MustBePositive)
+		v.Check(f.Near.RadiusKm <= 100, "radius", i18n.RadiusTooLarge, 100)
+	}
 }
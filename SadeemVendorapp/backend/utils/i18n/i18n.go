@@ -0,0 +1,49 @@
+// Package i18n is the catalog of messages the API sends to clients. Every message has a stable
+// code that clients can rely on and a text in each supported language.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Code identifies a message independently of its wording.
+type Code string
+
+// Languages messages are translated into.
+var Languages = []string{"en", "ar"}
+
+// DefaultLanguage is used when a message has no text in the requested language.
+const DefaultLanguage = "en"
+
+// T returns the text of code in lang, formatted with args. Texts without placeholders ignore
+// args, so a validation rule can always pass its bound.
+func T(lang string, code Code, args ...interface{}) string {
+	texts, ok := catalog[code]
+	if !ok {
+		return string(code)
+	}
+	text, ok := texts[lang]
+	if !ok || text == "" {
+		text = texts[DefaultLanguage]
+	}
+	if len(args) > 0 && strings.Contains(text, "%") {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// Message is a code with the values that fill its placeholders.
+type Message struct {
+	Code Code
+	Args []interface{}
+}
+
+func (m Message) Text(lang string) string {
+	return T(lang, m.Code, m.Args...)
+}
+
+// Error makes a message usable as an error, so helpers can return what the client is told.
+func (m Message) Error() string {
+	return m.Text(DefaultLanguage)
+}
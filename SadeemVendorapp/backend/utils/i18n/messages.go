@@ -0,0 +1,834 @@
+package i18n
+
+// Generic responses
+const (
+	ServerError      Code = "server_error"
+	NotFound         Code = "not_found"
+	FailedValidation Code = "failed_validation"
+	InvalidToken     Code = "invalid_token"
+	ExpiredToken     Code = "expired_token"
+	MissingToken     Code = "missing_token"
+	InvalidClaims    Code = "invalid_claims"
+	NoPermission     Code = "no_permission"
+	UserIDMissing    Code = "user_id_missing"
+	BadRequest       Code = "bad_request"
+	RateLimited      Code = "rate_limited"
+	VendorClosed     Code = "vendor_closed"
+	VendorPaused     Code = "vendor_paused"
+)
+
+// Request errors
+const (
+	InvalidPathID          Code = "invalid_path_id"
+	ContentTypeUnsupported Code = "content_type_unsupported"
+	BodyEmpty              Code = "body_empty"
+	BodyMalformed          Code = "body_malformed"
+	BodyInvalid            Code = "body_invalid"
+	BodyFieldType          Code = "body_field_type"
+	BodyUnknownField       Code = "body_unknown_field"
+	BodyTooLarge           Code = "body_too_large"
+	BodyMultipleValues     Code = "body_multiple_values"
+	FormInvalid            Code = "form_invalid"
+	FormValueInvalid       Code = "form_value_invalid"
+	ImageInvalid           Code = "image_invalid"
+	UserUpdateForbidden    Code = "user_update_forbidden"
+)
+
+// Cart and table rules
+const (
+	CartEmpty         Code = "cart_empty"
+	CartMixedVendors  Code = "cart_mixed_vendors"
+	CartOtherVendor   Code = "cart_other_vendor"
+	CartRemoveTooMany Code = "cart_remove_too_many"
+	NotSeated         Code = "not_seated"
+	TableUnavailable  Code = "table_unavailable"
+	TableCodeInvalid  Code = "table_code_invalid"
+)
+
+// Data layer errors
+const (
+	RecordNotFound       Code = "record_not_found"
+	EmailTaken           Code = "email_taken"
+	EmailNotFound        Code = "email_not_found"
+	DuplicatedRole       Code = "duplicated_role"
+	HasRole              Code = "has_role"
+	HasNoRoles           Code = "has_no_roles"
+	VendorIDIncorrect    Code = "vendor_id_incorrect"
+	UserHasTable         Code = "user_has_table"
+	UserHasNoTable       Code = "user_has_no_table"
+	ItemAlreadyInserted  Code = "item_already_inserted"
+	QuantityUnavailable  Code = "quantity_unavailable"
+	TableTokenExpired    Code = "table_token_expired"
+	TableOccupied        Code = "table_occupied"
+	ServiceRequestClosed Code = "service_request_closed"
+	PlanLimitReached     Code = "plan_limit_reached"
+	PlanLimitTables      Code = "plan_limit_tables"
+	PlanLimitItems       Code = "plan_limit_items"
+	PlanLimitStaff       Code = "plan_limit_staff"
+	DuplicatedPlan       Code = "duplicated_plan"
+	InvoiceNotPending    Code = "invoice_not_pending"
+	DuplicatedTag        Code = "duplicated_tag"
+	AlreadyReviewed      Code = "already_reviewed"
+	AlreadyReplied       Code = "already_replied"
+	OrderNotCompleted    Code = "order_not_completed"
+	NotPurchaser         Code = "not_purchaser"
+	ItemNotInOrder       Code = "item_not_in_order"
+)
+
+// Validation messages
+const (
+	NameRequired                Code = "name_required"
+	NameTooShort                Code = "name_too_short"
+	NameTooLong                 Code = "name_too_long"
+	DescriptionRequired         Code = "description_required"
+	DescriptionTooShort         Code = "description_too_short"
+	DescriptionTooLong          Code = "description_too_long"
+	DaysNotPositive             Code = "days_not_positive"
+	DaysTooMany                 Code = "days_too_many"
+	AddressTooLong              Code = "address_too_long"
+	LocationIncomplete          Code = "location_incomplete"
+	LatitudeOutOfRange          Code = "latitude_out_of_range"
+	LongitudeOutOfRange         Code = "longitude_out_of_range"
+	RoleNotFound                Code = "role_not_found"
+	RoleNotAllowed              Code = "role_not_allowed"
+	CustomerIDRequired          Code = "customer_id_required"
+	VendorIDRequired            Code = "vendor_id_required"
+	OrderStatusRequired         Code = "order_status_required"
+	OrderStatusInvalid          Code = "order_status_invalid"
+	TotalCostNegative           Code = "total_cost_negative"
+	PhoneInvalid                Code = "phone_invalid"
+	EmailInvalid                Code = "email_invalid"
+	PasswordTooShort            Code = "password_too_short"
+	WeekdayOutOfRange           Code = "weekday_out_of_range"
+	TimeFormatInvalid           Code = "time_format_invalid"
+	HoursSameTime               Code = "hours_same_time"
+	DayFormatInvalid            Code = "day_format_invalid"
+	HoursRequired               Code = "hours_required"
+	TimezoneInvalid             Code = "timezone_invalid"
+	NoteTooLong                 Code = "note_too_long"
+	ServiceRequestTypeInvalid   Code = "service_request_type_invalid"
+	ServiceRequestStatusInvalid Code = "service_request_status_invalid"
+	TimestampInvalid            Code = "timestamp_invalid"
+	AreaNameRequired            Code = "area_name_required"
+	AreaNameTooLong             Code = "area_name_too_long"
+	AreaNamesNotUnique          Code = "area_names_not_unique"
+	TableIDRequired             Code = "table_id_required"
+	TableAreaUnknown            Code = "table_area_unknown"
+	TablePositionNegative       Code = "table_position_negative"
+	TableShapeInvalid           Code = "table_shape_invalid"
+	TableRotationOutOfRange     Code = "table_rotation_out_of_range"
+	TableCapacityOutOfRange     Code = "table_capacity_out_of_range"
+	TablePlacedTwice            Code = "table_placed_twice"
+	RatingOutOfRange            Code = "rating_out_of_range"
+	CommentTooLong              Code = "comment_too_long"
+	ItemRatingOutOfRange        Code = "item_rating_out_of_range"
+	ItemRatedTwice              Code = "item_rated_twice"
+	ReplyRequired               Code = "reply_required"
+	ReplyTooLong                Code = "reply_too_long"
+	ReasonRequired              Code = "reason_required"
+	ReasonTooLong               Code = "reason_too_long"
+	ReviewStatusInvalid         Code = "review_status_invalid"
+	LangInvalid                 Code = "lang_invalid"
+	TranslationEmpty            Code = "translation_empty"
+	QuantityNegative            Code = "quantity_negative"
+	PriceNotPositive            Code = "price_not_positive"
+	PriceNegative               Code = "price_negative"
+	DiscountNotPositive         Code = "discount_not_positive"
+	DiscountAbovePrice          Code = "discount_above_price"
+	DiscountExpiryRequired      Code = "discount_expiry_required"
+	SlugInvalid                 Code = "slug_invalid"
+	SlugTooLong                 Code = "slug_too_long"
+	TagKindInvalid              Code = "tag_kind_invalid"
+	TagsNotUnique               Code = "tags_not_unique"
+	TooManyTags                 Code = "too_many_tags"
+	LimitNegative               Code = "limit_negative"
+	MustBePositive              Code = "must_be_positive"
+	MustBeAtMost                Code = "must_be_at_most"
+	SortInvalid                 Code = "sort_invalid"
+	RadiusTooLarge              Code = "radius_too_large"
+	DistanceSortNeedsPoint      Code = "distance_sort_needs_point"
+	PhoneRequired               Code = "phone_required"
+	EmailRequired               Code = "email_required"
+	PasswordRequired            Code = "password_required"
+	PriceRequired               Code = "price_required"
+	QuantityRequired            Code = "quantity_required"
+	TotalCostRequired           Code = "total_cost_required"
+	OrderIDRequired             Code = "order_id_required"
+	ItemIDRequired              Code = "item_id_required"
+	PlanIDRequired              Code = "plan_id_required"
+	BillingDaysRequired         Code = "billing_days_required"
+	RoleRequired                Code = "role_required"
+	UserIDRequired              Code = "user_id_required"
+	QuantityNotPositive         Code = "quantity_not_positive"
+	MinutesOutOfRange           Code = "minutes_out_of_range"
+	CursorInvalid               Code = "cursor_invalid"
+	CursorNeedsType             Code = "cursor_needs_type"
+	SearchQueryInvalid          Code = "search_query_invalid"
+	SearchQueryTooLong          Code = "search_query_too_long"
+	SearchTypeInvalid           Code = "search_type_invalid"
+	FilterInvalid               Code = "filter_invalid"
+	FilterOperatorInvalid       Code = "filter_operator_invalid"
+	FilterValueInvalid          Code = "filter_value_invalid"
+	FieldInvalid                Code = "field_invalid"
+	IncludeInvalid              Code = "include_invalid"
+	EditConflict                Code = "edit_conflict"
+	PreconditionFailed          Code = "precondition_failed"
+	PreconditionRequired        Code = "precondition_required"
+	IDInvalid                   Code = "id_invalid"
+	NearPointInvalid            Code = "near_point_invalid"
+	RadiusInvalid               Code = "radius_invalid"
+	QRScaleOutOfRange           Code = "qr_scale_out_of_range"
+	QRFormatInvalid             Code = "qr_format_invalid"
+)
+
+// catalog holds the text of every code in every language. Placeholders follow fmt.
+var catalog = map[Code]map[string]string{
+	ServerError: {
+		"en": "the server encountered a problem and could not process your request",
+		"ar": "واجه الخادم مشكلة ولم يتمكن من معالجة طلبك",
+	},
+	NotFound: {
+		"en": "resources not found",
+		"ar": "المورد غير موجود",
+	},
+	FailedValidation: {
+		"en": "some fields are invalid",
+		"ar": "بعض الحقول غير صالحة",
+	},
+	InvalidToken: {
+		"en": "invalid token",
+		"ar": "رمز الدخول غير صالح",
+	},
+	ExpiredToken: {
+		"en": "token has expired",
+		"ar": "انتهت صلاحية رمز الدخول",
+	},
+	MissingToken: {
+		"en": "missing authorization token",
+		"ar": "رمز الدخول مفقود",
+	},
+	InvalidClaims: {
+		"en": "invalid token claims",
+		"ar": "بيانات رمز الدخول غير صالحة",
+	},
+	NoPermission: {
+		"en": "You don't have permission",
+		"ar": "ليس لديك صلاحية",
+	},
+	UserIDMissing: {
+		"en": "User ID is missing from context",
+		"ar": "معرف المستخدم مفقود",
+	},
+	BadRequest: {
+		"en": "the request is invalid",
+		"ar": "الطلب غير صالح",
+	},
+	RateLimited: {
+		"en": "too many requests; try again shortly",
+		"ar": "طلبات كثيرة جدًا؛ حاول مرة أخرى بعد قليل",
+	},
+	VendorClosed: {
+		"en": "vendor is closed",
+		"ar": "المتجر مغلق",
+	},
+	VendorPaused: {
+		"en": "vendor has paused ordering",
+		"ar": "أوقف المتجر استقبال الطلبات مؤقتًا",
+	},
+
+	InvalidPathID: {
+		"en": "%s in the path is not a valid ID",
+		"ar": "قيمة %s في المسار ليست معرفًا صالحًا",
+	},
+	ContentTypeUnsupported: {
+		"en": "unsupported Content-Type %q, use application/json or a form",
+		"ar": "نوع المحتوى %q غير مدعوم، استخدم application/json أو نموذجًا",
+	},
+	BodyEmpty: {
+		"en": "body must not be empty",
+		"ar": "يجب ألا يكون متن الطلب فارغًا",
+	},
+	BodyMalformed: {
+		"en": "body contains badly-formed JSON",
+		"ar": "يحتوي متن الطلب على JSON غير سليم",
+	},
+	BodyInvalid: {
+		"en": "body contains invalid JSON",
+		"ar": "يحتوي متن الطلب على JSON غير صالح",
+	},
+	BodyFieldType: {
+		"en": "body contains incorrect JSON type for field %q",
+		"ar": "نوع القيمة في الحقل %q من متن الطلب غير صحيح",
+	},
+	BodyUnknownField: {
+		"en": "body contains unknown key %s",
+		"ar": "يحتوي متن الطلب على مفتاح غير معروف %s",
+	},
+	BodyTooLarge: {
+		"en": "body must not be larger than %d bytes",
+		"ar": "يجب ألا يزيد حجم متن الطلب عن %d بايت",
+	},
+	BodyMultipleValues: {
+		"en": "body must only contain a single JSON value",
+		"ar": "يجب أن يحتوي متن الطلب على قيمة JSON واحدة فقط",
+	},
+	FormInvalid: {
+		"en": "body must be a valid form",
+		"ar": "يجب أن يكون متن الطلب نموذجًا صالحًا",
+	},
+	FormValueInvalid: {
+		"en": "invalid value for %s",
+		"ar": "قيمة %s غير صالحة",
+	},
+	ImageInvalid: {
+		"en": "invalid image",
+		"ar": "الصورة غير صالحة",
+	},
+	UserUpdateForbidden: {
+		"en": "you do not have permission to update this user",
+		"ar": "ليس لديك صلاحية لتعديل هذا المستخدم",
+	},
+
+	CartEmpty: {
+		"en": "Cart is empty.",
+		"ar": "السلة فارغة.",
+	},
+	CartMixedVendors: {
+		"en": "All items in the cart must be from the same vendor.",
+		"ar": "يجب أن تكون جميع أصناف السلة من المتجر نفسه.",
+	},
+	CartOtherVendor: {
+		"en": "You can only add or update items from the vendor of this cart.",
+		"ar": "يمكنك فقط إضافة أو تعديل أصناف من متجر هذه السلة.",
+	},
+	CartRemoveTooMany: {
+		"en": "cannot remove more items than exist in the cart",
+		"ar": "لا يمكن إزالة أصناف أكثر مما في السلة",
+	},
+	NotSeated: {
+		"en": "You must be seated at this table.",
+		"ar": "يجب أن تكون جالسًا على هذه الطاولة.",
+	},
+	TableUnavailable: {
+		"en": "The table is not available! Try again later.",
+		"ar": "الطاولة غير متاحة! حاول مرة أخرى لاحقًا.",
+	},
+	TableCodeInvalid: {
+		"en": "invalid table code",
+		"ar": "رمز الطاولة غير صالح",
+	},
+
+	RecordNotFound: {
+		"en": "Resources could not be found",
+		"ar": "تعذر العثور على المورد",
+	},
+	EmailTaken: {
+		"en": "Email already exists, try something else",
+		"ar": "البريد الإلكتروني مستخدم بالفعل، جرّب بريدًا آخر",
+	},
+	EmailNotFound: {
+		"en": "Email not found, try again",
+		"ar": "البريد الإلكتروني غير موجود، حاول مرة أخرى",
+	},
+	DuplicatedRole: {
+		"en": "User already have the role",
+		"ar": "المستخدم لديه هذا الدور بالفعل",
+	},
+	HasRole: {
+		"en": "User already has a role",
+		"ar": "المستخدم لديه دور بالفعل",
+	},
+	HasNoRoles: {
+		"en": "User has no roles",
+		"ar": "المستخدم ليس لديه أي دور",
+	},
+	VendorIDIncorrect: {
+		"en": "Vendor ID is incorrect",
+		"ar": "معرف المتجر غير صحيح",
+	},
+	UserHasTable: {
+		"en": "user already have a table",
+		"ar": "المستخدم لديه طاولة بالفعل",
+	},
+	UserHasNoTable: {
+		"en": "user has no table",
+		"ar": "المستخدم ليس لديه طاولة",
+	},
+	ItemAlreadyInserted: {
+		"en": "item already inserted!",
+		"ar": "تمت إضافة الصنف بالفعل!",
+	},
+	QuantityUnavailable: {
+		"en": "requested quantity is not available",
+		"ar": "الكمية المطلوبة غير متوفرة",
+	},
+	TableTokenExpired: {
+		"en": "table code is no longer valid",
+		"ar": "رمز الطاولة لم يعد صالحًا",
+	},
+	TableOccupied: {
+		"en": "table is taken; ask someone seated there for the session code",
+		"ar": "الطاولة محجوزة؛ اطلب رمز الجلسة من أحد الجالسين عليها",
+	},
+	ServiceRequestClosed: {
+		"en": "service request was already handled",
+		"ar": "تمت معالجة طلب الخدمة بالفعل",
+	},
+	PlanLimitReached: {
+		"en": "plan limit reached",
+		"ar": "تم بلوغ حد الخطة",
+	},
+	PlanLimitTables: {
+		"en": "plan limit reached: the vendor's plan allows %d tables",
+		"ar": "تم بلوغ حد الخطة: تسمح خطة المتجر بـ %d طاولة",
+	},
+	PlanLimitItems: {
+		"en": "plan limit reached: the vendor's plan allows %d items",
+		"ar": "تم بلوغ حد الخطة: تسمح خطة المتجر بـ %d صنف",
+	},
+	PlanLimitStaff: {
+		"en": "plan limit reached: the vendor's plan allows %d staff",
+		"ar": "تم بلوغ حد الخطة: تسمح خطة المتجر بـ %d موظف",
+	},
+	DuplicatedPlan: {
+		"en": "a plan with this name already exists",
+		"ar": "توجد خطة بهذا الاسم بالفعل",
+	},
+	InvoiceNotPending: {
+		"en": "invoice is no longer pending",
+		"ar": "الفاتورة لم تعد قيد الانتظار",
+	},
+	DuplicatedTag: {
+		"en": "a tag with this slug already exists",
+		"ar": "يوجد وسم بهذا المعرّف بالفعل",
+	},
+	AlreadyReviewed: {
+		"en": "this order has already been reviewed",
+		"ar": "تم تقييم هذا الطلب بالفعل",
+	},
+	AlreadyReplied: {
+		"en": "this review already has a reply",
+		"ar": "تم الرد على هذا التقييم بالفعل",
+	},
+	OrderNotCompleted: {
+		"en": "only completed orders can be reviewed",
+		"ar": "يمكن تقييم الطلبات المكتملة فقط",
+	},
+	NotPurchaser: {
+		"en": "only the customer who placed the order can review it",
+		"ar": "يمكن للعميل الذي قدّم الطلب فقط أن يقيّمه",
+	},
+	ItemNotInOrder: {
+		"en": "only items from the order can be rated",
+		"ar": "يمكن تقييم أصناف الطلب فقط",
+	},
+
+	NameRequired: {
+		"en": "Name can not be empty",
+		"ar": "الاسم مطلوب",
+	},
+	NameTooShort: {
+		"en": "Name can't be less than %d letters",
+		"ar": "يجب ألا يقل الاسم عن %d أحرف",
+	},
+	NameTooLong: {
+		"en": "Name can't be larger than %d letters",
+		"ar": "يجب ألا يزيد الاسم عن %d حرفًا",
+	},
+	DescriptionRequired: {
+		"en": "description can't be empty",
+		"ar": "الوصف مطلوب",
+	},
+	DescriptionTooShort: {
+		"en": "description can't be less than %d letters",
+		"ar": "يجب ألا يقل الوصف عن %d أحرف",
+	},
+	DescriptionTooLong: {
+		"en": "description can't be larger than %d letters",
+		"ar": "يجب ألا يزيد الوصف عن %d حرفًا",
+	},
+	DaysNotPositive: {
+		"en": "must be more then 0 days",
+		"ar": "يجب أن تكون المدة أكثر من 0 يوم",
+	},
+	DaysTooMany: {
+		"en": "days must be less than %d",
+		"ar": "يجب أن تكون المدة أقل من %d يوم",
+	},
+	AddressTooLong: {
+		"en": "address can't be larger than %d letters",
+		"ar": "يجب ألا يزيد العنوان عن %d حرفًا",
+	},
+	LocationIncomplete: {
+		"en": "latitude and longitude must be set together",
+		"ar": "يجب تحديد خط العرض وخط الطول معًا",
+	},
+	LatitudeOutOfRange: {
+		"en": "latitude must be between -90 and 90",
+		"ar": "يجب أن يكون خط العرض بين -90 و 90",
+	},
+	LongitudeOutOfRange: {
+		"en": "longitude must be between -180 and 180",
+		"ar": "يجب أن يكون خط الطول بين -180 و 180",
+	},
+	RoleNotFound: {
+		"en": "Role not found",
+		"ar": "الدور غير موجود",
+	},
+	RoleNotAllowed: {
+		"en": "You don't have the required role to perform this operation",
+		"ar": "ليس لديك الدور المطلوب لتنفيذ هذه العملية",
+	},
+	CustomerIDRequired: {
+		"en": "Customer ID is required",
+		"ar": "معرف العميل مطلوب",
+	},
+	VendorIDRequired: {
+		"en": "Vendor ID is required",
+		"ar": "معرف المتجر مطلوب",
+	},
+	OrderStatusRequired: {
+		"en": "Order status is required",
+		"ar": "حالة الطلب مطلوبة",
+	},
+	OrderStatusInvalid: {
+		"en": "Order status must be completed or preparing",
+		"ar": "يجب أن تكون حالة الطلب مكتمل أو قيد التحضير",
+	},
+	TotalCostNegative: {
+		"en": "Total order cost must be a non-negative number",
+		"ar": "يجب ألا تكون التكلفة الإجمالية للطلب سالبة",
+	},
+	PhoneInvalid: {
+		"en": "Invalid phone number",
+		"ar": "رقم الهاتف غير صالح",
+	},
+	EmailInvalid: {
+		"en": "Invalid email format",
+		"ar": "صيغة البريد الإلكتروني غير صالحة",
+	},
+	PasswordTooShort: {
+		"en": "Password must be at least %d letters",
+		"ar": "يجب ألا تقل كلمة المرور عن %d أحرف",
+	},
+	WeekdayOutOfRange: {
+		"en": "weekday must be between 0 (Sunday) and 6 (Saturday)",
+		"ar": "يجب أن يكون اليوم بين 0 (الأحد) و 6 (السبت)",
+	},
+	TimeFormatInvalid: {
+		"en": "times must be in HH:MM format",
+		"ar": "يجب أن تكون الأوقات بصيغة HH:MM",
+	},
+	HoursSameTime: {
+		"en": "opening and closing times can't be the same",
+		"ar": "لا يمكن أن يتطابق وقت الفتح مع وقت الإغلاق",
+	},
+	DayFormatInvalid: {
+		"en": "day must be in YYYY-MM-DD format",
+		"ar": "يجب أن يكون اليوم بصيغة YYYY-MM-DD",
+	},
+	HoursRequired: {
+		"en": "opens_at and closes_at are required unless the day is closed",
+		"ar": "وقتا الفتح والإغلاق مطلوبان ما لم يكن اليوم مغلقًا",
+	},
+	TimezoneInvalid: {
+		"en": "timezone must be an IANA name such as Asia/Riyadh",
+		"ar": "يجب أن تكون المنطقة الزمنية اسمًا من IANA مثل Asia/Riyadh",
+	},
+	NoteTooLong: {
+		"en": "note can't be larger than %d letters",
+		"ar": "يجب ألا تزيد الملاحظة عن %d حرفًا",
+	},
+	ServiceRequestTypeInvalid: {
+		"en": "type must be one of call_waiter, bring_bill, water, cleaning",
+		"ar": "يجب أن يكون النوع أحد: call_waiter أو bring_bill أو water أو cleaning",
+	},
+	ServiceRequestStatusInvalid: {
+		"en": "status must be pending, acknowledged, resolved or cancelled",
+		"ar": "يجب أن تكون الحالة pending أو acknowledged أو resolved أو cancelled",
+	},
+	TimestampInvalid: {
+		"en": "must be an RFC 3339 timestamp",
+		"ar": "يجب أن يكون الوقت بصيغة RFC 3339",
+	},
+	AreaNameRequired: {
+		"en": "area name can not be empty",
+		"ar": "اسم المنطقة مطلوب",
+	},
+	AreaNameTooLong: {
+		"en": "area name can't be larger than %d letters",
+		"ar": "يجب ألا يزيد اسم المنطقة عن %d حرفًا",
+	},
+	AreaNamesNotUnique: {
+		"en": "area names must be unique",
+		"ar": "يجب ألا تتكرر أسماء المناطق",
+	},
+	TableIDRequired: {
+		"en": "table_id is required",
+		"ar": "معرف الطاولة مطلوب",
+	},
+	TableAreaUnknown: {
+		"en": "table area must be one of the saved areas",
+		"ar": "يجب أن تكون منطقة الطاولة إحدى المناطق المحفوظة",
+	},
+	TablePositionNegative: {
+		"en": "table position can't be negative",
+		"ar": "لا يمكن أن يكون موضع الطاولة سالبًا",
+	},
+	TableShapeInvalid: {
+		"en": "shape must be square, round or rectangle",
+		"ar": "يجب أن يكون الشكل مربعًا أو دائريًا أو مستطيلًا",
+	},
+	TableRotationOutOfRange: {
+		"en": "rotation must be between 0 and 359",
+		"ar": "يجب أن يكون الدوران بين 0 و 359",
+	},
+	TableCapacityOutOfRange: {
+		"en": "capacity must be between 1 and 50",
+		"ar": "يجب أن تكون السعة بين 1 و 50",
+	},
+	TablePlacedTwice: {
+		"en": "a table can only be placed once",
+		"ar": "لا يمكن وضع الطاولة أكثر من مرة",
+	},
+	RatingOutOfRange: {
+		"en": "rating must be between 1 and 5",
+		"ar": "يجب أن يكون التقييم بين 1 و 5",
+	},
+	CommentTooLong: {
+		"en": "comment can't be larger than %d letters",
+		"ar": "يجب ألا يزيد التعليق عن %d حرفًا",
+	},
+	ItemRatingOutOfRange: {
+		"en": "item ratings must be between 1 and 5",
+		"ar": "يجب أن تكون تقييمات الأصناف بين 1 و 5",
+	},
+	ItemRatedTwice: {
+		"en": "each item can only be rated once",
+		"ar": "يمكن تقييم كل صنف مرة واحدة فقط",
+	},
+	ReplyRequired: {
+		"en": "reply can not be empty",
+		"ar": "الرد مطلوب",
+	},
+	ReplyTooLong: {
+		"en": "reply can't be larger than %d letters",
+		"ar": "يجب ألا يزيد الرد عن %d حرفًا",
+	},
+	ReasonRequired: {
+		"en": "a reason is required to hide or flag a review",
+		"ar": "يجب ذكر سبب لإخفاء التقييم أو الإبلاغ عنه",
+	},
+	ReasonTooLong: {
+		"en": "reason can't be larger than %d letters",
+		"ar": "يجب ألا يزيد السبب عن %d حرفًا",
+	},
+	ReviewStatusInvalid: {
+		"en": "status must be visible, flagged or hidden",
+		"ar": "يجب أن تكون الحالة visible أو flagged أو hidden",
+	},
+	LangInvalid: {
+		"en": "lang must be en or ar",
+		"ar": "يجب أن تكون اللغة en أو ar",
+	},
+	TranslationEmpty: {
+		"en": "name or description is required",
+		"ar": "الاسم أو الوصف مطلوب",
+	},
+	QuantityNegative: {
+		"en": "Quantity must be non-negative",
+		"ar": "يجب ألا تكون الكمية سالبة",
+	},
+	PriceNotPositive: {
+		"en": "Price must be greater than zero",
+		"ar": "يجب أن يكون السعر أكبر من صفر",
+	},
+	PriceNegative: {
+		"en": "price can't be negative",
+		"ar": "لا يمكن أن يكون السعر سالبًا",
+	},
+	DiscountNotPositive: {
+		"en": "Discount must be greater than zero",
+		"ar": "يجب أن يكون الخصم أكبر من صفر",
+	},
+	DiscountAbovePrice: {
+		"en": "Discount must be less than the price!",
+		"ar": "يجب أن يكون الخصم أقل من السعر!",
+	},
+	DiscountExpiryRequired: {
+		"en": "Discount expiry date is required when a discount is provided",
+		"ar": "تاريخ انتهاء الخصم مطلوب عند تحديد خصم",
+	},
+	SlugInvalid: {
+		"en": "slug must be lowercase letters, digits and dashes",
+		"ar": "يجب أن يتكون المعرّف من أحرف إنجليزية صغيرة وأرقام وشرطات",
+	},
+	SlugTooLong: {
+		"en": "slug can't be larger than %d letters",
+		"ar": "يجب ألا يزيد المعرّف عن %d حرفًا",
+	},
+	TagKindInvalid: {
+		"en": "kind must be cuisine, price or feature",
+		"ar": "يجب أن يكون النوع cuisine أو price أو feature",
+	},
+	TagsNotUnique: {
+		"en": "tags must be unique",
+		"ar": "يجب ألا تتكرر الوسوم",
+	},
+	TooManyTags: {
+		"en": "must be a maximum of %d tags",
+		"ar": "يجب ألا يزيد عدد الوسوم عن %d",
+	},
+	LimitNegative: {
+		"en": "limit can't be negative",
+		"ar": "لا يمكن أن يكون الحد سالبًا",
+	},
+	MustBePositive: {
+		"en": "must be greater than zero",
+		"ar": "يجب أن تكون القيمة أكبر من صفر",
+	},
+	MustBeAtMost: {
+		"en": "must be a maximum of %d",
+		"ar": "يجب ألا تزيد القيمة عن %d",
+	},
+	SortInvalid: {
+		"en": "invalid sort value",
+		"ar": "قيمة الترتيب غير صالحة",
+	},
+	RadiusTooLarge: {
+		"en": "must be a maximum of %d km",
+		"ar": "يجب ألا يزيد نصف القطر عن %d كم",
+	},
+	DistanceSortNeedsPoint: {
+		"en": "distance sort needs lat and lng",
+		"ar": "الترتيب حسب المسافة يتطلب تحديد lat و lng",
+	},
+	PhoneRequired: {
+		"en": "Phone can not be empty",
+		"ar": "رقم الهاتف مطلوب",
+	},
+	EmailRequired: {
+		"en": "Email can not be empty",
+		"ar": "البريد الإلكتروني مطلوب",
+	},
+	PasswordRequired: {
+		"en": "Password can not be empty",
+		"ar": "كلمة المرور مطلوبة",
+	},
+	PriceRequired: {
+		"en": "Price is required",
+		"ar": "السعر مطلوب",
+	},
+	QuantityRequired: {
+		"en": "Quantity is required",
+		"ar": "الكمية مطلوبة",
+	},
+	TotalCostRequired: {
+		"en": "Total order cost is required",
+		"ar": "التكلفة الإجمالية للطلب مطلوبة",
+	},
+	OrderIDRequired: {
+		"en": "Order ID is required",
+		"ar": "معرف الطلب مطلوب",
+	},
+	ItemIDRequired: {
+		"en": "Item ID is required",
+		"ar": "معرف الصنف مطلوب",
+	},
+	PlanIDRequired: {
+		"en": "Plan ID is required",
+		"ar": "معرف الباقة مطلوب",
+	},
+	BillingDaysRequired: {
+		"en": "Billing days are required",
+		"ar": "عدد أيام الفوترة مطلوب",
+	},
+	RoleRequired: {
+		"en": "Role is required",
+		"ar": "الدور مطلوب",
+	},
+	UserIDRequired: {
+		"en": "User ID is required",
+		"ar": "معرف المستخدم مطلوب",
+	},
+	QuantityNotPositive: {
+		"en": "Quantity must be greater than zero",
+		"ar": "يجب أن تكون الكمية أكبر من صفر",
+	},
+	MinutesOutOfRange: {
+		"en": "minutes must be between 1 and 1440",
+		"ar": "يجب أن تكون الدقائق بين 1 و 1440",
+	},
+	CursorInvalid: {
+		"en": "invalid or expired cursor; start again from the first page",
+		"ar": "المؤشر غير صالح أو منتهي الصلاحية؛ ابدأ من الصفحة الأولى",
+	},
+	CursorNeedsType: {
+		"en": "a cursor pages one group; pass type=vendors or type=items with it",
+		"ar": "المؤشر يتصفح مجموعة واحدة؛ أرسل type=vendors أو type=items معه",
+	},
+	SearchQueryInvalid: {
+		"en": "q must contain at least one letter or digit",
+		"ar": "يجب أن يحتوي q على حرف أو رقم واحد على الأقل",
+	},
+	SearchQueryTooLong: {
+		"en": "q can't be larger than %d letters",
+		"ar": "يجب ألا يزيد q عن %d حرفًا",
+	},
+	SearchTypeInvalid: {
+		"en": "type must be all, vendors or items",
+		"ar": "يجب أن يكون type إحدى القيم all أو vendors أو items",
+	},
+	FilterInvalid: {
+		"en": "this list can't be filtered on this field",
+		"ar": "لا يمكن تصفية هذه القائمة حسب هذا الحقل",
+	},
+	FilterOperatorInvalid: {
+		"en": "this field can't be filtered with this operator",
+		"ar": "لا يمكن تصفية هذا الحقل بهذا المعامل",
+	},
+	FilterValueInvalid: {
+		"en": "invalid filter value",
+		"ar": "قيمة التصفية غير صالحة",
+	},
+	FieldInvalid: {
+		"en": "unknown field %s",
+		"ar": "الحقل %s غير معروف",
+	},
+	IncludeInvalid: {
+		"en": "%s can't be included here",
+		"ar": "لا يمكن تضمين %s هنا",
+	},
+	EditConflict: {
+		"en": "this record was changed by someone else; reload it and try again",
+		"ar": "تم تعديل هذا السجل من قبل شخص آخر؛ أعد تحميله وحاول مرة أخرى",
+	},
+	PreconditionFailed: {
+		"en": "this record has changed since you read it; reload it and send its new ETag in If-Match",
+		"ar": "تغير هذا السجل منذ قراءته؛ أعد تحميله وأرسل وسم ETag الجديد في If-Match",
+	},
+	PreconditionRequired: {
+		"en": "send the ETag you read this record with in If-Match",
+		"ar": "أرسل وسم ETag الذي قرأت به هذا السجل في If-Match",
+	},
+	IDInvalid: {
+		"en": "must be a valid ID",
+		"ar": "يجب أن يكون معرفًا صالحًا",
+	},
+	NearPointInvalid: {
+		"en": "lat and lng must both be valid numbers",
+		"ar": "يجب أن يكون lat و lng رقمين صالحين",
+	},
+	RadiusInvalid: {
+		"en": "radius must be a number of kilometres",
+		"ar": "يجب أن يكون نصف القطر عددًا من الكيلومترات",
+	},
+	QRScaleOutOfRange: {
+		"en": "scale must be between %d and %d",
+		"ar": "يجب أن يكون المقياس بين %d و %d",
+	},
+	QRFormatInvalid: {
+		"en": "format must be png or svg",
+		"ar": "يجب أن تكون الصيغة png أو svg",
+	},
+}
@@ -0,0 +1,96 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// declaredCodes reads every Code constant declared in the package, so a new code is checked
+// even before anything uses it.
+func declaredCodes(t *testing.T) []Code {
+	t.Helper()
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var codes []Code
+	for _, file := range pkgs["i18n"].Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				value := spec.(*ast.ValueSpec)
+				if ident, ok := value.Type.(*ast.Ident); !ok || ident.Name != "Code" {
+					continue
+				}
+				for _, v := range value.Values {
+					lit, ok := v.(*ast.BasicLit)
+					if !ok {
+						t.Fatalf("code %s must be a string literal", value.Names[0].Name)
+					}
+					code, err := strconv.Unquote(lit.Value)
+					if err != nil {
+						t.Fatal(err)
+					}
+					codes = append(codes, Code(code))
+				}
+			}
+		}
+	}
+	if len(codes) == 0 {
+		t.Fatal("no codes found")
+	}
+	return codes
+}
+
+var verbRX = regexp.MustCompile(`%[-+# 0-9.\[\]]*[a-zA-Z]`)
+
+func TestEveryCodeIsTranslated(t *testing.T) {
+	seen := map[Code]bool{}
+	for _, code := range declaredCodes(t) {
+		if seen[code] {
+			t.Errorf("code %q is declared twice", code)
+		}
+		seen[code] = true
+
+		texts, ok := catalog[code]
+		if !ok {
+			t.Errorf("code %q has no messages", code)
+			continue
+		}
+		verbs := len(verbRX.FindAllString(texts[DefaultLanguage], -1))
+		for _, lang := range Languages {
+			text := texts[lang]
+			if text == "" {
+				t.Errorf("code %q has no %s translation", code, lang)
+				continue
+			}
+			if n := len(verbRX.FindAllString(text, -1)); n != verbs {
+				t.Errorf("code %q takes %d values in %s but %d in %s", code, n, lang, verbs, DefaultLanguage)
+			}
+		}
+	}
+
+	for code := range catalog {
+		if !seen[code] {
+			t.Errorf("catalog has %q which is not a declared code", code)
+		}
+	}
+}
+
+func TestTFallsBack(t *testing.T) {
+	if got := T("fr", NameTooLong, 20); got != "Name can't be larger than 20 letters" {
+		t.Errorf("unknown language: got %q", got)
+	}
+	if got := T("ar", Code("no_such_code")); got != "no_such_code" {
+		t.Errorf("unknown code: got %q", got)
+	}
+}
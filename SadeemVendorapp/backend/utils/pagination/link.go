@@ -0,0 +1,29 @@
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Links are the Link header values of the page at u: the first page and, when they exist,
+// the next and previous ones. Every other query parameter is kept.
+func Links(u *url.URL, meta Metadata) []string {
+	link := func(cursor, rel string) string {
+		query := u.Query()
+		query.Del("cursor")
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		target := url.URL{Path: u.Path, RawQuery: query.Encode()}
+		return fmt.Sprintf("<%s>; rel=%q", target.String(), rel)
+	}
+
+	links := []string{link("", "first")}
+	if meta.PrevCursor != "" {
+		links = append(links, link(meta.PrevCursor, "prev"))
+	}
+	if meta.NextCursor != "" {
+		links = append(links, link(meta.NextCursor, "next"))
+	}
+	return links
+}
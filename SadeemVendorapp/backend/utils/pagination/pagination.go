@@ -0,0 +1,241 @@
+// Package pagination pages lists with keyset cursors. A page starts right after (or, going
+// back, right before) the sort key of the row its cursor was taken from, so pages stay stable
+// while rows are added or removed, and deep pages cost the same as the first one.
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// Column is one sort key: a column or SQL expression of the listed rows and its direction.
+// Args fill the expression's placeholders. Field is the db tag of the row field holding the
+// column's value, which a cursor keeps; it defaults to the column name without its table.
+// The column must not be NULL.
+type Column struct {
+	Expr  string
+	Desc  bool
+	Args  []interface{}
+	Field string
+}
+
+func Asc(expr string, args ...interface{}) Column {
+	return Column{Expr: expr, Args: args, Field: expr[strings.LastIndex(expr, ".")+1:]}
+}
+
+func Desc(expr string, args ...interface{}) Column {
+	column := Asc(expr, args...)
+	column.Desc = true
+	return column
+}
+
+// As reads the column's value from the row field tagged field, for expressions.
+func (c Column) As(field string) Column {
+	c.Field = field
+	return c
+}
+
+// with is the column's arguments followed by value, the right side of a comparison.
+func (c Column) with(value interface{}) []interface{} {
+	return append(append([]interface{}{}, c.Args...), value)
+}
+
+// Order is one way to sort a list. Its last column must be unique, such as the primary key,
+// so rows with equal sort values keep a stable order and no row is skipped or repeated.
+type Order []Column
+
+// fields finds row fields by their db tag, as sqlx does when scanning them.
+var fields = reflectx.NewMapperFunc("db", strings.ToLower)
+
+// key is the row's value of every column of the order.
+func (o Order) key(row interface{}) []interface{} {
+	value := reflect.Indirect(reflect.ValueOf(row))
+	key := make([]interface{}, len(o))
+	for i, column := range o {
+		field := fields.TypeMap(value.Type()).GetByPath(column.Field)
+		if field == nil {
+			panic(fmt.Sprintf("pagination: %s has no field tagged %q", value.Type(), column.Field))
+		}
+		if found := reflectx.FieldByIndexesReadOnly(value, field.Index); found.Kind() != reflect.Pointer || !found.IsNil() {
+			key[i] = reflect.Indirect(found).Interface()
+		}
+	}
+	return key
+}
+
+// Sorts is the safelist of orders a list can be read in, by the name clients pass as sort.
+// With Fields, a sort can also be spelled out as a comma separated list of field names, each
+// descending with a leading -, such as "-price,name"; Tie ends such an order so it is stable.
+type Sorts struct {
+	Default string
+	Orders  map[string]Order
+	Fields  map[string]Column
+	Tie     Column
+}
+
+// order is the order named sort, or the one it spells out with Fields.
+func (s Sorts) order(sort string) (Order, bool) {
+	if order, ok := s.Orders[sort]; ok {
+		return order, true
+	}
+	if len(s.Fields) == 0 || sort == "" {
+		return nil, false
+	}
+
+	var order Order
+	seen := map[string]bool{}
+	for _, name := range strings.Split(sort, ",") {
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		column, ok := s.Fields[name]
+		if !ok || seen[name] {
+			return nil, false
+		}
+		seen[name] = true
+		column.Desc = desc
+		order = append(order, column)
+	}
+	return append(order, s.Tie), true
+}
+
+// Limits bound the number of rows on a page.
+type Limits struct {
+	Default int
+	Max     int
+}
+
+// DefaultLimits apply where no limits are configured.
+var DefaultLimits = Limits{Default: 20, Max: 100}
+
+// Params is the page a client asked for.
+type Params struct {
+	Limit  int
+	Sort   string
+	Order  Order
+	Cursor *Cursor
+}
+
+// Parse reads the limit, sort and cursor query parameters and adds any problem with them to
+// v. A cursor carries the sort it was taken with; asking for another sort with it is an error.
+func Parse(v *validator.Validator, query url.Values, sorts Sorts, limits Limits, signer Signer) Params {
+	if limits.Default <= 0 || limits.Max <= 0 {
+		limits = DefaultLimits
+	}
+	p := Params{Limit: limits.Default, Sort: query.Get("sort")}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		v.Check(err == nil && n > 0, "limit", i18n.MustBePositive)
+		v.Check(n <= limits.Max, "limit", i18n.MustBeAtMost, limits.Max)
+		p.Limit = n
+	}
+
+	if token := query.Get("cursor"); token != "" {
+		cursor, err := signer.Decode(token)
+		if err == nil && p.Sort != "" && p.Sort != cursor.Sort {
+			err = ErrInvalidCursor
+		}
+		if err == nil {
+			if order, _ := sorts.order(cursor.Sort); len(cursor.Key) != len(order) {
+				err = ErrInvalidCursor
+			}
+		}
+		v.Check(err == nil, "cursor", i18n.CursorInvalid)
+		if err == nil {
+			p.Cursor, p.Sort = cursor, cursor.Sort
+		}
+	}
+
+	if p.Sort == "" {
+		p.Sort = sorts.Default
+	}
+	order, ok := sorts.order(p.Sort)
+	v.Check(ok, "sort", i18n.SortInvalid)
+	p.Order = order
+	return p
+}
+
+// Apply sorts q, starts it at the cursor and asks for one row more than the page, so Page can
+// tell whether another page follows.
+func (p Params) Apply(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+	backward := p.Cursor != nil && p.Cursor.Backward
+	if p.Cursor != nil {
+		q = q.Where(p.after(backward))
+	}
+	for _, column := range p.Order {
+		direction := " ASC"
+		if column.Desc != backward {
+			direction = " DESC"
+		}
+		q = q.OrderByClause(column.Expr+direction, column.Args...)
+	}
+	return q.Limit(uint64(p.Limit + 1))
+}
+
+// after matches the rows that sort after the cursor's key, or before it going back:
+// (a > x) OR (a = x AND b > y) OR ...
+func (p Params) after(backward bool) squirrel.Or {
+	var or squirrel.Or
+	for i, column := range p.Order {
+		and := squirrel.And{}
+		for j, equal := range p.Order[:i] {
+			and = append(and, squirrel.Expr(equal.Expr+" = ?", equal.with(p.Cursor.Key[j])...))
+		}
+		operator := " > ?"
+		if column.Desc != backward {
+			operator = " < ?"
+		}
+		and = append(and, squirrel.Expr(column.Expr+operator, column.with(p.Cursor.Key[i])...))
+		or = append(or, and)
+	}
+	return or
+}
+
+// Metadata describes a page and the ones around it. Next and Prev are signed into NextCursor
+// and PrevCursor before the page is sent.
+type Metadata struct {
+	TotalCount int     `json:"total_count"`
+	Limit      int     `json:"limit"`
+	Sort       string  `json:"sort"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	PrevCursor string  `json:"prev_cursor,omitempty"`
+	Next       *Cursor `json:"-"`
+	Prev       *Cursor `json:"-"`
+}
+
+// Page trims the extra row Apply asked for, puts a page read backwards back in order and
+// describes it.
+func Page[T any](rows []T, p Params, total int) ([]T, Metadata) {
+	meta := Metadata{TotalCount: total, Limit: p.Limit, Sort: p.Sort}
+	more := len(rows) > p.Limit
+	if more {
+		rows = rows[:p.Limit]
+	}
+	backward := p.Cursor != nil && p.Cursor.Backward
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, meta
+	}
+
+	// Going forward there is an earlier page whenever a cursor was given; going back there is
+	// always a later one, the page the cursor came from
+	if more || backward {
+		meta.Next = &Cursor{Sort: p.Sort, Key: p.Order.key(rows[len(rows)-1])}
+	}
+	if backward && more || !backward && p.Cursor != nil {
+		meta.Prev = &Cursor{Sort: p.Sort, Key: p.Order.key(rows[0]), Backward: true}
+	}
+	return rows, meta
+}
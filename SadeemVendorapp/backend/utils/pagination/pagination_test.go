@@ -0,0 +1,240 @@
+package pagination
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"project/utils/i18n"
+	"project/utils/validator"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type row struct {
+	ID   int    `db:"id"`
+	Name string `db:"name"`
+}
+
+var testSorts = Sorts{
+	Default: "latest",
+	Orders: map[string]Order{
+		"latest": {Desc("id")},
+		"name":   {Asc("t.name"), Asc("t.id")},
+	},
+}
+
+func TestSignerRejectsTamperedCursors(t *testing.T) {
+	signer := NewSigner("secret")
+	token := signer.Encode(&Cursor{Sort: "name", Key: []interface{}{"Cafe", 7}})
+
+	cursor, err := signer.Decode(token)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if cursor.Sort != "name" || cursor.Key[0] != "Cafe" || cursor.Key[1] != json.Number("7") {
+		t.Errorf("cursor = %+v", cursor)
+	}
+
+	forged := NewSigner("other").Encode(&Cursor{Sort: "name", Key: []interface{}{"Cafe", 7}})
+	for _, token := range []string{forged, token[1:], "not-a-cursor", token + "x"} {
+		if _, err := signer.Decode(token); err != ErrInvalidCursor {
+			t.Errorf("Decode(%q) err = %v, want ErrInvalidCursor", token, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	signer := NewSigner("secret")
+	nameCursor := signer.Encode(&Cursor{Sort: "name", Key: []interface{}{"Cafe", 7}})
+	shortCursor := signer.Encode(&Cursor{Sort: "name", Key: []interface{}{"Cafe"}})
+	limits := Limits{Default: 10, Max: 50}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantLimit int
+		wantSort  string
+		wantError map[string]i18n.Code
+	}{
+		{"defaults", "", 10, "latest", map[string]i18n.Code{}},
+		{"limit and sort", "limit=50&sort=name", 50, "name", map[string]i18n.Code{}},
+		{"limit too large", "limit=51", 51, "latest", map[string]i18n.Code{"limit": i18n.MustBeAtMost}},
+		{"limit not a number", "limit=ten", 0, "latest", map[string]i18n.Code{"limit": i18n.MustBePositive}},
+		{"unknown sort", "sort=price", 10, "price", map[string]i18n.Code{"sort": i18n.SortInvalid}},
+		{"cursor keeps its sort", "cursor=" + nameCursor, 10, "name", map[string]i18n.Code{}},
+		{"cursor of another sort", "sort=latest&cursor=" + nameCursor, 10, "latest", map[string]i18n.Code{"cursor": i18n.CursorInvalid}},
+		{"cursor of another order", "cursor=" + shortCursor, 10, "latest", map[string]i18n.Code{"cursor": i18n.CursorInvalid}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			v := validator.New()
+			p := Parse(v, query, testSorts, limits, signer)
+
+			got := map[string]i18n.Code{}
+			for key, message := range v.Errors {
+				got[key] = message.Code
+			}
+			if !reflect.DeepEqual(got, tt.wantError) {
+				t.Errorf("errors = %v, want %v", got, tt.wantError)
+			}
+			if p.Limit != tt.wantLimit || p.Sort != tt.wantSort {
+				t.Errorf("limit, sort = %d, %q, want %d, %q", p.Limit, p.Sort, tt.wantLimit, tt.wantSort)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		cursor   *Cursor
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{"first page", nil,
+			"SELECT id, name FROM t ORDER BY t.name ASC, t.id ASC LIMIT 3", nil},
+		{"after a row", &Cursor{Sort: "name", Key: []interface{}{"Cafe", 7}},
+			"SELECT id, name FROM t WHERE ((t.name > ?) OR (t.name = ? AND t.id > ?)) ORDER BY t.name ASC, t.id ASC LIMIT 3",
+			[]interface{}{"Cafe", "Cafe", 7}},
+		{"before a row", &Cursor{Sort: "name", Key: []interface{}{"Cafe", 7}, Backward: true},
+			"SELECT id, name FROM t WHERE ((t.name < ?) OR (t.name = ? AND t.id < ?)) ORDER BY t.name DESC, t.id DESC LIMIT 3",
+			[]interface{}{"Cafe", "Cafe", 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Params{Limit: 2, Sort: "name", Order: testSorts.Orders["name"], Cursor: tt.cursor}
+			sql, args, err := p.Apply(squirrel.Select("id", "name").From("t")).ToSql()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %q\nwant  %q", sql, tt.wantSQL)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestPage(t *testing.T) {
+	order := testSorts.Orders["name"]
+	rows := func(ids ...int) []row {
+		out := make([]row, len(ids))
+		for i, id := range ids {
+			out[i] = row{ID: id, Name: string(rune('a' + id))}
+		}
+		return out
+	}
+	key := func(id int) []interface{} { return []interface{}{string(rune('a' + id)), id} }
+
+	tests := []struct {
+		name     string
+		cursor   *Cursor
+		rows     []row
+		wantIDs  []int
+		wantNext []interface{}
+		wantPrev []interface{}
+	}{
+		{"only page", nil, rows(1, 2), []int{1, 2}, nil, nil},
+		{"first of several", nil, rows(1, 2, 3), []int{1, 2}, key(2), nil},
+		{"middle", &Cursor{Sort: "name"}, rows(3, 4, 5), []int{3, 4}, key(4), key(3)},
+		{"last", &Cursor{Sort: "name"}, rows(5), []int{5}, nil, key(5)},
+		{"back to a middle page", &Cursor{Sort: "name", Backward: true}, rows(4, 3, 2), []int{3, 4}, key(4), key(3)},
+		{"back to the first page", &Cursor{Sort: "name", Backward: true}, rows(2, 1), []int{1, 2}, key(2), nil},
+		{"empty", &Cursor{Sort: "name"}, rows(), []int{}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, meta := Page(tt.rows, Params{Limit: 2, Sort: "name", Order: order, Cursor: tt.cursor}, 9)
+
+			ids := []int{}
+			for _, r := range got {
+				ids = append(ids, r.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+			if meta.TotalCount != 9 || meta.Limit != 2 || meta.Sort != "name" {
+				t.Errorf("meta = %+v", meta)
+			}
+			checkCursor(t, "next", meta.Next, tt.wantNext, false)
+			checkCursor(t, "prev", meta.Prev, tt.wantPrev, true)
+		})
+	}
+}
+
+func checkCursor(t *testing.T, name string, got *Cursor, wantKey []interface{}, backward bool) {
+	t.Helper()
+	switch {
+	case wantKey == nil && got != nil:
+		t.Errorf("%s = %+v, want none", name, got)
+	case wantKey != nil && got == nil:
+		t.Errorf("%s missing, want key %v", name, wantKey)
+	case wantKey != nil && (!reflect.DeepEqual(got.Key, wantKey) || got.Backward != backward || got.Sort != "name"):
+		t.Errorf("%s = %+v, want key %v", name, got, wantKey)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	u, _ := url.Parse("/v1/vendors?search=cafe&cursor=old&limit=5")
+	links := Links(u, Metadata{NextCursor: "n", PrevCursor: "p"})
+	want := []string{
+		`</v1/vendors?limit=5&search=cafe>; rel="first"`,
+		`</v1/vendors?cursor=p&limit=5&search=cafe>; rel="prev"`,
+		`</v1/vendors?cursor=n&limit=5&search=cafe>; rel="next"`,
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("links = %q, want %q", links, want)
+	}
+
+	if links := Links(u, Metadata{}); len(links) != 1 {
+		t.Errorf("links of the only page = %q, want just the first", links)
+	}
+}
+
+func TestParseFieldSorts(t *testing.T) {
+	sorts := Sorts{
+		Default: "name",
+		Fields:  map[string]Column{"name": Asc("name"), "price": Asc("price")},
+		Tie:     Asc("id"),
+	}
+	tests := []struct {
+		sort    string
+		wantSQL string
+	}{
+		{"", "SELECT id FROM t ORDER BY name ASC, id ASC LIMIT 11"},
+		{"-price,name", "SELECT id FROM t ORDER BY price DESC, name ASC, id ASC LIMIT 11"},
+		{"price,price", ""},
+		{"id", ""},
+		{"name;DROP TABLE items", ""},
+		{"name DESC", ""},
+		{"-", ""},
+		{"name,", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			v := validator.New()
+			p := Parse(v, url.Values{"sort": {tt.sort}}, sorts, Limits{Default: 10, Max: 10}, NewSigner("secret"))
+			if tt.wantSQL == "" {
+				if v.Errors["sort"].Code != i18n.SortInvalid {
+					t.Errorf("errors = %v, want sort invalid", v.Errors)
+				}
+				return
+			}
+			if !v.Valid() {
+				t.Fatalf("errors = %v", v.Errors)
+			}
+			sql, _, err := p.Apply(squirrel.Select("id").From("t")).ToSql()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %q\nwant  %q", sql, tt.wantSQL)
+			}
+		})
+	}
+}
@@ -0,0 +1,555 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The tables below are copied from ISO/IEC 18004 rather than derived from the encoder, so
+// the tests catch a mistake in its arithmetic instead of repeating it.
+
+// byteCapacityM is how many bytes each version holds in byte mode at level M.
+var byteCapacityM = []int{-1,
+	14, 26, 42, 62, 84, 106, 122, 152, 180, 213,
+	251, 287, 331, 362, 412, 450, 504, 560, 624, 666,
+	711, 779, 857, 911, 997, 1059, 1125, 1190, 1264, 1370,
+	1452, 1538, 1628, 1722, 1809, 1911, 1989, 2099, 2213, 2331,
+}
+
+// alignmentCenters are the row and column coordinates of each version's alignment patterns.
+var alignmentCenters = [][]int{nil,
+	{}, {6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+	{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50}, {6, 30, 54}, {6, 32, 58}, {6, 34, 62},
+	{6, 26, 46, 66}, {6, 26, 48, 70}, {6, 26, 50, 74}, {6, 30, 54, 78}, {6, 30, 56, 82}, {6, 30, 58, 86}, {6, 34, 62, 90},
+	{6, 28, 50, 72, 94}, {6, 26, 50, 74, 98}, {6, 30, 54, 78, 102}, {6, 28, 54, 80, 106}, {6, 32, 58, 84, 110}, {6, 30, 58, 86, 114}, {6, 34, 62, 90, 118},
+	{6, 26, 50, 74, 98, 122}, {6, 30, 54, 78, 102, 126}, {6, 26, 52, 78, 104, 130}, {6, 30, 56, 82, 108, 134}, {6, 34, 60, 86, 112, 138}, {6, 30, 58, 86, 114, 142}, {6, 34, 62, 90, 118, 146},
+	{6, 30, 54, 78, 102, 126, 150}, {6, 24, 50, 76, 102, 128, 154}, {6, 28, 54, 80, 106, 132, 158}, {6, 32, 58, 84, 110, 136, 162}, {6, 26, 54, 82, 110, 138, 166}, {6, 30, 58, 86, 114, 142, 170},
+}
+
+// formatInfoM is the 15-bit format information of level M, by mask.
+var formatInfoM = []string{
+	"101010000010010", "101000100100101", "101111001111100", "101101101001011",
+	"100010111111001", "100000011001110", "100111110010111", "100101010100000",
+}
+
+// versionInfo is the 18-bit version information of versions 7 to 40.
+var versionInfo = map[int]int{
+	7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3, 11: 0x0BBF6, 12: 0x0C762, 13: 0x0D847,
+	14: 0x0E60D, 15: 0x0F928, 16: 0x10B78, 17: 0x1145D, 18: 0x12A17, 19: 0x13532, 20: 0x149A6,
+	21: 0x15683, 22: 0x168C9, 23: 0x177EC, 24: 0x18EC4, 25: 0x191E1, 26: 0x1AFAB, 27: 0x1B08E,
+	28: 0x1CC1A, 29: 0x1D33F, 30: 0x1ED75, 31: 0x1F250, 32: 0x209D5, 33: 0x216F0, 34: 0x228BA,
+	35: 0x2379F, 36: 0x24B0B, 37: 0x2542E, 38: 0x26A64, 39: 0x27541, 40: 0x28C69,
+}
+
+// decode reads a symbol back into its text, checking every structure a scanner relies on:
+// finder, timing and alignment patterns, both copies of the format and version information,
+// the Reed-Solomon check of every block, and the segment with its padding.
+func decode(modules [][]bool) (string, error) {
+	size := len(modules)
+	version := (size - 17) / 4
+	if size < 21 || (size-17)%4 != 0 || version > maxVersion {
+		return "", fmt.Errorf("invalid size %d", size)
+	}
+	for _, row := range modules {
+		if len(row) != size {
+			return "", errors.New("symbol is not square")
+		}
+	}
+	at := func(x, y int) bool { return modules[y][x] }
+	function := make([][]bool, size)
+	for i := range function {
+		function[i] = make([]bool, size)
+	}
+	expect := func(x, y int, dark bool, what string) error {
+		function[y][x] = true
+		if at(x, y) != dark {
+			return fmt.Errorf("%s module (%d,%d) is wrong", what, x, y)
+		}
+		return nil
+	}
+
+	// Finder patterns and their light separators
+	for _, corner := range [][2]int{{0, 0}, {size - 7, 0}, {0, size - 7}} {
+		for dy := -1; dy <= 7; dy++ {
+			for dx := -1; dx <= 7; dx++ {
+				x, y := corner[0]+dx, corner[1]+dy
+				if x < 0 || x >= size || y < 0 || y >= size {
+					continue
+				}
+				ring := max(abs(dx-3), abs(dy-3))
+				if err := expect(x, y, ring != 2 && ring != 4, "finder"); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+	// Timing patterns
+	for i := 8; i < size-8; i++ {
+		if err := expect(i, 6, i%2 == 0, "timing"); err != nil {
+			return "", err
+		}
+		if err := expect(6, i, i%2 == 0, "timing"); err != nil {
+			return "", err
+		}
+	}
+	// Alignment patterns, except where they would overlap a finder
+	centers := alignmentCenters[version]
+	for _, cx := range centers {
+		for _, cy := range centers {
+			if (cx == 6 && cy == 6) || (cx == 6 && cy == centers[len(centers)-1]) || (cy == 6 && cx == centers[len(centers)-1]) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					if err := expect(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1, "alignment"); err != nil {
+						return "", err
+					}
+				}
+			}
+		}
+	}
+	if err := expect(8, size-8, true, "dark"); err != nil {
+		return "", err
+	}
+
+	// Format information, which both copies must agree on
+	var first, second int
+	formatAt := func(x, y int) int {
+		function[y][x] = true
+		if at(x, y) {
+			return 1
+		}
+		return 0
+	}
+	for i, xy := range [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		first |= formatAt(xy[0], xy[1]) << i
+	}
+	for i := 0; i < 8; i++ {
+		second |= formatAt(size-1-i, 8) << i
+	}
+	for i := 8; i < 15; i++ {
+		second |= formatAt(8, size-15+i) << i
+	}
+	if first != second {
+		return "", fmt.Errorf("format copies differ: %015b and %015b", first, second)
+	}
+	mask := -1
+	for m, bits := range formatInfoM {
+		if want, _ := strconv.ParseInt(bits, 2, 32); int(want) == first {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		return "", fmt.Errorf("format %015b is not level M", first)
+	}
+
+	// Version information, in the two blocks beside the upper right and lower left finders
+	if version >= 7 {
+		var right, below int
+		for i := 0; i < 18; i++ {
+			a, b := size-11+i%3, i/3
+			right |= formatAt(a, b) << i
+			below |= formatAt(b, a) << i
+		}
+		if right != versionInfo[version] || below != versionInfo[version] {
+			return "", fmt.Errorf("version information %018b and %018b, want %018b", right, below, versionInfo[version])
+		}
+	}
+
+	// Codewords, in the zigzag of two-module columns from the bottom right
+	var raw []byte
+	var current byte
+	n := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		upward := ((size-1-right)/2)%2 == 0
+		if right < 6 {
+			upward = ((size-2-right)/2)%2 == 0
+		}
+		for i := 0; i < size; i++ {
+			y := i
+			if upward {
+				y = size - 1 - i
+			}
+			for _, x := range []int{right, right - 1} {
+				if function[y][x] {
+					continue
+				}
+				dark := at(x, y) != maskBit(mask, x, y)
+				current <<= 1
+				if dark {
+					current |= 1
+				}
+				if n++; n%8 == 0 {
+					raw = append(raw, current)
+					current = 0
+				}
+			}
+		}
+	}
+	// Whatever is left over is remainder bits, 0 to 7 of them
+	if rem := n % 8; rem != 0 && current != 0 {
+		return "", errors.New("remainder bits are not light")
+	}
+
+	// Undo the interleaving and check each block
+	blocks, eccLen := numErrorCorrectionBlocks[version], eccCodewordsPerBlock[version]
+	if len(raw) != numRawDataModules(version)/8 {
+		return "", fmt.Errorf("read %d codewords, want %d", len(raw), numRawDataModules(version)/8)
+	}
+	short := blocks - len(raw)%blocks
+	shortData := len(raw)/blocks - eccLen
+	data := make([][]byte, blocks)
+	k := 0
+	for i := 0; i <= shortData; i++ {
+		for b := 0; b < blocks; b++ {
+			if i < shortData || b >= short {
+				data[b] = append(data[b], raw[k])
+				k++
+			}
+		}
+	}
+	var payload []byte
+	for b := range data {
+		block := append([]byte(nil), data[b]...)
+		for i := 0; i < eccLen; i++ {
+			block = append(block, raw[k+i*blocks+b])
+		}
+		if !syndromesZero(block, eccLen) {
+			return "", fmt.Errorf("block %d fails its Reed-Solomon check", b)
+		}
+		payload = append(payload, data[b]...)
+	}
+
+	// A single byte-mode segment, a terminator and alternating pad codewords
+	r := &bitReader{data: payload}
+	if mode := r.read(4); mode != 0x4 {
+		return "", fmt.Errorf("mode %04b, want byte mode", mode)
+	}
+	length := r.read(charCountBitsSpec(version))
+	if length*8 > len(payload)*8-r.pos {
+		return "", fmt.Errorf("length %d overruns the data", length)
+	}
+	text := make([]byte, length)
+	for i := range text {
+		text[i] = byte(r.read(8))
+	}
+	// The segment ends four bits short of a byte, which the terminator fills
+	if r.read(4) != 0 {
+		return "", errors.New("terminator is not zero")
+	}
+	for pad := 0xEC; r.pos < len(payload)*8; pad ^= 0xEC ^ 0x11 {
+		if got := r.read(8); got != pad {
+			return "", fmt.Errorf("pad codeword %02X, want %02X", got, pad)
+		}
+	}
+	return string(text), nil
+}
+
+// charCountBitsSpec is the width of the byte mode length field.
+func charCountBitsSpec(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// maskBit is the data mask pattern as ISO/IEC 18004 states it, in row i and column j.
+func maskBit(mask, j, i int) bool {
+	switch mask {
+	case 0:
+		return (i+j)%2 == 0
+	case 1:
+		return i%2 == 0
+	case 2:
+		return j%3 == 0
+	case 3:
+		return (i+j)%3 == 0
+	case 4:
+		return (i/2+j/3)%2 == 0
+	case 5:
+		return (i*j)%2+(i*j)%3 == 0
+	case 6:
+		return ((i*j)%2+(i*j)%3)%2 == 0
+	default:
+		return ((i+j)%2+(i*j)%3)%2 == 0
+	}
+}
+
+// gfExp and gfLog are GF(256) under x^8+x^4+x^3+x^2+1, built apart from gfMultiply.
+var gfExp, gfLog = func() ([512]byte, [256]int) {
+	var exp [512]byte
+	var log [256]int
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i], exp[i+255] = byte(x), byte(x)
+		log[x] = i
+		if x <<= 1; x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	return exp, log
+}()
+
+// syndromesZero reports whether block, read as a polynomial, has roots α^0 to α^(eccLen-1),
+// which holds for every valid Reed-Solomon codeword.
+func syndromesZero(block []byte, eccLen int) bool {
+	for i := 0; i < eccLen; i++ {
+		var s byte
+		for _, c := range block {
+			if s != 0 {
+				s = gfExp[gfLog[s]+i]
+			}
+			s ^= c
+		}
+		if s != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		v <<= 1
+		if r.pos < len(r.data)*8 && r.data[r.pos/8]&(0x80>>(r.pos%8)) != 0 {
+			v |= 1
+		}
+		r.pos++
+	}
+	return v
+}
+
+// filler is length bytes of every value, so each version is tested at full capacity.
+func filler(length, seed int) string {
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = byte(i*31 + seed*7)
+	}
+	return string(b)
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	texts := []string{
+		"",
+		"a",
+		"https://sadeem.app/scan?t=eyJ0IjoiNGI2ZiIsInYiOjN9.c2lnbmF0dXJl",
+		"طاولة ٤ — Table 4",
+		"\x00\xff\x00\xff",
+	}
+	for _, text := range texts {
+		code, err := Encode(text)
+		if err != nil {
+			t.Fatalf("Encode(%q): %v", text, err)
+		}
+		got, err := decode(code.Modules)
+		if err != nil {
+			t.Fatalf("decode(Encode(%q)): %v", text, err)
+		}
+		if got != text {
+			t.Errorf("round trip of %q gave %q", text, got)
+		}
+	}
+}
+
+func TestEncodeEveryVersion(t *testing.T) {
+	for version := minVersion; version <= maxVersion; version++ {
+		text := filler(byteCapacityM[version], version)
+		code, err := Encode(text)
+		if err != nil {
+			t.Fatalf("version %d: %v", version, err)
+		}
+		if code.Version != version || code.Size != version*4+17 || len(code.Modules) != code.Size {
+			t.Fatalf("%d bytes: version %d size %d, want version %d", len(text), code.Version, code.Size, version)
+		}
+		got, err := decode(code.Modules)
+		if err != nil {
+			t.Fatalf("version %d: %v", version, err)
+		}
+		if got != text {
+			t.Errorf("version %d: round trip changed the text", version)
+		}
+	}
+}
+
+func TestEncodeCapacity(t *testing.T) {
+	for version := minVersion; version < maxVersion; version++ {
+		code, err := Encode(filler(byteCapacityM[version]+1, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code.Version != version+1 {
+			t.Errorf("%d bytes used version %d, want %d", byteCapacityM[version]+1, code.Version, version+1)
+		}
+	}
+	if _, err := Encode(filler(byteCapacityM[maxVersion]+1, 0)); !errors.Is(err, ErrDataTooLong) {
+		t.Errorf("oversized text: err = %v, want ErrDataTooLong", err)
+	}
+}
+
+func TestDecodeCatchesDamage(t *testing.T) {
+	code, err := Encode("https://sadeem.app/scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bottom right is the first data codeword, away from every function pattern
+	code.Modules[code.Size-1][code.Size-1] = !code.Modules[code.Size-1][code.Size-1]
+	if _, err = decode(code.Modules); err == nil || !strings.Contains(err.Error(), "Reed-Solomon") {
+		t.Errorf("decode of a damaged code: %v", err)
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	for mask, want := range formatInfoM {
+		c := newCode(1)
+		c.drawFormatBits(mask)
+		var got strings.Builder
+		// Bit 14 first, read down the column beside the top left finder then along its row
+		for _, xy := range [][2]int{{0, 8}, {1, 8}, {2, 8}, {3, 8}, {4, 8}, {5, 8}, {7, 8}, {8, 8}, {8, 7}, {8, 5}, {8, 4}, {8, 3}, {8, 2}, {8, 1}, {8, 0}} {
+			got.WriteString(map[bool]string{true: "1", false: "0"}[c.Modules[xy[1]][xy[0]]])
+		}
+		if got.String() != want {
+			t.Errorf("mask %d: format %s, want %s", mask, got.String(), want)
+		}
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	for version := minVersion; version <= maxVersion; version++ {
+		got := alignmentPositions(version)
+		if fmt.Sprint(got) != fmt.Sprint(alignmentCenters[version]) {
+			t.Errorf("version %d: %v, want %v", version, got, alignmentCenters[version])
+		}
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// The worked example of ISO/IEC 18004 annex I: "01234567" as a 1-M symbol
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	want := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("ECC = % X, want % X", got, want)
+	}
+	for x := 0; x < 256; x++ {
+		for y := 0; y < 256; y++ {
+			want := byte(0)
+			if x != 0 && y != 0 {
+				want = gfExp[gfLog[x]+gfLog[y]]
+			}
+			if got := gfMultiply(byte(x), byte(y)); got != want {
+				t.Fatalf("gfMultiply(%d, %d) = %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestEncodePicksLowestPenalty(t *testing.T) {
+	code, err := Encode("https://sadeem.app/scan?t=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chosen int
+	for mask, bits := range formatInfoM {
+		want, _ := strconv.ParseInt(bits, 2, 32)
+		format := 0
+		for i := 0; i < 8; i++ {
+			if code.Modules[8][code.Size-1-i] {
+				format |= 1 << i
+			}
+		}
+		for i := 8; i < 15; i++ {
+			if code.Modules[code.Size-15+i][8] {
+				format |= 1 << i
+			}
+		}
+		if format == int(want) {
+			chosen = mask
+		}
+	}
+	best := code.penaltyScore()
+	for mask := 0; mask < 8; mask++ {
+		if mask == chosen {
+			continue
+		}
+		code.applyMask(chosen)
+		code.applyMask(mask)
+		code.drawFormatBits(mask)
+		if penalty := code.penaltyScore(); penalty < best {
+			t.Errorf("mask %d scores %d, below the chosen mask %d at %d", mask, penalty, chosen, best)
+		}
+		code.applyMask(mask)
+		code.applyMask(chosen)
+		code.drawFormatBits(chosen)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	code, err := Encode("table 12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = code.PNG(0); err == nil {
+		t.Error("PNG(0) succeeded")
+	}
+	const scale = 3
+	b, err := code.PNG(scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dim := (code.Size + 2*quietZone) * scale
+	if bounds := img.Bounds(); bounds.Dx() != dim || bounds.Dy() != dim {
+		t.Fatalf("image is %v, want %dx%d", bounds, dim, dim)
+	}
+	// Sample the middle of every module, quiet zone included
+	for y := -quietZone; y < code.Size+quietZone; y++ {
+		for x := -quietZone; x < code.Size+quietZone; x++ {
+			r, _, _, _ := img.At((x+quietZone)*scale+scale/2, (y+quietZone)*scale+scale/2).RGBA()
+			dark := x >= 0 && y >= 0 && x < code.Size && y < code.Size && code.Modules[y][x]
+			if (r == 0) != dark {
+				t.Fatalf("pixel of module (%d,%d) has red %d, dark %v", x, y, r, dark)
+			}
+		}
+	}
+}
+
+func TestSVG(t *testing.T) {
+	code, err := Encode("table 12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := code.SVG(4)
+	dim := code.Size + 2*quietZone
+	if want := fmt.Sprintf(`viewBox="0 0 %d %d" width="%d" height="%d"`, dim, dim, dim*4, dim*4); !strings.Contains(svg, want) {
+		t.Errorf("svg lacks %s", want)
+	}
+	modules := make([][]bool, code.Size)
+	for i := range modules {
+		modules[i] = make([]bool, code.Size)
+	}
+	for _, m := range regexp.MustCompile(`M(\d+),(\d+)h1v1h-1z`).FindAllStringSubmatch(svg, -1) {
+		x, _ := strconv.Atoi(m[1])
+		y, _ := strconv.Atoi(m[2])
+		modules[y-quietZone][x-quietZone] = true
+	}
+	if got, err := decode(modules); err != nil || got != "table 12" {
+		t.Errorf("decoding the SVG path: %q, %v", got, err)
+	}
+}
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -99,17 +102,6 @@ func DeleteImageFile(filePath string) error {
 	return nil
 }
 
-// for converting string to float
-func NormalizeFloatInput(input string) string {
-	if strings.Contains(input, ".") {
-		parts := strings.Split(input, ".")
-		if len(parts[1]) == 0 {
-			return input + "0"
-		}
-	}
-	return input + ".0"
-}
-
 var jwtSecret = []byte("ahmedpa55word")
 
 func GenerateToken(userID, userRole string) (string, error) {
@@ -129,18 +121,61 @@ func GenerateToken(userID, userRole string) (string, error) {
 	return tokenString, nil
 }
 
+// ValidateToken parses a signed token. Failures are ErrInvalidToken, ErrExpiredToken or ErrInvalidClaims.
 func ValidateToken(tokenString string) (*jwt.Token, error) {
 	segments := strings.Split(tokenString, ".")
 	if len(segments) != 3 {
-		return nil, fmt.Errorf("token contains an invalid number of segments")
+		return nil, ErrInvalidToken
 	}
 
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(jwtSecret), nil
 	})
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidClaims
+	}
+	return token, nil
+}
+
+// GenerateTableToken signs a table ID and its QR token version so a printed code can be
+// verified without a lookup table. Bumping the version invalidates every older token.
+func GenerateTableToken(tableID string, version int) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d", tableID, version)))
+	return payload + "." + signTablePayload(payload)
+}
+
+// ParseTableToken verifies a token produced by GenerateTableToken and returns its contents.
+func ParseTableToken(token string) (string, int, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(signTablePayload(payload))) {
+		return "", 0, ErrInvalidToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", 0, ErrInvalidToken
+	}
+	tableID, versionStr, found := strings.Cut(string(raw), ":")
+	if !found {
+		return "", 0, ErrInvalidToken
+	}
+	version, err := strconv.Atoi(versionStr)
+	if err != nil {
+		return "", 0, ErrInvalidToken
+	}
+	return tableID, version, nil
+}
+
+func signTablePayload(payload string) string {
+	mac := hmac.New(sha256.New, jwtSecret)
+	mac.Write([]byte("table:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 }
 
 func CheckPassword(storedHash, password string) bool {
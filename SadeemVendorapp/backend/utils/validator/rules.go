@@ -0,0 +1,193 @@
+package validator
+
+import (
+	"project/utils/i18n"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Rule is the validation of one field, declared with String, Number, UUID or Expect and applied
+// with Validator.Validate. A field's checks run in order and the first one that fails is its
+// error. A value left at its zero value, or a nil optional value, counts as not given: it fails
+// Required and passes every other check.
+type Rule interface {
+	Key() string
+	Failure() (i18n.Message, bool)
+}
+
+// Validate adds the error of every failing rule, in the order the rules are declared.
+func (v *Validator) Validate(rules ...Rule) {
+	for _, rule := range rules {
+		if message, failed := rule.Failure(); failed {
+			v.AddError(rule.Key(), message.Code, message.Args...)
+		}
+	}
+}
+
+type field struct {
+	key     string
+	empty   bool
+	failure *i18n.Message
+}
+
+func (f *field) Key() string {
+	return f.key
+}
+
+func (f *field) Failure() (i18n.Message, bool) {
+	if f.failure == nil {
+		return i18n.Message{}, false
+	}
+	return *f.failure, true
+}
+
+func (f *field) fail(code i18n.Code, args ...interface{}) {
+	if f.failure == nil {
+		f.failure = &i18n.Message{Code: code, Args: args}
+	}
+}
+
+// check fails the field with code unless ok. Fields that were not given are not checked.
+func (f *field) check(ok bool, code i18n.Code, args ...interface{}) {
+	if !ok && !f.empty {
+		f.fail(code, args...)
+	}
+}
+
+func (f *field) required(code i18n.Code) {
+	if f.empty {
+		f.fail(code)
+	}
+}
+
+// Expect is a rule for checks no builder covers, such as one field depending on another.
+func Expect(key string, ok bool, code i18n.Code, args ...interface{}) Rule {
+	f := &field{key: key}
+	f.check(ok, code, args...)
+	return f
+}
+
+// StringRule checks a text field. Lengths count letters, not bytes.
+type StringRule struct {
+	field
+	value string
+}
+
+func String(key, value string) *StringRule {
+	return &StringRule{field: field{key: key, empty: value == ""}, value: value}
+}
+
+// OptionalString is String for a field that may be left out.
+func OptionalString(key string, value *string) *StringRule {
+	if value == nil {
+		return &StringRule{field: field{key: key, empty: true}}
+	}
+	return String(key, *value)
+}
+
+func (s *StringRule) Required(code i18n.Code) *StringRule {
+	s.required(code)
+	return s
+}
+
+// MinLength fails with code, given n, when the value is shorter than n letters.
+func (s *StringRule) MinLength(n int, code i18n.Code) *StringRule {
+	s.check(utf8.RuneCountInString(s.value) >= n, code, n)
+	return s
+}
+
+// MaxLength fails with code, given n, when the value is longer than n letters.
+func (s *StringRule) MaxLength(n int, code i18n.Code) *StringRule {
+	s.check(utf8.RuneCountInString(s.value) <= n, code, n)
+	return s
+}
+
+func (s *StringRule) Matches(rx *regexp.Regexp, code i18n.Code) *StringRule {
+	s.check(rx.MatchString(s.value), code)
+	return s
+}
+
+func (s *StringRule) OneOf(code i18n.Code, values ...string) *StringRule {
+	s.check(In(s.value, values...), code)
+	return s
+}
+
+func (s *StringRule) UUID(code i18n.Code) *StringRule {
+	_, err := uuid.Parse(s.value)
+	s.check(err == nil, code)
+	return s
+}
+
+// Numeric is the types NumberRule checks.
+type Numeric interface {
+	~int | ~int64 | ~float64
+}
+
+// NumberRule checks a numeric field. The bound of a failed check is passed to its message.
+type NumberRule[T Numeric] struct {
+	field
+	value T
+}
+
+func Number[T Numeric](key string, value T) *NumberRule[T] {
+	return &NumberRule[T]{field: field{key: key, empty: value == 0}, value: value}
+}
+
+// OptionalNumber is Number for a field that may be left out; a given zero is checked like any
+// other value.
+func OptionalNumber[T Numeric](key string, value *T) *NumberRule[T] {
+	if value == nil {
+		return &NumberRule[T]{field: field{key: key, empty: true}}
+	}
+	return &NumberRule[T]{field: field{key: key}, value: *value}
+}
+
+func (n *NumberRule[T]) Required(code i18n.Code) *NumberRule[T] {
+	n.required(code)
+	return n
+}
+
+// Min fails when the value is below min.
+func (n *NumberRule[T]) Min(min T, code i18n.Code) *NumberRule[T] {
+	n.check(n.value >= min, code, min)
+	return n
+}
+
+// Max fails when the value is above max.
+func (n *NumberRule[T]) Max(max T, code i18n.Code) *NumberRule[T] {
+	n.check(n.value <= max, code, max)
+	return n
+}
+
+// Above fails unless the value is greater than bound.
+func (n *NumberRule[T]) Above(bound T, code i18n.Code) *NumberRule[T] {
+	n.check(n.value > bound, code, bound)
+	return n
+}
+
+// Below fails unless the value is less than bound, such as a discount below the price.
+func (n *NumberRule[T]) Below(bound T, code i18n.Code) *NumberRule[T] {
+	n.check(n.value < bound, code, bound)
+	return n
+}
+
+// UUIDRule checks an ID field; uuid.Nil counts as not given.
+type UUIDRule struct {
+	field
+}
+
+func UUID(key string, value uuid.UUID) *UUIDRule {
+	return &UUIDRule{field: field{key: key, empty: value == uuid.Nil}}
+}
+
+// OptionalUUID is UUID for a field that may be left out.
+func OptionalUUID(key string, value *uuid.UUID) *UUIDRule {
+	return &UUIDRule{field: field{key: key, empty: value == nil || *value == uuid.Nil}}
+}
+
+func (u *UUIDRule) Required(code i18n.Code) *UUIDRule {
+	u.required(code)
+	return u
+}
@@ -0,0 +1,74 @@
+package validator
+
+import (
+	"project/utils/i18n"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestRules(t *testing.T) {
+	slug := regexp.MustCompile(`^[a-z]+$`)
+	tests := []struct {
+		name string
+		rule Rule
+		want i18n.Code
+	}{
+		{"required string given", String("name", "cafe").Required(i18n.NameRequired), ""},
+		{"required string missing", String("name", "").Required(i18n.NameRequired), i18n.NameRequired},
+		{"empty string skips checks", String("name", "").MinLength(3, i18n.NameTooShort), ""},
+		{"too short", String("name", "ab").MinLength(3, i18n.NameTooShort), i18n.NameTooShort},
+		{"too long", String("name", "abcdef").MaxLength(5, i18n.NameTooLong), i18n.NameTooLong},
+		{"length counts letters", String("name", "مقهى").MaxLength(4, i18n.NameTooLong), ""},
+		{"first failure wins", String("name", "a").MinLength(3, i18n.NameTooShort).Matches(slug, i18n.SlugInvalid), i18n.NameTooShort},
+		{"no match", String("slug", "Cafe").Matches(slug, i18n.SlugInvalid), i18n.SlugInvalid},
+		{"one of", String("kind", "price").OneOf(i18n.TagKindInvalid, "cuisine", "price"), ""},
+		{"not one of", String("kind", "other").OneOf(i18n.TagKindInvalid, "cuisine", "price"), i18n.TagKindInvalid},
+		{"uuid string", String("id", "not-a-uuid").UUID(i18n.VendorIDRequired), i18n.VendorIDRequired},
+		{"nil optional string", OptionalString("address", nil).MaxLength(1, i18n.AddressTooLong), ""},
+		{"number in range", Number("days", 30).Above(0, i18n.DaysNotPositive).Max(1000, i18n.DaysTooMany), ""},
+		{"number zero required", Number("days", 0).Required(i18n.DaysNotPositive), i18n.DaysNotPositive},
+		{"number below min", Number("quantity", -1).Min(0, i18n.QuantityNegative), i18n.QuantityNegative},
+		{"number above max", Number("days", 1001).Max(1000, i18n.DaysTooMany), i18n.DaysTooMany},
+		{"not above", Number("price", -2.5).Above(0, i18n.PriceNotPositive), i18n.PriceNotPositive},
+		{"not below", Number("discount", 10.0).Below(10, i18n.DiscountAbovePrice), i18n.DiscountAbovePrice},
+		{"nil optional number", OptionalNumber[int]("max_items", nil).Required(i18n.QuantityRequired), i18n.QuantityRequired},
+		{"given optional zero", OptionalNumber("max_items", intPtr(0)).Required(i18n.QuantityRequired).Min(0, i18n.LimitNegative), ""},
+		{"nil uuid", UUID("vendor_id", uuid.Nil).Required(i18n.VendorIDRequired), i18n.VendorIDRequired},
+		{"uuid given", UUID("vendor_id", uuid.New()).Required(i18n.VendorIDRequired), ""},
+		{"nil optional uuid", OptionalUUID("plan_id", nil).Required(i18n.PlanIDRequired), i18n.PlanIDRequired},
+		{"expect fails", Expect("location", false, i18n.LocationIncomplete), i18n.LocationIncomplete},
+		{"expect holds", Expect("location", true, i18n.LocationIncomplete), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New()
+			v.Validate(tt.rule)
+			if got := v.Errors[tt.rule.Key()].Code; got != tt.want {
+				t.Errorf("code = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCollectsEveryField(t *testing.T) {
+	v := New()
+	v.Validate(
+		String("name", "").Required(i18n.NameRequired),
+		Number("price", 5.0).Above(0, i18n.PriceNotPositive),
+		Number("days", 2000).Max(1000, i18n.DaysTooMany),
+		Number("days", -1).Above(0, i18n.DaysNotPositive),
+		UUID("vendor_id", uuid.Nil).Required(i18n.VendorIDRequired),
+	)
+
+	if want := []string{"name", "days", "vendor_id"}; !reflect.DeepEqual(v.ErrorOrder, want) {
+		t.Errorf("ErrorOrder = %v, want %v", v.ErrorOrder, want)
+	}
+	if got := v.Errors["days"]; got.Code != i18n.DaysTooMany || !reflect.DeepEqual(got.Args, []interface{}{1000}) {
+		t.Errorf("days = %+v, want the first failure with its bound", got)
+	}
+}
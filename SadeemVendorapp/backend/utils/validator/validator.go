@@ -1,9 +1,14 @@
 package validator
 
-import "regexp"
+import (
+	"project/utils/i18n"
+	"regexp"
+)
 
+// Validator collects one message per field, and the fields in the order they failed. Messages
+// are catalog codes so they can be rendered in the client's language.
 type Validator struct {
-	Errors     map[string]string
+	Errors     map[string]i18n.Message
 	ErrorOrder []string
 }
 
@@ -13,20 +18,21 @@ var (
 )
 
 func New() *Validator {
-	return &Validator{Errors: make(map[string]string)}
+	return &Validator{Errors: make(map[string]i18n.Message)}
 }
 func (v *Validator) Valid() bool {
 	return len(v.Errors) == 0
 }
-func (v *Validator) AddError(key, message string) {
+func (v *Validator) AddError(key string, code i18n.Code, args ...interface{}) {
 	if _, exists := v.Errors[key]; !exists {
-		v.Errors[key] = message
+		v.Errors[key] = i18n.Message{Code: code, Args: args}
+		v.ErrorOrder = append(v.ErrorOrder, key)
 	}
 
 }
-func (v *Validator) Check(ok bool, key, message string) {
+func (v *Validator) Check(ok bool, key string, code i18n.Code, args ...interface{}) {
 	if !ok {
-		v.AddError(key, message)
+		v.AddError(key, code, args...)
 	}
 }
 